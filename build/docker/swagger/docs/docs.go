@@ -20,9 +20,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/books": {
+        "/audit": {
             "get": {
-                "description": "Get a list of books based on specified query parameters",
+                "description": "Search the changes made across the library, oldest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,9 +30,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Audit"
                 ],
-                "summary": "Get a list of books",
+                "summary": "Search the audit trail",
                 "parameters": [
                     {
                         "type": "integer",
@@ -42,76 +42,119 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Number of books per page",
+                        "description": "Number of records per page",
                         "name": "per_page",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Filter books by updated timestamp (Unix timestamp)",
-                        "name": "updated_at",
+                        "type": "string",
+                        "description": "Filter by who made the change",
+                        "name": "actor",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Filter books by number of pages",
-                        "name": "book_pages",
+                        "type": "string",
+                        "description": "Filter by the kind of record changed (book)",
+                        "name": "entity",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Filter books by published date (Unix timestamp)",
-                        "name": "published",
+                        "description": "Filter by the id of the record changed",
+                        "name": "entity_id",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Filter books by ISBN",
-                        "name": "isbn",
+                        "description": "Filter by action (create, update, delete, restore, purge)",
+                        "name": "action",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Filter books by title",
-                        "name": "title",
+                        "type": "integer",
+                        "description": "Changes made at or after this time (Unix timestamp)",
+                        "name": "from",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Filter books by author",
-                        "name": "author",
+                        "type": "integer",
+                        "description": "Changes made at or before this time (Unix timestamp)",
+                        "name": "to",
                         "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved audit records",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetAuditRecordsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/authors": {
+            "get": {
+                "description": "Get a list of authors ordered by sort name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authors"
+                ],
+                "summary": "Get a list of authors",
+                "parameters": [
                     {
-                        "type": "string",
-                        "description": "Filter books by publisher",
-                        "name": "publisher",
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Filter books by genre",
-                        "name": "genre",
+                        "type": "integer",
+                        "description": "Number of authors per page",
+                        "name": "per_page",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Filter books by language",
-                        "name": "language",
+                        "description": "Filter authors by name, sort name or alias",
+                        "name": "name",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Filter books by availability",
-                        "name": "availability",
+                        "type": "integer",
+                        "description": "Filter authors credited on a book",
+                        "name": "book_id",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved books",
+                        "description": "Successfully retrieved authors",
                         "schema": {
-                            "$ref": "#/definitions/swagger.GetBooksReponse"
+                            "$ref": "#/definitions/swagger.GetAuthorsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "500": {
@@ -123,7 +166,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new book entry",
+                "description": "Add an author that books can be credited to",
                 "consumes": [
                     "application/json"
                 ],
@@ -131,25 +174,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Authors"
                 ],
-                "summary": "Create a new book",
+                "summary": "Create a new author",
                 "parameters": [
                     {
-                        "description": "New book details",
+                        "description": "New author details",
                         "name": "requestBody",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/swagger.CreateBookRequestBody"
+                            "$ref": "#/definitions/swagger.CreateAuthorRequestBody"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully created book",
+                        "description": "Successfully created author",
                         "schema": {
-                            "$ref": "#/definitions/books.Book"
+                            "$ref": "#/definitions/authors.Author"
                         }
                     },
                     "400": {
@@ -167,9 +210,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/books/{book_id}": {
+        "/authors/{author_id}": {
             "get": {
-                "description": "Get details of a book by its ID",
+                "description": "Get details of an author by their ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -177,24 +220,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Authors"
                 ],
-                "summary": "Get a book by ID",
+                "summary": "Get an author by ID",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Book ID",
-                        "name": "book_id",
+                        "description": "Author ID",
+                        "name": "author_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully retrieved book",
+                        "description": "Successfully retrieved author",
                         "schema": {
-                            "$ref": "#/definitions/books.Book"
+                            "$ref": "#/definitions/authors.Author"
+                        }
+                    },
+                    "404": {
+                        "description": "Author does not exist",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "500": {
@@ -206,7 +255,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "Update details of a book by its ID",
+                "description": "Update the names, life dates or aliases of an author",
                 "consumes": [
                     "application/json"
                 ],
@@ -214,31 +263,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Authors"
                 ],
-                "summary": "Update a book by ID",
+                "summary": "Update an author by ID",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Book ID",
-                        "name": "book_id",
+                        "description": "Author ID",
+                        "name": "author_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "New book details",
+                        "description": "New author details",
                         "name": "requestBody",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/swagger.UpdateBookRequestBody"
+                            "$ref": "#/definitions/swagger.UpdateAuthorRequestBody"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "book by author has been updated successfully",
+                        "description": "Successfully updated author",
+                        "schema": {
+                            "$ref": "#/definitions/authors.Author"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Author does not exist",
                         "schema": {
                             "type": "string"
                         }
@@ -252,7 +313,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "delete a book by ID (Hard delete)",
+                "description": "delete an author by ID, authors still credited on books cannot be deleted",
                 "consumes": [
                     "application/json"
                 ],
@@ -260,22 +321,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Authors"
                 ],
-                "summary": "delete a book by ID",
+                "summary": "delete an author by ID",
                 "parameters": [
                     {
                         "type": "integer",
                         "format": "int64",
-                        "description": "Book ID",
-                        "name": "book_id",
+                        "description": "Author ID",
+                        "name": "author_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully deleted book",
+                        "description": "Successfully deleted author",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Author does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Author is credited on books",
                         "schema": {
                             "type": "string"
                         }
@@ -288,85 +361,4751 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "books.Availability": {
-            "type": "string",
-            "enum": [
-                "available",
-                "not_available"
-            ],
-            "x-enum-varnames": [
-                "Available",
-                "NotAvailable"
-            ]
         },
-        "books.Book": {
-            "type": "object",
-            "required": [
-                "author",
-                "availability",
-                "genre",
-                "isbn",
-                "language",
-                "pages",
-                "published",
-                "publisher",
-                "title"
-            ],
-            "properties": {
-                "author": {
-                    "type": "string"
-                },
-                "availability": {
-                    "$ref": "#/definitions/books.Availability"
-                },
-                "created_at": {
-                    "$ref": "#/definitions/utils.CustomTime"
-                },
-                "deleted_at": {
-                    "$ref": "#/definitions/utils.CustomTime"
-                },
-                "genre": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "isbn": {
-                    "type": "string"
-                },
-                "language": {
-                    "type": "string"
-                },
-                "pages": {
-                    "type": "integer"
-                },
-                "published": {
-                    "$ref": "#/definitions/utils.CustomDate"
-                },
-                "publisher": {
-                    "type": "string"
-                },
-                "title": {
-                    "type": "string"
-                },
-                "updated_at": {
-                    "$ref": "#/definitions/utils.CustomTime"
+        "/authors/{author_id}/books": {
+            "get": {
+                "description": "Get the books an author is credited on and the role they had on each",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authors"
+                ],
+                "summary": "Get the books of an author",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Author ID",
+                        "name": "author_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of books per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by role (author, editor, translator, illustrator)",
+                        "name": "role",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved books",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetAuthorBooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Author does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
                 }
             }
         },
-        "swagger.CreateBookRequestBody": {
-            "type": "object",
-            "properties": {
-                "author": {
-                    "type": "string"
-                },
-                "availability": {
-                    "type": "string"
-                },
-                "genre": {
+        "/authors/{author_id}/books/{book_id}": {
+            "put": {
+                "description": "Link an author to a book in a role, crediting the same role again moves it to the new position",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authors"
+                ],
+                "summary": "Credit an author on a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Author ID",
+                        "name": "author_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Credit details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.CreditAuthorRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully credited author",
+                        "schema": {
+                            "$ref": "#/definitions/authors.Credit"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Author or book does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Unlink an author from a book, every role is removed unless one is given",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authors"
+                ],
+                "summary": "Remove an author credit from a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Author ID",
+                        "name": "author_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only remove this role",
+                        "name": "role",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully removed credit",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Author is not credited on this book",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books": {
+            "get": {
+                "description": "Get a list of books based on specified query parameters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Get a list of books",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of books per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter books by updated timestamp (Unix timestamp)",
+                        "name": "updated_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter books by number of pages",
+                        "name": "book_pages",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter books by published date (Unix timestamp)",
+                        "name": "published",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by ISBN, any valid ISBN-10 or ISBN-13 form of it matches",
+                        "name": "isbn",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by author, credited authors are matched on their name, sort name or aliases",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by publisher",
+                        "name": "publisher",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by genre, including the books in its sub-genres",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter books by a node of the genre tree and the nodes below it",
+                        "name": "genre_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by language",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by availability",
+                        "name": "availability",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter books by the decade they were published in, such as 1980",
+                        "name": "decade",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to order by, - in front sorts descending, for example -published,title. Ties are ordered by id, without a sort books are listed by updated_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor or prev_cursor of an earlier response, or empty to list the first page by cursor. Cursors replace page and keep the sort they were made with",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Set to false to leave out counting every book that matches, count and total_pages are then left out of the response",
+                        "name": "total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated facets to count the books by: genre, language, publisher, availability and decade. Each facet is counted with every filter but its own",
+                        "name": "facets",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved books",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetBooksReponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new book entry",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Create a new book",
+                "parameters": [
+                    {
+                        "description": "New book details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.CreateBookRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully created book",
+                        "schema": {
+                            "$ref": "#/definitions/books.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/bulk": {
+            "post": {
+                "description": "Create up to 500 books in one request. In all_or_nothing mode (the default) no book is created unless\nevery book can be, in partial mode every valid book is created. Each book gets a result in the order sent",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Create books in bulk",
+                "parameters": [
+                    {
+                        "description": "Books to create",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.BulkCreateBooksRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Per book results, with the id of each created book",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.BulkCreateBooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: nothing was created in all_or_nothing mode",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.BulkCreateBooksResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many books in one request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Move every book picked by ids or by a filter to the trash in one transaction. In all_or_nothing mode\n(the default) no book is deleted unless every book can be, a dry run reports what would be deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Delete books in bulk",
+                "parameters": [
+                    {
+                        "description": "Books to delete",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.BulkDeleteBooksRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Per book results",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.BulkBooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: no book could be deleted",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.BulkBooksResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many books in one request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Apply the same merge patch to every book picked by ids or by a filter, in one transaction. Availability\ncomes from the copies, copy_status moves the copies of each book that are not on loan or on hold off the\nshelf. In all_or_nothing mode (the default) no book is changed unless every book can be, a dry run\nreports what would change without changing anything",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Update books in bulk",
+                "parameters": [
+                    {
+                        "description": "Books to change and the changes",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.BulkUpdateBooksRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Per book results with the fields that changed",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.BulkBooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: no book could be changed",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.BulkBooksResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Too many books in one request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/export": {
+            "get": {
+                "description": "Stream every book that matches the filters as CSV, newline delimited JSON, MARC 21 (ISO 2709) or MARCXML.\nThe CSV starts with the columns a CSV import reads and the MARC records are read back by the MARC import,\nso an export can be imported again. Takes the same filters as listing the books",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/marc",
+                    "application/marcxml+xml"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Export the catalogue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (default), ndjson, marc21 or marcxml",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by ISBN",
+                        "name": "isbn",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by author",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by publisher",
+                        "name": "publisher",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by genre, including the books in its sub-genres",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter books by a node of the genre tree and the nodes below it",
+                        "name": "genre_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by language",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by availability",
+                        "name": "availability",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to order by, - in front sorts descending, for example -published,title",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The books, one per line",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters or format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/import": {
+            "post": {
+                "description": "Stream a CSV file with a header row into the catalogue. Columns are matched to book fields by name\nunless map gives another header for a field. Every row is validated and the report lists the line\nand reason of each row that was not imported",
+                "consumes": [
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Import books from a CSV file",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Column a field is read from as field:header, e.g. title:Book Title",
+                        "name": "map",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Go layout of the published column, 2006-01-02 and unix timestamps by default",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "description": "CSV file",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rows imported and rejected",
+                        "schema": {
+                            "$ref": "#/definitions/books.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: the header or the column mappings are wrong, or no row could be imported",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/import/marc": {
+            "post": {
+                "description": "Read MARC 21 bibliographic records in ISO 2709 or MARCXML into the catalogue. 020 gives the ISBN, 100 the\nauthor, 245 the title, 264 or 260 the publisher and date, 041 the language, 300 the pages and 655 or 650\nthe genre. Records are numbered from 1 in the report, which warns about fields that could not be mapped",
+                "consumes": [
+                    "application/marc",
+                    "application/marcxml+xml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Import books from MARC records",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "marc21 or marcxml, taken from the Content-Type when it is not given",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "description": "MARC records",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Records imported, rejected and warned about",
+                        "schema": {
+                            "$ref": "#/definitions/books.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: no record could be imported",
+                        "schema": {
+                            "$ref": "#/definitions/books.ImportReport"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported MARC format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/import/onix": {
+            "post": {
+                "description": "Create or update books from the products of an ONIX 3.0 message in reference or short tags, matched by\nISBN. The product form decides whether a product is a book, and the language, page count, publication\ndate and publisher are mapped along with the title, author and main subject. Products are numbered from 1\nin the report, which says whether each one was inserted, updated or skipped and why. A feed that only\nrepeats books already in the catalogue is skipped throughout, so skipped products are not an error",
+                "consumes": [
+                    "text/xml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Ingest an ONIX 3.0 feed",
+                "parameters": [
+                    {
+                        "description": "ONIX 3.0 message",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Products inserted, updated and skipped",
+                        "schema": {
+                            "$ref": "#/definitions/books.IngestReport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/search": {
+            "get": {
+                "description": "Rank the books by how well their title, author, publisher and genre match the words of q.\nWords in double quotes are searched as a phrase and a word ending in * matches every word starting\nwith it. Matches in the title count for the most unless other boosts are given in fields. Each hit\ncarries the fields that matched with the terms wrapped in \u003cmark\u003e, long values are cut to a snippet",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Full-text search of the books",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Words to search for, for example \\",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fields to search with optional boosts, for example title^5,author",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of books per page, 20 by default and at most 100",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Books ranked by relevance",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.SearchBooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query or fields",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/trash": {
+            "get": {
+                "description": "Get the deleted books that have not been purged yet, most recently changed last",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Get the books in the trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of books per page",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved deleted books",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetBooksReponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{book_id}": {
+            "get": {
+                "description": "Get details of a book by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Get a book by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved book",
+                        "schema": {
+                            "$ref": "#/definitions/books.Book"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the book, send it in If-Match to update it"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Book does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Book has been deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update details of a book by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Update a book by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New book details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.UpdateBookRequestBody"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the book was read at, the update is refused if it has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "book by author has been updated successfully",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the book"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Book does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Another book has the ISBN",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Book has been deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Book has been changed since the ETag in If-Match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Move a book to the trash, it can be restored until the retention period is over and it is purged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "delete a book by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully deleted book",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Book does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Book has already been deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change only the fields that are sent. A JSON Merge Patch (RFC 7396) is a partial book where null clears a field,\na JSON Patch (RFC 6902) is a list of add, replace, remove and test operations on top level fields.\nPublisher, genre, language and pages may be cleared, the other fields can only be replaced",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Patch a book by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.PatchBookRequestBody"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/merge-patch+json (default) or application/json-patch+json",
+                        "name": "Content-Type",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the book was read at, the patch is refused if it has changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The patched book",
+                        "schema": {
+                            "$ref": "#/definitions/books.Book"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the book"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid patch",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Book does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "A test operation of the JSON Patch failed or another book has the ISBN",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "410": {
+                        "description": "Book has been deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Book has been changed since the ETag in If-Match",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported patch format",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{book_id}/history": {
+            "get": {
+                "description": "Every create, update, delete, restore and purge of a book, oldest first, including after it was purged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Get the change history of a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records per page",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved book history",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetAuditRecordsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{book_id}/restore": {
+            "post": {
+                "description": "Take a book back out of the trash before it is purged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Restore a deleted book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully restored book",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Book does not exist or is not in the trash",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/copies": {
+            "get": {
+                "description": "Get a list of copies based on specified query parameters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Copies"
+                ],
+                "summary": "Get a list of copies",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of copies per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter copies by book",
+                        "name": "book_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter copies by barcode",
+                        "name": "barcode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter copies by shelf location prefix",
+                        "name": "shelf_location",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter copies by status (available, on_loan, on_hold, in_repair, lost, withdrawn)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved copies",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetCopiesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Register a physical copy of a book, a copy put on the shelf goes to the holds queue first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Copies"
+                ],
+                "summary": "Add a copy of a book",
+                "parameters": [
+                    {
+                        "description": "New copy details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.CreateCopyRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully created copy",
+                        "schema": {
+                            "$ref": "#/definitions/copies.Copy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Book does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Barcode is already in use",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/copies/{copy_id}": {
+            "get": {
+                "description": "Get details of a copy by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Copies"
+                ],
+                "summary": "Get a copy by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Copy ID",
+                        "name": "copy_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved copy",
+                        "schema": {
+                            "$ref": "#/definitions/copies.Copy"
+                        }
+                    },
+                    "404": {
+                        "description": "Copy does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update the barcode, shelf location, condition or status of a copy",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Copies"
+                ],
+                "summary": "Update a copy by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Copy ID",
+                        "name": "copy_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New copy details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.UpdateCopyRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully updated copy",
+                        "schema": {
+                            "$ref": "#/definitions/copies.Copy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Copy does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Barcode is already in use or the copy is on loan or on hold",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete a copy by ID, copies with loan history must be withdrawn instead",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Copies"
+                ],
+                "summary": "delete a copy by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Copy ID",
+                        "name": "copy_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully deleted copy",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Copy does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Copy is in circulation or has loan history",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/fines": {
+            "get": {
+                "description": "Get a list of overdue fines based on specified query parameters, amounts are in cents",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Fines"
+                ],
+                "summary": "Get a list of fines",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of fines per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter fines by loan",
+                        "name": "loan_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter fines by member",
+                        "name": "member_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter fines by status (outstanding, paid, waived)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved fines",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetFinesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/fines/balance/{member_id}": {
+            "get": {
+                "description": "Get the total a member owes in outstanding fines and whether it blocks checkouts, amounts are in cents",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Fines"
+                ],
+                "summary": "Get a member's fine balance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Member ID",
+                        "name": "member_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved balance",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.MemberBalanceResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/fines/{fine_id}": {
+            "get": {
+                "description": "Get details of a fine by its ID, amounts are in cents",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Fines"
+                ],
+                "summary": "Get a fine by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Fine ID",
+                        "name": "fine_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved fine",
+                        "schema": {
+                            "$ref": "#/definitions/fines.Fine"
+                        }
+                    },
+                    "404": {
+                        "description": "Fine does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/fines/{fine_id}/pay": {
+            "post": {
+                "description": "Record a full or part payment against a fine, the amount is in cents",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Fines"
+                ],
+                "summary": "Pay a fine",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Fine ID",
+                        "name": "fine_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Payment details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.PayFineRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully paid fine",
+                        "schema": {
+                            "$ref": "#/definitions/fines.Fine"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid payment amount",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Fine does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Fine has already been paid or waived",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/fines/{fine_id}/waive": {
+            "post": {
+                "description": "Cancel whatever is left of a fine, a reason is required",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Fines"
+                ],
+                "summary": "Waive a fine",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Fine ID",
+                        "name": "fine_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Waiver details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.WaiveFineRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully waived fine",
+                        "schema": {
+                            "$ref": "#/definitions/fines.Fine"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Reason is required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Fine does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Fine has already been paid or waived",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres": {
+            "get": {
+                "description": "Get nodes of the genre tree, listed depth first by path",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Get a list of genres",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of genres per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter genres by name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by kind (genre, subject)",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only the direct children of this genre, 0 for the top level",
+                        "name": "parent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Every genre below this one at any depth",
+                        "name": "ancestor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Genres a book is filed under",
+                        "name": "book_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved genres",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetGenresResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a node to the genre tree, at the top level or below an existing node",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Create a genre or subject",
+                "parameters": [
+                    {
+                        "description": "New genre details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.CreateGenreRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully created genre",
+                        "schema": {
+                            "$ref": "#/definitions/genres.Node"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Parent genre does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Parent already has a genre with this name",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres/{genre_id}": {
+            "get": {
+                "description": "Get a node of the genre tree and its full path",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Get a genre by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Genre ID",
+                        "name": "genre_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved genre",
+                        "schema": {
+                            "$ref": "#/definitions/genres.Node"
+                        }
+                    },
+                    "404": {
+                        "description": "Genre does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Rename a node of the genre tree, the paths of the nodes below it follow",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Rename a genre",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Genre ID",
+                        "name": "genre_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New name",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.RenameGenreRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully renamed genre",
+                        "schema": {
+                            "$ref": "#/definitions/genres.Node"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Genre does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Parent already has a genre with this name",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete an empty genre, genres with books or sub-genres must be merged or emptied first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "delete a genre by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Genre ID",
+                        "name": "genre_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully deleted genre",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Genre does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Genre still has books or sub-genres",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres/{genre_id}/books/{book_id}": {
+            "put": {
+                "description": "File a book under a node of the genre tree, a book may be filed under several nodes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "File a book under a genre",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Genre ID",
+                        "name": "genre_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully filed book",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Genre or book does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove a book from a node of the genre tree",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Remove a book from a genre",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Genre ID",
+                        "name": "genre_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully removed book",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Book is not in this genre",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres/{genre_id}/merge": {
+            "post": {
+                "description": "Refile the books and sub-genres of a genre under the target genre and remove it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Merge a genre into another",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Genre ID to merge away",
+                        "name": "genre_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Genre to merge into",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.MergeGenreRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The genre that was merged into",
+                        "schema": {
+                            "$ref": "#/definitions/genres.Node"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Genre does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "The merge would create a cycle or a duplicate name",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres/{genre_id}/move": {
+            "post": {
+                "description": "Move a node and everything below it under a new parent, a parent_id of 0 moves it to the top level",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Move a genre",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Genre ID",
+                        "name": "genre_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New parent",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.MoveGenreRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully moved genre",
+                        "schema": {
+                            "$ref": "#/definitions/genres.Node"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Genre or parent does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "The move would create a cycle or a duplicate name",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds": {
+            "get": {
+                "description": "Get holds in queue order based on specified query parameters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Holds"
+                ],
+                "summary": "Get a list of holds",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of holds per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter holds by book",
+                        "name": "book_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter holds by member",
+                        "name": "member_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter holds by status (waiting, ready, fulfilled, cancelled, expired)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved holds",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetHoldsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Join the queue for a book that has no copies on the shelf",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Holds"
+                ],
+                "summary": "Place a hold",
+                "parameters": [
+                    {
+                        "description": "Hold details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.PlaceHoldRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully placed hold",
+                        "schema": {
+                            "$ref": "#/definitions/holds.Hold"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Book or member does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Book is available or member already has an open hold",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{hold_id}": {
+            "get": {
+                "description": "Get details of a hold, including its place in the queue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Holds"
+                ],
+                "summary": "Get a hold by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Hold ID",
+                        "name": "hold_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved hold",
+                        "schema": {
+                            "$ref": "#/definitions/holds.Hold"
+                        }
+                    },
+                    "404": {
+                        "description": "Hold does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/holds/{hold_id}/cancel": {
+            "post": {
+                "description": "Leave the queue, a hold that was ready for pickup passes its copy to the next member",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Holds"
+                ],
+                "summary": "Cancel a hold",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Hold ID",
+                        "name": "hold_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully cancelled hold",
+                        "schema": {
+                            "$ref": "#/definitions/holds.Hold"
+                        }
+                    },
+                    "404": {
+                        "description": "Hold does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Hold is no longer open",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/loans": {
+            "get": {
+                "description": "Get a list of loans based on specified query parameters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loans"
+                ],
+                "summary": "Get a list of loans",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of loans per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter loans by book",
+                        "name": "book_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter loans by copy",
+                        "name": "copy_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter loans by member",
+                        "name": "member_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter loans by status (active, overdue, returned)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved loans",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetLoansResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Lend a copy of a book to a member, the copy set aside for their hold or any copy on the shelf if none is given",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loans"
+                ],
+                "summary": "Check out a book",
+                "parameters": [
+                    {
+                        "description": "Loan details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.CheckoutBookRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully checked out book",
+                        "schema": {
+                            "$ref": "#/definitions/loans.Loan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Book or member does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Book is not available, or the member cannot borrow or owes too much in fines",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/loans/{loan_id}": {
+            "get": {
+                "description": "Get details of a loan by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loans"
+                ],
+                "summary": "Get a loan by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Loan ID",
+                        "name": "loan_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved loan",
+                        "schema": {
+                            "$ref": "#/definitions/loans.Loan"
+                        }
+                    },
+                    "404": {
+                        "description": "Loan does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/loans/{loan_id}/return": {
+            "post": {
+                "description": "Close a loan, pass the copy to the next hold or back on the shelf and charge any overdue fine",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Loans"
+                ],
+                "summary": "Return a book",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Loan ID",
+                        "name": "loan_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully returned book",
+                        "schema": {
+                            "$ref": "#/definitions/loans.Loan"
+                        }
+                    },
+                    "404": {
+                        "description": "Loan does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Loan has already been returned",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/members": {
+            "get": {
+                "description": "Get a list of members based on specified query parameters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Members"
+                ],
+                "summary": "Get a list of members",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of members per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter members by card number",
+                        "name": "card_number",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter members by name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter members by email",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter members by membership type",
+                        "name": "membership_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter members by status (active, suspended, expired)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved members",
+                        "schema": {
+                            "$ref": "#/definitions/swagger.GetMembersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Register a new member and issue their library card",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Members"
+                ],
+                "summary": "Create a new member",
+                "parameters": [
+                    {
+                        "description": "New member details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.CreateMemberRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully created member",
+                        "schema": {
+                            "$ref": "#/definitions/members.Member"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Card number is already in use",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/members/{member_id}": {
+            "get": {
+                "description": "Get details of a member by their ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Members"
+                ],
+                "summary": "Get a member by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Member ID",
+                        "name": "member_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved member",
+                        "schema": {
+                            "$ref": "#/definitions/members.Member"
+                        }
+                    },
+                    "404": {
+                        "description": "Member does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update details of a member by their ID, including suspending or renewing the membership",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Members"
+                ],
+                "summary": "Update a member by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Member ID",
+                        "name": "member_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New member details",
+                        "name": "requestBody",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/swagger.UpdateMemberRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "member has been updated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid input data",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Member does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete a member by ID, members with loan or hold history must be suspended instead",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Members"
+                ],
+                "summary": "delete a member by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Member ID",
+                        "name": "member_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully deleted member",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Member does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Member has loan or hold history",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/opds": {
+            "get": {
+                "description": "Navigation feed with the new additions, the genre and language feeds and every book, for e-reader apps",
+                "produces": [
+                    "application/atom+xml"
+                ],
+                "tags": [
+                    "OPDS"
+                ],
+                "summary": "OPDS catalogue root",
+                "responses": {
+                    "200": {
+                        "description": "OPDS navigation feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/opds/books": {
+            "get": {
+                "description": "Acquisition feed of the books that match the filters, the OpenSearch description points here",
+                "produces": [
+                    "application/atom+xml"
+                ],
+                "tags": [
+                    "OPDS"
+                ],
+                "summary": "OPDS books",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter books by ISBN",
+                        "name": "isbn",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by author",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by publisher",
+                        "name": "publisher",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by genre, including the books in its sub-genres",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filter books by a node of the genre tree and the nodes below it",
+                        "name": "genre_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by language",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter books by availability",
+                        "name": "availability",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Books per page, 25 by default and at most 100",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OPDS acquisition feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/opds/books/{book_id}": {
+            "get": {
+                "description": "Complete OPDS entry of a book",
+                "produces": [
+                    "application/atom+xml"
+                ],
+                "tags": [
+                    "OPDS"
+                ],
+                "summary": "OPDS book entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Book ID",
+                        "name": "book_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OPDS entry",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: book_id is not a number",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Book does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/opds/genres/{genre_id}": {
+            "get": {
+                "description": "Navigation feed of the top of the genre tree, or of the genres below one with a feed of every book in it",
+                "produces": [
+                    "application/atom+xml"
+                ],
+                "tags": [
+                    "OPDS"
+                ],
+                "summary": "OPDS genres",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "format": "int64",
+                        "description": "Genre ID",
+                        "name": "genre_id",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OPDS navigation feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: genre_id is not a number",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Genre does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/opds/languages": {
+            "get": {
+                "description": "Navigation feed with a feed of books for every language the catalogue has books in",
+                "produces": [
+                    "application/atom+xml"
+                ],
+                "tags": [
+                    "OPDS"
+                ],
+                "summary": "OPDS languages",
+                "responses": {
+                    "200": {
+                        "description": "OPDS navigation feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/opds/new": {
+            "get": {
+                "description": "Acquisition feed of the books most recently added to the catalogue, newest first",
+                "produces": [
+                    "application/atom+xml"
+                ],
+                "tags": [
+                    "OPDS"
+                ],
+                "summary": "OPDS new additions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number, from 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Books per page, 25 by default and at most 100",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OPDS acquisition feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request: Invalid query parameters",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/opds/opensearch.xml": {
+            "get": {
+                "description": "Describes how to search the catalogue, the search terms are matched against the title",
+                "produces": [
+                    "application/opensearchdescription+xml"
+                ],
+                "tags": [
+                    "OPDS"
+                ],
+                "summary": "OPDS OpenSearch description",
+                "responses": {
+                    "200": {
+                        "description": "OpenSearch description",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/sru": {
+            "get": {
+                "description": "Search the books with a CQL query such as dc.title any \"orwell\" and dc.language = eng, returning Dublin Core or MARCXML records. Without a query the server describes itself. Problems with the request are reported as SRU diagnostics",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "SRU"
+                ],
+                "summary": "SRU search and explain",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "searchRetrieve or explain",
+                        "name": "operation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "SRU version, 1.1 or 1.2",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CQL query",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Position of the first record, from 1",
+                        "name": "startRecord",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records, 10 by default and at most 100",
+                        "name": "maximumRecords",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "dc or marcxml",
+                        "name": "recordSchema",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "xml or string",
+                        "name": "recordPacking",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "SRU response",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "SRU response with a general system error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "audit.Action": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "delete",
+                "restore",
+                "purge"
+            ],
+            "x-enum-varnames": [
+                "Create",
+                "Update",
+                "Delete",
+                "Restore",
+                "Purge"
+            ]
+        },
+        "audit.Change": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "new": {},
+                "old": {}
+            }
+        },
+        "audit.Entity": {
+            "type": "string",
+            "enum": [
+                "book"
+            ],
+            "x-enum-varnames": [
+                "Book"
+            ]
+        },
+        "audit.Record": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/audit.Action"
+                },
+                "actor": {
+                    "description": "Who made the change, system for scheduled jobs",
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/audit.Change"
+                    }
+                },
+                "created_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "entity": {
+                    "$ref": "#/definitions/audit.Entity"
+                },
+                "entity_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "authors.Author": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "birth_year": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "death_year": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sort_name": {
+                    "description": "Used to order authors, \"Orwell, George\" for \"George Orwell\" unless it is given",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                }
+            }
+        },
+        "authors.Credit": {
+            "type": "object",
+            "required": [
+                "book_id",
+                "role"
+            ],
+            "properties": {
+                "author_id": {
+                    "type": "integer"
+                },
+                "book_id": {
+                    "type": "integer"
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "Order the author appears in on the book, starting at 1",
+                    "type": "integer"
+                },
+                "role": {
+                    "$ref": "#/definitions/authors.Role"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "authors.Role": {
+            "type": "string",
+            "enum": [
+                "author",
+                "editor",
+                "translator",
+                "illustrator"
+            ],
+            "x-enum-varnames": [
+                "Writer",
+                "Editor",
+                "Translator",
+                "Illustrator"
+            ]
+        },
+        "books.Availability": {
+            "type": "string",
+            "enum": [
+                "available",
+                "not_available"
+            ],
+            "x-enum-varnames": [
+                "Available",
+                "NotAvailable"
+            ]
+        },
+        "books.Book": {
+            "type": "object",
+            "required": [
+                "author",
+                "genre",
+                "isbn",
+                "language",
+                "pages",
+                "published",
+                "publisher",
+                "title"
+            ],
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "availability": {
+                    "$ref": "#/definitions/books.Availability"
+                },
+                "copies_available": {
+                    "type": "integer"
+                },
+                "copies_total": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "deleted_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "pages": {
+                    "type": "integer"
+                },
+                "published": {
+                    "$ref": "#/definitions/utils.CustomDate"
+                },
+                "publisher": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "books.BulkResult": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "description": "Fields a bulk update changed, or would change on a dry run",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/audit.Change"
+                    }
+                },
+                "copies_changed": {
+                    "description": "Copies a bulk update took off the shelf",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "books.FacetBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "books.ImportReport": {
+            "type": "object",
+            "properties": {
+                "imported": {
+                    "type": "integer"
+                },
+                "rejected": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/books.RejectedRow"
+                    }
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/books.ImportWarning"
+                    }
+                }
+            }
+        },
+        "books.ImportWarning": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "record": {
+                    "type": "integer"
+                }
+            }
+        },
+        "books.IngestOutcome": {
+            "type": "string",
+            "enum": [
+                "inserted",
+                "updated",
+                "skipped"
+            ],
+            "x-enum-varnames": [
+                "Inserted",
+                "Updated",
+                "Skipped"
+            ]
+        },
+        "books.IngestReport": {
+            "type": "object",
+            "properties": {
+                "inserted": {
+                    "type": "integer"
+                },
+                "products": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/books.IngestResult"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "books.IngestResult": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "description": "Fields an update changed on the stored book",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/audit.Change"
+                    }
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "outcome": {
+                    "$ref": "#/definitions/books.IngestOutcome"
+                },
+                "product": {
+                    "type": "integer"
+                },
+                "product_form": {
+                    "description": "ONIX product form code, BC for a paperback, EA for a digital book, and whether it is print or digital,\nso editions of one title with their own isbns can be told apart",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "books.RejectedRow": {
+            "type": "object",
+            "properties": {
+                "line": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "books.SearchHit": {
+            "type": "object",
+            "properties": {
+                "book": {
+                    "$ref": "#/definitions/books.Book"
+                },
+                "highlights": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "copies.Condition": {
+            "type": "string",
+            "enum": [
+                "new",
+                "good",
+                "fair",
+                "poor",
+                "damaged"
+            ],
+            "x-enum-varnames": [
+                "New",
+                "Good",
+                "Fair",
+                "Poor",
+                "Damaged"
+            ]
+        },
+        "copies.Copy": {
+            "type": "object",
+            "required": [
+                "barcode",
+                "book_id",
+                "condition",
+                "status"
+            ],
+            "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "book_id": {
+                    "type": "integer"
+                },
+                "condition": {
+                    "$ref": "#/definitions/copies.Condition"
+                },
+                "created_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "shelf_location": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/copies.Status"
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                }
+            }
+        },
+        "copies.Status": {
+            "type": "string",
+            "enum": [
+                "available",
+                "on_loan",
+                "on_hold",
+                "in_repair",
+                "lost",
+                "withdrawn"
+            ],
+            "x-enum-varnames": [
+                "Available",
+                "OnLoan",
+                "OnHold",
+                "InRepair",
+                "Lost",
+                "Withdrawn"
+            ]
+        },
+        "fines.Fine": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "amount_paid": {
+                    "type": "integer"
+                },
+                "assessed_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "created_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "days_overdue": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "loan_id": {
+                    "type": "integer"
+                },
+                "member_id": {
+                    "type": "integer"
+                },
+                "settled_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "status": {
+                    "$ref": "#/definitions/fines.Status"
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "waiver_reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "fines.Status": {
+            "type": "string",
+            "enum": [
+                "outstanding",
+                "paid",
+                "waived"
+            ],
+            "x-enum-varnames": [
+                "Outstanding",
+                "Paid",
+                "Waived"
+            ]
+        },
+        "genres.Kind": {
+            "type": "string",
+            "enum": [
+                "genre",
+                "subject"
+            ],
+            "x-enum-varnames": [
+                "Genre",
+                "Subject"
+            ]
+        },
+        "genres.Node": {
+            "type": "object",
+            "required": [
+                "kind",
+                "name"
+            ],
+            "properties": {
+                "created_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "$ref": "#/definitions/genres.Kind"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "description": "Zero for the top level of the tree",
+                    "type": "integer"
+                },
+                "path": {
+                    "description": "Names from the top of the tree down to this node, e.g. Fiction \u003e Science Fiction \u003e Dystopian",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                }
+            }
+        },
+        "holds.Hold": {
+            "type": "object",
+            "required": [
+                "book_id",
+                "member_id"
+            ],
+            "properties": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "copy_id": {
+                    "description": "The copy set aside for the member once the hold is ready",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "expires_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "member_id": {
+                    "type": "integer"
+                },
+                "placed_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "position": {
+                    "description": "Place in the queue for waiting holds, derived from the order holds were placed in",
+                    "type": "integer"
+                },
+                "ready_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "status": {
+                    "$ref": "#/definitions/holds.Status"
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                }
+            }
+        },
+        "holds.Status": {
+            "type": "string",
+            "enum": [
+                "waiting",
+                "ready",
+                "fulfilled",
+                "cancelled",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "Waiting",
+                "Ready",
+                "Fulfilled",
+                "Cancelled",
+                "Expired"
+            ]
+        },
+        "loans.Loan": {
+            "type": "object",
+            "required": [
+                "book_id",
+                "member_id"
+            ],
+            "properties": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "checked_out_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "copy_id": {
+                    "description": "Optional on checkout, any copy on the shelf is handed out when it is left empty",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "due_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "fine": {
+                    "description": "Charged when the book came back late, only set on the return response",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/fines.Fine"
+                        }
+                    ]
+                },
+                "fine_pending": {
+                    "description": "The fine could not be assessed on return, it is assessed by the background sweep instead",
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "member_id": {
+                    "type": "integer"
+                },
+                "returned_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "status": {
+                    "$ref": "#/definitions/loans.Status"
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                }
+            }
+        },
+        "loans.Status": {
+            "type": "string",
+            "enum": [
+                "active",
+                "overdue",
+                "returned"
+            ],
+            "x-enum-varnames": [
+                "Active",
+                "Overdue",
+                "Returned"
+            ]
+        },
+        "members.Member": {
+            "type": "object",
+            "required": [
+                "card_number",
+                "email",
+                "expires_at",
+                "membership_type",
+                "name",
+                "status"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "card_number": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "$ref": "#/definitions/utils.CustomDate"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "membership_type": {
+                    "$ref": "#/definitions/members.MembershipType"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/members.Status"
+                },
+                "updated_at": {
+                    "$ref": "#/definitions/utils.CustomTime"
+                }
+            }
+        },
+        "members.MembershipType": {
+            "type": "string",
+            "enum": [
+                "standard",
+                "student",
+                "senior",
+                "staff"
+            ],
+            "x-enum-varnames": [
+                "Standard",
+                "Student",
+                "Senior",
+                "Staff"
+            ]
+        },
+        "members.Status": {
+            "type": "string",
+            "enum": [
+                "active",
+                "suspended",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "Active",
+                "Suspended",
+                "Expired"
+            ]
+        },
+        "swagger.BulkBooksFilter": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "availability": {
+                    "type": "string"
+                },
+                "book_pages": {
+                    "type": "integer"
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "genre_id": {
+                    "type": "integer"
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "published": {
+                    "type": "string"
+                },
+                "publisher": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.BulkBooksResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/books.BulkResult"
+                    }
+                }
+            }
+        },
+        "swagger.BulkCreateBooksRequestBody": {
+            "type": "object",
+            "properties": {
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/swagger.CreateBookRequestBody"
+                    }
+                },
+                "mode": {
+                    "description": "all_or_nothing (default) or partial",
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.BulkCreateBooksResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/books.BulkResult"
+                    }
+                }
+            }
+        },
+        "swagger.BulkDeleteBooksRequestBody": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "filter": {
+                    "$ref": "#/definitions/swagger.BulkBooksFilter"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "mode": {
+                    "description": "all_or_nothing (default) or partial",
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.BulkUpdateBooksRequestBody": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "description": "A merge patch applied to every book, isbn cannot be set in bulk",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/swagger.PatchBookRequestBody"
+                        }
+                    ]
+                },
+                "copy_status": {
+                    "description": "in_repair, lost or withdrawn",
+                    "type": "string"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "filter": {
+                    "$ref": "#/definitions/swagger.BulkBooksFilter"
+                },
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "mode": {
+                    "description": "all_or_nothing (default) or partial",
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.CheckoutBookRequestBody": {
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "copy_id": {
+                    "type": "integer"
+                },
+                "due_at": {
+                    "type": "integer"
+                },
+                "member_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.CreateAuthorRequestBody": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "birth_year": {
+                    "type": "integer"
+                },
+                "death_year": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sort_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.CreateBookRequestBody": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "genre": {
+                    "type": "string"
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "pages": {
+                    "type": "integer"
+                },
+                "published": {
+                    "type": "integer"
+                },
+                "publisher": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.CreateCopyRequestBody": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "book_id": {
+                    "type": "integer"
+                },
+                "condition": {
+                    "type": "string"
+                },
+                "shelf_location": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.CreateGenreRequestBody": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.CreateMemberRequestBody": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "card_number": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "integer"
+                },
+                "membership_type": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.CreditAuthorRequestBody": {
+            "type": "object",
+            "properties": {
+                "position": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.GetAuditRecordsResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "records": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/audit.Record"
+                    }
+                }
+            }
+        },
+        "swagger.GetAuthorBooksResponse": {
+            "type": "object",
+            "properties": {
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/authors.Credit"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.GetAuthorsResponse": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/authors.Author"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.GetBooksReponse": {
+            "type": "object",
+            "properties": {
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/books.Book"
+                    }
+                },
+                "count": {
+                    "description": "Every book that matches the filters, left out when total=false",
+                    "type": "integer"
+                },
+                "facets": {
+                    "description": "Only when facets are asked for, keyed by facet",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/books.FacetBucket"
+                        }
+                    }
+                },
+                "has_next": {
+                    "type": "boolean"
+                },
+                "next_cursor": {
+                    "description": "Only when the books are listed by cursor and there is a page in that direction",
+                    "type": "string"
+                },
+                "page": {
+                    "description": "Page and total_pages are only given when listing by page",
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.GetCopiesResponse": {
+            "type": "object",
+            "properties": {
+                "copies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/copies.Copy"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.GetFinesResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "fines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fines.Fine"
+                    }
+                }
+            }
+        },
+        "swagger.GetGenresResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/genres.Node"
+                    }
+                }
+            }
+        },
+        "swagger.GetHoldsResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "holds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/holds.Hold"
+                    }
+                }
+            }
+        },
+        "swagger.GetLoansResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "loans": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/loans.Loan"
+                    }
+                }
+            }
+        },
+        "swagger.GetMembersResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/members.Member"
+                    }
+                }
+            }
+        },
+        "swagger.MemberBalanceResponse": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "type": "integer"
+                },
+                "checkout_blocked": {
+                    "type": "boolean"
+                },
+                "member_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.MergeGenreRequestBody": {
+            "type": "object",
+            "properties": {
+                "target_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.MoveGenreRequestBody": {
+            "type": "object",
+            "properties": {
+                "parent_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.PatchBookRequestBody": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "genre": {
                     "type": "string"
                 },
                 "isbn": {
@@ -389,17 +5128,67 @@ const docTemplate = `{
                 }
             }
         },
-        "swagger.GetBooksReponse": {
+        "swagger.PayFineRequestBody": {
             "type": "object",
             "properties": {
-                "books": {
+                "amount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.PlaceHoldRequestBody": {
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "type": "integer"
+                },
+                "member_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "swagger.RenameGenreRequestBody": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.SearchBooksResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "hits": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/books.Book"
+                        "$ref": "#/definitions/books.SearchHit"
+                    }
+                }
+            }
+        },
+        "swagger.UpdateAuthorRequestBody": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
                 },
-                "count": {
+                "birth_year": {
+                    "type": "integer"
+                },
+                "death_year": {
                     "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "sort_name": {
+                    "type": "string"
                 }
             }
         },
@@ -409,9 +5198,6 @@ const docTemplate = `{
                 "author": {
                     "type": "string"
                 },
-                "availability": {
-                    "type": "string"
-                },
                 "genre": {
                     "type": "string"
                 },
@@ -435,6 +5221,60 @@ const docTemplate = `{
                 }
             }
         },
+        "swagger.UpdateCopyRequestBody": {
+            "type": "object",
+            "properties": {
+                "barcode": {
+                    "type": "string"
+                },
+                "condition": {
+                    "type": "string"
+                },
+                "shelf_location": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.UpdateMemberRequestBody": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "card_number": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "integer"
+                },
+                "membership_type": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "swagger.WaiveFineRequestBody": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
         "utils.CustomDate": {
             "type": "object",
             "properties": {
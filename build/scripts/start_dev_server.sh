@@ -14,6 +14,15 @@ export REDIS_HOST="localhost"
 export REDIS_PORT="6389"
 export RATE_LIMITER_MAX_REQUESTS=30
 export RATE_LIMITER_WINDOW=1
+# Overdue fines in cents, per membership type overrides use FINES_<TYPE>_DAILY_RATE etc
+export FINES_CHECKOUT_BLOCK_THRESHOLD=1000
+export FINES_DAILY_RATE=25
+export FINES_GRACE_PERIOD_DAYS=2
+export FINES_MAX_FINE=2000
+export FINES_STUDENT_DAILY_RATE=10
+export FINES_SENIOR_DAILY_RATE=10
+# Key the cursors of the book listing are signed with
+export BOOKS_CURSOR_SECRET="change-me-in-production"
 go run cmd/main.go server
 # Create a dump for running in compose 
 # mysqldump -u root -p --host 127.0.0.1 --port 3306 --ssl-mode=REQUIRED library_dev > dump_file.sql
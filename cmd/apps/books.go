@@ -2,13 +2,16 @@ package apps
 
 import (
 	"context"
+	"crypto/rand"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/GabDewraj/library-api/cmd/config"
 	"github.com/GabDewraj/library-api/pkgs/api/handlers"
 	"github.com/GabDewraj/library-api/pkgs/api/middleware"
 	"github.com/GabDewraj/library-api/pkgs/api/routers"
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
 	"github.com/GabDewraj/library-api/pkgs/domain/books"
 	"github.com/GabDewraj/library-api/pkgs/infrastructure/cache/redcache"
 	"github.com/GabDewraj/library-api/pkgs/infrastructure/repo"
@@ -19,6 +22,9 @@ import (
 	"go.uber.org/fx"
 )
 
+// How often books past their trash retention period are purged
+const trashPurgeInterval = time.Hour
+
 type BooksAppParams struct {
 	fx.In
 	Cfg      *config.Config
@@ -45,10 +51,16 @@ func BooksApp(p BooksAppParams) {
 			redcache.NewRedisCache,
 			repo.NewBooksDB,
 			books.NewService,
+			repo.NewAuditDB,
+			audit.NewService,
 			middleware.NewMiddlwareStack,
+			NewCursorSigner,
 			handlers.NewBooksHandler,
 		),
 		fx.Invoke(routers.NewBooksRouter),
+		fx.Invoke(func(service books.Service) {
+			go purgeDeletedBooks(p.CTX, service, time.Duration(p.Cfg.BooksConfig.TrashRetentionDays)*24*time.Hour)
+		}),
 	)
 
 	logrus.Infoln("Books application is running...")
@@ -74,3 +86,38 @@ func BooksApp(p BooksAppParams) {
 		os.Exit(0)
 	}(p.CTX, p.MU)
 }
+
+// Sign book cursors with the configured secret. Without one a random key is made, the cursors then stop working
+// when the server restarts and are not accepted by other instances
+func NewCursorSigner(cfg *config.Config) (*books.CursorSigner, error) {
+	if secret := cfg.BooksConfig.CursorSecret; secret != "" {
+		return books.NewCursorSigner([]byte(secret)), nil
+	}
+	logrus.Warn("BOOKS_CURSOR_SECRET is not set, book cursors are signed with a key that only lasts until restart")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return books.NewCursorSigner(key), nil
+}
+
+// Periodically hard delete the books that have been in the trash for longer than the retention period
+func purgeDeletedBooks(ctx context.Context, service books.Service, retention time.Duration) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := service.PurgeDeletedBooks(ctx, retention)
+			if err != nil {
+				logrus.Errorf("Failed to purge deleted books: %v", err)
+				continue
+			}
+			if purged > 0 {
+				logrus.Infof("Purged %d deleted books", purged)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package apps
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/GabDewraj/library-api/cmd/config"
+	"github.com/GabDewraj/library-api/pkgs/api/handlers"
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/api/routers"
+	"github.com/GabDewraj/library-api/pkgs/domain/holds"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/cache/redcache"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/repo"
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+// How often ready holds that were never collected are expired
+const holdExpiryInterval = 15 * time.Minute
+
+type HoldsAppParams struct {
+	fx.In
+	Cfg      *config.Config
+	Router   *chi.Mux
+	Logger   *logrus.Logger
+	DB       *sqlx.DB
+	Redis    *redis.Client
+	MU       *sync.Mutex
+	CTX      context.Context
+	Shutdown chan os.Signal
+}
+
+func HoldsApp(p HoldsAppParams) {
+	// Create the application
+	app := fx.New(
+		fx.Supply(
+			p.Router,
+			p.DB,
+			p.Cfg,
+			p.Redis,
+			p.MU,
+		),
+		fx.Provide(
+			redcache.NewRedisCache,
+			repo.NewHoldsDB,
+			holds.NewService,
+			middleware.NewMiddlwareStack,
+			handlers.NewHoldsHandler,
+		),
+		fx.Invoke(routers.NewHoldsRouter),
+		fx.Invoke(func(service holds.Service) {
+			go expireReadyHolds(p.CTX, service)
+		}),
+	)
+
+	logrus.Infoln("Holds application is running...")
+	if err := app.Start(p.CTX); err != nil {
+		logrus.Errorf("Holds application is shutting down with ERR: %v", err)
+		os.Exit(1)
+		return
+	}
+	// Wait for the shutdown signal, using shared application to listen for cancel signal incase of error
+	go func(ctx context.Context, mu *sync.Mutex) {
+		mu.Lock()
+		<-p.Shutdown
+		logger := logrus.StandardLogger()
+		logger.Info("Received shutdown signal. Shutting down gracefully...")
+
+		// Stop the application
+		if err := app.Stop(ctx); err != nil {
+			logger.Error("Error stopping the application:", err)
+			os.Exit(1)
+		}
+		mu.Unlock()
+		os.Exit(0)
+	}(p.CTX, p.MU)
+}
+
+// Periodically pass books that were not collected in time on to the next member in the queue
+func expireReadyHolds(ctx context.Context, service holds.Service) {
+	ticker := time.NewTicker(holdExpiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := service.ExpireReadyHolds(ctx)
+			if err != nil {
+				logrus.Errorf("Failed to expire ready holds: %v", err)
+				continue
+			}
+			if expired > 0 {
+				logrus.Infof("Expired %d uncollected holds", expired)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package apps
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/GabDewraj/library-api/cmd/config"
+	"github.com/GabDewraj/library-api/pkgs/api/handlers"
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/api/routers"
+	"github.com/GabDewraj/library-api/pkgs/domain/fines"
+	"github.com/GabDewraj/library-api/pkgs/domain/loans"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/cache/redcache"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/repo"
+	"github.com/go-chi/chi"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+// How often late returns left without a fine are assessed again
+const lateReturnSweepInterval = 15 * time.Minute
+
+type LoansAppParams struct {
+	fx.In
+	Cfg      *config.Config
+	Router   *chi.Mux
+	Logger   *logrus.Logger
+	DB       *sqlx.DB
+	Redis    *redis.Client
+	MU       *sync.Mutex
+	CTX      context.Context
+	Shutdown chan os.Signal
+}
+
+func LoansApp(p LoansAppParams) {
+	// Create the application
+	app := fx.New(
+		fx.Supply(
+			p.Router,
+			p.DB,
+			p.Cfg,
+			p.Redis,
+			p.MU,
+		),
+		fx.Provide(
+			redcache.NewRedisCache,
+			repo.NewLoansDB,
+			// Checkouts and returns consult the fines ledger
+			repo.NewFinesDB,
+			NewFinesPolicy,
+			fines.NewService,
+			loans.NewService,
+			middleware.NewMiddlwareStack,
+			handlers.NewLoansHandler,
+		),
+		fx.Invoke(routers.NewLoansRouter),
+		fx.Invoke(func(service loans.Service) {
+			go assessLateReturns(p.CTX, service)
+		}),
+	)
+
+	logrus.Infoln("Loans application is running...")
+	if err := app.Start(p.CTX); err != nil {
+		logrus.Errorf("Loans application is shutting down with ERR: %v", err)
+		os.Exit(1)
+		return
+	}
+	// Wait for the shutdown signal, using shared application to listen for cancel signal incase of error
+	go func(ctx context.Context, mu *sync.Mutex) {
+		mu.Lock()
+		<-p.Shutdown
+		logger := logrus.StandardLogger()
+		logger.Info("Received shutdown signal. Shutting down gracefully...")
+
+		// Stop the application
+		if err := app.Stop(ctx); err != nil {
+			logger.Error("Error stopping the application:", err)
+			os.Exit(1)
+		}
+		mu.Unlock()
+		os.Exit(0)
+	}(p.CTX, p.MU)
+}
+
+// Periodically fine the late returns whose fine could not be assessed when the book came back
+func assessLateReturns(ctx context.Context, service loans.Service) {
+	ticker := time.NewTicker(lateReturnSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			assessed, err := service.AssessLateReturns(ctx, loans.LateReturnWindow)
+			if err != nil {
+				logrus.Errorf("Failed to assess late returns: %v", err)
+			}
+			if assessed > 0 {
+				logrus.Infof("Assessed fines for %d late returns", assessed)
+			}
+		}
+	}
+}
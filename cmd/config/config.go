@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -18,6 +19,8 @@ type Config struct {
 	DB               DBConfig
 	RedisConfig      RedisConfig
 	MiddlewareConfig MiddlewareConfig
+	FinesConfig      FinesConfig
+	BooksConfig      BooksConfig
 }
 
 // Mysql DB config
@@ -42,6 +45,31 @@ type MiddlewareConfig struct {
 	RateWindow  int
 }
 
+// Overdue fine policy, all amounts are in cents
+type FinesConfig struct {
+	// Members owing more than this cannot check out books
+	CheckoutBlockThreshold int
+	Default                FinePolicyConfig
+	// Overrides of the default policy keyed by membership type
+	MembershipPolicies map[string]FinePolicyConfig
+}
+type FinePolicyConfig struct {
+	DailyRate       int
+	GracePeriodDays int
+	// Zero means the fine is not capped
+	MaxFine int
+}
+
+// Deleted books stay in the trash for the retention period before they are purged
+type BooksConfig struct {
+	TrashRetentionDays int
+	// Key cursors handed out by the book listing are signed with, shared by every instance behind a load balancer
+	CursorSecret string
+}
+
+// Membership types that can be given their own fine policy through the environment
+var fineMembershipTypes = []string{"standard", "student", "senior", "staff"}
+
 func NewConfig() (*Config, error) {
 	// Retrieve params for rate limiting
 	maxrequests, err := strconv.Atoi(os.Getenv("RATE_LIMITER_MAX_REQUESTS"))
@@ -57,18 +85,17 @@ func NewConfig() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	fines, err := newFinesConfig()
+	if err != nil {
+		return nil, err
+	}
+	trashRetention, err := envInt("BOOKS_TRASH_RETENTION_DAYS", 30)
+	if err != nil {
+		return nil, err
+	}
 	return &Config{
 		ServerPort: fmt.Sprintf(":%s", os.Getenv("SERVER_PORT")),
-		DB: DBConfig{
-			Driver:                 os.Getenv("MYSQL_DRIVER"),
-			Host:                   os.Getenv("MYSQL_HOST"),
-			Port:                   os.Getenv("MYSQL_PORT"),
-			Database:               os.Getenv("MYSQL_DATABASE"),
-			Password:               os.Getenv("MYSQL_PASSWORD"),
-			Username:               os.Getenv("MYSQL_USERNAME"),
-			MigrationDirectoryPath: os.Getenv("SERVER_MIGRATION_DIRECTORY"),
-			ForceTLS:               false,
-		},
+		DB:         dbConfigFromEnv(),
 		RedisConfig: RedisConfig{
 			Host: os.Getenv("REDIS_HOST"),
 			Port: redisport,
@@ -77,9 +104,93 @@ func NewConfig() (*Config, error) {
 			MaxRequests: maxrequests,
 			RateWindow:  ratewindow,
 		},
+		FinesConfig: *fines,
+		BooksConfig: BooksConfig{
+			TrashRetentionDays: trashRetention,
+			CursorSecret:       os.Getenv("BOOKS_CURSOR_SECRET"),
+		},
+	}, nil
+}
+
+// NewDBConfig only reads the database from the environment, for commands that run without the server
+func NewDBConfig() *Config {
+	return &Config{DB: dbConfigFromEnv()}
+}
+
+func dbConfigFromEnv() DBConfig {
+	return DBConfig{
+		Driver:                 os.Getenv("MYSQL_DRIVER"),
+		Host:                   os.Getenv("MYSQL_HOST"),
+		Port:                   os.Getenv("MYSQL_PORT"),
+		Database:               os.Getenv("MYSQL_DATABASE"),
+		Password:               os.Getenv("MYSQL_PASSWORD"),
+		Username:               os.Getenv("MYSQL_USERNAME"),
+		MigrationDirectoryPath: os.Getenv("SERVER_MIGRATION_DIRECTORY"),
+		ForceTLS:               false,
+	}
+}
+
+// Fine policies are optional in the environment, unset values fall back to library wide defaults
+func newFinesConfig() (*FinesConfig, error) {
+	threshold, err := envInt("FINES_CHECKOUT_BLOCK_THRESHOLD", 1000)
+	if err != nil {
+		return nil, err
+	}
+	defaultPolicy, err := finePolicyFromEnv("FINES", FinePolicyConfig{
+		DailyRate:       25,
+		GracePeriodDays: 2,
+		MaxFine:         2000,
+	})
+	if err != nil {
+		return nil, err
+	}
+	policies := map[string]FinePolicyConfig{}
+	for _, membershipType := range fineMembershipTypes {
+		policy, err := finePolicyFromEnv("FINES_"+strings.ToUpper(membershipType), *defaultPolicy)
+		if err != nil {
+			return nil, err
+		}
+		policies[membershipType] = *policy
+	}
+	return &FinesConfig{
+		CheckoutBlockThreshold: threshold,
+		Default:                *defaultPolicy,
+		MembershipPolicies:     policies,
+	}, nil
+}
+
+func finePolicyFromEnv(prefix string, fallback FinePolicyConfig) (*FinePolicyConfig, error) {
+	dailyRate, err := envInt(prefix+"_DAILY_RATE", fallback.DailyRate)
+	if err != nil {
+		return nil, err
+	}
+	gracePeriod, err := envInt(prefix+"_GRACE_PERIOD_DAYS", fallback.GracePeriodDays)
+	if err != nil {
+		return nil, err
+	}
+	maxFine, err := envInt(prefix+"_MAX_FINE", fallback.MaxFine)
+	if err != nil {
+		return nil, err
+	}
+	return &FinePolicyConfig{
+		DailyRate:       dailyRate,
+		GracePeriodDays: gracePeriod,
+		MaxFine:         maxFine,
 	}, nil
 }
 
+func envInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	converted, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return converted, nil
+}
+
 // Database Connection Configuration.
 func NewDBConnection(config *Config) (*sqlx.DB, error) {
 	dbConfig := config.DB
@@ -128,7 +239,18 @@ func PerformMigrations(db *sqlx.DB, logger *logrus.Logger, args *Config) error {
 		Dir: args.DB.MigrationDirectoryPath,
 	}, migrate.Up)
 	logger.Infof("Performed %d migrations", n)
-	return err
+	if err != nil {
+		return err
+	}
+	// ISBNs the normalization could not rewrite stay in place until someone resolves them
+	var unresolved int
+	if err := db.Get(&unresolved, "SELECT COUNT(*) FROM isbn_review;"); err != nil {
+		return err
+	}
+	if unresolved > 0 {
+		logger.Warnf("%d books have an invalid or duplicate ISBN, they are listed in the isbn_review table", unresolved)
+	}
+	return nil
 }
 
 // Create a Redis client for Cache service
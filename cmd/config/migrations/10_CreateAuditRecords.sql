@@ -0,0 +1,16 @@
+-- +migrate Up
+-- No foreign keys so that the history of a book outlives the book
+CREATE TABLE `audit_records` (
+    `id` BIGINT AUTO_INCREMENT PRIMARY KEY,
+    `entity` VARCHAR(30) NOT NULL,
+    `entity_id` INT NOT NULL,
+    `action` VARCHAR(30) NOT NULL,
+    `actor` VARCHAR(255) NOT NULL,
+    `changes` JSON NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_entity_entity_id` (`entity`, `entity_id`),
+    INDEX `idx_actor_created_at` (`actor`, `created_at`),
+    INDEX `idx_created_at` (`created_at`)
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+-- +migrate Down
+DROP TABLE audit_records;
@@ -0,0 +1,5 @@
+-- +migrate Up
+-- Every change to a book bumps its version, clients send it back in If-Match to avoid lost updates
+ALTER TABLE `books` ADD COLUMN `version` INT NOT NULL DEFAULT 1 AFTER `pages`;
+-- +migrate Down
+ALTER TABLE `books` DROP COLUMN `version`;
@@ -0,0 +1,14 @@
+-- +migrate Up
+-- Books match a full-text search on the index over every text field, the index of each field ranks it so fields
+-- can be weighted. InnoDB builds one full-text index at a time
+ALTER TABLE `books` ADD FULLTEXT INDEX `ft_books` (`title`, `author`, `publisher`, `genre`);
+ALTER TABLE `books` ADD FULLTEXT INDEX `ft_title` (`title`);
+ALTER TABLE `books` ADD FULLTEXT INDEX `ft_author` (`author`);
+ALTER TABLE `books` ADD FULLTEXT INDEX `ft_publisher` (`publisher`);
+ALTER TABLE `books` ADD FULLTEXT INDEX `ft_genre` (`genre`);
+-- +migrate Down
+ALTER TABLE `books` DROP INDEX `ft_genre`;
+ALTER TABLE `books` DROP INDEX `ft_publisher`;
+ALTER TABLE `books` DROP INDEX `ft_author`;
+ALTER TABLE `books` DROP INDEX `ft_title`;
+ALTER TABLE `books` DROP INDEX `ft_books`;
@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE `loans` (
+    `id` INT AUTO_INCREMENT PRIMARY KEY,
+    `book_id` INT NOT NULL,
+    `member_id` INT NOT NULL,
+    `checked_out_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `due_at` TIMESTAMP NOT NULL,
+    `returned_at` TIMESTAMP NULL,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_book_id_returned_at` (`book_id`, `returned_at`),
+    INDEX `idx_member_id_returned_at` (`member_id`, `returned_at`),
+    INDEX `idx_due_at` (`due_at`),
+    CONSTRAINT `fk__loans__books` FOREIGN KEY (`book_id`) REFERENCES `books` (`id`) ON DELETE CASCADE
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+-- +migrate Down
+DROP TABLE loans;
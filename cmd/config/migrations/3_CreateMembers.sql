@@ -0,0 +1,37 @@
+-- +migrate Up
+CREATE TABLE `members` (
+    `id` INT AUTO_INCREMENT PRIMARY KEY,
+    `card_number` VARCHAR(64) NOT NULL,
+    `name` VARCHAR(255) NOT NULL,
+    `email` VARCHAR(255) NOT NULL,
+    `phone` VARCHAR(50) NOT NULL DEFAULT '',
+    `address` VARCHAR(512) NOT NULL DEFAULT '',
+    `membership_type` VARCHAR(30) NOT NULL,
+    `status` VARCHAR(30) NOT NULL,
+    `expires_at` DATE NOT NULL,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY `uk__card_number` (`card_number`),
+    INDEX `idx_name` (`name`),
+    INDEX `idx_email` (`email`),
+    INDEX `idx_status_expires_at` (`status`, `expires_at`)
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+-- Loans made before members existed keep their member id through a suspended placeholder member
+INSERT INTO `members` (`id`, `card_number`, `name`, `email`, `membership_type`, `status`, `expires_at`)
+SELECT DISTINCT l.`member_id`, CONCAT('M', LPAD(l.`member_id`, 9, '0')), 'Unknown member', '',
+    'standard', 'suspended', CURRENT_DATE
+FROM `loans` l
+WHERE l.`member_id` > 0;
+-- A member id of zero or less cannot be a member of its own, those loans all go to one more placeholder
+INSERT INTO `members` (`card_number`, `name`, `email`, `membership_type`, `status`, `expires_at`)
+SELECT 'M-UNKNOWN', 'Unknown member', '', 'standard', 'suspended', CURRENT_DATE
+FROM DUAL
+WHERE EXISTS (SELECT 1 FROM `loans` WHERE `member_id` <= 0);
+UPDATE `loans` l JOIN `members` m ON m.`card_number` = 'M-UNKNOWN'
+SET l.`member_id` = m.`id`
+WHERE l.`member_id` <= 0;
+ALTER TABLE `loans`
+    ADD CONSTRAINT `fk__loans__members` FOREIGN KEY (`member_id`) REFERENCES `members` (`id`);
+-- +migrate Down
+ALTER TABLE `loans` DROP FOREIGN KEY `fk__loans__members`;
+DROP TABLE members;
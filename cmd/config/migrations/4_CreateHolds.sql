@@ -0,0 +1,19 @@
+-- +migrate Up
+CREATE TABLE `holds` (
+    `id` INT AUTO_INCREMENT PRIMARY KEY,
+    `book_id` INT NOT NULL,
+    `member_id` INT NOT NULL,
+    `status` VARCHAR(30) NOT NULL,
+    `placed_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `ready_at` TIMESTAMP NULL,
+    `expires_at` TIMESTAMP NULL,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_book_id_status_id` (`book_id`, `status`, `id`),
+    INDEX `idx_member_id_status` (`member_id`, `status`),
+    INDEX `idx_status_expires_at` (`status`, `expires_at`),
+    CONSTRAINT `fk__holds__books` FOREIGN KEY (`book_id`) REFERENCES `books` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk__holds__members` FOREIGN KEY (`member_id`) REFERENCES `members` (`id`)
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+-- +migrate Down
+DROP TABLE holds;
@@ -0,0 +1,21 @@
+-- +migrate Up
+CREATE TABLE `fines` (
+    `id` INT AUTO_INCREMENT PRIMARY KEY,
+    `loan_id` INT NOT NULL,
+    `member_id` INT NOT NULL,
+    `days_overdue` INT NOT NULL,
+    `amount` INT NOT NULL,
+    `amount_paid` INT NOT NULL DEFAULT 0,
+    `status` VARCHAR(30) NOT NULL,
+    `waiver_reason` VARCHAR(512) NOT NULL DEFAULT '',
+    `assessed_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `settled_at` TIMESTAMP NULL,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY `uk__loan_id` (`loan_id`),
+    INDEX `idx_member_id_status` (`member_id`, `status`),
+    CONSTRAINT `fk__fines__loans` FOREIGN KEY (`loan_id`) REFERENCES `loans` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk__fines__members` FOREIGN KEY (`member_id`) REFERENCES `members` (`id`)
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+-- +migrate Down
+DROP TABLE fines;
@@ -0,0 +1,46 @@
+-- +migrate Up
+CREATE TABLE `copies` (
+    `id` INT AUTO_INCREMENT PRIMARY KEY,
+    `book_id` INT NOT NULL,
+    `barcode` VARCHAR(64) NOT NULL,
+    `shelf_location` VARCHAR(255) NOT NULL DEFAULT '',
+    `condition` VARCHAR(30) NOT NULL,
+    `status` VARCHAR(30) NOT NULL,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY `uk__barcode` (`barcode`),
+    INDEX `idx_book_id_status` (`book_id`, `status`),
+    INDEX `idx_shelf_location` (`shelf_location`),
+    CONSTRAINT `fk__copies__books` FOREIGN KEY (`book_id`) REFERENCES `books` (`id`) ON DELETE CASCADE
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+-- Every existing book becomes a single copy that keeps its current circulation state
+INSERT INTO `copies` (`book_id`, `barcode`, `condition`, `status`)
+SELECT b.`id`, CONCAT('B', LPAD(b.`id`, 9, '0')), 'good',
+    CASE
+        WHEN b.`availability` = 'available' THEN 'available'
+        WHEN EXISTS (SELECT 1 FROM `loans` l WHERE l.`book_id` = b.`id` AND l.`returned_at` IS NULL) THEN 'on_loan'
+        WHEN EXISTS (SELECT 1 FROM `holds` h WHERE h.`book_id` = b.`id` AND h.`status` = 'ready') THEN 'on_hold'
+        ELSE 'in_repair'
+    END
+FROM `books` b;
+-- Loans and ready holds now point at the copy that was handed out or set aside
+ALTER TABLE `loans` ADD COLUMN `copy_id` INT NULL AFTER `book_id`;
+UPDATE `loans` l JOIN `copies` c ON c.`book_id` = l.`book_id` SET l.`copy_id` = c.`id`;
+ALTER TABLE `loans`
+    MODIFY `copy_id` INT NOT NULL,
+    ADD INDEX `idx_copy_id_returned_at` (`copy_id`, `returned_at`),
+    ADD CONSTRAINT `fk__loans__copies` FOREIGN KEY (`copy_id`) REFERENCES `copies` (`id`) ON DELETE CASCADE;
+ALTER TABLE `holds` ADD COLUMN `copy_id` INT NULL AFTER `book_id`;
+UPDATE `holds` h JOIN `copies` c ON c.`book_id` = h.`book_id` SET h.`copy_id` = c.`id` WHERE h.`status` = 'ready';
+ALTER TABLE `holds`
+    ADD CONSTRAINT `fk__holds__copies` FOREIGN KEY (`copy_id`) REFERENCES `copies` (`id`) ON DELETE SET NULL;
+-- Availability is now worked out from the copies on the shelf
+ALTER TABLE `books` DROP COLUMN `availability`;
+-- +migrate Down
+ALTER TABLE `books` ADD COLUMN `availability` VARCHAR(30) NOT NULL DEFAULT 'not_available' AFTER `pages`,
+    ADD INDEX `idx_availability` (`availability`);
+UPDATE `books` b SET b.`availability` = 'available'
+WHERE EXISTS (SELECT 1 FROM `copies` c WHERE c.`book_id` = b.`id` AND c.`status` = 'available');
+ALTER TABLE `holds` DROP FOREIGN KEY `fk__holds__copies`, DROP COLUMN `copy_id`;
+ALTER TABLE `loans` DROP FOREIGN KEY `fk__loans__copies`, DROP INDEX `idx_copy_id_returned_at`, DROP COLUMN `copy_id`;
+DROP TABLE copies;
@@ -0,0 +1,40 @@
+-- +migrate Up
+CREATE TABLE `authors` (
+    `id` INT AUTO_INCREMENT PRIMARY KEY,
+    `name` VARCHAR(255) NOT NULL,
+    `sort_name` VARCHAR(255) NOT NULL,
+    `birth_year` SMALLINT NULL,
+    `death_year` SMALLINT NULL,
+    `aliases` JSON NOT NULL,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_name` (`name`),
+    INDEX `idx_sort_name` (`sort_name`)
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+CREATE TABLE `book_authors` (
+    `book_id` INT NOT NULL,
+    `author_id` INT NOT NULL,
+    `role` VARCHAR(30) NOT NULL,
+    `position` INT NOT NULL DEFAULT 1,
+    PRIMARY KEY (`book_id`, `author_id`, `role`),
+    INDEX `idx_author_id_role` (`author_id`, `role`),
+    CONSTRAINT `fk__book_authors__books` FOREIGN KEY (`book_id`) REFERENCES `books` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk__book_authors__authors` FOREIGN KEY (`author_id`) REFERENCES `authors` (`id`)
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+-- Every distinct author string becomes an author, "George Orwell" is sorted as "Orwell, George"
+INSERT INTO `authors` (`name`, `sort_name`, `aliases`)
+SELECT a.`name`,
+    CASE
+        WHEN LOCATE(' ', a.`name`) = 0 THEN a.`name`
+        ELSE CONCAT(SUBSTRING_INDEX(a.`name`, ' ', -1), ', ',
+            TRIM(LEFT(a.`name`, CHAR_LENGTH(a.`name`) - CHAR_LENGTH(SUBSTRING_INDEX(a.`name`, ' ', -1)))))
+    END,
+    '[]'
+FROM (SELECT DISTINCT TRIM(`author`) AS `name` FROM `books` WHERE TRIM(`author`) <> '') a;
+INSERT INTO `book_authors` (`book_id`, `author_id`, `role`, `position`)
+SELECT b.`id`, MIN(a.`id`), 'author', 1
+FROM `books` b JOIN `authors` a ON a.`name` = TRIM(b.`author`)
+GROUP BY b.`id`;
+-- +migrate Down
+DROP TABLE book_authors;
+DROP TABLE authors;
@@ -0,0 +1,43 @@
+-- +migrate Up
+CREATE TABLE `genres` (
+    `id` INT AUTO_INCREMENT PRIMARY KEY,
+    `parent_id` INT NULL,
+    `name` VARCHAR(255) NOT NULL,
+    `kind` VARCHAR(30) NOT NULL,
+    `updated_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_parent_id_name` (`parent_id`, `name`),
+    INDEX `idx_name` (`name`),
+    CONSTRAINT `fk__genres__genres` FOREIGN KEY (`parent_id`) REFERENCES `genres` (`id`)
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+-- Every ancestor and descendant pair in the tree, including each node paired with itself at depth 0
+CREATE TABLE `genre_closure` (
+    `ancestor_id` INT NOT NULL,
+    `descendant_id` INT NOT NULL,
+    `depth` INT NOT NULL,
+    PRIMARY KEY (`ancestor_id`, `descendant_id`),
+    INDEX `idx_descendant_id_depth` (`descendant_id`, `depth`),
+    CONSTRAINT `fk__genre_closure__ancestors` FOREIGN KEY (`ancestor_id`) REFERENCES `genres` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk__genre_closure__descendants` FOREIGN KEY (`descendant_id`) REFERENCES `genres` (`id`) ON DELETE CASCADE
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+CREATE TABLE `book_genres` (
+    `book_id` INT NOT NULL,
+    `genre_id` INT NOT NULL,
+    PRIMARY KEY (`book_id`, `genre_id`),
+    INDEX `idx_genre_id` (`genre_id`),
+    CONSTRAINT `fk__book_genres__books` FOREIGN KEY (`book_id`) REFERENCES `books` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `fk__book_genres__genres` FOREIGN KEY (`genre_id`) REFERENCES `genres` (`id`)
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+-- Every distinct genre string becomes a top level genre that the books are filed under
+INSERT INTO `genres` (`name`, `kind`)
+SELECT DISTINCT TRIM(`genre`), 'genre' FROM `books` WHERE TRIM(`genre`) <> '';
+INSERT INTO `genre_closure` (`ancestor_id`, `descendant_id`, `depth`)
+SELECT `id`, `id`, 0 FROM `genres`;
+INSERT INTO `book_genres` (`book_id`, `genre_id`)
+SELECT b.`id`, MIN(g.`id`)
+FROM `books` b JOIN `genres` g ON g.`name` = TRIM(b.`genre`)
+GROUP BY b.`id`;
+-- +migrate Down
+DROP TABLE book_genres;
+DROP TABLE genre_closure;
+DROP TABLE genres;
@@ -0,0 +1,77 @@
+-- +migrate Up
+-- ISBNs that could not be normalized are kept as they are and listed here for a librarian to resolve:
+-- invalid when the check digit does not verify, duplicate when the normalized form belongs to another book.
+-- A row is deleted once its book has been fixed
+CREATE TABLE `isbn_review` (
+    `id` INT AUTO_INCREMENT PRIMARY KEY,
+    `book_id` INT NOT NULL,
+    `isbn` VARCHAR(255) NOT NULL,
+    `normalized` VARCHAR(13) NULL,
+    `reason` VARCHAR(30) NOT NULL,
+    `created_at` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `idx_book_id` (`book_id`),
+    INDEX `idx_reason` (`reason`)
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+-- Every stored ISBN without its hyphens and spaces
+CREATE TABLE `isbn_candidates` (
+    `book_id` INT PRIMARY KEY,
+    `isbn` VARCHAR(255) NOT NULL,
+    `stripped` VARCHAR(255) NOT NULL,
+    `normalized` VARCHAR(13) NULL,
+    INDEX `idx_normalized` (`normalized`)
+) COLLATE = 'utf8mb4_unicode_ci' ENGINE = InnoDB;
+INSERT INTO `isbn_candidates` (`book_id`, `isbn`, `stripped`)
+SELECT `id`, `isbn`, UPPER(REPLACE(REPLACE(`isbn`, '-', ''), ' ', '')) FROM `books`;
+-- ISBN-13s are kept when their check digit verifies, digits weigh 1 and 3 in turn
+UPDATE `isbn_candidates`
+SET `normalized` = `stripped`
+WHERE `stripped` REGEXP '^97[89][0-9]{10}$'
+    AND MOD(
+        SUBSTRING(`stripped`, 1, 1) + 3 * SUBSTRING(`stripped`, 2, 1)
+        + SUBSTRING(`stripped`, 3, 1) + 3 * SUBSTRING(`stripped`, 4, 1)
+        + SUBSTRING(`stripped`, 5, 1) + 3 * SUBSTRING(`stripped`, 6, 1)
+        + SUBSTRING(`stripped`, 7, 1) + 3 * SUBSTRING(`stripped`, 8, 1)
+        + SUBSTRING(`stripped`, 9, 1) + 3 * SUBSTRING(`stripped`, 10, 1)
+        + SUBSTRING(`stripped`, 11, 1) + 3 * SUBSTRING(`stripped`, 12, 1)
+        + SUBSTRING(`stripped`, 13, 1), 10) = 0;
+-- ISBN-10s whose check digit verifies, digits weigh 10 down to 1 and X stands for 10, become ISBN-13s:
+-- prefix 978 and work out a new check digit, 978 itself weighs 38
+UPDATE `isbn_candidates`
+SET `normalized` = CONCAT('978', LEFT(`stripped`, 9), MOD(10 - MOD(38
+    + 3 * SUBSTRING(`stripped`, 1, 1) + SUBSTRING(`stripped`, 2, 1)
+    + 3 * SUBSTRING(`stripped`, 3, 1) + SUBSTRING(`stripped`, 4, 1)
+    + 3 * SUBSTRING(`stripped`, 5, 1) + SUBSTRING(`stripped`, 6, 1)
+    + 3 * SUBSTRING(`stripped`, 7, 1) + SUBSTRING(`stripped`, 8, 1)
+    + 3 * SUBSTRING(`stripped`, 9, 1), 10), 10))
+WHERE `stripped` REGEXP '^[0-9]{9}[0-9X]$'
+    AND MOD(
+        10 * SUBSTRING(`stripped`, 1, 1) + 9 * SUBSTRING(`stripped`, 2, 1)
+        + 8 * SUBSTRING(`stripped`, 3, 1) + 7 * SUBSTRING(`stripped`, 4, 1)
+        + 6 * SUBSTRING(`stripped`, 5, 1) + 5 * SUBSTRING(`stripped`, 6, 1)
+        + 4 * SUBSTRING(`stripped`, 7, 1) + 3 * SUBSTRING(`stripped`, 8, 1)
+        + 2 * SUBSTRING(`stripped`, 9, 1)
+        + IF(RIGHT(`stripped`, 1) = 'X', 10, RIGHT(`stripped`, 1)), 11) = 0;
+INSERT INTO `isbn_review` (`book_id`, `isbn`, `normalized`, `reason`)
+SELECT `book_id`, `isbn`, NULL, 'invalid' FROM `isbn_candidates` WHERE `normalized` IS NULL;
+-- Books that would end up with the same ISBN are all left for review rather than one of them winning
+INSERT INTO `isbn_review` (`book_id`, `isbn`, `normalized`, `reason`)
+SELECT c.`book_id`, c.`isbn`, c.`normalized`, 'duplicate'
+FROM `isbn_candidates` c
+JOIN (
+    SELECT `normalized` FROM `isbn_candidates`
+    WHERE `normalized` IS NOT NULL
+    GROUP BY `normalized`
+    HAVING COUNT(*) > 1
+) d ON d.`normalized` = c.`normalized`;
+-- The rest are rewritten, without IGNORE so anything missed above fails the migration instead of being skipped
+UPDATE `books` b
+JOIN `isbn_candidates` c ON c.`book_id` = b.`id`
+SET b.`isbn` = c.`normalized`
+WHERE c.`normalized` IS NOT NULL
+    AND c.`normalized` <> b.`isbn`
+    AND NOT EXISTS (SELECT 1 FROM `isbn_review` r WHERE r.`book_id` = b.`id`);
+DROP TABLE `isbn_candidates`;
+
+-- +migrate Down
+-- The original formatting of the ISBNs is not kept, normalized values stay in place
+DROP TABLE `isbn_review`;
@@ -10,6 +10,9 @@ import (
 
 	"github.com/GabDewraj/library-api/cmd/apps"
 	"github.com/GabDewraj/library-api/cmd/config"
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/repo"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
 	"github.com/go-chi/chi"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -69,6 +72,119 @@ func main() {
 			},
 		})
 
+	// Import a CSV file of books straight into the catalogue
+	var (
+		columnMappings []string
+		dateFormat     string
+		actor          string
+	)
+	importCmd := &cobra.Command{
+		Use:   "import-books [file]",
+		Short: "Import books from a CSV file with a header row",
+		Long: `Rows are validated like books created through the api and inserted in batches.
+Columns are matched to book fields by name, use --map field:header for columns with other names.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			logger := logrus.StandardLogger()
+			columns, err := books.ParseColumnMapping(columnMappings)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			file, err := os.Open(args[0])
+			if err != nil {
+				logger.Fatal(err)
+			}
+			defer file.Close()
+			app := fx.New(
+				fx.Provide(
+					logrus.StandardLogger,
+					config.NewDBConfig,
+					config.NewDBConnection,
+					repo.NewBooksDB,
+					books.NewService,
+				),
+				fx.Invoke(func(service books.Service) error {
+					report, err := service.ImportBooks(utils.WithActor(ctx, actor), file,
+						&books.ImportOptions{Columns: columns, DateFormat: dateFormat})
+					if err != nil {
+						return err
+					}
+					for _, rejected := range report.Rejected {
+						logger.Warnf("line %d: %s", rejected.Line, rejected.Reason)
+					}
+					logger.Infof("Imported %d of %d rows from %s", report.Imported, report.Rows, args[0])
+					return nil
+				}),
+			)
+			if err := app.Start(ctx); err != nil {
+				logger.Fatal("Error importing books: ", err)
+			}
+		},
+	}
+	importCmd.Flags().StringArrayVar(&columnMappings, "map", nil, "column a field is read from as field:header, may be repeated")
+	importCmd.Flags().StringVar(&dateFormat, "date-format", "", "Go layout of the published column, 2006-01-02 and unix timestamps by default")
+	importCmd.Flags().StringVar(&actor, "actor", "cli", "who the imported books are attributed to in the audit trail")
+	rootCmd.AddCommand(importCmd)
+
+	// Export the catalogue to a file or stdout
+	var (
+		exportFormat string
+		exportOutput string
+		exportFilter books.GetBooksParams
+	)
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the books that match the filters as CSV, newline delimited JSON, MARC 21 or MARCXML",
+		Long:  `The CSV starts with the columns import-books reads, so an export can be imported again.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			logger := logrus.StandardLogger()
+			out := os.Stdout
+			if exportOutput != "" {
+				file, err := os.Create(exportOutput)
+				if err != nil {
+					logger.Fatal(err)
+				}
+				defer file.Close()
+				out = file
+			}
+			app := fx.New(
+				fx.Provide(
+					logrus.StandardLogger,
+					config.NewDBConfig,
+					config.NewDBConnection,
+					repo.NewBooksDB,
+					books.NewService,
+				),
+				fx.Invoke(func(service books.Service) error {
+					exported, err := service.ExportBooks(ctx, &exportFilter, books.ExportFormat(exportFormat), out)
+					if err != nil {
+						return err
+					}
+					logger.Infof("Exported %d books", exported)
+					return nil
+				}),
+			)
+			if err := app.Start(ctx); err != nil {
+				logger.Fatal("Error exporting books: ", err)
+			}
+		},
+	}
+	exportCmd.Flags().StringVar(&exportFormat, "format", string(books.CSV), "csv, ndjson, marc21 or marcxml")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "file to write to, stdout by default")
+	exportCmd.Flags().StringVar(&exportFilter.ISBN, "isbn", "", "filter books by ISBN")
+	exportCmd.Flags().StringVar(&exportFilter.Title, "title", "", "filter books by title")
+	exportCmd.Flags().StringVar(&exportFilter.Author, "author", "", "filter books by author")
+	exportCmd.Flags().StringVar(&exportFilter.Publisher, "publisher", "", "filter books by publisher")
+	exportCmd.Flags().StringVar(&exportFilter.Genre, "genre", "", "filter books by genre, including its sub-genres")
+	exportCmd.Flags().IntVar(&exportFilter.GenreID, "genre-id", 0, "filter books by a node of the genre tree")
+	exportCmd.Flags().StringVar(&exportFilter.Language, "language", "", "filter books by language")
+	exportCmd.Flags().StringVar((*string)(&exportFilter.Availability), "availability", "", "available or not_available")
+	rootCmd.AddCommand(exportCmd)
+
 	// library Server
 	rootCmd.AddCommand(
 		&cobra.Command{
@@ -100,6 +216,16 @@ func main() {
 					fx.Invoke(config.PerformMigrations),
 					// Initialize all separate server applications
 					fx.Invoke(apps.BooksApp),
+					fx.Invoke(apps.LoansApp),
+					fx.Invoke(apps.MembersApp),
+					fx.Invoke(apps.HoldsApp),
+					fx.Invoke(apps.FinesApp),
+					fx.Invoke(apps.CopiesApp),
+					fx.Invoke(apps.AuthorsApp),
+					fx.Invoke(apps.GenresApp),
+					fx.Invoke(apps.AuditApp),
+					fx.Invoke(apps.OPDSApp),
+					fx.Invoke(apps.SRUApp),
 					// Run the router
 					fx.Invoke(
 						func(r *chi.Mux, cfg *config.Config, logger *logrus.Logger) {
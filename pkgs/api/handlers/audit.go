@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+type AuditHandlerParams struct {
+	fx.In
+	AuditService audit.Service
+}
+
+type auditHandler struct {
+	auditService audit.Service
+	logger       logrus.FieldLogger
+}
+
+func NewAuditHandler(p AuditHandlerParams) audit.Handler {
+	return &auditHandler{
+		auditService: p.AuditService,
+		logger: logrus.WithFields(logrus.Fields{
+			"package": "handlers",
+			"domain":  "audit",
+		}),
+	}
+}
+
+// @Summary Search the audit trail
+// @Description Search the changes made across the library, oldest first
+// @Tags Audit
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of records per page"
+// @Param actor query string false "Filter by who made the change"
+// @Param entity query string false "Filter by the kind of record changed (book)"
+// @Param entity_id query int false "Filter by the id of the record changed"
+// @Param action query string false "Filter by action (create, update, delete, restore, purge)"
+// @Param from query int false "Changes made at or after this time (Unix timestamp)"
+// @Param to query int false "Changes made at or before this time (Unix timestamp)"
+// @Success 200 {object} swagger.GetAuditRecordsResponse "Successfully retrieved audit records"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /audit [get]
+func (h *auditHandler) GetRecords(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var params audit.GetRecordsParams
+	var from, to int
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":      &params.Page,
+		"per_page":  &params.PerPage,
+		"entity_id": &params.EntityID,
+		"from":      &from,
+		"to":        &to,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				h.logger.Error(err)
+				http.Error(res, "failed to convert "+key+" string parameter to integer", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+	}
+	if from != 0 {
+		params.From = time.Unix(int64(from), 0)
+	}
+	if to != 0 {
+		params.To = time.Unix(int64(to), 0)
+	}
+	params.Actor = query.Get("actor")
+	params.Entity = audit.Entity(query.Get("entity"))
+	params.Action = audit.Action(query.Get("action"))
+	if err := params.ValidateSearch(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	records, count, err := h.auditService.GetRecords(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve audit records", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Records []*audit.Record `json:"records"`
+		Count   int             `json:"count"`
+	}{
+		Records: records,
+		Count:   count,
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/authors"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+type AuthorsHandlerParams struct {
+	fx.In
+	AuthorService authors.Service
+}
+
+type authorsHandler struct {
+	authorService authors.Service
+	logger        logrus.FieldLogger
+}
+
+func NewAuthorsHandler(p AuthorsHandlerParams) authors.Handler {
+	return &authorsHandler{
+		authorService: p.AuthorService,
+		logger: logrus.WithFields(logrus.Fields{
+			"package": "handlers",
+			"domain":  "authors",
+		}),
+	}
+}
+
+// @Summary Create a new author
+// @Description Add an author that books can be credited to
+// @Tags Authors
+// @Accept json
+// @Produce json
+// @Param requestBody body swagger.CreateAuthorRequestBody true "New author details"
+// @Success 200 {object} authors.Author "Successfully created author"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /authors [post]
+func (h *authorsHandler) CreateAuthor(res http.ResponseWriter, req *http.Request) {
+	var newAuthor authors.Author
+	if err := json.NewDecoder(req.Body).Decode(&newAuthor); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for create author", http.StatusBadRequest)
+		return
+	}
+	// Validate the Request
+	if err := newAuthor.ValidateCreateAuthor(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.authorService.CreateAuthors(req.Context(), []*authors.Author{&newAuthor}); err != nil {
+		h.logger.Error(err)
+		h.writeAuthorError(res, err, "failed to create author")
+		return
+	}
+	payload, err := json.Marshal(newAuthor)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get an author by ID
+// @Description Get details of an author by their ID
+// @Tags Authors
+// @Accept json
+// @Produce json
+// @Param author_id path int true "Author ID" Format(int64)
+// @Success 200 {object} authors.Author "Successfully retrieved author"
+// @Failure 404 {string} string "Author does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /authors/{author_id} [get]
+func (h *authorsHandler) GetAuthorByID(res http.ResponseWriter, req *http.Request) {
+	authorID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "author_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert author_id to integer", http.StatusBadRequest)
+		return
+	}
+	retrievedAuthors, _, err := h.authorService.GetAuthors(req.Context(), &authors.GetAuthorsParams{ID: authorID})
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve author", http.StatusInternalServerError)
+		return
+	}
+	if len(retrievedAuthors) == 0 {
+		http.Error(res, authors.ErrAuthorNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	payload, err := json.Marshal(retrievedAuthors[0])
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not marshall author data to json", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get a list of authors
+// @Description Get a list of authors ordered by sort name
+// @Tags Authors
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of authors per page"
+// @Param name query string false "Filter authors by name, sort name or alias"
+// @Param book_id query int false "Filter authors credited on a book"
+// @Success 200 {object} swagger.GetAuthorsResponse "Successfully retrieved authors"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /authors [get]
+func (h *authorsHandler) GetAuthors(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var params authors.GetAuthorsParams
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":     &params.Page,
+		"per_page": &params.PerPage,
+		"book_id":  &params.BookID,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				h.logger.Error(err)
+				http.Error(res, "failed to convert "+key+" string parameter to integer", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+	}
+	params.Name = query.Get("name")
+	retrievedAuthors, count, err := h.authorService.GetAuthors(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve authors", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Authors []*authors.Author `json:"authors"`
+		Count   int               `json:"count"`
+	}{
+		Authors: retrievedAuthors,
+		Count:   count,
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update an author by ID
+// @Description Update the names, life dates or aliases of an author
+// @Tags Authors
+// @Accept json
+// @Produce json
+// @Param author_id path int true "Author ID" Format(int64)
+// @Param requestBody body swagger.UpdateAuthorRequestBody true "New author details"
+// @Success 200 {object} authors.Author "Successfully updated author"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Author does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /authors/{author_id} [put]
+func (h *authorsHandler) UpdateAuthor(res http.ResponseWriter, req *http.Request) {
+	authorID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "author_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert author_id to integer", http.StatusBadRequest)
+		return
+	}
+	requestBody := struct {
+		Name      string          `json:"name"`
+		SortName  string          `json:"sort_name"`
+		BirthYear int             `json:"birth_year"`
+		DeathYear int             `json:"death_year"`
+		Aliases   authors.Aliases `json:"aliases"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body", http.StatusBadRequest)
+		return
+	}
+	update := authors.Author{
+		ID:        authorID,
+		Name:      requestBody.Name,
+		SortName:  requestBody.SortName,
+		BirthYear: requestBody.BirthYear,
+		DeathYear: requestBody.DeathYear,
+		Aliases:   requestBody.Aliases,
+	}
+	if err := update.ValidateUpdateAuthor(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	updatedAuthor, err := h.authorService.UpdateAuthor(req.Context(), &update)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeAuthorError(res, err, "failed to update author")
+		return
+	}
+	payload, err := json.Marshal(updatedAuthor)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary delete an author by ID
+// @Description delete an author by ID, authors still credited on books cannot be deleted
+// @Tags Authors
+// @Accept json
+// @Produce json
+// @Param author_id path int true "Author ID" Format(int64)
+// @Success 200 {string} string "Successfully deleted author"
+// @Failure 404 {string} string "Author does not exist"
+// @Failure 409 {string} string "Author is credited on books"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /authors/{author_id} [delete]
+func (h *authorsHandler) DeleteAuthor(res http.ResponseWriter, req *http.Request) {
+	authorID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "author_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert author_id to integer", http.StatusBadRequest)
+		return
+	}
+	if err := h.authorService.DeleteAuthorByID(req.Context(), authorID); err != nil {
+		h.logger.Error(err)
+		h.writeAuthorError(res, err, "failed to delete author")
+		return
+	}
+	if _, err := res.Write([]byte(fmt.Sprintf("Successfully deleted author %d", authorID))); err != nil {
+		http.Error(res, "Could not write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get the books of an author
+// @Description Get the books an author is credited on and the role they had on each
+// @Tags Authors
+// @Accept json
+// @Produce json
+// @Param author_id path int true "Author ID" Format(int64)
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of books per page"
+// @Param role query string false "Filter by role (author, editor, translator, illustrator)"
+// @Success 200 {object} swagger.GetAuthorBooksResponse "Successfully retrieved books"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 404 {string} string "Author does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /authors/{author_id}/books [get]
+func (h *authorsHandler) GetAuthorBooks(res http.ResponseWriter, req *http.Request) {
+	authorID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "author_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert author_id to integer", http.StatusBadRequest)
+		return
+	}
+	query := req.URL.Query()
+	params := authors.GetCreditsParams{AuthorID: authorID, Role: authors.Role(query.Get("role"))}
+	for key, target := range map[string]*int{
+		"page":     &params.Page,
+		"per_page": &params.PerPage,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				h.logger.Error(err)
+				http.Error(res, "failed to convert "+key+" string parameter to integer", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+	}
+	retrievedAuthors, _, err := h.authorService.GetAuthors(req.Context(), &authors.GetAuthorsParams{ID: authorID})
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve author", http.StatusInternalServerError)
+		return
+	}
+	if len(retrievedAuthors) == 0 {
+		http.Error(res, authors.ErrAuthorNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	credits, count, err := h.authorService.GetCredits(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve books for author", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Books []*authors.Credit `json:"books"`
+		Count int               `json:"count"`
+	}{
+		Books: credits,
+		Count: count,
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Credit an author on a book
+// @Description Link an author to a book in a role, crediting the same role again moves it to the new position
+// @Tags Authors
+// @Accept json
+// @Produce json
+// @Param author_id path int true "Author ID" Format(int64)
+// @Param book_id path int true "Book ID" Format(int64)
+// @Param requestBody body swagger.CreditAuthorRequestBody true "Credit details"
+// @Success 200 {object} authors.Credit "Successfully credited author"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Author or book does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /authors/{author_id}/books/{book_id} [put]
+func (h *authorsHandler) CreditAuthor(res http.ResponseWriter, req *http.Request) {
+	authorID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "author_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert author_id to integer", http.StatusBadRequest)
+		return
+	}
+	bookID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "book_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert book_id to integer", http.StatusBadRequest)
+		return
+	}
+	requestBody := struct {
+		Role     authors.Role `json:"role"`
+		Position int          `json:"position"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body", http.StatusBadRequest)
+		return
+	}
+	credit := authors.Credit{
+		AuthorID: authorID,
+		BookID:   bookID,
+		Role:     requestBody.Role,
+		Position: requestBody.Position,
+	}
+	if err := credit.ValidateCredit(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.authorService.CreditAuthor(req.Context(), &credit); err != nil {
+		h.logger.Error(err)
+		h.writeAuthorError(res, err, "failed to credit author")
+		return
+	}
+	payload, err := json.Marshal(credit)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Remove an author credit from a book
+// @Description Unlink an author from a book, every role is removed unless one is given
+// @Tags Authors
+// @Accept json
+// @Produce json
+// @Param author_id path int true "Author ID" Format(int64)
+// @Param book_id path int true "Book ID" Format(int64)
+// @Param role query string false "Only remove this role"
+// @Success 200 {string} string "Successfully removed credit"
+// @Failure 404 {string} string "Author is not credited on this book"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /authors/{author_id}/books/{book_id} [delete]
+func (h *authorsHandler) RemoveCredit(res http.ResponseWriter, req *http.Request) {
+	authorID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "author_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert author_id to integer", http.StatusBadRequest)
+		return
+	}
+	bookID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "book_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert book_id to integer", http.StatusBadRequest)
+		return
+	}
+	role := authors.Role(req.URL.Query().Get("role"))
+	if err := h.authorService.RemoveCredit(req.Context(), authorID, bookID, role); err != nil {
+		h.logger.Error(err)
+		h.writeAuthorError(res, err, "failed to remove credit")
+		return
+	}
+	if _, err := res.Write([]byte("Successfully removed credit")); err != nil {
+		http.Error(res, "Could not write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Map domain errors onto http status codes, anything unknown is hidden behind the fallback message
+func (h *authorsHandler) writeAuthorError(res http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, authors.ErrAuthorNotFound), errors.Is(err, authors.ErrBookNotFound),
+		errors.Is(err, authors.ErrCreditNotFound):
+		http.Error(res, err.Error(), http.StatusNotFound)
+	case errors.Is(err, authors.ErrAuthorHasBooks):
+		http.Error(res, err.Error(), http.StatusConflict)
+	case errors.Is(err, authors.ErrInvalidLifeDates), errors.Is(err, authors.ErrEmptyAlias):
+		http.Error(res, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(res, fallback, http.StatusInternalServerError)
+	}
+}
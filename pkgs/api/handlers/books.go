@@ -2,12 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
 	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
 	"github.com/GabDewraj/library-api/pkgs/infrastructure/cache"
 	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
 	"github.com/go-chi/chi"
@@ -18,14 +24,18 @@ import (
 // Uber fx: Package by uber used for dependency management in app and server lifecycle
 type BooksHandlerParams struct {
 	fx.In
-	BookService books.Service
+	BookService  books.Service
+	AuditService audit.Service
 	// Other infrastructure layer services: Generic packages that don't contain business logic
 	CacheService cache.Service
+	Cursors      *books.CursorSigner
 }
 
 type booksHandler struct {
 	bookService  books.Service
+	auditService audit.Service
 	cacheService cache.Service
+	cursors      *books.CursorSigner
 	logger       logrus.FieldLogger
 }
 
@@ -33,7 +43,9 @@ func NewBooksHandler(p BooksHandlerParams) books.Handler {
 
 	return &booksHandler{
 		bookService:  p.BookService,
+		auditService: p.AuditService,
 		cacheService: p.CacheService,
+		cursors:      p.Cursors,
 		logger: logrus.WithFields(logrus.Fields{
 			"package": "handlers",
 			"domain":  "books",
@@ -89,6 +101,224 @@ func (h *booksHandler) CreateBook(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// @Summary Create books in bulk
+// @Description Create up to 500 books in one request. In all_or_nothing mode (the default) no book is created unless
+// @Description every book can be, in partial mode every valid book is created. Each book gets a result in the order sent
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param requestBody body swagger.BulkCreateBooksRequestBody true "Books to create"
+// @Success 200 {object} swagger.BulkCreateBooksResponse "Per book results, with the id of each created book"
+// @Failure 400 {object} swagger.BulkCreateBooksResponse "Bad Request: nothing was created in all_or_nothing mode"
+// @Failure 413 {string} string "Too many books in one request"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/bulk [post]
+func (h *booksHandler) BulkCreateBooks(res http.ResponseWriter, req *http.Request) {
+	requestBody := struct {
+		Mode  books.BulkMode `json:"mode"`
+		Books []*books.Book  `json:"books"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for bulk create", http.StatusBadRequest)
+		return
+	}
+	switch requestBody.Mode {
+	case "":
+		requestBody.Mode = books.AllOrNothing
+	case books.AllOrNothing, books.Partial:
+	default:
+		http.Error(res, "value for mode is not recognised, please use all_or_nothing or partial", http.StatusBadRequest)
+		return
+	}
+	if len(requestBody.Books) == 0 {
+		http.Error(res, "books field is required", http.StatusBadRequest)
+		return
+	}
+	if len(requestBody.Books) > books.MaxBulkBooks {
+		http.Error(res, books.ErrBulkTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	for _, book := range requestBody.Books {
+		if book == nil {
+			http.Error(res, "books cannot contain null", http.StatusBadRequest)
+			return
+		}
+	}
+	results, err := h.bookService.CreateBooksBulk(req.Context(), requestBody.Books, requestBody.Mode)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to create books", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Results []*books.BulkResult `json:"results"`
+		Created int                 `json:"created"`
+		Failed  int                 `json:"failed"`
+	}{
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			response.Failed++
+			continue
+		}
+		response.Created++
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	if response.Created == 0 && response.Failed > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Import books from a CSV file
+// @Description Stream a CSV file with a header row into the catalogue. Columns are matched to book fields by name
+// @Description unless map gives another header for a field. Every row is validated and the report lists the line
+// @Description and reason of each row that was not imported
+// @Tags Books
+// @Accept text/csv
+// @Produce json
+// @Param map query []string false "Column a field is read from as field:header, e.g. title:Book Title" collectionFormat(multi)
+// @Param date_format query string false "Go layout of the published column, 2006-01-02 and unix timestamps by default"
+// @Param requestBody body string true "CSV file"
+// @Success 200 {object} books.ImportReport "Rows imported and rejected"
+// @Failure 400 {string} string "Bad Request: the header or the column mappings are wrong, or no row could be imported"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/import [post]
+func (h *booksHandler) ImportBooks(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	columns, err := books.ParseColumnMapping(query["map"])
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	options := books.ImportOptions{Columns: columns, DateFormat: query.Get("date_format")}
+	report, err := h.bookService.ImportBooks(req.Context(), req.Body, &options)
+	if err != nil {
+		h.logger.Error(err)
+		if errors.Is(err, books.ErrImportColumns) {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(res, "failed to import books", http.StatusInternalServerError)
+		return
+	}
+	payload, err := json.Marshal(report)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	if report.Imported == 0 && len(report.Rejected) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Ingest an ONIX 3.0 feed
+// @Description Create or update books from the products of an ONIX 3.0 message in reference or short tags, matched by
+// @Description ISBN. The product form decides whether a product is a book, and the language, page count, publication
+// @Description date and publisher are mapped along with the title, author and main subject. Products are numbered from 1
+// @Description in the report, which says whether each one was inserted, updated or skipped and why. A feed that only
+// @Description repeats books already in the catalogue is skipped throughout, so skipped products are not an error
+// @Tags Books
+// @Accept xml
+// @Produce json
+// @Param requestBody body string true "ONIX 3.0 message"
+// @Success 200 {object} books.IngestReport "Products inserted, updated and skipped"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/import/onix [post]
+func (h *booksHandler) IngestONIX(res http.ResponseWriter, req *http.Request) {
+	report, err := h.bookService.IngestONIX(req.Context(), req.Body)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to ingest onix feed", http.StatusInternalServerError)
+		return
+	}
+	payload, err := json.Marshal(report)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Import books from MARC records
+// @Description Read MARC 21 bibliographic records in ISO 2709 or MARCXML into the catalogue. 020 gives the ISBN, 100 the
+// @Description author, 245 the title, 264 or 260 the publisher and date, 041 the language, 300 the pages and 655 or 650
+// @Description the genre. Records are numbered from 1 in the report, which warns about fields that could not be mapped
+// @Tags Books
+// @Accept application/marc
+// @Accept application/marcxml+xml
+// @Produce json
+// @Param format query string false "marc21 or marcxml, taken from the Content-Type when it is not given"
+// @Param requestBody body string true "MARC records"
+// @Success 200 {object} books.ImportReport "Records imported, rejected and warned about"
+// @Failure 400 {object} books.ImportReport "Bad Request: no record could be imported"
+// @Failure 415 {string} string "Unsupported MARC format"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/import/marc [post]
+func (h *booksHandler) ImportMARC(res http.ResponseWriter, req *http.Request) {
+	format := books.ExportFormat(req.URL.Query().Get("format"))
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		switch mediaType {
+		case "application/marc", "application/octet-stream":
+			format = books.MARC21
+		case "application/marcxml+xml", "application/xml", "text/xml":
+			format = books.MARCXML
+		}
+	}
+	if format != books.MARC21 && format != books.MARCXML {
+		http.Error(res, "send application/marc or application/marcxml+xml, or set format to marc21 or marcxml",
+			http.StatusUnsupportedMediaType)
+		return
+	}
+	report, err := h.bookService.ImportMARC(req.Context(), req.Body, format)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to import books", http.StatusInternalServerError)
+		return
+	}
+	payload, err := json.Marshal(report)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	if report.Imported == 0 && len(report.Rejected) > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
 // @Summary Get a book by ID
 // @Description Get details of a book by its ID
 // @Tags Books
@@ -96,6 +326,9 @@ func (h *booksHandler) CreateBook(res http.ResponseWriter, req *http.Request) {
 // @Produce json
 // @Param book_id path int true "Book ID" Format(int64)
 // @Success 200 {object} books.Book "Successfully retrieved book"
+// @Header 200 {string} ETag "Version of the book, send it in If-Match to update it"
+// @Failure 404 {string} string "Book does not exist"
+// @Failure 410 {string} string "Book has been deleted"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /books/{book_id} [get]
 func (h *booksHandler) GetBookByID(res http.ResponseWriter, req *http.Request) {
@@ -107,18 +340,35 @@ func (h *booksHandler) GetBookByID(res http.ResponseWriter, req *http.Request) {
 		http.Error(res, "could not convert book_id to integer", http.StatusBadRequest)
 		return
 	}
-	retrievedBook, _, err := h.bookService.GetBooks(req.Context(), &books.GetBooksParams{ID: bookID})
+	retrievedBook, count, err := h.bookService.GetBooks(req.Context(), &books.GetBooksParams{ID: bookID})
 	if err != nil {
 		h.logger.Error(err)
 		http.Error(res, "could not retrieve book", http.StatusInternalServerError)
 		return
 	}
+	// Tell a book in the trash apart from one that never existed
+	if count == 0 {
+		_, trashed, err := h.bookService.GetBooks(req.Context(), &books.GetBooksParams{ID: bookID, Deleted: true})
+		if err != nil {
+			h.logger.Error(err)
+			http.Error(res, "could not retrieve book", http.StatusInternalServerError)
+			return
+		}
+		if trashed > 0 {
+			h.writeBookError(res, books.ErrBookDeleted, "")
+			return
+		}
+		h.writeBookError(res, books.ErrBookNotFound, "")
+		return
+	}
 	payload, err := json.Marshal(retrievedBook)
 	if err != nil {
 		h.logger.Error(err)
 		http.Error(res, "could not marshall book data to json", http.StatusInternalServerError)
 		return
 	}
+	// Send the version back in If-Match when updating the book
+	res.Header().Set("ETag", books.ETag(retrievedBook[0].Version))
 	if _, err := res.Write(payload); err != nil {
 		h.logger.Error(err)
 		http.Error(res, "failed to write response", http.StatusInternalServerError)
@@ -136,89 +386,166 @@ func (h *booksHandler) GetBookByID(res http.ResponseWriter, req *http.Request) {
 // @Param updated_at query int false "Filter books by updated timestamp (Unix timestamp)"
 // @Param book_pages query int false "Filter books by number of pages"
 // @Param published query int false "Filter books by published date (Unix timestamp)"
-// @Param isbn query string false "Filter books by ISBN"
+// @Param isbn query string false "Filter books by ISBN, any valid ISBN-10 or ISBN-13 form of it matches"
 // @Param title query string false "Filter books by title"
-// @Param author query string false "Filter books by author"
+// @Param author query string false "Filter books by author, credited authors are matched on their name, sort name or aliases"
 // @Param publisher query string false "Filter books by publisher"
-// @Param genre query string false "Filter books by genre"
+// @Param genre query string false "Filter books by genre, including the books in its sub-genres"
+// @Param genre_id query int false "Filter books by a node of the genre tree and the nodes below it"
 // @Param language query string false "Filter books by language"
 // @Param availability query string false "Filter books by availability"
+// @Param decade query int false "Filter books by the decade they were published in, such as 1980"
+// @Param sort query string false "Comma separated fields to order by, - in front sorts descending, for example -published,title. Ties are ordered by id, without a sort books are listed by updated_at"
+// @Param cursor query string false "next_cursor or prev_cursor of an earlier response, or empty to list the first page by cursor. Cursors replace page and keep the sort they were made with"
+// @Param total query bool false "Set to false to leave out counting every book that matches, count and total_pages are then left out of the response"
+// @Param facets query string false "Comma separated facets to count the books by: genre, language, publisher, availability and decade. Each facet is counted with every filter but its own"
 // @Success 200 {object} swagger.GetBooksReponse "Successfully retrieved books"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /books [get]
 func (h *booksHandler) GetBooks(res http.ResponseWriter, req *http.Request) {
-	query := req.URL.Query()
-
-	var params books.GetBooksParams
-	if pageStr := query.Get("page"); pageStr != "" {
-		page, err := strconv.Atoi(pageStr)
+	params, err := booksParamsFromQuery(req.URL.Query())
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	facets, err := books.ParseFacets(req.URL.Query().Get("facets"))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response := struct {
+		Books []*books.Book `json:"books"`
+		// Every book that matches, left out when the books were not counted
+		Count      *int                                 `json:"count,omitempty"`
+		Page       int                                  `json:"page,omitempty"`
+		PerPage    int                                  `json:"per_page,omitempty"`
+		TotalPages *int                                 `json:"total_pages,omitempty"`
+		HasNext    bool                                 `json:"has_next"`
+		NextCursor string                               `json:"next_cursor,omitempty"`
+		PrevCursor string                               `json:"prev_cursor,omitempty"`
+		Facets     map[books.Facet][]*books.FacetBucket `json:"facets,omitempty"`
+	}{}
+	// Sending a cursor, even an empty one for the first page, lists the books by cursor instead of by page
+	if req.URL.Query().Has("cursor") {
+		if token := req.URL.Query().Get("cursor"); token != "" {
+			cursor, err := h.cursors.Verify(token)
+			if err == nil {
+				err = params.UseCursor(cursor)
+			}
+			if err != nil {
+				http.Error(res, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if params.PerPage <= 0 {
+			params.PerPage = books.DefaultCursorPerPage
+		}
+		page, err := h.bookService.GetBooksByCursor(req.Context(), params)
 		if err != nil {
 			h.logger.Error(err)
-			http.Error(res, "failed to convert page string parameter to integer", http.StatusBadRequest)
+			http.Error(res, "could not retrieve books", http.StatusInternalServerError)
 			return
 		}
-		params.Page = page
-	}
-	if perPageStr := query.Get("per_page"); perPageStr != "" {
-		perPage, err := strconv.Atoi(perPageStr)
-		if err != nil {
+		response.Books, response.PerPage, response.HasNext = page.Books, params.PerPage, page.Next != nil
+		if page.Total >= 0 {
+			response.Count = &page.Total
+		}
+		if response.NextCursor, response.PrevCursor, err = h.signCursors(page); err != nil {
 			h.logger.Error(err)
-			http.Error(res, "failed to convert per_page string parameter to integer", http.StatusBadRequest)
+			http.Error(res, "could not sign cursor", http.StatusInternalServerError)
 			return
 		}
-		params.PerPage = perPage
-	}
-	if updatedAtStr := query.Get("updated_at"); updatedAtStr != "" {
-		convertedUpdatedAt, err := strconv.Atoi(updatedAtStr)
+	} else {
+		page, err := h.bookService.GetBooksPage(req.Context(), params)
 		if err != nil {
-			h.logger.Error(err)
-			http.Error(res, "failed to convert updated_at string parameter to integer", http.StatusInternalServerError)
+			http.Error(res, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		params.UpdatedAt = utils.CustomTime{
-			Time: time.Unix(int64(convertedUpdatedAt), 0),
+		response.Books, response.Page, response.PerPage, response.HasNext = page.Books, page.Page, page.PerPage, page.HasNext
+		if page.Total >= 0 {
+			totalPages := page.TotalPages()
+			response.Count, response.TotalPages = &page.Total, &totalPages
 		}
 	}
-	if bookPagesStr := query.Get("book_pages"); bookPagesStr != "" {
-		pages, err := strconv.Atoi(bookPagesStr)
-		if err != nil {
+	if len(facets) > 0 {
+		if response.Facets, err = h.bookService.GetFacets(req.Context(), params, facets); err != nil {
 			h.logger.Error(err)
-			http.Error(res, "failed to convert book_pages string parameter to integer", http.StatusInternalServerError)
+			http.Error(res, "could not count facets", http.StatusInternalServerError)
 			return
 		}
-		params.BookPages = pages
 	}
-	if publishedStr := query.Get("published"); publishedStr != "" {
-		published, err := strconv.Atoi(publishedStr)
-		if err != nil {
-			h.logger.Error(err)
-			http.Error(res, "failed to convert published string parameter to integer", http.StatusInternalServerError)
-			return
-		}
-		params.Published = utils.CustomDate{
-			Time: time.Unix(int64(published), 0),
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write reponse", http.StatusInternalServerError)
+		return
+	}
+
+}
+
+// @Summary Full-text search of the books
+// @Description Rank the books by how well their title, author, publisher and genre match the words of q.
+// @Description Words in double quotes are searched as a phrase and a word ending in * matches every word starting
+// @Description with it. Matches in the title count for the most unless other boosts are given in fields. Each hit
+// @Description carries the fields that matched with the terms wrapped in <mark>, long values are cut to a snippet
+// @Tags Books
+// @Produce json
+// @Param q query string true "Words to search for, for example \"animal farm\" orw*"
+// @Param fields query string false "Fields to search with optional boosts, for example title^5,author"
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of books per page, 20 by default and at most 100"
+// @Success 200 {object} swagger.SearchBooksResponse "Books ranked by relevance"
+// @Failure 400 {string} string "Bad Request: Invalid query or fields"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/search [get]
+func (h *booksHandler) FullTextSearch(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	fullText, err := books.ParseFullText(query.Get("q"), query.Get("fields"))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params := &books.FullTextParams{Query: fullText, Page: 1, PerPage: books.DefaultSearchPerPage}
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":     &params.Page,
+		"per_page": &params.PerPage,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil || value < 1 {
+				http.Error(res, key+" must be a whole number above 0", http.StatusBadRequest)
+				return
+			}
+			*target = value
 		}
 	}
-	// String values
-	params.ISBN = query.Get("isbn")
-	params.Title = query.Get("title")
-	params.Author = query.Get("author")
-	params.Publisher = query.Get("publisher")
-	params.Genre = query.Get("genre")
-	params.Language = query.Get("language")
-	params.Availability = books.Availability(query.Get("availability"))
-	retrievedBooks, count, err := h.bookService.GetBooks(req.Context(), &params)
+	if params.PerPage > books.MaxSearchPerPage {
+		params.PerPage = books.MaxSearchPerPage
+	}
+	hits, count, err := h.bookService.FullTextSearch(req.Context(), params)
 	if err != nil {
-		http.Error(res, err.Error(), http.StatusInternalServerError)
+		h.logger.Error(err)
+		http.Error(res, "could not search books", http.StatusInternalServerError)
 		return
 	}
 	response := struct {
-		Books []*books.Book `json:"books"`
-		Count int           `json:"count"`
+		Hits  []*books.SearchHit `json:"hits"`
+		Count int                `json:"count"`
 	}{
-		Books: retrievedBooks,
+		Hits:  hits,
 		Count: count,
 	}
+	if response.Hits == nil {
+		response.Hits = []*books.SearchHit{}
+	}
 	payload, err := json.Marshal(response)
 	if err != nil {
 		h.logger.Error(err)
@@ -227,10 +554,69 @@ func (h *booksHandler) GetBooks(res http.ResponseWriter, req *http.Request) {
 	}
 	if _, err := res.Write(payload); err != nil {
 		h.logger.Error(err)
-		http.Error(res, "failed to write reponse", http.StatusInternalServerError)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
 		return
 	}
+}
 
+// @Summary Export the catalogue
+// @Description Stream every book that matches the filters as CSV, newline delimited JSON, MARC 21 (ISO 2709) or MARCXML.
+// @Description The CSV starts with the columns a CSV import reads and the MARC records are read back by the MARC import,
+// @Description so an export can be imported again. Takes the same filters as listing the books
+// @Tags Books
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/marc
+// @Produce application/marcxml+xml
+// @Param format query string false "csv (default), ndjson, marc21 or marcxml"
+// @Param isbn query string false "Filter books by ISBN"
+// @Param title query string false "Filter books by title"
+// @Param author query string false "Filter books by author"
+// @Param publisher query string false "Filter books by publisher"
+// @Param genre query string false "Filter books by genre, including the books in its sub-genres"
+// @Param genre_id query int false "Filter books by a node of the genre tree and the nodes below it"
+// @Param language query string false "Filter books by language"
+// @Param availability query string false "Filter books by availability"
+// @Param sort query string false "Comma separated fields to order by, - in front sorts descending, for example -published,title"
+// @Success 200 {string} string "The books, one per line"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters or format"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/export [get]
+func (h *booksHandler) ExportBooks(res http.ResponseWriter, req *http.Request) {
+	params, err := booksParamsFromQuery(req.URL.Query())
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := books.ExportFormat(req.URL.Query().Get("format"))
+	var contentType, extension string
+	switch format {
+	case "", books.CSV:
+		format, contentType, extension = books.CSV, "text/csv; charset=utf-8", "csv"
+	case books.NDJSON:
+		contentType, extension = "application/x-ndjson", "ndjson"
+	case books.MARC21:
+		contentType, extension = "application/marc", "mrc"
+	case books.MARCXML:
+		contentType, extension = "application/marcxml+xml", "xml"
+	default:
+		http.Error(res, books.ErrExportFormat.Error(), http.StatusBadRequest)
+		return
+	}
+	res.Header().Set("Content-Type", contentType)
+	res.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="books-%s.%s"`,
+		time.Now().UTC().Format("20060102"), extension))
+	// The status is sent with the first row, an error after that can only cut the download short
+	exported, err := h.bookService.ExportBooks(req.Context(), params, format, res)
+	if err != nil {
+		h.logger.Errorf("export stopped after %d books: %v", exported, err)
+		if exported == 0 {
+			res.Header().Del("Content-Disposition")
+			http.Error(res, "failed to export books", http.StatusInternalServerError)
+		}
+		return
+	}
 }
 
 // @Summary Update a book by ID
@@ -240,7 +626,13 @@ func (h *booksHandler) GetBooks(res http.ResponseWriter, req *http.Request) {
 // @Produce json
 // @Param book_id path int true "Book ID" Format(int64)
 // @Param requestBody body swagger.UpdateBookRequestBody true "New book details"
+// @Param If-Match header string false "ETag the book was read at, the update is refused if it has changed since"
 // @Success 200 {string} string "book by author has been updated successfully"
+// @Header 200 {string} ETag "New version of the book"
+// @Failure 404 {string} string "Book does not exist"
+// @Failure 409 {string} string "Another book has the ISBN"
+// @Failure 410 {string} string "Book has been deleted"
+// @Failure 412 {string} string "Book has been changed since the ETag in If-Match"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /books/{book_id} [put]
 func (h *booksHandler) UpdateBook(res http.ResponseWriter, req *http.Request) {
@@ -253,15 +645,14 @@ func (h *booksHandler) UpdateBook(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 	requestBody := struct {
-		ISBN         string             `json:"isbn"`
-		Title        string             `json:"title"`
-		Author       string             `json:"author"`
-		Publisher    string             `json:"publisher"`
-		Published    utils.CustomDate   `json:"published"`
-		Genre        string             `json:"genre"`
-		Language     string             `json:"language"`
-		Pages        int                `json:"pages"`
-		Availability books.Availability `json:"availability"`
+		ISBN      string           `json:"isbn"`
+		Title     string           `json:"title"`
+		Author    string           `json:"author"`
+		Publisher string           `json:"publisher"`
+		Published utils.CustomDate `json:"published"`
+		Genre     string           `json:"genre"`
+		Language  string           `json:"language"`
+		Pages     int              `json:"pages"`
 	}{}
 	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
 		h.logger.Error(err)
@@ -269,26 +660,35 @@ func (h *booksHandler) UpdateBook(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 	updatedBook := books.Book{
-		ID:           bookID,
-		ISBN:         requestBody.ISBN,
-		Title:        requestBody.Title,
-		Author:       requestBody.Author,
-		Publisher:    requestBody.Publisher,
-		Published:    requestBody.Published,
-		Genre:        requestBody.Genre,
-		Language:     requestBody.Language,
-		Pages:        requestBody.Pages,
-		Availability: requestBody.Availability,
+		ID:        bookID,
+		ISBN:      requestBody.ISBN,
+		Title:     requestBody.Title,
+		Author:    requestBody.Author,
+		Publisher: requestBody.Publisher,
+		Published: requestBody.Published,
+		Genre:     requestBody.Genre,
+		Language:  requestBody.Language,
+		Pages:     requestBody.Pages,
 	}
 	if err := updatedBook.ValidateUpdateBook(); err != nil {
 		http.Error(res, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// Without If-Match, or with *, the update is applied to whatever version is stored
+	if ifMatch := req.Header.Get("If-Match"); ifMatch != "" && ifMatch != "*" {
+		version, err := books.ParseETag(ifMatch)
+		if err != nil {
+			h.writeBookError(res, err, "")
+			return
+		}
+		updatedBook.Version = version
+	}
 	if err = h.bookService.UpdateBook(req.Context(), &updatedBook); err != nil {
 		h.logger.Error(err)
-		http.Error(res, "failed to update book", http.StatusInternalServerError)
+		h.writeBookError(res, err, "failed to update book")
 		return
 	}
+	res.Header().Set("ETag", books.ETag(updatedBook.Version))
 	if _, err := res.Write([]byte(fmt.Sprintf("%s by %s has been updated successfully",
 		updatedBook.Title, updatedBook.Author))); err != nil {
 		http.Error(res, "Could not write response", http.StatusInternalServerError)
@@ -297,13 +697,149 @@ func (h *booksHandler) UpdateBook(res http.ResponseWriter, req *http.Request) {
 
 }
 
+// @Summary Patch a book by ID
+// @Description Change only the fields that are sent. A JSON Merge Patch (RFC 7396) is a partial book where null clears a field,
+// @Description a JSON Patch (RFC 6902) is a list of add, replace, remove and test operations on top level fields.
+// @Description Publisher, genre, language and pages may be cleared, the other fields can only be replaced
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param book_id path int true "Book ID" Format(int64)
+// @Param requestBody body swagger.PatchBookRequestBody true "Fields to change"
+// @Param Content-Type header string false "application/merge-patch+json (default) or application/json-patch+json"
+// @Param If-Match header string false "ETag the book was read at, the patch is refused if it has changed since"
+// @Success 200 {object} books.Book "The patched book"
+// @Header 200 {string} ETag "New version of the book"
+// @Failure 400 {string} string "Bad Request: Invalid patch"
+// @Failure 404 {string} string "Book does not exist"
+// @Failure 409 {string} string "A test operation of the JSON Patch failed or another book has the ISBN"
+// @Failure 410 {string} string "Book has been deleted"
+// @Failure 412 {string} string "Book has been changed since the ETag in If-Match"
+// @Failure 415 {string} string "Unsupported patch format"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/{book_id} [patch]
+func (h *booksHandler) PatchBook(res http.ResponseWriter, req *http.Request) {
+	bookID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "book_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert book_id to integer", http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	var patch *books.Patch
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case "", "application/json", "application/merge-patch+json":
+		patch, err = books.ParseMergePatch(bookID, body)
+	case "application/json-patch+json":
+		patch, err = books.ParseJSONPatch(bookID, body)
+	default:
+		http.Error(res, "use application/merge-patch+json or application/json-patch+json", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := patch.ValidatePatch(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if ifMatch := req.Header.Get("If-Match"); ifMatch != "" && ifMatch != "*" {
+		version, err := books.ParseETag(ifMatch)
+		if err != nil {
+			h.writeBookError(res, err, "")
+			return
+		}
+		patch.Version = version
+	}
+	patchedBook, err := h.bookService.PatchBook(req.Context(), patch)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeBookError(res, err, "failed to patch book")
+		return
+	}
+	payload, err := json.Marshal(patchedBook)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("ETag", books.ETag(patchedBook.Version))
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update books in bulk
+// @Description Apply the same merge patch to every book picked by ids or by a filter, in one transaction. Availability
+// @Description comes from the copies, copy_status moves the copies of each book that are not on loan or on hold off the
+// @Description shelf. In all_or_nothing mode (the default) no book is changed unless every book can be, a dry run
+// @Description reports what would change without changing anything
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param requestBody body swagger.BulkUpdateBooksRequestBody true "Books to change and the changes"
+// @Success 200 {object} swagger.BulkBooksResponse "Per book results with the fields that changed"
+// @Failure 400 {object} swagger.BulkBooksResponse "Bad Request: no book could be changed"
+// @Failure 413 {string} string "Too many books in one request"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/bulk [patch]
+func (h *booksHandler) BulkUpdateBooks(res http.ResponseWriter, req *http.Request) {
+	requestBody := struct {
+		bulkSelectionBody
+		Changes    json.RawMessage `json:"changes"`
+		CopyStatus copies.Status   `json:"copy_status"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for bulk update", http.StatusBadRequest)
+		return
+	}
+	update := books.BulkUpdate{CopyStatus: requestBody.CopyStatus}
+	if len(requestBody.Changes) > 0 && string(requestBody.Changes) != "null" {
+		patch, err := books.ParseMergePatch(0, requestBody.Changes)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		update.Patch = patch
+	}
+	selection, err := requestBody.selection()
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	update.BulkSelection = *selection
+	if err := update.ValidateBulkUpdate(); err != nil {
+		h.writeBulkError(res, err, "")
+		return
+	}
+	results, err := h.bookService.UpdateBooksBulk(req.Context(), &update)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeBulkError(res, err, "failed to update books")
+		return
+	}
+	h.writeBulkResults(res, results, update.DryRun)
+}
+
 // @Summary delete a book by ID
-// @Description delete a book by ID (Hard delete)
+// @Description Move a book to the trash, it can be restored until the retention period is over and it is purged
 // @Tags Books
 // @Accept json
 // @Produce json
 // @Param book_id path int true "Book ID" Format(int64)
 // @Success 200 {string} string "Successfully deleted book"
+// @Failure 404 {string} string "Book does not exist"
+// @Failure 410 {string} string "Book has already been deleted"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /books/{book_id} [delete]
 func (h *booksHandler) DeleteBook(res http.ResponseWriter, req *http.Request) {
@@ -316,10 +852,9 @@ func (h *booksHandler) DeleteBook(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// Function is extensible to soft delete by updating the book deleted_at field
 	if err = h.bookService.DeleteBookByID(req.Context(), bookID); err != nil {
 		h.logger.Error(err)
-		http.Error(res, "failed to delete book", http.StatusInternalServerError)
+		h.writeBookError(res, err, "failed to delete book")
 		return
 	}
 	if _, err := res.Write([]byte("Successfully deleted book")); err != nil {
@@ -327,3 +862,380 @@ func (h *booksHandler) DeleteBook(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 }
+
+// @Summary Delete books in bulk
+// @Description Move every book picked by ids or by a filter to the trash in one transaction. In all_or_nothing mode
+// @Description (the default) no book is deleted unless every book can be, a dry run reports what would be deleted
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param requestBody body swagger.BulkDeleteBooksRequestBody true "Books to delete"
+// @Success 200 {object} swagger.BulkBooksResponse "Per book results"
+// @Failure 400 {object} swagger.BulkBooksResponse "Bad Request: no book could be deleted"
+// @Failure 413 {string} string "Too many books in one request"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/bulk [delete]
+func (h *booksHandler) BulkDeleteBooks(res http.ResponseWriter, req *http.Request) {
+	var requestBody bulkSelectionBody
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for bulk delete", http.StatusBadRequest)
+		return
+	}
+	selection, err := requestBody.selection()
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := selection.ValidateSelection(); err != nil {
+		h.writeBulkError(res, err, "")
+		return
+	}
+	results, err := h.bookService.DeleteBooksBulk(req.Context(), selection)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeBulkError(res, err, "failed to delete books")
+		return
+	}
+	h.writeBulkResults(res, results, selection.DryRun)
+}
+
+// @Summary Get the books in the trash
+// @Description Get the deleted books that have not been purged yet, most recently changed last
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of books per page"
+// @Success 200 {object} swagger.GetBooksReponse "Successfully retrieved deleted books"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/trash [get]
+func (h *booksHandler) GetTrash(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	params := books.GetBooksParams{Deleted: true}
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":     &params.Page,
+		"per_page": &params.PerPage,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				h.logger.Error(err)
+				http.Error(res, "failed to convert "+key+" string parameter to integer", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+	}
+	deletedBooks, count, err := h.bookService.GetBooks(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve deleted books", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Books []*books.Book `json:"books"`
+		Count int           `json:"count"`
+	}{
+		Books: deletedBooks,
+		Count: count,
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Restore a deleted book
+// @Description Take a book back out of the trash before it is purged
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param book_id path int true "Book ID" Format(int64)
+// @Success 200 {string} string "Successfully restored book"
+// @Failure 404 {string} string "Book does not exist or is not in the trash"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/{book_id}/restore [post]
+func (h *booksHandler) RestoreBook(res http.ResponseWriter, req *http.Request) {
+	bookID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "book_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert book_id to integer", http.StatusBadRequest)
+		return
+	}
+	if err := h.bookService.RestoreBookByID(req.Context(), bookID); err != nil {
+		h.logger.Error(err)
+		h.writeBookError(res, err, "failed to restore book")
+		return
+	}
+	if _, err := res.Write([]byte(fmt.Sprintf("Successfully restored book %d", bookID))); err != nil {
+		http.Error(res, "Could not write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get the change history of a book
+// @Description Every create, update, delete, restore and purge of a book, oldest first, including after it was purged
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param book_id path int true "Book ID" Format(int64)
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of records per page"
+// @Success 200 {object} swagger.GetAuditRecordsResponse "Successfully retrieved book history"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /books/{book_id}/history [get]
+func (h *booksHandler) GetBookHistory(res http.ResponseWriter, req *http.Request) {
+	bookID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "book_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert book_id to integer", http.StatusBadRequest)
+		return
+	}
+	query := req.URL.Query()
+	params := audit.GetRecordsParams{Entity: audit.Book, EntityID: bookID}
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":     &params.Page,
+		"per_page": &params.PerPage,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				h.logger.Error(err)
+				http.Error(res, "failed to convert "+key+" string parameter to integer", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+	}
+	records, count, err := h.auditService.GetRecords(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve book history", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Records []*audit.Record `json:"records"`
+		Count   int             `json:"count"`
+	}{
+		Records: records,
+		Count:   count,
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Tokens of the cursors either side of a page, empty where there is no page
+func (h *booksHandler) signCursors(page *books.CursorPage) (string, string, error) {
+	var tokens [2]string
+	for i, cursor := range []*books.Cursor{page.Next, page.Prev} {
+		if cursor == nil {
+			continue
+		}
+		token, err := h.cursors.Sign(cursor)
+		if err != nil {
+			return "", "", err
+		}
+		tokens[i] = token
+	}
+	return tokens[0], tokens[1], nil
+}
+
+// Read the filters of a book listing out of the query string, the export takes the same ones
+func booksParamsFromQuery(query url.Values) (*books.GetBooksParams, error) {
+	var params books.GetBooksParams
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":       &params.Page,
+		"per_page":   &params.PerPage,
+		"book_pages": &params.BookPages,
+		"genre_id":   &params.GenreID,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert %s string parameter to integer", key)
+			}
+			*target = value
+		}
+	}
+	if updatedAtStr := query.Get("updated_at"); updatedAtStr != "" {
+		convertedUpdatedAt, err := strconv.Atoi(updatedAtStr)
+		if err != nil {
+			return nil, errors.New("failed to convert updated_at string parameter to integer")
+		}
+		params.UpdatedAt = utils.CustomTime{
+			Time: time.Unix(int64(convertedUpdatedAt), 0),
+		}
+	}
+	if publishedStr := query.Get("published"); publishedStr != "" {
+		published, err := strconv.Atoi(publishedStr)
+		if err != nil {
+			return nil, errors.New("failed to convert published string parameter to integer")
+		}
+		params.Published = utils.CustomDate{
+			Time: time.Unix(int64(published), 0),
+		}
+	}
+	if sortStr := query.Get("sort"); sortStr != "" {
+		keys, err := books.ParseSort(sortStr)
+		if err != nil {
+			return nil, err
+		}
+		params.Sort = keys
+	}
+	if totalStr := query.Get("total"); totalStr != "" {
+		total, err := strconv.ParseBool(totalStr)
+		if err != nil {
+			return nil, errors.New("total must be true or false")
+		}
+		params.SkipTotal = !total
+	}
+	if decadeStr := query.Get("decade"); decadeStr != "" {
+		decade, err := books.ParseDecade(decadeStr)
+		if err != nil {
+			return nil, err
+		}
+		params.Decade = decade
+	}
+	// String values
+	params.ISBN = query.Get("isbn")
+	params.Title = query.Get("title")
+	params.Author = query.Get("author")
+	params.Publisher = query.Get("publisher")
+	params.Genre = query.Get("genre")
+	params.Language = query.Get("language")
+	params.Availability = books.Availability(query.Get("availability"))
+	return &params, nil
+}
+
+// Body shared by the bulk update and delete requests, the filter takes the same fields as listing the books
+type bulkSelectionBody struct {
+	Mode   books.BulkMode `json:"mode"`
+	DryRun bool           `json:"dry_run"`
+	IDs    []int          `json:"ids"`
+	Filter *struct {
+		ISBN         string             `json:"isbn"`
+		Title        string             `json:"title"`
+		Author       string             `json:"author"`
+		Publisher    string             `json:"publisher"`
+		Published    utils.CustomDate   `json:"published"`
+		Genre        string             `json:"genre"`
+		GenreID      int                `json:"genre_id"`
+		Language     string             `json:"language"`
+		BookPages    int                `json:"book_pages"`
+		Availability books.Availability `json:"availability"`
+	} `json:"filter"`
+}
+
+func (b *bulkSelectionBody) selection() (*books.BulkSelection, error) {
+	switch b.Mode {
+	case "", books.AllOrNothing, books.Partial:
+	default:
+		return nil, errors.New("value for mode is not recognised, please use all_or_nothing or partial")
+	}
+	selection := &books.BulkSelection{IDs: b.IDs, Mode: b.Mode, DryRun: b.DryRun}
+	if b.Filter != nil {
+		selection.Filter = &books.GetBooksParams{
+			ISBN:         b.Filter.ISBN,
+			Title:        b.Filter.Title,
+			Author:       b.Filter.Author,
+			Publisher:    b.Filter.Publisher,
+			Published:    b.Filter.Published,
+			Genre:        b.Filter.Genre,
+			GenreID:      b.Filter.GenreID,
+			Language:     b.Filter.Language,
+			BookPages:    b.Filter.BookPages,
+			Availability: b.Filter.Availability,
+		}
+	}
+	return selection, nil
+}
+
+// Write the per book results of a bulk update or delete, a request where every book failed is a bad request
+func (h *booksHandler) writeBulkResults(res http.ResponseWriter, results []*books.BulkResult, dryRun bool) {
+	response := struct {
+		Results []*books.BulkResult `json:"results"`
+		Applied int                 `json:"applied"`
+		Failed  int                 `json:"failed"`
+		DryRun  bool                `json:"dry_run"`
+	}{
+		Results: results,
+		DryRun:  dryRun,
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			response.Failed++
+			continue
+		}
+		response.Applied++
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	if response.Applied == 0 && response.Failed > 0 {
+		res.WriteHeader(http.StatusBadRequest)
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *booksHandler) writeBulkError(res http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, books.ErrBulkTooLarge):
+		http.Error(res, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, books.ErrBulkSelection), errors.Is(err, books.ErrBulkCopyStatus),
+		errors.Is(err, books.ErrInvalidPatch):
+		http.Error(res, err.Error(), http.StatusBadRequest)
+	default:
+		if fallback == "" {
+			// Validation messages of the patch fields are safe to show
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(res, fallback, http.StatusInternalServerError)
+	}
+}
+
+// Map domain errors onto http status codes, anything unknown is hidden behind the fallback message
+func (h *booksHandler) writeBookError(res http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, books.ErrBookNotFound), errors.Is(err, books.ErrBookNotInTrash):
+		http.Error(res, err.Error(), http.StatusNotFound)
+	case errors.Is(err, books.ErrBookDeleted):
+		http.Error(res, err.Error(), http.StatusGone)
+	case errors.Is(err, books.ErrVersionConflict):
+		http.Error(res, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, books.ErrPatchTestFailed), errors.Is(err, books.ErrBookAlreadyExists):
+		http.Error(res, err.Error(), http.StatusConflict)
+	default:
+		http.Error(res, fallback, http.StatusInternalServerError)
+	}
+}
@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,3 +19,56 @@ func TestExtractQueryParams(t *testing.T) {
 
 	assertWithTest.NotNil(req)
 }
+
+func TestBooksParamsFromQuery(t *testing.T) {
+	assertWithTest := assert.New(t)
+	query, err := url.ParseQuery("page=2&per_page=10&genre_id=4&title=Dune&availability=available&published=0")
+	assertWithTest.Nil(err)
+	params, err := booksParamsFromQuery(query)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(2, params.Page)
+	assertWithTest.Equal(10, params.PerPage)
+	assertWithTest.Equal(4, params.GenreID)
+	assertWithTest.Equal("Dune", params.Title)
+	assertWithTest.Equal(books.Available, params.Availability)
+	assertWithTest.Equal(int64(0), params.Published.Unix())
+
+	_, err = booksParamsFromQuery(url.Values{"book_pages": {"many"}})
+	assertWithTest.EqualError(err, "failed to convert book_pages string parameter to integer")
+
+	params, err = booksParamsFromQuery(url.Values{"decade": {"1980"}})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1980, params.Decade)
+	_, err = booksParamsFromQuery(url.Values{"decade": {"1984"}})
+	assertWithTest.ErrorIs(err, books.ErrDecade)
+
+	params, err = booksParamsFromQuery(url.Values{"sort": {"-published,title"}})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]books.SortKey{{Field: "published", Descending: true}, {Field: "title"}}, params.Sort)
+	_, err = booksParamsFromQuery(url.Values{"sort": {"deleted_at"}})
+	assertWithTest.ErrorIs(err, books.ErrSort)
+
+	params, err = booksParamsFromQuery(url.Values{"total": {"false"}})
+	assertWithTest.Nil(err)
+	assertWithTest.True(params.SkipTotal)
+	_, err = booksParamsFromQuery(url.Values{"total": {"some"}})
+	assertWithTest.EqualError(err, "total must be true or false")
+}
+
+func TestWriteBookError(t *testing.T) {
+	assertWithTest := assert.New(t)
+	handler := &booksHandler{}
+	for err, status := range map[error]int{
+		books.ErrBookNotFound:      http.StatusNotFound,
+		books.ErrBookDeleted:       http.StatusGone,
+		books.ErrVersionConflict:   http.StatusPreconditionFailed,
+		books.ErrPatchTestFailed:   http.StatusConflict,
+		books.ErrBookAlreadyExists: http.StatusConflict,
+		fmt.Errorf("update book 4: %w", books.ErrBookAlreadyExists): http.StatusConflict,
+		fmt.Errorf("connection refused"):                            http.StatusInternalServerError,
+	} {
+		res := httptest.NewRecorder()
+		handler.writeBookError(res, err, "failed to update book")
+		assertWithTest.Equal(status, res.Code, err.Error())
+	}
+}
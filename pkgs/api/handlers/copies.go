@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+type CopiesHandlerParams struct {
+	fx.In
+	CopyService copies.Service
+}
+
+type copiesHandler struct {
+	copyService copies.Service
+	logger      logrus.FieldLogger
+}
+
+func NewCopiesHandler(p CopiesHandlerParams) copies.Handler {
+	return &copiesHandler{
+		copyService: p.CopyService,
+		logger: logrus.WithFields(logrus.Fields{
+			"package": "handlers",
+			"domain":  "copies",
+		}),
+	}
+}
+
+// @Summary Add a copy of a book
+// @Description Register a physical copy of a book, a copy put on the shelf goes to the holds queue first
+// @Tags Copies
+// @Accept json
+// @Produce json
+// @Param requestBody body swagger.CreateCopyRequestBody true "New copy details"
+// @Success 200 {object} copies.Copy "Successfully created copy"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Book does not exist"
+// @Failure 409 {string} string "Barcode is already in use"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /copies [post]
+func (h *copiesHandler) CreateCopy(res http.ResponseWriter, req *http.Request) {
+	var newCopy copies.Copy
+	if err := json.NewDecoder(req.Body).Decode(&newCopy); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for create copy", http.StatusBadRequest)
+		return
+	}
+	// Validate the Request
+	if err := newCopy.ValidateCreateCopy(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.copyService.CreateCopies(req.Context(), []*copies.Copy{&newCopy}); err != nil {
+		h.logger.Error(err)
+		h.writeCopyError(res, err, "failed to create copy")
+		return
+	}
+	payload, err := json.Marshal(newCopy)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get a copy by ID
+// @Description Get details of a copy by its ID
+// @Tags Copies
+// @Accept json
+// @Produce json
+// @Param copy_id path int true "Copy ID" Format(int64)
+// @Success 200 {object} copies.Copy "Successfully retrieved copy"
+// @Failure 404 {string} string "Copy does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /copies/{copy_id} [get]
+func (h *copiesHandler) GetCopyByID(res http.ResponseWriter, req *http.Request) {
+	copyID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "copy_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert copy_id to integer", http.StatusBadRequest)
+		return
+	}
+	retrievedCopies, _, err := h.copyService.GetCopies(req.Context(), &copies.GetCopiesParams{ID: copyID})
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve copy", http.StatusInternalServerError)
+		return
+	}
+	if len(retrievedCopies) == 0 {
+		http.Error(res, copies.ErrCopyNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	payload, err := json.Marshal(retrievedCopies[0])
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not marshall copy data to json", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get a list of copies
+// @Description Get a list of copies based on specified query parameters
+// @Tags Copies
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of copies per page"
+// @Param book_id query int false "Filter copies by book"
+// @Param barcode query string false "Filter copies by barcode"
+// @Param shelf_location query string false "Filter copies by shelf location prefix"
+// @Param status query string false "Filter copies by status (available, on_loan, on_hold, in_repair, lost, withdrawn)"
+// @Success 200 {object} swagger.GetCopiesResponse "Successfully retrieved copies"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /copies [get]
+func (h *copiesHandler) GetCopies(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var params copies.GetCopiesParams
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":     &params.Page,
+		"per_page": &params.PerPage,
+		"book_id":  &params.BookID,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				h.logger.Error(err)
+				http.Error(res, "failed to convert "+key+" string parameter to integer", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+	}
+	// String values
+	params.Barcode = query.Get("barcode")
+	params.ShelfLocation = query.Get("shelf_location")
+	params.Status = copies.Status(query.Get("status"))
+	retrievedCopies, count, err := h.copyService.GetCopies(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve copies", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Copies []*copies.Copy `json:"copies"`
+		Count  int            `json:"count"`
+	}{
+		Copies: retrievedCopies,
+		Count:  count,
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update a copy by ID
+// @Description Update the barcode, shelf location, condition or status of a copy
+// @Tags Copies
+// @Accept json
+// @Produce json
+// @Param copy_id path int true "Copy ID" Format(int64)
+// @Param requestBody body swagger.UpdateCopyRequestBody true "New copy details"
+// @Success 200 {object} copies.Copy "Successfully updated copy"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Copy does not exist"
+// @Failure 409 {string} string "Barcode is already in use or the copy is on loan or on hold"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /copies/{copy_id} [put]
+func (h *copiesHandler) UpdateCopy(res http.ResponseWriter, req *http.Request) {
+	copyID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "copy_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert copy_id to integer", http.StatusBadRequest)
+		return
+	}
+	requestBody := struct {
+		Barcode       string           `json:"barcode"`
+		ShelfLocation string           `json:"shelf_location"`
+		Condition     copies.Condition `json:"condition"`
+		Status        copies.Status    `json:"status"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body", http.StatusBadRequest)
+		return
+	}
+	update := copies.Copy{
+		ID:            copyID,
+		Barcode:       requestBody.Barcode,
+		ShelfLocation: requestBody.ShelfLocation,
+		Condition:     requestBody.Condition,
+		Status:        requestBody.Status,
+	}
+	if err := update.ValidateUpdateCopy(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	updatedCopy, err := h.copyService.UpdateCopy(req.Context(), &update)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeCopyError(res, err, "failed to update copy")
+		return
+	}
+	payload, err := json.Marshal(updatedCopy)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary delete a copy by ID
+// @Description delete a copy by ID, copies with loan history must be withdrawn instead
+// @Tags Copies
+// @Accept json
+// @Produce json
+// @Param copy_id path int true "Copy ID" Format(int64)
+// @Success 200 {string} string "Successfully deleted copy"
+// @Failure 404 {string} string "Copy does not exist"
+// @Failure 409 {string} string "Copy is in circulation or has loan history"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /copies/{copy_id} [delete]
+func (h *copiesHandler) DeleteCopy(res http.ResponseWriter, req *http.Request) {
+	copyID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "copy_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert copy_id to integer", http.StatusBadRequest)
+		return
+	}
+	if err := h.copyService.DeleteCopyByID(req.Context(), copyID); err != nil {
+		h.logger.Error(err)
+		h.writeCopyError(res, err, "failed to delete copy")
+		return
+	}
+	if _, err := res.Write([]byte(fmt.Sprintf("Successfully deleted copy %d", copyID))); err != nil {
+		http.Error(res, "Could not write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Map domain errors onto http status codes, anything unknown is hidden behind the fallback message
+func (h *copiesHandler) writeCopyError(res http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, copies.ErrCopyNotFound), errors.Is(err, copies.ErrBookNotFound):
+		http.Error(res, err.Error(), http.StatusNotFound)
+	case errors.Is(err, copies.ErrCopyAlreadyExists), errors.Is(err, copies.ErrCopyInCirculation),
+		errors.Is(err, copies.ErrCopyHasLoanHistory):
+		http.Error(res, err.Error(), http.StatusConflict)
+	default:
+		http.Error(res, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/fines"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+type FinesHandlerParams struct {
+	fx.In
+	FineService fines.Service
+}
+
+type finesHandler struct {
+	fineService fines.Service
+	logger      logrus.FieldLogger
+}
+
+func NewFinesHandler(p FinesHandlerParams) fines.Handler {
+	return &finesHandler{
+		fineService: p.FineService,
+		logger: logrus.WithFields(logrus.Fields{
+			"package": "handlers",
+			"domain":  "fines",
+		}),
+	}
+}
+
+// @Summary Get a list of fines
+// @Description Get a list of overdue fines based on specified query parameters, amounts are in cents
+// @Tags Fines
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of fines per page"
+// @Param loan_id query int false "Filter fines by loan"
+// @Param member_id query int false "Filter fines by member"
+// @Param status query string false "Filter fines by status (outstanding, paid, waived)"
+// @Success 200 {object} swagger.GetFinesResponse "Successfully retrieved fines"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /fines [get]
+func (h *finesHandler) GetFines(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var params fines.GetFinesParams
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":      &params.Page,
+		"per_page":  &params.PerPage,
+		"loan_id":   &params.LoanID,
+		"member_id": &params.MemberID,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				h.logger.Error(err)
+				http.Error(res, "failed to convert "+key+" string parameter to integer", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+	}
+	params.Status = fines.Status(query.Get("status"))
+	retrievedFines, count, err := h.fineService.GetFines(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve fines", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Fines []*fines.Fine `json:"fines"`
+		Count int           `json:"count"`
+	}{
+		Fines: retrievedFines,
+		Count: count,
+	}
+	h.writeJSON(res, response)
+}
+
+// @Summary Get a fine by ID
+// @Description Get details of a fine by its ID, amounts are in cents
+// @Tags Fines
+// @Accept json
+// @Produce json
+// @Param fine_id path int true "Fine ID" Format(int64)
+// @Success 200 {object} fines.Fine "Successfully retrieved fine"
+// @Failure 404 {string} string "Fine does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /fines/{fine_id} [get]
+func (h *finesHandler) GetFineByID(res http.ResponseWriter, req *http.Request) {
+	fineID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "fine_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert fine_id to integer", http.StatusBadRequest)
+		return
+	}
+	retrievedFines, _, err := h.fineService.GetFines(req.Context(), &fines.GetFinesParams{ID: fineID})
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve fine", http.StatusInternalServerError)
+		return
+	}
+	if len(retrievedFines) == 0 {
+		http.Error(res, fines.ErrFineNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	h.writeJSON(res, retrievedFines[0])
+}
+
+// @Summary Get a member's fine balance
+// @Description Get the total a member owes in outstanding fines and whether it blocks checkouts, amounts are in cents
+// @Tags Fines
+// @Accept json
+// @Produce json
+// @Param member_id path int true "Member ID" Format(int64)
+// @Success 200 {object} swagger.MemberBalanceResponse "Successfully retrieved balance"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /fines/balance/{member_id} [get]
+func (h *finesHandler) GetMemberBalance(res http.ResponseWriter, req *http.Request) {
+	memberID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "member_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert member_id to integer", http.StatusBadRequest)
+		return
+	}
+	balance, err := h.fineService.OutstandingBalance(req.Context(), memberID)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve balance", http.StatusInternalServerError)
+		return
+	}
+	blocked, err := h.fineService.CheckoutBlocked(req.Context(), memberID)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve balance", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		MemberID        int  `json:"member_id"`
+		Balance         int  `json:"balance"`
+		CheckoutBlocked bool `json:"checkout_blocked"`
+	}{
+		MemberID:        memberID,
+		Balance:         balance,
+		CheckoutBlocked: blocked,
+	}
+	h.writeJSON(res, response)
+}
+
+// @Summary Pay a fine
+// @Description Record a full or part payment against a fine, the amount is in cents
+// @Tags Fines
+// @Accept json
+// @Produce json
+// @Param fine_id path int true "Fine ID" Format(int64)
+// @Param requestBody body swagger.PayFineRequestBody true "Payment details"
+// @Success 200 {object} fines.Fine "Successfully paid fine"
+// @Failure 400 {string} string "Bad Request: Invalid payment amount"
+// @Failure 404 {string} string "Fine does not exist"
+// @Failure 409 {string} string "Fine has already been paid or waived"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /fines/{fine_id}/pay [post]
+func (h *finesHandler) PayFine(res http.ResponseWriter, req *http.Request) {
+	fineID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "fine_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert fine_id to integer", http.StatusBadRequest)
+		return
+	}
+	requestBody := struct {
+		Amount int `json:"amount"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for pay fine", http.StatusBadRequest)
+		return
+	}
+	paidFine, err := h.fineService.PayFine(req.Context(), fineID, requestBody.Amount)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeFineError(res, err, "failed to pay fine")
+		return
+	}
+	h.writeJSON(res, paidFine)
+}
+
+// @Summary Waive a fine
+// @Description Cancel whatever is left of a fine, a reason is required
+// @Tags Fines
+// @Accept json
+// @Produce json
+// @Param fine_id path int true "Fine ID" Format(int64)
+// @Param requestBody body swagger.WaiveFineRequestBody true "Waiver details"
+// @Success 200 {object} fines.Fine "Successfully waived fine"
+// @Failure 400 {string} string "Bad Request: Reason is required"
+// @Failure 404 {string} string "Fine does not exist"
+// @Failure 409 {string} string "Fine has already been paid or waived"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /fines/{fine_id}/waive [post]
+func (h *finesHandler) WaiveFine(res http.ResponseWriter, req *http.Request) {
+	fineID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "fine_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert fine_id to integer", http.StatusBadRequest)
+		return
+	}
+	requestBody := struct {
+		Reason string `json:"reason"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for waive fine", http.StatusBadRequest)
+		return
+	}
+	waivedFine, err := h.fineService.WaiveFine(req.Context(), fineID, requestBody.Reason)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeFineError(res, err, "failed to waive fine")
+		return
+	}
+	h.writeJSON(res, waivedFine)
+}
+
+func (h *finesHandler) writeJSON(res http.ResponseWriter, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Map domain errors onto http status codes, anything unknown is hidden behind the fallback message
+func (h *finesHandler) writeFineError(res http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, fines.ErrFineNotFound):
+		http.Error(res, err.Error(), http.StatusNotFound)
+	case errors.Is(err, fines.ErrFineNotOutstanding):
+		http.Error(res, err.Error(), http.StatusConflict)
+	case errors.Is(err, fines.ErrInvalidPayment), errors.Is(err, fines.ErrOverpayment),
+		errors.Is(err, fines.ErrWaiverReason):
+		http.Error(res, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(res, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/genres"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+type GenresHandlerParams struct {
+	fx.In
+	GenreService genres.Service
+}
+
+type genresHandler struct {
+	genreService genres.Service
+	logger       logrus.FieldLogger
+}
+
+func NewGenresHandler(p GenresHandlerParams) genres.Handler {
+	return &genresHandler{
+		genreService: p.GenreService,
+		logger: logrus.WithFields(logrus.Fields{
+			"package": "handlers",
+			"domain":  "genres",
+		}),
+	}
+}
+
+// @Summary Create a genre or subject
+// @Description Add a node to the genre tree, at the top level or below an existing node
+// @Tags Genres
+// @Accept json
+// @Produce json
+// @Param requestBody body swagger.CreateGenreRequestBody true "New genre details"
+// @Success 200 {object} genres.Node "Successfully created genre"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Parent genre does not exist"
+// @Failure 409 {string} string "Parent already has a genre with this name"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /genres [post]
+func (h *genresHandler) CreateGenre(res http.ResponseWriter, req *http.Request) {
+	var newNode genres.Node
+	if err := json.NewDecoder(req.Body).Decode(&newNode); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for create genre", http.StatusBadRequest)
+		return
+	}
+	// Validate the Request
+	if err := newNode.ValidateCreateNode(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.genreService.CreateNodes(req.Context(), []*genres.Node{&newNode}); err != nil {
+		h.logger.Error(err)
+		h.writeGenreError(res, err, "failed to create genre")
+		return
+	}
+	h.writeNode(res, &newNode)
+}
+
+// @Summary Get a genre by ID
+// @Description Get a node of the genre tree and its full path
+// @Tags Genres
+// @Accept json
+// @Produce json
+// @Param genre_id path int true "Genre ID" Format(int64)
+// @Success 200 {object} genres.Node "Successfully retrieved genre"
+// @Failure 404 {string} string "Genre does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /genres/{genre_id} [get]
+func (h *genresHandler) GetGenreByID(res http.ResponseWriter, req *http.Request) {
+	genreID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "genre_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert genre_id to integer", http.StatusBadRequest)
+		return
+	}
+	nodes, _, err := h.genreService.GetNodes(req.Context(), &genres.GetNodesParams{ID: genreID})
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve genre", http.StatusInternalServerError)
+		return
+	}
+	if len(nodes) == 0 {
+		http.Error(res, genres.ErrGenreNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	h.writeNode(res, nodes[0])
+}
+
+// @Summary Get a list of genres
+// @Description Get nodes of the genre tree, listed depth first by path
+// @Tags Genres
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of genres per page"
+// @Param name query string false "Filter genres by name"
+// @Param kind query string false "Filter by kind (genre, subject)"
+// @Param parent_id query int false "Only the direct children of this genre, 0 for the top level"
+// @Param ancestor_id query int false "Every genre below this one at any depth"
+// @Param book_id query int false "Genres a book is filed under"
+// @Success 200 {object} swagger.GetGenresResponse "Successfully retrieved genres"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /genres [get]
+func (h *genresHandler) GetGenres(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var params genres.GetNodesParams
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":        &params.Page,
+		"per_page":    &params.PerPage,
+		"parent_id":   &params.ParentID,
+		"ancestor_id": &params.AncestorID,
+		"book_id":     &params.BookID,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				h.logger.Error(err)
+				http.Error(res, "failed to convert "+key+" string parameter to integer", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+	}
+	// An explicit parent_id of 0 asks for the top level of the tree
+	if query.Get("parent_id") == "0" {
+		params.ParentID = -1
+	}
+	params.Name = query.Get("name")
+	params.Kind = genres.Kind(query.Get("kind"))
+	nodes, count, err := h.genreService.GetNodes(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve genres", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Genres []*genres.Node `json:"genres"`
+		Count  int            `json:"count"`
+	}{
+		Genres: nodes,
+		Count:  count,
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Rename a genre
+// @Description Rename a node of the genre tree, the paths of the nodes below it follow
+// @Tags Genres
+// @Accept json
+// @Produce json
+// @Param genre_id path int true "Genre ID" Format(int64)
+// @Param requestBody body swagger.RenameGenreRequestBody true "New name"
+// @Success 200 {object} genres.Node "Successfully renamed genre"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Genre does not exist"
+// @Failure 409 {string} string "Parent already has a genre with this name"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /genres/{genre_id} [put]
+func (h *genresHandler) RenameGenre(res http.ResponseWriter, req *http.Request) {
+	genreID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "genre_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert genre_id to integer", http.StatusBadRequest)
+		return
+	}
+	requestBody := struct {
+		Name string `json:"name"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body", http.StatusBadRequest)
+		return
+	}
+	renamed := genres.Node{ID: genreID, Name: requestBody.Name}
+	if err := renamed.ValidateRename(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	node, err := h.genreService.RenameNode(req.Context(), genreID, renamed.Name)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeGenreError(res, err, "failed to rename genre")
+		return
+	}
+	h.writeNode(res, node)
+}
+
+// @Summary Move a genre
+// @Description Move a node and everything below it under a new parent, a parent_id of 0 moves it to the top level
+// @Tags Genres
+// @Accept json
+// @Produce json
+// @Param genre_id path int true "Genre ID" Format(int64)
+// @Param requestBody body swagger.MoveGenreRequestBody true "New parent"
+// @Success 200 {object} genres.Node "Successfully moved genre"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Genre or parent does not exist"
+// @Failure 409 {string} string "The move would create a cycle or a duplicate name"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /genres/{genre_id}/move [post]
+func (h *genresHandler) MoveGenre(res http.ResponseWriter, req *http.Request) {
+	genreID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "genre_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert genre_id to integer", http.StatusBadRequest)
+		return
+	}
+	requestBody := struct {
+		ParentID int `json:"parent_id"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body", http.StatusBadRequest)
+		return
+	}
+	if requestBody.ParentID < 0 {
+		http.Error(res, "parent_id cannot be negative", http.StatusBadRequest)
+		return
+	}
+	node, err := h.genreService.MoveNode(req.Context(), genreID, requestBody.ParentID)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeGenreError(res, err, "failed to move genre")
+		return
+	}
+	h.writeNode(res, node)
+}
+
+// @Summary Merge a genre into another
+// @Description Refile the books and sub-genres of a genre under the target genre and remove it
+// @Tags Genres
+// @Accept json
+// @Produce json
+// @Param genre_id path int true "Genre ID to merge away" Format(int64)
+// @Param requestBody body swagger.MergeGenreRequestBody true "Genre to merge into"
+// @Success 200 {object} genres.Node "The genre that was merged into"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Genre does not exist"
+// @Failure 409 {string} string "The merge would create a cycle or a duplicate name"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /genres/{genre_id}/merge [post]
+func (h *genresHandler) MergeGenre(res http.ResponseWriter, req *http.Request) {
+	genreID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "genre_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert genre_id to integer", http.StatusBadRequest)
+		return
+	}
+	requestBody := struct {
+		TargetID int `json:"target_id"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body", http.StatusBadRequest)
+		return
+	}
+	if requestBody.TargetID <= 0 {
+		http.Error(res, "target_id field is required", http.StatusBadRequest)
+		return
+	}
+	node, err := h.genreService.MergeNodes(req.Context(), genreID, requestBody.TargetID)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeGenreError(res, err, "failed to merge genre")
+		return
+	}
+	h.writeNode(res, node)
+}
+
+// @Summary delete a genre by ID
+// @Description delete an empty genre, genres with books or sub-genres must be merged or emptied first
+// @Tags Genres
+// @Accept json
+// @Produce json
+// @Param genre_id path int true "Genre ID" Format(int64)
+// @Success 200 {string} string "Successfully deleted genre"
+// @Failure 404 {string} string "Genre does not exist"
+// @Failure 409 {string} string "Genre still has books or sub-genres"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /genres/{genre_id} [delete]
+func (h *genresHandler) DeleteGenre(res http.ResponseWriter, req *http.Request) {
+	genreID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "genre_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert genre_id to integer", http.StatusBadRequest)
+		return
+	}
+	if err := h.genreService.DeleteNodeByID(req.Context(), genreID); err != nil {
+		h.logger.Error(err)
+		h.writeGenreError(res, err, "failed to delete genre")
+		return
+	}
+	if _, err := res.Write([]byte(fmt.Sprintf("Successfully deleted genre %d", genreID))); err != nil {
+		http.Error(res, "Could not write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary File a book under a genre
+// @Description File a book under a node of the genre tree, a book may be filed under several nodes
+// @Tags Genres
+// @Accept json
+// @Produce json
+// @Param genre_id path int true "Genre ID" Format(int64)
+// @Param book_id path int true "Book ID" Format(int64)
+// @Success 200 {string} string "Successfully filed book"
+// @Failure 404 {string} string "Genre or book does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /genres/{genre_id}/books/{book_id} [put]
+func (h *genresHandler) AddBook(res http.ResponseWriter, req *http.Request) {
+	genreID, bookID, ok := h.genreAndBookIDs(res, req)
+	if !ok {
+		return
+	}
+	if err := h.genreService.AddBook(req.Context(), genreID, bookID); err != nil {
+		h.logger.Error(err)
+		h.writeGenreError(res, err, "failed to file book under genre")
+		return
+	}
+	if _, err := res.Write([]byte(fmt.Sprintf("Successfully filed book %d under genre %d", bookID, genreID))); err != nil {
+		http.Error(res, "Could not write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Remove a book from a genre
+// @Description Remove a book from a node of the genre tree
+// @Tags Genres
+// @Accept json
+// @Produce json
+// @Param genre_id path int true "Genre ID" Format(int64)
+// @Param book_id path int true "Book ID" Format(int64)
+// @Success 200 {string} string "Successfully removed book"
+// @Failure 404 {string} string "Book is not in this genre"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /genres/{genre_id}/books/{book_id} [delete]
+func (h *genresHandler) RemoveBook(res http.ResponseWriter, req *http.Request) {
+	genreID, bookID, ok := h.genreAndBookIDs(res, req)
+	if !ok {
+		return
+	}
+	if err := h.genreService.RemoveBook(req.Context(), genreID, bookID); err != nil {
+		h.logger.Error(err)
+		h.writeGenreError(res, err, "failed to remove book from genre")
+		return
+	}
+	if _, err := res.Write([]byte(fmt.Sprintf("Successfully removed book %d from genre %d", bookID, genreID))); err != nil {
+		http.Error(res, "Could not write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (h *genresHandler) genreAndBookIDs(res http.ResponseWriter, req *http.Request) (int, int, bool) {
+	genreID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "genre_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert genre_id to integer", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	bookID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "book_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert book_id to integer", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return genreID, bookID, true
+}
+
+func (h *genresHandler) writeNode(res http.ResponseWriter, node *genres.Node) {
+	payload, err := json.Marshal(node)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Map domain errors onto http status codes, anything unknown is hidden behind the fallback message
+func (h *genresHandler) writeGenreError(res http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, genres.ErrGenreNotFound), errors.Is(err, genres.ErrParentNotFound),
+		errors.Is(err, genres.ErrBookNotFound), errors.Is(err, genres.ErrBookNotInGenre):
+		http.Error(res, err.Error(), http.StatusNotFound)
+	case errors.Is(err, genres.ErrGenreAlreadyExists), errors.Is(err, genres.ErrGenreCycle),
+		errors.Is(err, genres.ErrGenreNotEmpty):
+		http.Error(res, err.Error(), http.StatusConflict)
+	default:
+		http.Error(res, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/holds"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+type HoldsHandlerParams struct {
+	fx.In
+	HoldService holds.Service
+}
+
+type holdsHandler struct {
+	holdService holds.Service
+	logger      logrus.FieldLogger
+}
+
+func NewHoldsHandler(p HoldsHandlerParams) holds.Handler {
+	return &holdsHandler{
+		holdService: p.HoldService,
+		logger: logrus.WithFields(logrus.Fields{
+			"package": "handlers",
+			"domain":  "holds",
+		}),
+	}
+}
+
+// @Summary Place a hold
+// @Description Join the queue for a book that has no copies on the shelf
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Param requestBody body swagger.PlaceHoldRequestBody true "Hold details"
+// @Success 200 {object} holds.Hold "Successfully placed hold"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Book or member does not exist"
+// @Failure 409 {string} string "Book is available or member already has an open hold"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /holds [post]
+func (h *holdsHandler) PlaceHold(res http.ResponseWriter, req *http.Request) {
+	var newHold holds.Hold
+	if err := json.NewDecoder(req.Body).Decode(&newHold); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for place hold", http.StatusBadRequest)
+		return
+	}
+	// Validate the Request
+	if err := newHold.ValidatePlaceHold(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.holdService.PlaceHold(req.Context(), &newHold); err != nil {
+		h.logger.Error(err)
+		h.writeHoldError(res, err, "failed to place hold")
+		return
+	}
+	payload, err := json.Marshal(newHold)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Cancel a hold
+// @Description Leave the queue, a hold that was ready for pickup passes its copy to the next member
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Param hold_id path int true "Hold ID" Format(int64)
+// @Success 200 {object} holds.Hold "Successfully cancelled hold"
+// @Failure 404 {string} string "Hold does not exist"
+// @Failure 409 {string} string "Hold is no longer open"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /holds/{hold_id}/cancel [post]
+func (h *holdsHandler) CancelHold(res http.ResponseWriter, req *http.Request) {
+	holdID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "hold_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert hold_id to integer", http.StatusBadRequest)
+		return
+	}
+	cancelledHold, err := h.holdService.CancelHold(req.Context(), holdID)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeHoldError(res, err, "failed to cancel hold")
+		return
+	}
+	payload, err := json.Marshal(cancelledHold)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get a list of holds
+// @Description Get holds in queue order based on specified query parameters
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of holds per page"
+// @Param book_id query int false "Filter holds by book"
+// @Param member_id query int false "Filter holds by member"
+// @Param status query string false "Filter holds by status (waiting, ready, fulfilled, cancelled, expired)"
+// @Success 200 {object} swagger.GetHoldsResponse "Successfully retrieved holds"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /holds [get]
+func (h *holdsHandler) GetHolds(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var params holds.GetHoldsParams
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":      &params.Page,
+		"per_page":  &params.PerPage,
+		"book_id":   &params.BookID,
+		"member_id": &params.MemberID,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				h.logger.Error(err)
+				http.Error(res, "failed to convert "+key+" string parameter to integer", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+	}
+	params.Status = holds.Status(query.Get("status"))
+	retrievedHolds, count, err := h.holdService.GetHolds(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve holds", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Holds []*holds.Hold `json:"holds"`
+		Count int           `json:"count"`
+	}{
+		Holds: retrievedHolds,
+		Count: count,
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get a hold by ID
+// @Description Get details of a hold, including its place in the queue
+// @Tags Holds
+// @Accept json
+// @Produce json
+// @Param hold_id path int true "Hold ID" Format(int64)
+// @Success 200 {object} holds.Hold "Successfully retrieved hold"
+// @Failure 404 {string} string "Hold does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /holds/{hold_id} [get]
+func (h *holdsHandler) GetHoldByID(res http.ResponseWriter, req *http.Request) {
+	holdID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "hold_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert hold_id to integer", http.StatusBadRequest)
+		return
+	}
+	retrievedHolds, _, err := h.holdService.GetHolds(req.Context(), &holds.GetHoldsParams{ID: holdID})
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve hold", http.StatusInternalServerError)
+		return
+	}
+	if len(retrievedHolds) == 0 {
+		http.Error(res, holds.ErrHoldNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	payload, err := json.Marshal(retrievedHolds[0])
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not marshall hold data to json", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Map domain errors onto http status codes, anything unknown is hidden behind the fallback message
+func (h *holdsHandler) writeHoldError(res http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, holds.ErrBookNotFound), errors.Is(err, holds.ErrMemberNotFound),
+		errors.Is(err, holds.ErrHoldNotFound):
+		http.Error(res, err.Error(), http.StatusNotFound)
+	case errors.Is(err, holds.ErrBookAvailable), errors.Is(err, holds.ErrBookAlreadyOnLoan),
+		errors.Is(err, holds.ErrMemberNotActive), errors.Is(err, holds.ErrHoldAlreadyExists),
+		errors.Is(err, holds.ErrHoldNotOpen):
+		http.Error(res, err.Error(), http.StatusConflict)
+	default:
+		http.Error(res, fallback, http.StatusInternalServerError)
+	}
+}
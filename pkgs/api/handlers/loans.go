@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/loans"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+type LoansHandlerParams struct {
+	fx.In
+	LoanService loans.Service
+}
+
+type loansHandler struct {
+	loanService loans.Service
+	logger      logrus.FieldLogger
+}
+
+func NewLoansHandler(p LoansHandlerParams) loans.Handler {
+	return &loansHandler{
+		loanService: p.LoanService,
+		logger: logrus.WithFields(logrus.Fields{
+			"package": "handlers",
+			"domain":  "loans",
+		}),
+	}
+}
+
+// @Summary Check out a book
+// @Description Lend a copy of a book to a member, the copy set aside for their hold or any copy on the shelf if none is given
+// @Tags Loans
+// @Accept json
+// @Produce json
+// @Param requestBody body swagger.CheckoutBookRequestBody true "Loan details"
+// @Success 200 {object} loans.Loan "Successfully checked out book"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Book or member does not exist"
+// @Failure 409 {string} string "Book is not available, or the member cannot borrow or owes too much in fines"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /loans [post]
+func (h *loansHandler) CheckoutBook(res http.ResponseWriter, req *http.Request) {
+	var newLoan loans.Loan
+	if err := json.NewDecoder(req.Body).Decode(&newLoan); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for checkout", http.StatusBadRequest)
+		return
+	}
+	// Validate the Request
+	if err := newLoan.ValidateCheckout(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.loanService.CheckoutBook(req.Context(), &newLoan); err != nil {
+		h.logger.Error(err)
+		h.writeLoanError(res, err, "failed to check out book")
+		return
+	}
+	payload, err := json.Marshal(newLoan)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Return a book
+// @Description Close a loan, pass the copy to the next hold or back on the shelf and charge any overdue fine
+// @Tags Loans
+// @Accept json
+// @Produce json
+// @Param loan_id path int true "Loan ID" Format(int64)
+// @Success 200 {object} loans.Loan "Successfully returned book"
+// @Failure 404 {string} string "Loan does not exist"
+// @Failure 409 {string} string "Loan has already been returned"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /loans/{loan_id}/return [post]
+func (h *loansHandler) ReturnBook(res http.ResponseWriter, req *http.Request) {
+	loanID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "loan_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert loan_id to integer", http.StatusBadRequest)
+		return
+	}
+	returnedLoan, err := h.loanService.ReturnBook(req.Context(), loanID)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeLoanError(res, err, "failed to return book")
+		return
+	}
+	if returnedLoan.FinePending {
+		h.logger.Warnf("loan %d was returned but its fine could not be assessed, the late return sweep will retry",
+			returnedLoan.ID)
+	}
+	payload, err := json.Marshal(returnedLoan)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get a list of loans
+// @Description Get a list of loans based on specified query parameters
+// @Tags Loans
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of loans per page"
+// @Param book_id query int false "Filter loans by book"
+// @Param copy_id query int false "Filter loans by copy"
+// @Param member_id query int false "Filter loans by member"
+// @Param status query string false "Filter loans by status (active, overdue, returned)"
+// @Success 200 {object} swagger.GetLoansResponse "Successfully retrieved loans"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /loans [get]
+func (h *loansHandler) GetLoans(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var params loans.GetLoansParams
+	// Integer values
+	for key, target := range map[string]*int{
+		"page":      &params.Page,
+		"per_page":  &params.PerPage,
+		"book_id":   &params.BookID,
+		"copy_id":   &params.CopyID,
+		"member_id": &params.MemberID,
+	} {
+		if valueStr := query.Get(key); valueStr != "" {
+			value, err := strconv.Atoi(valueStr)
+			if err != nil {
+				h.logger.Error(err)
+				http.Error(res, "failed to convert "+key+" string parameter to integer", http.StatusBadRequest)
+				return
+			}
+			*target = value
+		}
+	}
+	params.Status = loans.Status(query.Get("status"))
+	retrievedLoans, count, err := h.loanService.GetLoans(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve loans", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Loans []*loans.Loan `json:"loans"`
+		Count int           `json:"count"`
+	}{
+		Loans: retrievedLoans,
+		Count: count,
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get a loan by ID
+// @Description Get details of a loan by its ID
+// @Tags Loans
+// @Accept json
+// @Produce json
+// @Param loan_id path int true "Loan ID" Format(int64)
+// @Success 200 {object} loans.Loan "Successfully retrieved loan"
+// @Failure 404 {string} string "Loan does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /loans/{loan_id} [get]
+func (h *loansHandler) GetLoanByID(res http.ResponseWriter, req *http.Request) {
+	loanID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "loan_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert loan_id to integer", http.StatusBadRequest)
+		return
+	}
+	retrievedLoans, _, err := h.loanService.GetLoans(req.Context(), &loans.GetLoansParams{ID: loanID})
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve loan", http.StatusInternalServerError)
+		return
+	}
+	if len(retrievedLoans) == 0 {
+		http.Error(res, loans.ErrLoanNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	payload, err := json.Marshal(retrievedLoans[0])
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not marshall loan data to json", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Map domain errors onto http status codes, anything unknown is hidden behind the fallback message
+func (h *loansHandler) writeLoanError(res http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, loans.ErrBookNotFound), errors.Is(err, loans.ErrLoanNotFound),
+		errors.Is(err, loans.ErrMemberNotFound):
+		http.Error(res, err.Error(), http.StatusNotFound)
+	case errors.Is(err, loans.ErrBookNotAvailable), errors.Is(err, loans.ErrLoanAlreadyReturned),
+		errors.Is(err, loans.ErrMemberNotActive), errors.Is(err, loans.ErrMemberOwesFines):
+		http.Error(res, err.Error(), http.StatusConflict)
+	default:
+		http.Error(res, fallback, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/members"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+type MembersHandlerParams struct {
+	fx.In
+	MemberService members.Service
+}
+
+type membersHandler struct {
+	memberService members.Service
+	logger        logrus.FieldLogger
+}
+
+func NewMembersHandler(p MembersHandlerParams) members.Handler {
+	return &membersHandler{
+		memberService: p.MemberService,
+		logger: logrus.WithFields(logrus.Fields{
+			"package": "handlers",
+			"domain":  "members",
+		}),
+	}
+}
+
+// @Summary Create a new member
+// @Description Register a new member and issue their library card
+// @Tags Members
+// @Accept json
+// @Produce json
+// @Param requestBody body swagger.CreateMemberRequestBody true "New member details"
+// @Success 200 {object} members.Member "Successfully created member"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 409 {string} string "Card number is already in use"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /members [post]
+func (h *membersHandler) CreateMember(res http.ResponseWriter, req *http.Request) {
+	var newMember members.Member
+	if err := json.NewDecoder(req.Body).Decode(&newMember); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body for create member", http.StatusBadRequest)
+		return
+	}
+	// Validate the Request
+	if err := newMember.ValidateCreateMember(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.memberService.CreateMembers(req.Context(), []*members.Member{&newMember}); err != nil {
+		h.logger.Error(err)
+		switch err {
+		case members.ErrMemberAlreadyExists:
+			http.Error(res, err.Error(), http.StatusConflict)
+		default:
+			http.Error(res, "failed to create member", http.StatusInternalServerError)
+		}
+		return
+	}
+	payload, err := json.Marshal(newMember)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get a member by ID
+// @Description Get details of a member by their ID
+// @Tags Members
+// @Accept json
+// @Produce json
+// @Param member_id path int true "Member ID" Format(int64)
+// @Success 200 {object} members.Member "Successfully retrieved member"
+// @Failure 404 {string} string "Member does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /members/{member_id} [get]
+func (h *membersHandler) GetMemberByID(res http.ResponseWriter, req *http.Request) {
+	memberID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "member_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert member_id to integer", http.StatusBadRequest)
+		return
+	}
+	retrievedMembers, _, err := h.memberService.GetMembers(req.Context(), &members.GetMembersParams{ID: memberID})
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve member", http.StatusInternalServerError)
+		return
+	}
+	if len(retrievedMembers) == 0 {
+		http.Error(res, members.ErrMemberNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	payload, err := json.Marshal(retrievedMembers[0])
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not marshall member data to json", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Get a list of members
+// @Description Get a list of members based on specified query parameters
+// @Tags Members
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number for pagination"
+// @Param per_page query int false "Number of members per page"
+// @Param card_number query string false "Filter members by card number"
+// @Param name query string false "Filter members by name"
+// @Param email query string false "Filter members by email"
+// @Param membership_type query string false "Filter members by membership type"
+// @Param status query string false "Filter members by status (active, suspended, expired)"
+// @Success 200 {object} swagger.GetMembersResponse "Successfully retrieved members"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /members [get]
+func (h *membersHandler) GetMembers(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var params members.GetMembersParams
+	if pageStr := query.Get("page"); pageStr != "" {
+		page, err := strconv.Atoi(pageStr)
+		if err != nil {
+			h.logger.Error(err)
+			http.Error(res, "failed to convert page string parameter to integer", http.StatusBadRequest)
+			return
+		}
+		params.Page = page
+	}
+	if perPageStr := query.Get("per_page"); perPageStr != "" {
+		perPage, err := strconv.Atoi(perPageStr)
+		if err != nil {
+			h.logger.Error(err)
+			http.Error(res, "failed to convert per_page string parameter to integer", http.StatusBadRequest)
+			return
+		}
+		params.PerPage = perPage
+	}
+	// String values
+	params.CardNumber = query.Get("card_number")
+	params.Name = query.Get("name")
+	params.Email = query.Get("email")
+	params.MembershipType = members.MembershipType(query.Get("membership_type"))
+	params.Status = members.Status(query.Get("status"))
+	retrievedMembers, count, err := h.memberService.GetMembers(req.Context(), &params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve members", http.StatusInternalServerError)
+		return
+	}
+	response := struct {
+		Members []*members.Member `json:"members"`
+		Count   int               `json:"count"`
+	}{
+		Members: retrievedMembers,
+		Count:   count,
+	}
+	payload, err := json.Marshal(response)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	if _, err := res.Write(payload); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary Update a member by ID
+// @Description Update details of a member by their ID, including suspending or renewing the membership
+// @Tags Members
+// @Accept json
+// @Produce json
+// @Param member_id path int true "Member ID" Format(int64)
+// @Param requestBody body swagger.UpdateMemberRequestBody true "New member details"
+// @Success 200 {string} string "member has been updated successfully"
+// @Failure 400 {string} string "Bad Request: Invalid input data"
+// @Failure 404 {string} string "Member does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /members/{member_id} [put]
+func (h *membersHandler) UpdateMember(res http.ResponseWriter, req *http.Request) {
+	memberID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "member_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert member_id to integer", http.StatusBadRequest)
+		return
+	}
+	requestBody := struct {
+		CardNumber     string                 `json:"card_number"`
+		Name           string                 `json:"name"`
+		Email          string                 `json:"email"`
+		Phone          string                 `json:"phone"`
+		Address        string                 `json:"address"`
+		MembershipType members.MembershipType `json:"membership_type"`
+		Status         members.Status         `json:"status"`
+		ExpiresAt      utils.CustomDate       `json:"expires_at"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to unmarshall request body", http.StatusBadRequest)
+		return
+	}
+	updatedMember := members.Member{
+		ID:             memberID,
+		CardNumber:     requestBody.CardNumber,
+		Name:           requestBody.Name,
+		Email:          requestBody.Email,
+		Phone:          requestBody.Phone,
+		Address:        requestBody.Address,
+		MembershipType: requestBody.MembershipType,
+		Status:         requestBody.Status,
+		ExpiresAt:      requestBody.ExpiresAt,
+	}
+	if err := updatedMember.ValidateUpdateMember(); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.memberService.UpdateMember(req.Context(), &updatedMember); err != nil {
+		h.logger.Error(err)
+		switch err {
+		case members.ErrMemberNotFound:
+			http.Error(res, err.Error(), http.StatusNotFound)
+		case members.ErrMemberAlreadyExists:
+			http.Error(res, err.Error(), http.StatusConflict)
+		default:
+			http.Error(res, "failed to update member", http.StatusInternalServerError)
+		}
+		return
+	}
+	if _, err := res.Write([]byte(fmt.Sprintf("member %d has been updated successfully", memberID))); err != nil {
+		http.Error(res, "Could not write response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// @Summary delete a member by ID
+// @Description delete a member by ID, members with loan or hold history must be suspended instead
+// @Tags Members
+// @Accept json
+// @Produce json
+// @Param member_id path int true "Member ID" Format(int64)
+// @Success 200 {string} string "Successfully deleted member"
+// @Failure 404 {string} string "Member does not exist"
+// @Failure 409 {string} string "Member has loan or hold history"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /members/{member_id} [delete]
+func (h *membersHandler) DeleteMember(res http.ResponseWriter, req *http.Request) {
+	memberID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "member_id"))
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not convert member_id to integer", http.StatusBadRequest)
+		return
+	}
+	if err := h.memberService.DeleteMemberByID(req.Context(), memberID); err != nil {
+		h.logger.Error(err)
+		switch err {
+		case members.ErrMemberNotFound:
+			http.Error(res, err.Error(), http.StatusNotFound)
+		case members.ErrMemberHasLoans:
+			http.Error(res, err.Error(), http.StatusConflict)
+		default:
+			http.Error(res, "failed to delete member", http.StatusInternalServerError)
+		}
+		return
+	}
+	if _, err := res.Write([]byte("Successfully deleted member")); err != nil {
+		http.Error(res, "Could not write response", http.StatusInternalServerError)
+		return
+	}
+}
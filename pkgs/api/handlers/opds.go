@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/genres"
+	"github.com/GabDewraj/library-api/pkgs/domain/opds"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+type OPDSHandlerParams struct {
+	fx.In
+	BookService  books.Service
+	GenreService genres.Service
+}
+
+type opdsHandler struct {
+	bookService  books.Service
+	genreService genres.Service
+	logger       logrus.FieldLogger
+}
+
+func NewOPDSHandler(p OPDSHandlerParams) opds.Handler {
+	return &opdsHandler{
+		bookService:  p.BookService,
+		genreService: p.GenreService,
+		logger: logrus.WithFields(logrus.Fields{
+			"package": "handlers",
+			"domain":  "opds",
+		}),
+	}
+}
+
+// @Summary OPDS catalogue root
+// @Description Navigation feed with the new additions, the genre and language feeds and every book, for e-reader apps
+// @Tags OPDS
+// @Produce application/atom+xml
+// @Success 200 {string} string "OPDS navigation feed"
+// @Router /opds [get]
+func (h *opdsHandler) Root(res http.ResponseWriter, req *http.Request) {
+	h.writeDocument(res, opds.NavigationType, catalogue(req).Root(time.Now()))
+}
+
+// @Summary OPDS genres
+// @Description Navigation feed of the top of the genre tree, or of the genres below one with a feed of every book in it
+// @Tags OPDS
+// @Produce application/atom+xml
+// @Param genre_id path int false "Genre ID" Format(int64)
+// @Success 200 {string} string "OPDS navigation feed"
+// @Failure 400 {string} string "Bad Request: genre_id is not a number"
+// @Failure 404 {string} string "Genre does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /opds/genres/{genre_id} [get]
+func (h *opdsHandler) Genres(res http.ResponseWriter, req *http.Request) {
+	var parent *genres.Node
+	params := &genres.GetNodesParams{ParentID: -1, Kind: genres.Genre}
+	if idParam := chi.URLParamFromCtx(req.Context(), "genre_id"); idParam != "" {
+		genreID, err := strconv.Atoi(idParam)
+		if err != nil {
+			http.Error(res, "could not convert genre_id to integer", http.StatusBadRequest)
+			return
+		}
+		nodes, _, err := h.genreService.GetNodes(req.Context(), &genres.GetNodesParams{ID: genreID})
+		if err != nil {
+			h.logger.Error(err)
+			http.Error(res, "could not retrieve genre", http.StatusInternalServerError)
+			return
+		}
+		if len(nodes) == 0 {
+			http.Error(res, genres.ErrGenreNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		parent = nodes[0]
+		params.ParentID = genreID
+	}
+	children, _, err := h.genreService.GetNodes(req.Context(), params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve genres", http.StatusInternalServerError)
+		return
+	}
+	h.writeDocument(res, opds.NavigationType, catalogue(req).Genres(parent, children, time.Now()))
+}
+
+// @Summary OPDS languages
+// @Description Navigation feed with a feed of books for every language the catalogue has books in
+// @Tags OPDS
+// @Produce application/atom+xml
+// @Success 200 {string} string "OPDS navigation feed"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /opds/languages [get]
+func (h *opdsHandler) Languages(res http.ResponseWriter, req *http.Request) {
+	languages, err := h.bookService.GetLanguages(req.Context())
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve languages", http.StatusInternalServerError)
+		return
+	}
+	h.writeDocument(res, opds.NavigationType, catalogue(req).Languages(languages, time.Now()))
+}
+
+// @Summary OPDS new additions
+// @Description Acquisition feed of the books most recently added to the catalogue, newest first
+// @Tags OPDS
+// @Produce application/atom+xml
+// @Param page query int false "Page number, from 1"
+// @Param per_page query int false "Books per page, 25 by default and at most 100"
+// @Success 200 {string} string "OPDS acquisition feed"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /opds/new [get]
+func (h *opdsHandler) NewBooks(res http.ResponseWriter, req *http.Request) {
+	h.acquisition(res, req, "new", "New additions", "/new", true)
+}
+
+// @Summary OPDS books
+// @Description Acquisition feed of the books that match the filters, the OpenSearch description points here
+// @Tags OPDS
+// @Produce application/atom+xml
+// @Param isbn query string false "Filter books by ISBN"
+// @Param title query string false "Filter books by title"
+// @Param author query string false "Filter books by author"
+// @Param publisher query string false "Filter books by publisher"
+// @Param genre query string false "Filter books by genre, including the books in its sub-genres"
+// @Param genre_id query int false "Filter books by a node of the genre tree and the nodes below it"
+// @Param language query string false "Filter books by language"
+// @Param availability query string false "Filter books by availability"
+// @Param page query int false "Page number, from 1"
+// @Param per_page query int false "Books per page, 25 by default and at most 100"
+// @Success 200 {string} string "OPDS acquisition feed"
+// @Failure 400 {string} string "Bad Request: Invalid query parameters"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /opds/books [get]
+func (h *opdsHandler) Books(res http.ResponseWriter, req *http.Request) {
+	h.acquisition(res, req, "books", "Books", "/books", false)
+}
+
+// @Summary OPDS book entry
+// @Description Complete OPDS entry of a book
+// @Tags OPDS
+// @Produce application/atom+xml
+// @Param book_id path int true "Book ID" Format(int64)
+// @Success 200 {string} string "OPDS entry"
+// @Failure 400 {string} string "Bad Request: book_id is not a number"
+// @Failure 404 {string} string "Book does not exist"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /opds/books/{book_id} [get]
+func (h *opdsHandler) Book(res http.ResponseWriter, req *http.Request) {
+	bookID, err := strconv.Atoi(chi.URLParamFromCtx(req.Context(), "book_id"))
+	if err != nil {
+		http.Error(res, "could not convert book_id to integer", http.StatusBadRequest)
+		return
+	}
+	found, _, err := h.bookService.GetBooks(req.Context(), &books.GetBooksParams{ID: bookID})
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve book", http.StatusInternalServerError)
+		return
+	}
+	if len(found) == 0 {
+		http.Error(res, books.ErrBookNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	c := catalogue(req)
+	h.writeDocument(res, opds.EntryType, c.Document(c.BookEntry(found[0])))
+}
+
+// @Summary OPDS OpenSearch description
+// @Description Describes how to search the catalogue, the search terms are matched against the title
+// @Tags OPDS
+// @Produce application/opensearchdescription+xml
+// @Success 200 {string} string "OpenSearch description"
+// @Router /opds/opensearch.xml [get]
+func (h *opdsHandler) OpenSearch(res http.ResponseWriter, req *http.Request) {
+	h.writeDocument(res, opds.OpenSearchType, catalogue(req).OpenSearch())
+}
+
+// Serve a page of books, the filters are read like the ones of listing the books
+func (h *opdsHandler) acquisition(res http.ResponseWriter, req *http.Request, id, title, path string, newest bool) {
+	query := req.URL.Query()
+	params, err := booksParamsFromQuery(query)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	params.PerPage = opds.PerPage(params.PerPage)
+	params.Newest = newest
+	found, _, err := h.bookService.GetBooks(req.Context(), params)
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "could not retrieve books", http.StatusInternalServerError)
+		return
+	}
+	// Paging is added back by the feed for each of its links
+	query.Del("page")
+	query.Del("per_page")
+	feed := catalogue(req).Acquisition(id, title, path, query, params.Page, params.PerPage, found, time.Now())
+	h.writeDocument(res, opds.AcquisitionType, feed)
+}
+
+func (h *opdsHandler) writeDocument(res http.ResponseWriter, contentType string, document interface{}) {
+	payload, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", contentType)
+	if _, err := res.Write(append([]byte(xml.Header), payload...)); err != nil {
+		h.logger.Error(err)
+	}
+}
+
+// Links in the catalogue point back at the address the request came in on, behind a proxy that forwards the scheme
+func catalogue(req *http.Request) *opds.Catalogue {
+	scheme := "http"
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return &opds.Catalogue{BaseURL: scheme + "://" + req.Host}
+}
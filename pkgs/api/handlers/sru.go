@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net"
+	"net/http"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/sru"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/fx"
+)
+
+type SRUHandlerParams struct {
+	fx.In
+	BookService books.Service
+}
+
+type sruHandler struct {
+	bookService books.Service
+	logger      logrus.FieldLogger
+}
+
+func NewSRUHandler(p SRUHandlerParams) sru.Handler {
+	return &sruHandler{
+		bookService: p.BookService,
+		logger: logrus.WithFields(logrus.Fields{
+			"package": "handlers",
+			"domain":  "sru",
+		}),
+	}
+}
+
+// @Summary SRU search and explain
+// @Description Search the books with a CQL query such as dc.title any "orwell" and dc.language = eng, returning Dublin Core or MARCXML records. Without a query the server describes itself. Problems with the request are reported as SRU diagnostics
+// @Tags SRU
+// @Produce xml
+// @Param operation query string false "searchRetrieve or explain"
+// @Param version query string false "SRU version, 1.1 or 1.2"
+// @Param query query string false "CQL query"
+// @Param startRecord query int false "Position of the first record, from 1"
+// @Param maximumRecords query int false "Number of records, 10 by default and at most 100"
+// @Param recordSchema query string false "dc or marcxml"
+// @Param recordPacking query string false "xml or string"
+// @Success 200 {string} string "SRU response"
+// @Failure 500 {string} string "SRU response with a general system error"
+// @Router /sru [get]
+func (h *sruHandler) SRU(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	operation := query.Get("operation")
+	if operation == "" && query.Get("query") != "" {
+		operation = sru.SearchRetrieve
+	}
+	switch operation {
+	case "", sru.Explain:
+		response := h.explain(req)
+		if diagnostic := sru.CheckVersion(query.Get("version")); diagnostic != nil {
+			response = &sru.ExplainResponse{Version: sru.Version, Diagnostics: []*sru.Diagnostic{diagnostic}}
+		}
+		h.writeResponse(res, http.StatusOK, response)
+	case sru.SearchRetrieve:
+		h.searchRetrieve(res, req)
+	default:
+		h.writeResponse(res, http.StatusOK, &sru.ExplainResponse{
+			Version:     sru.Version,
+			Diagnostics: []*sru.Diagnostic{sru.NewDiagnostic(sru.DiagUnsupportedOperation, operation)},
+		})
+	}
+}
+
+func (h *sruHandler) searchRetrieve(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	if diagnostic := sru.CheckVersion(query.Get("version")); diagnostic != nil {
+		h.writeResponse(res, http.StatusOK, sru.NewDiagnosticResponse(diagnostic))
+		return
+	}
+	request, diagnostic := sru.ReadSearchRequest(query)
+	if diagnostic != nil {
+		h.writeResponse(res, http.StatusOK, sru.NewDiagnosticResponse(diagnostic))
+		return
+	}
+	found, total, err := h.bookService.SearchBooks(req.Context(), request.Query,
+		request.StartRecord-1, request.MaximumRecords)
+	if err != nil {
+		if diagnostic := sru.SearchDiagnostic(err); diagnostic != nil {
+			h.writeResponse(res, http.StatusOK, sru.NewDiagnosticResponse(diagnostic))
+			return
+		}
+		h.logger.Error(err)
+		h.writeResponse(res, http.StatusInternalServerError,
+			sru.NewDiagnosticResponse(sru.NewDiagnostic(sru.DiagGeneralError, "could not search books")))
+		return
+	}
+	// Past the last record is only an error when there is something to page through
+	if total > 0 && request.StartRecord > total {
+		response := sru.NewDiagnosticResponse(sru.NewDiagnostic(sru.DiagStartOutOfRange, query.Get("startRecord")))
+		response.NumberOfRecords = total
+		h.writeResponse(res, http.StatusOK, response)
+		return
+	}
+	response, err := sru.NewSearchResponse(request, found, total)
+	if err != nil {
+		h.logger.Error(err)
+		h.writeResponse(res, http.StatusInternalServerError,
+			sru.NewDiagnosticResponse(sru.NewDiagnostic(sru.DiagGeneralError, "could not encode records")))
+		return
+	}
+	h.writeResponse(res, http.StatusOK, response)
+}
+
+func (h *sruHandler) explain(req *http.Request) *sru.ExplainResponse {
+	host, port, err := net.SplitHostPort(req.Host)
+	if err != nil {
+		host, port = req.Host, "80"
+		if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+			port = "443"
+		}
+	}
+	return sru.NewExplainResponse(host, port)
+}
+
+func (h *sruHandler) writeResponse(res http.ResponseWriter, status int, response interface{}) {
+	payload, err := xml.MarshalIndent(response, "", "  ")
+	if err != nil {
+		h.logger.Error(err)
+		http.Error(res, "failed to marshal response data", http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	res.WriteHeader(status)
+	if _, err := res.Write(append([]byte(xml.Header), payload...)); err != nil {
+		h.logger.Error(err)
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+)
+
+// Header naming who is making the request, there is no authentication yet so it is taken on trust
+const ActorHeader = "X-Actor"
+
+// Actor attaches the caller to the request context, anonymous callers are recorded by ip
+func (s *service) Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := strings.TrimSpace(r.Header.Get(ActorHeader))
+		if actor == "" {
+			actor = "anonymous@" + s.getClientIp(r)
+		}
+		next.ServeHTTP(w, r.WithContext(utils.WithActor(r.Context(), actor)))
+	})
+}
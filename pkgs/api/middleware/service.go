@@ -25,6 +25,7 @@ type Service interface {
 	CORS(next http.Handler) http.Handler
 	RateLimiter(next http.Handler) http.Handler
 	CustomLogger(next http.Handler) http.Handler
+	Actor(next http.Handler) http.Handler
 }
 
 func NewMiddlwareStack(p Params) Service {
@@ -0,0 +1,28 @@
+package routers
+
+import (
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
+	"github.com/go-chi/chi"
+	"go.uber.org/fx"
+)
+
+type AuditRouterParams struct {
+	fx.In
+	Mux        *chi.Mux
+	Middleware middleware.Service
+	Handler    audit.Handler
+}
+
+func NewAuditRouter(params AuditRouterParams) {
+	params.Mux.Route("/audit", func(r chi.Router) {
+		// Logging
+		r.Use(params.Middleware.CustomLogger)
+		// Add CORS for browsers
+		r.Use(params.Middleware.CORS)
+		// Add rate limiting
+		r.Use(params.Middleware.RateLimiter)
+		// Routes
+		r.Get("/", params.Handler.GetRecords)
+	})
+}
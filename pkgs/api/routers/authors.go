@@ -0,0 +1,35 @@
+package routers
+
+import (
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/domain/authors"
+	"github.com/go-chi/chi"
+	"go.uber.org/fx"
+)
+
+type AuthorsRouterParams struct {
+	fx.In
+	Mux        *chi.Mux
+	Middleware middleware.Service
+	Handler    authors.Handler
+}
+
+func NewAuthorsRouter(params AuthorsRouterParams) {
+	params.Mux.Route("/authors", func(r chi.Router) {
+		// Logging
+		r.Use(params.Middleware.CustomLogger)
+		// Add CORS for browsers
+		r.Use(params.Middleware.CORS)
+		// Add rate limiting
+		r.Use(params.Middleware.RateLimiter)
+		// Routes
+		r.Post("/", params.Handler.CreateAuthor)
+		r.Get("/", params.Handler.GetAuthors)
+		r.Get("/{author_id}", params.Handler.GetAuthorByID)
+		r.Put("/{author_id}", params.Handler.UpdateAuthor)
+		r.Delete("/{author_id}", params.Handler.DeleteAuthor)
+		r.Get("/{author_id}/books", params.Handler.GetAuthorBooks)
+		r.Put("/{author_id}/books/{book_id}", params.Handler.CreditAuthor)
+		r.Delete("/{author_id}/books/{book_id}", params.Handler.RemoveCredit)
+	})
+}
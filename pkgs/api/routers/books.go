@@ -24,12 +24,26 @@ func NewBooksRouter(params LibraryRouterParams) {
 		r.Use(params.Middleware.CORS)
 		// Add rate limiting
 		r.Use(params.Middleware.RateLimiter)
+		// Attribute changes to the caller in the audit trail
+		r.Use(params.Middleware.Actor)
 		// Routes
 		r.Post("/", params.Handler.CreateBook)
+		r.Post("/bulk", params.Handler.BulkCreateBooks)
+		r.Patch("/bulk", params.Handler.BulkUpdateBooks)
+		r.Delete("/bulk", params.Handler.BulkDeleteBooks)
+		r.Post("/import", params.Handler.ImportBooks)
+		r.Post("/import/marc", params.Handler.ImportMARC)
+		r.Post("/import/onix", params.Handler.IngestONIX)
 		r.Get("/", params.Handler.GetBooks)
+		r.Get("/search", params.Handler.FullTextSearch)
+		r.Get("/trash", params.Handler.GetTrash)
+		r.Get("/export", params.Handler.ExportBooks)
 		r.Get("/{book_id}", params.Handler.GetBookByID)
 		r.Put("/{book_id}", params.Handler.UpdateBook)
+		r.Patch("/{book_id}", params.Handler.PatchBook)
 		r.Delete("/{book_id}", params.Handler.DeleteBook)
+		r.Post("/{book_id}/restore", params.Handler.RestoreBook)
+		r.Get("/{book_id}/history", params.Handler.GetBookHistory)
 	})
 
 }
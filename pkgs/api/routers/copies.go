@@ -0,0 +1,32 @@
+package routers
+
+import (
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/go-chi/chi"
+	"go.uber.org/fx"
+)
+
+type CopiesRouterParams struct {
+	fx.In
+	Mux        *chi.Mux
+	Middleware middleware.Service
+	Handler    copies.Handler
+}
+
+func NewCopiesRouter(params CopiesRouterParams) {
+	params.Mux.Route("/copies", func(r chi.Router) {
+		// Logging
+		r.Use(params.Middleware.CustomLogger)
+		// Add CORS for browsers
+		r.Use(params.Middleware.CORS)
+		// Add rate limiting
+		r.Use(params.Middleware.RateLimiter)
+		// Routes
+		r.Post("/", params.Handler.CreateCopy)
+		r.Get("/", params.Handler.GetCopies)
+		r.Get("/{copy_id}", params.Handler.GetCopyByID)
+		r.Put("/{copy_id}", params.Handler.UpdateCopy)
+		r.Delete("/{copy_id}", params.Handler.DeleteCopy)
+	})
+}
@@ -0,0 +1,32 @@
+package routers
+
+import (
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/domain/fines"
+	"github.com/go-chi/chi"
+	"go.uber.org/fx"
+)
+
+type FinesRouterParams struct {
+	fx.In
+	Mux        *chi.Mux
+	Middleware middleware.Service
+	Handler    fines.Handler
+}
+
+func NewFinesRouter(params FinesRouterParams) {
+	params.Mux.Route("/fines", func(r chi.Router) {
+		// Logging
+		r.Use(params.Middleware.CustomLogger)
+		// Add CORS for browsers
+		r.Use(params.Middleware.CORS)
+		// Add rate limiting
+		r.Use(params.Middleware.RateLimiter)
+		// Routes
+		r.Get("/", params.Handler.GetFines)
+		r.Get("/balance/{member_id}", params.Handler.GetMemberBalance)
+		r.Get("/{fine_id}", params.Handler.GetFineByID)
+		r.Post("/{fine_id}/pay", params.Handler.PayFine)
+		r.Post("/{fine_id}/waive", params.Handler.WaiveFine)
+	})
+}
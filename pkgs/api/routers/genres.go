@@ -0,0 +1,36 @@
+package routers
+
+import (
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/domain/genres"
+	"github.com/go-chi/chi"
+	"go.uber.org/fx"
+)
+
+type GenresRouterParams struct {
+	fx.In
+	Mux        *chi.Mux
+	Middleware middleware.Service
+	Handler    genres.Handler
+}
+
+func NewGenresRouter(params GenresRouterParams) {
+	params.Mux.Route("/genres", func(r chi.Router) {
+		// Logging
+		r.Use(params.Middleware.CustomLogger)
+		// Add CORS for browsers
+		r.Use(params.Middleware.CORS)
+		// Add rate limiting
+		r.Use(params.Middleware.RateLimiter)
+		// Routes
+		r.Post("/", params.Handler.CreateGenre)
+		r.Get("/", params.Handler.GetGenres)
+		r.Get("/{genre_id}", params.Handler.GetGenreByID)
+		r.Put("/{genre_id}", params.Handler.RenameGenre)
+		r.Delete("/{genre_id}", params.Handler.DeleteGenre)
+		r.Post("/{genre_id}/move", params.Handler.MoveGenre)
+		r.Post("/{genre_id}/merge", params.Handler.MergeGenre)
+		r.Put("/{genre_id}/books/{book_id}", params.Handler.AddBook)
+		r.Delete("/{genre_id}/books/{book_id}", params.Handler.RemoveBook)
+	})
+}
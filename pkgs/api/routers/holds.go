@@ -0,0 +1,31 @@
+package routers
+
+import (
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/domain/holds"
+	"github.com/go-chi/chi"
+	"go.uber.org/fx"
+)
+
+type HoldsRouterParams struct {
+	fx.In
+	Mux        *chi.Mux
+	Middleware middleware.Service
+	Handler    holds.Handler
+}
+
+func NewHoldsRouter(params HoldsRouterParams) {
+	params.Mux.Route("/holds", func(r chi.Router) {
+		// Logging
+		r.Use(params.Middleware.CustomLogger)
+		// Add CORS for browsers
+		r.Use(params.Middleware.CORS)
+		// Add rate limiting
+		r.Use(params.Middleware.RateLimiter)
+		// Routes
+		r.Post("/", params.Handler.PlaceHold)
+		r.Get("/", params.Handler.GetHolds)
+		r.Get("/{hold_id}", params.Handler.GetHoldByID)
+		r.Post("/{hold_id}/cancel", params.Handler.CancelHold)
+	})
+}
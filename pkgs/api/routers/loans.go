@@ -0,0 +1,31 @@
+package routers
+
+import (
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/domain/loans"
+	"github.com/go-chi/chi"
+	"go.uber.org/fx"
+)
+
+type LoansRouterParams struct {
+	fx.In
+	Mux        *chi.Mux
+	Middleware middleware.Service
+	Handler    loans.Handler
+}
+
+func NewLoansRouter(params LoansRouterParams) {
+	params.Mux.Route("/loans", func(r chi.Router) {
+		// Logging
+		r.Use(params.Middleware.CustomLogger)
+		// Add CORS for browsers
+		r.Use(params.Middleware.CORS)
+		// Add rate limiting
+		r.Use(params.Middleware.RateLimiter)
+		// Routes
+		r.Post("/", params.Handler.CheckoutBook)
+		r.Get("/", params.Handler.GetLoans)
+		r.Get("/{loan_id}", params.Handler.GetLoanByID)
+		r.Post("/{loan_id}/return", params.Handler.ReturnBook)
+	})
+}
@@ -0,0 +1,32 @@
+package routers
+
+import (
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/domain/members"
+	"github.com/go-chi/chi"
+	"go.uber.org/fx"
+)
+
+type MembersRouterParams struct {
+	fx.In
+	Mux        *chi.Mux
+	Middleware middleware.Service
+	Handler    members.Handler
+}
+
+func NewMembersRouter(params MembersRouterParams) {
+	params.Mux.Route("/members", func(r chi.Router) {
+		// Logging
+		r.Use(params.Middleware.CustomLogger)
+		// Add CORS for browsers
+		r.Use(params.Middleware.CORS)
+		// Add rate limiting
+		r.Use(params.Middleware.RateLimiter)
+		// Routes
+		r.Post("/", params.Handler.CreateMember)
+		r.Get("/", params.Handler.GetMembers)
+		r.Get("/{member_id}", params.Handler.GetMemberByID)
+		r.Put("/{member_id}", params.Handler.UpdateMember)
+		r.Delete("/{member_id}", params.Handler.DeleteMember)
+	})
+}
@@ -0,0 +1,35 @@
+package routers
+
+import (
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/domain/opds"
+	"github.com/go-chi/chi"
+	"go.uber.org/fx"
+)
+
+type OPDSRouterParams struct {
+	fx.In
+	Mux        *chi.Mux
+	Middleware middleware.Service
+	Handler    opds.Handler
+}
+
+func NewOPDSRouter(params OPDSRouterParams) {
+	params.Mux.Route("/opds", func(r chi.Router) {
+		// Logging
+		r.Use(params.Middleware.CustomLogger)
+		// Add CORS for browsers
+		r.Use(params.Middleware.CORS)
+		// Add rate limiting
+		r.Use(params.Middleware.RateLimiter)
+		// Routes
+		r.Get("/", params.Handler.Root)
+		r.Get("/opensearch.xml", params.Handler.OpenSearch)
+		r.Get("/genres", params.Handler.Genres)
+		r.Get("/genres/{genre_id}", params.Handler.Genres)
+		r.Get("/languages", params.Handler.Languages)
+		r.Get("/new", params.Handler.NewBooks)
+		r.Get("/books", params.Handler.Books)
+		r.Get("/books/{book_id}", params.Handler.Book)
+	})
+}
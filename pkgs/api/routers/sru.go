@@ -0,0 +1,28 @@
+package routers
+
+import (
+	"github.com/GabDewraj/library-api/pkgs/api/middleware"
+	"github.com/GabDewraj/library-api/pkgs/domain/sru"
+	"github.com/go-chi/chi"
+	"go.uber.org/fx"
+)
+
+type SRURouterParams struct {
+	fx.In
+	Mux        *chi.Mux
+	Middleware middleware.Service
+	Handler    sru.Handler
+}
+
+func NewSRURouter(params SRURouterParams) {
+	params.Mux.Route("/sru", func(r chi.Router) {
+		// Logging
+		r.Use(params.Middleware.CustomLogger)
+		// Add CORS for browsers
+		r.Use(params.Middleware.CORS)
+		// Add rate limiting
+		r.Use(params.Middleware.RateLimiter)
+		// Routes
+		r.Get("/", params.Handler.SRU)
+	})
+}
@@ -0,0 +1,7 @@
+package audit
+
+import "net/http"
+
+type Handler interface {
+	GetRecords(res http.ResponseWriter, req *http.Request)
+}
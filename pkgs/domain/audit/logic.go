@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+)
+
+type Entity string
+type Action string
+
+// Create global errors that are specific to this domain
+var (
+	ErrInvalidTimeRange = errors.New("from cannot be after to")
+)
+
+const (
+	Book Entity = "book"
+)
+
+const (
+	Create  Action = "create"
+	Update  Action = "update"
+	Delete  Action = "delete"
+	Restore Action = "restore"
+	Purge   Action = "purge"
+)
+
+// A single change made to an entity, records are never updated or removed
+type Record struct {
+	ID       int    `json:"id" db:"id"`
+	Entity   Entity `json:"entity" db:"entity"`
+	EntityID int    `json:"entity_id" db:"entity_id"`
+	Action   Action `json:"action" db:"action"`
+	// Who made the change, system for scheduled jobs
+	Actor     string           `json:"actor" db:"actor"`
+	Changes   Changes          `json:"changes" db:"changes"`
+	CreatedAt utils.CustomTime `json:"created_at" db:"created_at"`
+}
+
+// The old and new value of one field, old is empty for a create and new is empty for a purge
+type Change struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// Changes are stored as a json array
+type Changes []Change
+
+type GetRecordsParams struct {
+	Page     int
+	PerPage  int
+	Entity   Entity
+	EntityID int
+	Action   Action
+	Actor    string
+	// Inclusive time range, either end may be left open
+	From time.Time
+	To   time.Time
+}
+
+// Object methods for aggregate root
+// Validation for searching the audit trail
+func (p *GetRecordsParams) ValidateSearch() error {
+	if !p.From.IsZero() && !p.To.IsZero() && p.From.After(p.To) {
+		return ErrInvalidTimeRange
+	}
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (c Changes) Value() (driver.Value, error) {
+	if c == nil {
+		c = Changes{}
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(payload), nil
+}
+
+// Scan implements sql.Scanner.
+func (c *Changes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = Changes{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("cannot scan %T into audit changes", value)
+	}
+}
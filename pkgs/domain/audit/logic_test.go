@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	now := time.Now()
+	assertWithTest.Nil((&GetRecordsParams{}).ValidateSearch())
+	assertWithTest.Nil((&GetRecordsParams{From: now.Add(-time.Hour), To: now}).ValidateSearch())
+	assertWithTest.Nil((&GetRecordsParams{From: now}).ValidateSearch())
+	assertWithTest.Equal(ErrInvalidTimeRange, (&GetRecordsParams{From: now, To: now.Add(-time.Hour)}).ValidateSearch())
+}
+
+func TestChangesRoundTrip(t *testing.T) {
+	assertWithTest := assert.New(t)
+	value, err := Changes{{Field: "title", Old: "1984", New: "Nineteen Eighty-Four"}}.Value()
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(`[{"field":"title","old":"1984","new":"Nineteen Eighty-Four"}]`, value)
+	var scanned Changes
+	assertWithTest.Nil(scanned.Scan([]byte(`[{"field":"pages","old":328,"new":330}]`)))
+	assertWithTest.Equal(Changes{{Field: "pages", Old: float64(328), New: float64(330)}}, scanned)
+	value, err = Changes(nil).Value()
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(`[]`, value)
+}
@@ -0,0 +1,8 @@
+package audit
+
+import "context"
+
+// Records are written by the repositories of the audited entities, in the same transaction as the change
+type Repository interface {
+	GetRecords(ctx context.Context, params *GetRecordsParams) ([]*Record, int, error)
+}
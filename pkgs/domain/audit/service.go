@@ -0,0 +1,22 @@
+package audit
+
+import "context"
+
+type Service interface {
+	GetRecords(ctx context.Context, params *GetRecordsParams) ([]*Record, int, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// GetRecords implements Service.
+func (s *service) GetRecords(ctx context.Context, params *GetRecordsParams) ([]*Record, int, error) {
+	return s.repo.GetRecords(ctx, params)
+}
@@ -0,0 +1,14 @@
+package authors
+
+import "net/http"
+
+type Handler interface {
+	CreateAuthor(res http.ResponseWriter, req *http.Request)
+	UpdateAuthor(res http.ResponseWriter, req *http.Request)
+	GetAuthors(res http.ResponseWriter, req *http.Request)
+	GetAuthorByID(res http.ResponseWriter, req *http.Request)
+	DeleteAuthor(res http.ResponseWriter, req *http.Request)
+	GetAuthorBooks(res http.ResponseWriter, req *http.Request)
+	CreditAuthor(res http.ResponseWriter, req *http.Request)
+	RemoveCredit(res http.ResponseWriter, req *http.Request)
+}
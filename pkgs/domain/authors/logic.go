@@ -0,0 +1,197 @@
+package authors
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/go-playground/validator"
+)
+
+type Role string
+
+// Create global errors that are specific to this domain
+var (
+	ErrAuthorNotFound   = errors.New("author does not exist")
+	ErrAuthorHasBooks   = errors.New("author is credited on books, remove the credits before deleting the author")
+	ErrBookNotFound     = errors.New("book does not exist")
+	ErrCreditNotFound   = errors.New("author is not credited on this book")
+	ErrInvalidLifeDates = errors.New("death_year cannot be before birth_year")
+	ErrEmptyAlias       = errors.New("aliases cannot be empty")
+)
+
+const (
+	Writer      Role = "author"
+	Editor      Role = "editor"
+	Translator  Role = "translator"
+	Illustrator Role = "illustrator"
+)
+
+// A person credited on one or more books, the free text author on a book is kept for display
+type Author struct {
+	ID   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name" validate:"required"`
+	// Used to order authors, "Orwell, George" for "George Orwell" unless it is given
+	SortName  string           `json:"sort_name" db:"sort_name"`
+	BirthYear int              `json:"birth_year" db:"birth_year"`
+	DeathYear int              `json:"death_year" db:"death_year"`
+	Aliases   Aliases          `json:"aliases" db:"aliases"`
+	UpdatedAt utils.CustomTime `json:"updated_at" db:"updated_at"`
+	CreatedAt utils.CustomTime `json:"created_at" db:"created_at"`
+}
+
+// Other names the author has published under, stored as a json array
+type Aliases []string
+
+// A link between an author and a book, an author may be credited on a book in more than one role
+type Credit struct {
+	AuthorID int  `json:"author_id" db:"author_id"`
+	BookID   int  `json:"book_id" db:"book_id" validate:"required"`
+	Role     Role `json:"role" db:"role" validate:"required,eq=author|eq=editor|eq=translator|eq=illustrator"`
+	// Order the author appears in on the book, starting at 1
+	Position int    `json:"position" db:"position"`
+	ISBN     string `json:"isbn" db:"isbn"`
+	Title    string `json:"title" db:"title"`
+}
+
+type GetAuthorsParams struct {
+	ID      int
+	Page    int
+	PerPage int
+	// Matches the name, sort name or any of the aliases
+	Name   string
+	BookID int
+}
+
+type GetCreditsParams struct {
+	AuthorID int
+	BookID   int
+	Page     int
+	PerPage  int
+	Role     Role
+}
+
+// Object methods for aggregate root
+// Validation for creating an Author
+func (a *Author) ValidateCreateAuthor() error {
+	validate := validator.New()
+	if err := validate.Struct(a); err != nil {
+		return validationErrMessage(err.(validator.ValidationErrors))
+	}
+	if a.SortName == "" {
+		a.SortName = DefaultSortName(a.Name)
+	}
+	return a.validateDetails()
+}
+
+// Validation for updating an Author, only the fields that were supplied are checked
+func (a *Author) ValidateUpdateAuthor() error {
+	return a.validateDetails()
+}
+
+// Merge an update into the stored author
+func (a *Author) ApplyUpdate(update *Author) error {
+	if update.Name != "" {
+		a.Name = update.Name
+	}
+	if update.SortName != "" {
+		a.SortName = update.SortName
+	}
+	if update.BirthYear != 0 {
+		a.BirthYear = update.BirthYear
+	}
+	if update.DeathYear != 0 {
+		a.DeathYear = update.DeathYear
+	}
+	if update.Aliases != nil {
+		a.Aliases = update.Aliases
+	}
+	return a.validateDetails()
+}
+
+// Validation for crediting an author on a book, the first position is used when none is given
+func (c *Credit) ValidateCredit() error {
+	validate := validator.New()
+	if err := validate.Struct(c); err != nil {
+		return validationErrMessage(err.(validator.ValidationErrors))
+	}
+	if c.Position <= 0 {
+		c.Position = 1
+	}
+	return nil
+}
+
+// Turn "George Orwell" into "Orwell, George", names that are a single word are left as they are
+func DefaultSortName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return strings.TrimSpace(name)
+	}
+	last := parts[len(parts)-1]
+	return last + ", " + strings.Join(parts[:len(parts)-1], " ")
+}
+
+// Value implements driver.Valuer so aliases are written as a json array
+func (a Aliases) Value() (driver.Value, error) {
+	if a == nil {
+		return "[]", nil
+	}
+	encoded, err := json.Marshal([]string(a))
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// Scan implements sql.Scanner for the json array stored in the aliases column
+func (a *Aliases) Scan(value interface{}) error {
+	var raw []byte
+	switch v := value.(type) {
+	case nil:
+		*a = Aliases{}
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for aliases: %T", value)
+	}
+	var decoded []string
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return err
+	}
+	*a = decoded
+	return nil
+}
+
+// Internal helper funcs for methods
+func (a *Author) validateDetails() error {
+	if a.BirthYear != 0 && a.DeathYear != 0 && a.DeathYear < a.BirthYear {
+		return ErrInvalidLifeDates
+	}
+	for _, alias := range a.Aliases {
+		if strings.TrimSpace(alias) == "" {
+			return ErrEmptyAlias
+		}
+	}
+	return nil
+}
+
+func validationErrMessage(errs validator.ValidationErrors) error {
+	for _, err := range errs {
+		field := strings.ToLower(err.Field())
+		switch err.Tag() {
+		case "required":
+			return fmt.Errorf("%s field is required", field)
+		case "eq=author|eq=editor|eq=translator|eq=illustrator":
+			return fmt.Errorf("value for %s is not recognised, please use author, editor, translator or illustrator", field)
+		default:
+			return fmt.Errorf("value for %s is not recognized", field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package authors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultSortName(t *testing.T) {
+	assertWithTest := assert.New(t)
+	assertWithTest.Equal("Orwell, George", DefaultSortName("George Orwell"))
+	assertWithTest.Equal("Tolkien, J. R. R.", DefaultSortName("J. R. R. Tolkien"))
+	assertWithTest.Equal("Homer", DefaultSortName(" Homer "))
+}
+
+func TestCreateAuthorValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	testCases := []struct {
+		Input         Author
+		ExpectedError error
+		Message       string
+	}{
+		{Input: Author{Name: "George Orwell", BirthYear: 1903, DeathYear: 1950}, ExpectedError: nil, Message: "Correct format for Author"},
+		{Input: Author{SortName: "Orwell, George"}, ExpectedError: errors.New("name field is required"), Message: "Name is required"},
+		{Input: Author{Name: "George Orwell", BirthYear: 1950, DeathYear: 1903}, ExpectedError: ErrInvalidLifeDates, Message: "Died before being born"},
+		{Input: Author{Name: "George Orwell", Aliases: Aliases{"Eric Blair", " "}}, ExpectedError: ErrEmptyAlias, Message: "Empty alias"},
+	}
+	for _, test := range testCases {
+		assertWithTest.Equal(test.ExpectedError, test.Input.ValidateCreateAuthor(), test.Message)
+	}
+	author := Author{Name: "George Orwell"}
+	assertWithTest.Nil(author.ValidateCreateAuthor())
+	assertWithTest.Equal("Orwell, George", author.SortName)
+}
+
+func TestCreditValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	credit := Credit{AuthorID: 1, BookID: 2, Role: Translator}
+	assertWithTest.Nil(credit.ValidateCredit())
+	assertWithTest.Equal(1, credit.Position)
+	assertWithTest.Equal(errors.New("value for role is not recognised, please use author, editor, translator or illustrator"),
+		(&Credit{AuthorID: 1, BookID: 2, Role: "narrator"}).ValidateCredit())
+}
+
+func TestAliasesRoundTrip(t *testing.T) {
+	assertWithTest := assert.New(t)
+	value, err := Aliases{"Eric Blair"}.Value()
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(`["Eric Blair"]`, value)
+	var scanned Aliases
+	assertWithTest.Nil(scanned.Scan([]byte(`["Eric Blair"]`)))
+	assertWithTest.Equal(Aliases{"Eric Blair"}, scanned)
+	assertWithTest.Nil(scanned.Scan(nil))
+	assertWithTest.Equal(Aliases{}, scanned)
+}
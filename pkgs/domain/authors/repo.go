@@ -0,0 +1,16 @@
+package authors
+
+import "context"
+
+type Repository interface {
+	InsertAuthors(ctx context.Context, newAuthors []*Author) error
+	GetAuthors(ctx context.Context, params *GetAuthorsParams) ([]*Author, int, error)
+	// Locks the author, applies the change and writes it back in one transaction
+	UpdateAuthor(ctx context.Context, authorID int, apply func(*Author) error) (*Author, error)
+	DeleteAuthorByID(ctx context.Context, id int) error
+	// Adds the credit, or moves it to the new position if the author already has that role on the book
+	CreditAuthor(ctx context.Context, credit *Credit) error
+	// Removes every role the author has on the book when role is empty
+	RemoveCredit(ctx context.Context, authorID, bookID int, role Role) error
+	GetCredits(ctx context.Context, params *GetCreditsParams) ([]*Credit, int, error)
+}
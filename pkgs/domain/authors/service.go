@@ -0,0 +1,60 @@
+package authors
+
+import "context"
+
+type Service interface {
+	CreateAuthors(ctx context.Context, newAuthors []*Author) error
+	UpdateAuthor(ctx context.Context, updatedAuthor *Author) (*Author, error)
+	GetAuthors(ctx context.Context, params *GetAuthorsParams) ([]*Author, int, error)
+	DeleteAuthorByID(ctx context.Context, id int) error
+	CreditAuthor(ctx context.Context, credit *Credit) error
+	RemoveCredit(ctx context.Context, authorID, bookID int, role Role) error
+	GetCredits(ctx context.Context, params *GetCreditsParams) ([]*Credit, int, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// CreateAuthors implements Service.
+func (s *service) CreateAuthors(ctx context.Context, newAuthors []*Author) error {
+	return s.repo.InsertAuthors(ctx, newAuthors)
+}
+
+// UpdateAuthor implements Service.
+func (s *service) UpdateAuthor(ctx context.Context, updatedAuthor *Author) (*Author, error) {
+	return s.repo.UpdateAuthor(ctx, updatedAuthor.ID, func(current *Author) error {
+		return current.ApplyUpdate(updatedAuthor)
+	})
+}
+
+// GetAuthors implements Service.
+func (s *service) GetAuthors(ctx context.Context, params *GetAuthorsParams) ([]*Author, int, error) {
+	return s.repo.GetAuthors(ctx, params)
+}
+
+// DeleteAuthorByID implements Service.
+func (s *service) DeleteAuthorByID(ctx context.Context, id int) error {
+	return s.repo.DeleteAuthorByID(ctx, id)
+}
+
+// CreditAuthor implements Service.
+func (s *service) CreditAuthor(ctx context.Context, credit *Credit) error {
+	return s.repo.CreditAuthor(ctx, credit)
+}
+
+// RemoveCredit implements Service.
+func (s *service) RemoveCredit(ctx context.Context, authorID, bookID int, role Role) error {
+	return s.repo.RemoveCredit(ctx, authorID, bookID, role)
+}
+
+// GetCredits implements Service.
+func (s *service) GetCredits(ctx context.Context, params *GetCreditsParams) ([]*Credit, int, error) {
+	return s.repo.GetCredits(ctx, params)
+}
@@ -4,8 +4,20 @@ import "net/http"
 
 type Handler interface {
 	CreateBook(res http.ResponseWriter, req *http.Request)
+	BulkCreateBooks(res http.ResponseWriter, req *http.Request)
+	ImportBooks(res http.ResponseWriter, req *http.Request)
+	ImportMARC(res http.ResponseWriter, req *http.Request)
+	IngestONIX(res http.ResponseWriter, req *http.Request)
 	UpdateBook(res http.ResponseWriter, req *http.Request)
+	PatchBook(res http.ResponseWriter, req *http.Request)
+	BulkUpdateBooks(res http.ResponseWriter, req *http.Request)
 	GetBooks(res http.ResponseWriter, req *http.Request)
+	FullTextSearch(res http.ResponseWriter, req *http.Request)
 	GetBookByID(res http.ResponseWriter, req *http.Request)
+	ExportBooks(res http.ResponseWriter, req *http.Request)
 	DeleteBook(res http.ResponseWriter, req *http.Request)
+	BulkDeleteBooks(res http.ResponseWriter, req *http.Request)
+	GetTrash(res http.ResponseWriter, req *http.Request)
+	RestoreBook(res http.ResponseWriter, req *http.Request)
+	GetBookHistory(res http.ResponseWriter, req *http.Request)
 }
@@ -1,10 +1,28 @@
 package books
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/cql"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/marc"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/onix"
 	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
 	"github.com/go-playground/validator"
 )
@@ -14,27 +32,237 @@ type Availability string
 // Create global errors that are specific to this domain
 var (
 	ErrBookAlreadyExists = errors.New("book already exists")
+	ErrInvalidISBN       = errors.New("isbn is not a valid ISBN-10 or ISBN-13")
+	ErrBookNotFound      = errors.New("book does not exist")
+	ErrBookDeleted       = errors.New("book has been deleted, it can be restored from the trash until it is purged")
+	ErrBookNotInTrash    = errors.New("book is not in the trash")
+	ErrVersionConflict   = errors.New("book has been changed since it was read, fetch it again and retry")
+	ErrInvalidPatch      = errors.New("patch is not valid")
+	ErrPatchTestFailed   = errors.New("patch test failed")
+	ErrBulkRolledBack    = errors.New("not applied because another book in the request failed")
+	ErrBulkTooLarge      = fmt.Errorf("a bulk request can take at most %d books", MaxBulkBooks)
+	ErrBulkSelection     = errors.New("send either ids or a filter with at least one field to pick the books")
+	ErrBulkCopyStatus    = errors.New("copy_status must be in_repair, lost or withdrawn")
+	ErrImportColumns     = errors.New("csv header is missing a column for a book field")
+	ErrImportMapping     = errors.New("column mappings are written field:header with the json name of a book field")
+	ErrExportFormat      = errors.New("format must be csv, ndjson, marc21 or marcxml")
+	ErrNotABook          = errors.New("product is not a book")
+	ErrONIXDelete        = errors.New("delete notifications are not applied, move the book to the trash instead")
+	ErrCQLIndex          = errors.New("index is not supported")
+	ErrCQLRelation       = errors.New("relation is not supported for this index")
+	ErrCQLModifier       = errors.New("relation and boolean modifiers are not supported")
+	ErrCQLBoolean        = errors.New("boolean operator is not supported")
+	ErrCQLTerm           = errors.New("term is not supported for this index")
+	ErrCQLSort           = errors.New("sortby is not supported")
+	ErrFacet             = errors.New("facets are genre, language, publisher, availability and decade")
+	ErrDecade            = errors.New("decade must be a year ending in 0, such as 1980")
+	ErrFullTextQuery     = errors.New("q needs at least one word to search for")
+	ErrFullTextField     = errors.New("fields are title, author, publisher and genre, each with an optional ^boost")
+	// Returned by a repository that has no full-text index, the books are then ranked in process
+	ErrFullTextUnsupported = errors.New("full-text search is not supported by the repository")
+	ErrInvalidCursor       = errors.New("cursor is not valid, start again from the first page")
+	ErrCursorSort          = errors.New("cursor was made for another sort, send the same sort or none")
+	ErrSort                = fmt.Errorf("sort takes up to %d fields of title, author, publisher, published, genre, "+
+		"language, pages, created_at, updated_at or id, each once and with a - in front to sort descending", MaxSortKeys)
 )
 
+// Formats the catalogue can be exported in, the MARC formats can be imported as well
+type ExportFormat string
+
+const (
+	CSV     ExportFormat = "csv"
+	NDJSON  ExportFormat = "ndjson"
+	MARC21  ExportFormat = "marc21"
+	MARCXML ExportFormat = "marcxml"
+)
+
+// Columns of a CSV export, the fields a CSV import reads come first so an export can be imported again
+var ExportColumns = []string{
+	"isbn", "title", "author", "publisher", "published", "genre", "language", "pages",
+	"id", "version", "availability", "copies_available", "copies_total", "created_at", "updated_at",
+}
+
+type BulkMode string
+
 const (
 	Available    Availability = "available"
 	NotAvailable Availability = "not_available"
 )
 
+const (
+	// Nothing is created unless every book can be
+	AllOrNothing BulkMode = "all_or_nothing"
+	// Every valid book is created and the others are reported
+	Partial BulkMode = "partial"
+)
+
+// Largest number of books a single bulk request may create, change or delete
+const MaxBulkBooks = 500
+
+// Outcome for one book of a bulk request, in the order the books were sent or matched
+type BulkResult struct {
+	Index int    `json:"index"`
+	ID    int    `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Fields a bulk update changed, or would change on a dry run
+	Changes audit.Changes `json:"changes,omitempty"`
+	// Copies a bulk update took off the shelf
+	CopiesChanged int `json:"copies_changed,omitempty"`
+}
+
+// Rows of a CSV import are inserted this many at a time
+const ImportBatchSize = 100
+
+// How the columns of a CSV import are read into books
+type ImportOptions struct {
+	// Header of the column each field is read from keyed by the json name of the field,
+	// fields that are left out are read from the column with their own name
+	Columns map[string]string
+	// Go layout of the published column, without one 2006-01-02 and unix timestamps are accepted
+	DateFormat string
+}
+
+// A row of a CSV import that was not inserted, lines are counted from 1 and include the header.
+// For a MARC import the line is the number of the record
+type RejectedRow struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// Something in an imported record that did not make it onto the book, the book is imported without it
+type ImportWarning struct {
+	Record int    `json:"record"`
+	Reason string `json:"reason"`
+}
+
+type ImportReport struct {
+	Rows     int              `json:"rows"`
+	Imported int              `json:"imported"`
+	Rejected []*RejectedRow   `json:"rejected"`
+	Warnings []*ImportWarning `json:"warnings,omitempty"`
+}
+
+type IngestOutcome string
+
+const (
+	Inserted IngestOutcome = "inserted"
+	Updated  IngestOutcome = "updated"
+	Skipped  IngestOutcome = "skipped"
+)
+
+// What an ONIX ingest did with one product, products are numbered from 1 in the order of the feed
+type IngestResult struct {
+	Product   int           `json:"product"`
+	Reference string        `json:"reference,omitempty"`
+	ISBN      string        `json:"isbn,omitempty"`
+	ID        int           `json:"id,omitempty"`
+	Outcome   IngestOutcome `json:"outcome"`
+	Reason    string        `json:"reason,omitempty"`
+	// ONIX product form code, BC for a paperback, EA for a digital book, and whether it is print or digital,
+	// so editions of one title with their own isbns can be told apart
+	ProductForm string `json:"product_form,omitempty"`
+	Format      string `json:"format,omitempty"`
+	// Fields an update changed on the stored book
+	Changes  audit.Changes `json:"changes,omitempty"`
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+type IngestReport struct {
+	Products int             `json:"products"`
+	Inserted int             `json:"inserted"`
+	Updated  int             `json:"updated"`
+	Skipped  int             `json:"skipped"`
+	Results  []*IngestResult `json:"results"`
+}
+
+// Tags a book is read from or written to, other fields of an imported record are reported as not mapped.
+// 001, 003, 005 and 008 are expected on every record and are left to the system that made it
+var marcMappedTags = map[string]bool{
+	"001": true, "003": true, "005": true, "008": true, "020": true, "041": true, "046": true,
+	"100": true, "110": true, "245": true, "260": true, "264": true, "300": true, "546": true,
+	"650": true, "655": true,
+}
+
+// MARC language codes and the names books use for them, the first code of a name is the one written
+var marcLanguages = []struct {
+	codes []string
+	name  string
+}{
+	{[]string{"eng"}, "English"}, {[]string{"fre", "fra"}, "French"}, {[]string{"ger", "deu"}, "German"},
+	{[]string{"spa"}, "Spanish"}, {[]string{"ita"}, "Italian"}, {[]string{"por"}, "Portuguese"},
+	{[]string{"dut", "nld"}, "Dutch"}, {[]string{"rus"}, "Russian"}, {[]string{"chi", "zho"}, "Chinese"},
+	{[]string{"jpn"}, "Japanese"}, {[]string{"kor"}, "Korean"}, {[]string{"ara"}, "Arabic"},
+	{[]string{"hin"}, "Hindi"}, {[]string{"gre", "ell"}, "Greek"}, {[]string{"lat"}, "Latin"},
+	{[]string{"swe"}, "Swedish"}, {[]string{"nor"}, "Norwegian"}, {[]string{"dan"}, "Danish"},
+	{[]string{"fin"}, "Finnish"}, {[]string{"pol"}, "Polish"}, {[]string{"tur"}, "Turkish"},
+	{[]string{"heb"}, "Hebrew"}, {[]string{"afr"}, "Afrikaans"}, {[]string{"zul"}, "Zulu"},
+}
+
+// Books a bulk update or delete applies to, listed by id or matched by a filter
+type BulkSelection struct {
+	IDs    []int
+	Filter *GetBooksParams
+	Mode   BulkMode
+	// Work out the outcome for every book without changing any of them
+	DryRun bool
+}
+
+// A bulk update applies the same patch to every selected book
+type BulkUpdate struct {
+	BulkSelection
+	Patch *Patch
+	// Availability comes from the copies, so a shelf is marked not_available by
+	// moving the copies that are not on loan or on hold to this status
+	CopyStatus copies.Status
+}
+
+// Availability and the copy counts are worked out from the copies on the shelf,
+// they cannot be set on the book itself. Version is bumped on every change to the book
 type Book struct {
-	ID           int              `json:"id" db:"id"`
-	ISBN         string           `json:"isbn" db:"isbn" validate:"required"`
-	Title        string           `json:"title" db:"title" validate:"required"`
-	Author       string           `json:"author" db:"author" validate:"required"`
-	Publisher    string           `json:"publisher" db:"publisher" validate:"required"`
-	Published    utils.CustomDate `json:"published" db:"published" validate:"required"`
-	Genre        string           `json:"genre" db:"genre" validate:"required"`
-	Language     string           `json:"language" db:"language" validate:"required"`
-	Pages        int              `json:"pages" db:"pages" validate:"required"`
-	Availability Availability     `json:"availability" db:"availability" validate:"required,eq=available|eq=not_available"`
-	UpdatedAt    utils.CustomTime `json:"updated_at" db:"updated_at"`
-	CreatedAt    utils.CustomTime `json:"created_at" db:"created_at"`
-	DeletedAt    utils.CustomTime `json:"deleted_at" db:"deleted_at"`
+	ID              int              `json:"id" db:"id"`
+	ISBN            string           `json:"isbn" db:"isbn" validate:"required,isbn"`
+	Title           string           `json:"title" db:"title" validate:"required"`
+	Author          string           `json:"author" db:"author" validate:"required"`
+	Publisher       string           `json:"publisher" db:"publisher" validate:"required"`
+	Published       utils.CustomDate `json:"published" db:"published" validate:"required"`
+	Genre           string           `json:"genre" db:"genre" validate:"required"`
+	Language        string           `json:"language" db:"language" validate:"required"`
+	Pages           int              `json:"pages" db:"pages" validate:"required"`
+	Version         int              `json:"version" db:"version"`
+	Availability    Availability     `json:"availability" db:"availability"`
+	CopiesAvailable int              `json:"copies_available" db:"copies_available"`
+	CopiesTotal     int              `json:"copies_total" db:"copies_total"`
+	UpdatedAt       utils.CustomTime `json:"updated_at" db:"updated_at"`
+	CreatedAt       utils.CustomTime `json:"created_at" db:"created_at"`
+	DeletedAt       utils.CustomTime `json:"deleted_at" db:"deleted_at"`
+}
+
+// A partial update to a book, only the fields that were sent are changed and a null clears the field
+type Patch struct {
+	ID int
+	// Zero unless the patch was sent with If-Match
+	Version int
+	// New values of the fields that were sent
+	Values Book
+	// Json names of the fields that were sent
+	Fields map[string]bool
+	// Values a JSON Patch expects the stored book to hold, keyed by json name
+	Tests map[string]interface{}
+}
+
+// Fields a patch may change, publisher, genre, language and pages may also be cleared
+var patchableFields = map[string]struct {
+	name      string
+	clearable bool
+}{
+	"isbn":      {name: "ISBN"},
+	"title":     {name: "Title"},
+	"author":    {name: "Author"},
+	"publisher": {name: "Publisher", clearable: true},
+	"published": {name: "Published"},
+	"genre":     {name: "Genre", clearable: true},
+	"language":  {name: "Language", clearable: true},
+	"pages":     {name: "Pages", clearable: true},
 }
 
 type GetBooksParams struct {
@@ -48,15 +276,466 @@ type GetBooksParams struct {
 	Publisher    string
 	Published    utils.CustomDate
 	Genre        string
+	GenreID      int
 	Language     string
 	BookPages    int
 	Availability Availability
+	// List the books in the trash instead of the live ones
+	Deleted bool
+	// List the most recently added books first instead of ordering by updated_at
+	Newest bool
+	// Skip this many books instead of whole pages, PerPage still limits how many are listed
+	Offset int
+	// A CQL search the books must also match, see ParseSearch
+	Search *cql.Query
+	// Books published in the ten years starting with this one, such as 1980
+	Decade int
+	// Order to list the books in, ties are broken on the id. Without one they are listed by updated_at
+	Sort []SortKey
+	// List the books after, or before, the position of a cursor instead of a page
+	Cursor *Cursor
+	// Leave out counting every book that matches when a page is listed, which can be slow on a large catalogue
+	SkipTotal bool
+}
+
+// A page of the books with where it sits among all that match
+type BooksPage struct {
+	Books   []*Book
+	Page    int
+	PerPage int
+	// Number of books that match, -1 when they were not counted
+	Total   int
+	HasNext bool
+}
+
+// TotalPages is the number of pages the books that match fill, -1 when they were not counted
+func (p *BooksPage) TotalPages() int {
+	switch {
+	case p.Total < 0:
+		return -1
+	case p.PerPage <= 0 && p.Total == 0:
+		return 0
+	case p.PerPage <= 0:
+		return 1
+	}
+	return (p.Total + p.PerPage - 1) / p.PerPage
+}
+
+// Order returns every key the books are listed by, ending with the id that breaks ties
+func (p *GetBooksParams) Order() []SortKey {
+	switch {
+	case len(p.Sort) > 0:
+		keys := append([]SortKey{}, p.Sort...)
+		for _, key := range keys {
+			if key.Field == "id" {
+				return keys
+			}
+		}
+		return append(keys, SortKey{Field: "id"})
+	case p.Newest:
+		return []SortKey{{Field: "created_at", Descending: true}, {Field: "id", Descending: true}}
+	}
+	return []SortKey{{Field: "updated_at"}, {Field: "id"}}
+}
+
+// The position of a book in a listing, the books of the next page come after it and the previous page before it
+type Cursor struct {
+	Keys []SortKey `json:"k"`
+	// Values of the book for each key, see CursorAt
+	Values []string `json:"v"`
+	// List the books before the position instead of after it
+	Backward bool `json:"b,omitempty"`
+}
+
+// CursorAt makes a cursor at the position of the book in a listing ordered by keys
+func CursorAt(book *Book, keys []SortKey, backward bool) *Cursor {
+	cursor := &Cursor{Keys: keys, Values: make([]string, len(keys)), Backward: backward}
+	for i, key := range keys {
+		switch key.Field {
+		case "id":
+			cursor.Values[i] = strconv.Itoa(book.ID)
+		case "pages":
+			cursor.Values[i] = strconv.Itoa(book.Pages)
+		case "published":
+			cursor.Values[i] = book.Published.Time.Format("2006-01-02")
+		case "created_at":
+			cursor.Values[i] = book.CreatedAt.Time.UTC().Format(time.RFC3339Nano)
+		case "updated_at":
+			cursor.Values[i] = book.UpdatedAt.Time.UTC().Format(time.RFC3339Nano)
+		default:
+			cursor.Values[i] = textField(book, key.Field)
+		}
+	}
+	return cursor
+}
+
+// Args reads the values of the cursor back into the types of their fields, to compare the columns with
+func (c *Cursor) Args() ([]interface{}, error) {
+	if len(c.Keys) == 0 || len(c.Keys) != len(c.Values) {
+		return nil, ErrInvalidCursor
+	}
+	args := make([]interface{}, len(c.Keys))
+	for i, key := range c.Keys {
+		var err error
+		switch key.Field {
+		case "id", "pages":
+			args[i], err = strconv.Atoi(c.Values[i])
+		case "published":
+			args[i], err = time.Parse("2006-01-02", c.Values[i])
+		case "created_at", "updated_at":
+			args[i], err = time.Parse(time.RFC3339Nano, c.Values[i])
+		default:
+			if !sortFields[key.Field] {
+				return nil, ErrInvalidCursor
+			}
+			args[i] = c.Values[i]
+		}
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return args, nil
+}
+
+// Signs cursors so the tokens handed to clients cannot be changed or made up
+type CursorSigner struct {
+	key []byte
+}
+
+func NewCursorSigner(key []byte) *CursorSigner {
+	return &CursorSigner{key: key}
+}
+
+// Sign turns the cursor into an opaque token, its json and an HMAC-SHA256 of it
+func (s *CursorSigner) Sign(cursor *Cursor) (string, error) {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.mac(payload)), nil
+}
+
+// Verify reads a token made by Sign, any token that was changed is an invalid cursor
+func (s *CursorSigner) Verify(token string) (*Cursor, error) {
+	encodedPayload, encodedMAC, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.mac(payload)) {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if _, err := cursor.Args(); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func (s *CursorSigner) mac(payload []byte) []byte {
+	hash := hmac.New(sha256.New, s.key)
+	hash.Write(payload)
+	return hash.Sum(nil)
+}
+
+// UseCursor lists the books from the position of the cursor. Without a sort of their own the params take the one
+// the cursor was made for, any other sort is refused as the position means nothing in it
+func (p *GetBooksParams) UseCursor(cursor *Cursor) error {
+	if len(p.Sort) == 0 && !p.Newest {
+		p.Sort = cursor.Keys
+	}
+	if !reflect.DeepEqual(p.Order(), cursor.Keys) {
+		return ErrCursorSort
+	}
+	p.Cursor = cursor
+	p.Page, p.Offset = 0, 0
+	return nil
+}
+
+// Books listed by cursor come this many to a page unless another number is asked for
+const DefaultCursorPerPage = 25
+
+// A page of books listed by cursor, with the cursors of the pages either side of it when there are any
+type CursorPage struct {
+	Books []*Book
+	Next  *Cursor
+	Prev  *Cursor
+	// Number of books that match ignoring the cursor, -1 when they were not counted
+	Total int
+}
+
+// A field to order books by, see ParseSort
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+const MaxSortKeys = 5
+
+// Fields books can be ordered by, by their json names
+var sortFields = map[string]bool{
+	"id": true, "title": true, "author": true, "publisher": true, "published": true, "genre": true,
+	"language": true, "pages": true, "created_at": true, "updated_at": true,
+}
+
+// ParseSort reads an order such as -published,title, a - in front of a field sorts it descending
+func ParseSort(value string) ([]SortKey, error) {
+	var keys []SortKey
+	seen := map[string]bool{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key := SortKey{Field: strings.ToLower(strings.TrimLeft(field, "+-")), Descending: strings.HasPrefix(field, "-")}
+		if len(field)-len(key.Field) > 1 || !sortFields[key.Field] || seen[key.Field] {
+			return nil, fmt.Errorf("%w: not %q", ErrSort, field)
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+	if len(keys) > MaxSortKeys {
+		return nil, ErrSort
+	}
+	return keys, nil
+}
+
+// SortBooks puts books in the order a repository lists them in for the keys. Text is compared without regard to
+// case, as the database collation does, and books that tie on every key are ordered by id
+func SortBooks(found []*Book, keys []SortKey) {
+	sort.SliceStable(found, func(i, j int) bool {
+		for _, key := range keys {
+			compared := compareField(found[i], found[j], key.Field)
+			if key.Descending {
+				compared = -compared
+			}
+			if compared != 0 {
+				return compared < 0
+			}
+		}
+		return found[i].ID < found[j].ID
+	})
+}
+
+// Negative when a comes before b in ascending order, zero when they are the same
+func compareField(a, b *Book, field string) int {
+	switch field {
+	case "id":
+		return compareInts(a.ID, b.ID)
+	case "pages":
+		return compareInts(a.Pages, b.Pages)
+	case "published":
+		return a.Published.Time.Compare(b.Published.Time)
+	case "created_at":
+		return a.CreatedAt.Time.Compare(b.CreatedAt.Time)
+	case "updated_at":
+		return a.UpdatedAt.Time.Compare(b.UpdatedAt.Time)
+	}
+	return strings.Compare(strings.ToLower(textField(a, field)), strings.ToLower(textField(b, field)))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func textField(book *Book, field string) string {
+	switch field {
+	case "title":
+		return book.Title
+	case "author":
+		return book.Author
+	case "publisher":
+		return book.Publisher
+	case "genre":
+		return book.Genre
+	case "language":
+		return book.Language
+	}
+	return ""
+}
+
+// Fields the books found can be counted by, each value with how many of the books hold it
+type Facet string
+
+const (
+	FacetGenre        Facet = "genre"
+	FacetLanguage     Facet = "language"
+	FacetPublisher    Facet = "publisher"
+	FacetAvailability Facet = "availability"
+	FacetDecade       Facet = "decade"
+)
+
+// Facets list the values held by the most books first, down to this many
+const MaxFacetBuckets = 50
+
+// A value of a facet and the number of books that would be found with it as the filter
+type FacetBucket struct {
+	Value string `json:"value" db:"value"`
+	Count int    `json:"count" db:"count"`
+}
+
+// ParseFacets reads a comma separated list of facets, each facet is listed once
+func ParseFacets(value string) ([]Facet, error) {
+	var facets []Facet
+	for _, name := range strings.Split(value, ",") {
+		facet := Facet(strings.ToLower(strings.TrimSpace(name)))
+		switch facet {
+		case "":
+			continue
+		case FacetGenre, FacetLanguage, FacetPublisher, FacetAvailability, FacetDecade:
+		default:
+			return nil, fmt.Errorf("%w: not %q", ErrFacet, name)
+		}
+		if !containsFacet(facets, facet) {
+			facets = append(facets, facet)
+		}
+	}
+	return facets, nil
+}
+
+// Whether the params narrow the books down, the order they are listed in does not
+func (p *GetBooksParams) selectsBooks() bool {
+	filter := *p
+	filter.Sort, filter.Cursor = nil, nil
+	return !reflect.DeepEqual(filter, GetBooksParams{})
+}
+
+// WithoutFilter copies the params without the filter of the facet or any paging, so the counts of a facet are
+// taken over the books the other filters find and picking one of its values does not hide the others
+func (p *GetBooksParams) WithoutFilter(facet Facet) *GetBooksParams {
+	params := *p
+	params.Page, params.PerPage, params.Offset = 0, 0, 0
+	switch facet {
+	case FacetGenre:
+		params.Genre, params.GenreID = "", 0
+	case FacetLanguage:
+		params.Language = ""
+	case FacetPublisher:
+		params.Publisher = ""
+	case FacetAvailability:
+		params.Availability = ""
+	case FacetDecade:
+		params.Decade, params.Published = 0, utils.CustomDate{}
+	}
+	return &params
+}
+
+// ParseDecade reads the decade filter, a year that starts a decade
+func ParseDecade(value string) (int, error) {
+	decade, err := strconv.Atoi(value)
+	if err != nil || decade <= 0 || decade%10 != 0 {
+		return 0, ErrDecade
+	}
+	return decade, nil
+}
+
+// Fields full-text search covers, a match in the title counts for more than one in the publisher by default
+var (
+	FullTextFields = []string{"title", "author", "publisher", "genre"}
+	fullTextBoosts = map[string]float64{"title": 4, "author": 3, "publisher": 1, "genre": 2}
+)
+
+const (
+	DefaultSearchPerPage = 20
+	MaxSearchPerPage     = 100
+	MaxFullTextTerms     = 20
+	MaxFullTextBoost     = 100
+	// Values longer than this are cut down to a snippet around the first match when highlighted
+	SnippetLength = 120
+	// Matched terms in the highlights are wrapped in these, the rest of the value is HTML escaped
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// One word, or the words of a phrase, to search for
+type FullTextTerm struct {
+	Words []string
+	// The last word matches every word that starts with it
+	Prefix bool
+}
+
+// A full-text query, see ParseFullText
+type FullTextQuery struct {
+	Terms []*FullTextTerm
+	// Fields searched in, in the order they were given
+	Fields []string
+	Boosts map[string]float64
+}
+
+type FullTextParams struct {
+	Query   *FullTextQuery
+	Page    int
+	PerPage int
+}
+
+// A book found by a full-text search. Scores rank the books of one search and are not comparable across searches
+type SearchHit struct {
+	Book       *Book             `json:"book"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// Fields a CQL search can be made in. Keywords is the title and the author
+const (
+	SearchKeywords  = "keywords"
+	SearchTitle     = "title"
+	SearchAuthor    = "author"
+	SearchPublisher = "publisher"
+	SearchPublished = "published"
+	SearchGenre     = "genre"
+	SearchLanguage  = "language"
+	SearchISBN      = "isbn"
+)
+
+// CQL indexes and the field each searches, the Dublin Core indexes may be given without their prefix
+var searchIndexes = map[string]string{
+	cql.ServerChoice: SearchKeywords, "serverchoice": SearchKeywords,
+	"dc.title": SearchTitle, "title": SearchTitle,
+	"dc.creator": SearchAuthor, "creator": SearchAuthor, "bath.author": SearchAuthor, "author": SearchAuthor,
+	"dc.publisher": SearchPublisher, "publisher": SearchPublisher,
+	"dc.date": SearchPublished, "date": SearchPublished,
+	"dc.subject": SearchGenre, "subject": SearchGenre,
+	"dc.language": SearchLanguage, "language": SearchLanguage,
+	"dc.identifier": SearchISBN, "identifier": SearchISBN, "bath.isbn": SearchISBN, "isbn": SearchISBN,
+}
+
+// Relations each field can be searched with
+var searchRelations = map[string][]string{
+	SearchKeywords:  {"=", "==", "exact", "any", "all", "adj", "<>"},
+	SearchTitle:     {"=", "==", "exact", "any", "all", "adj", "<>"},
+	SearchAuthor:    {"=", "==", "exact", "any", "all", "adj", "<>"},
+	SearchPublisher: {"=", "==", "exact", "any", "all", "adj", "<>"},
+	SearchGenre:     {"=", "==", "exact", "any", "all", "adj", "<>"},
+	SearchLanguage:  {"=", "==", "exact", "any", "<>"},
+	SearchISBN:      {"=", "==", "exact", "<>"},
+	SearchPublished: {"=", "==", "<", ">", "<=", ">=", "<>"},
+}
+
+// A language the catalogue has books in and how many of them there are
+type LanguageCount struct {
+	Language string `json:"language" db:"language"`
+	Books    int    `json:"books" db:"books"`
 }
 
 // Object methods for aggregate root
 // Validation for creating a Book
+// The ISBN is stored in its canonical ISBN-13 form once it has been validated
 func (b *Book) ValidateCreateBook() error {
-	validate := validator.New()
+	validate := newValidator()
 
 	err := validate.Struct(b)
 
@@ -64,22 +743,1080 @@ func (b *Book) ValidateCreateBook() error {
 		err, _ := validationErrMessage(err.(validator.ValidationErrors))
 		return err
 	}
+	b.ISBN, _ = NormalizeISBN(b.ISBN)
 	return nil
 }
 func (b *Book) ValidateUpdateBook() error {
-	validate := validator.New()
+	validate := newValidator()
 	err := validate.Struct(b)
 	if err != nil {
-		err, tag := validationErrMessage(err.(validator.ValidationErrors))
-		if tag == "eq=available|eq=not_available" {
+		// Updates may leave any field empty, so only the rules other than required are enforced
+		for _, fieldErr := range err.(validator.ValidationErrors) {
+			if fieldErr.Tag() != "required" {
+				err, _ := validationErrMessage(validator.ValidationErrors{fieldErr})
+				return err
+			}
+		}
+	}
+	if b.ISBN != "" {
+		b.ISBN, _ = NormalizeISBN(b.ISBN)
+	}
+	return nil
+}
+
+// Snapshot lists every audited field of a new book as changing from nothing
+func (b *Book) Snapshot() audit.Changes {
+	changes := audit.Changes{}
+	for _, field := range b.auditFields() {
+		changes = append(changes, audit.Change{Field: field.Field, New: field.New})
+	}
+	return changes
+}
+
+// Changes lists the audited fields the update would change, fields left empty in the update are kept
+func (b *Book) Changes(update *Book) audit.Changes {
+	return b.changes(update, func(field audit.Change) bool {
+		return field.New != "" && field.New != 0
+	})
+}
+
+// PatchChanges lists the audited fields the patch would change, including the ones it clears
+func (b *Book) PatchChanges(patch *Patch) audit.Changes {
+	return b.changes(&patch.Values, func(field audit.Change) bool {
+		return patch.Fields[field.Field]
+	})
+}
+
+// ParseMergePatch reads an RFC 7396 JSON Merge Patch, a null member clears the field
+func ParseMergePatch(bookID int, body []byte) (*Patch, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return nil, fmt.Errorf("%w: a merge patch must be a json object", ErrInvalidPatch)
+	}
+	patch := newPatch(bookID)
+	for field, value := range members {
+		if err := patch.set(field, value); err != nil {
+			return nil, err
+		}
+	}
+	return patch, nil
+}
+
+// ParseJSONPatch reads an RFC 6902 JSON Patch, add, replace, remove and test are supported on top level fields
+func ParseJSONPatch(bookID int, body []byte) (*Patch, error) {
+	var operations []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(body, &operations); err != nil {
+		return nil, fmt.Errorf("%w: a json patch must be an array of operations", ErrInvalidPatch)
+	}
+	patch := newPatch(bookID)
+	for _, operation := range operations {
+		if !strings.HasPrefix(operation.Path, "/") || strings.Count(operation.Path, "/") != 1 {
+			return nil, fmt.Errorf("%w: path %q must name a top level field", ErrInvalidPatch, operation.Path)
+		}
+		field := operation.Path[1:]
+		switch operation.Op {
+		case "add", "replace":
+			if operation.Value == nil {
+				return nil, fmt.Errorf("%w: %s of %s needs a value", ErrInvalidPatch, operation.Op, operation.Path)
+			}
+			if err := patch.set(field, operation.Value); err != nil {
+				return nil, err
+			}
+		case "remove":
+			if err := patch.set(field, json.RawMessage("null")); err != nil {
+				return nil, err
+			}
+		case "test":
+			// Tests compare against the stored book, so they are checked when the patch is applied
+			var expected Book
+			if err := decodeField(&expected, field, operation.Value); err != nil {
+				return nil, err
+			}
+			patch.Tests[field] = expected.auditValue(field)
+		default:
+			return nil, fmt.Errorf("%w: op %q is not supported", ErrInvalidPatch, operation.Op)
+		}
+	}
+	return patch, nil
+}
+
+// Validation for a Patch, only the fields that were sent are checked
+func (p *Patch) ValidatePatch() error {
+	if len(p.Fields) == 0 && len(p.Tests) == 0 {
+		return fmt.Errorf("%w: the patch is empty", ErrInvalidPatch)
+	}
+	var names []string
+	for field := range p.Fields {
+		names = append(names, patchableFields[field].name)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	validate := newValidator()
+	if err := validate.StructPartial(&p.Values, names...); err != nil {
+		for _, fieldErr := range err.(validator.ValidationErrors) {
+			// Clearable fields may be emptied, the others are still required
+			if fieldErr.Tag() == "required" && patchableFields[strings.ToLower(fieldErr.Field())].clearable {
+				continue
+			}
+			err, _ := validationErrMessage(validator.ValidationErrors{fieldErr})
 			return err
 		}
+	}
+	if p.Fields["isbn"] {
+		p.Values.ISBN, _ = NormalizeISBN(p.Values.ISBN)
+	}
+	return nil
+}
+
+// Validation for the books picked by a bulk request, a filter has to narrow the catalogue down
+func (s *BulkSelection) ValidateSelection() error {
+	if (len(s.IDs) == 0) == (s.Filter == nil) {
+		return ErrBulkSelection
+	}
+	if s.Filter != nil && !s.Filter.selectsBooks() {
+		return ErrBulkSelection
+	}
+	if len(s.IDs) > MaxBulkBooks {
+		return ErrBulkTooLarge
+	}
+	if s.Mode == "" {
+		s.Mode = AllOrNothing
+	}
+	return nil
+}
+
+// Validation for a bulk update, the isbn is unique to a book so it cannot be set on many at once
+func (u *BulkUpdate) ValidateBulkUpdate() error {
+	if err := u.ValidateSelection(); err != nil {
+		return err
+	}
+	switch u.CopyStatus {
+	case "", copies.InRepair, copies.Lost, copies.Withdrawn:
+	default:
+		return ErrBulkCopyStatus
+	}
+	if u.Patch == nil || len(u.Patch.Fields) == 0 {
+		if u.CopyStatus == "" {
+			return fmt.Errorf("%w: the patch is empty", ErrInvalidPatch)
+		}
 		return nil
 	}
+	if u.Patch.Fields["isbn"] {
+		return fmt.Errorf("%w: isbn cannot be set in bulk", ErrInvalidPatch)
+	}
+	return u.Patch.ValidatePatch()
+}
+
+// ParseColumnMapping reads field:header pairs as they are given on the command line or in the query string
+func ParseColumnMapping(pairs []string) (map[string]string, error) {
+	columns := map[string]string{}
+	for _, pair := range pairs {
+		field, header, found := strings.Cut(pair, ":")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if _, ok := patchableFields[field]; !found || !ok || strings.TrimSpace(header) == "" {
+			return nil, fmt.Errorf("%w: %q", ErrImportMapping, pair)
+		}
+		columns[field] = strings.TrimSpace(header)
+	}
+	return columns, nil
+}
+
+// Work out the index of the column every field is read from, headers are matched ignoring case
+func (o *ImportOptions) ColumnIndexes(header []string) (map[string]int, error) {
+	positions := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, taken := positions[name]; !taken {
+			positions[name] = i
+		}
+	}
+	indexes := map[string]int{}
+	var missing []string
+	for field := range patchableFields {
+		name := field
+		if mapped, ok := o.Columns[field]; ok {
+			name = strings.ToLower(mapped)
+		}
+		i, ok := positions[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		indexes[field] = i
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%w: %s", ErrImportColumns, strings.Join(missing, ", "))
+	}
+	return indexes, nil
+}
+
+// Read a book out of a CSV row, it still has to be validated before it is inserted
+func (o *ImportOptions) BookFromRow(row []string, columns map[string]int) (*Book, error) {
+	value := func(field string) string {
+		if i := columns[field]; i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	book := &Book{
+		ISBN:      value("isbn"),
+		Title:     value("title"),
+		Author:    value("author"),
+		Publisher: value("publisher"),
+		Genre:     value("genre"),
+		Language:  value("language"),
+	}
+	if pages := value("pages"); pages != "" {
+		converted, err := strconv.Atoi(pages)
+		if err != nil {
+			return nil, fmt.Errorf("pages %q is not a whole number", pages)
+		}
+		book.Pages = converted
+	}
+	if published := value("published"); published != "" {
+		date, err := o.parseDate(published)
+		if err != nil {
+			return nil, err
+		}
+		book.Published = utils.CustomDate{Time: date}
+	}
+	return book, nil
+}
+
+// FromMARC reads a book out of a MARC 21 bibliographic record, anything that could not be mapped is
+// returned as a warning and the book still has to be validated before it is inserted
+func FromMARC(record *marc.Record) (*Book, []string) {
+	book := &Book{}
+	var warnings []string
+	for _, field := range record.FieldsByTag("020") {
+		// The isbn is often followed by a qualifier such as (pbk.)
+		if isbn := strings.Fields(field.Subfield('a')); len(isbn) > 0 {
+			book.ISBN = isbn[0]
+			break
+		}
+	}
+	for _, tag := range []string{"100", "110"} {
+		if field := record.Field(tag); field != nil {
+			book.Author = trimISBD(field.Subfield('a'))
+			break
+		}
+	}
+	if field := record.Field("245"); field != nil {
+		book.Title = trimISBD(field.Subfield('a'))
+		if subtitle := trimISBD(field.Subfield('b')); subtitle != "" {
+			book.Title += ": " + subtitle
+		}
+	}
+	// The publication statement is 264 with a second indicator of 1, older records use 260
+	var publication *marc.Field
+	for _, field := range record.FieldsByTag("264") {
+		if field.Indicator2 == '1' {
+			publication = field
+			break
+		}
+	}
+	if publication == nil {
+		publication = record.Field("260")
+	}
+	var year string
+	if publication != nil {
+		book.Publisher = trimISBD(publication.Subfield('b'))
+		year = firstDigits(publication.Subfield('c'), 4)
+	}
+	if control := record.Field("008"); year == "" && control != nil && len(control.Value) >= 11 {
+		year = firstDigits(control.Value[7:11], 4)
+	}
+	// 046 carries the full date, the publication statement usually only has the year
+	if field := record.Field("046"); field != nil {
+		if date, err := time.Parse("20060102", field.Subfield('k')); err == nil {
+			book.Published = utils.CustomDate{Time: date}
+		}
+	}
+	if book.Published.IsZero() && year != "" {
+		date, _ := time.Parse("2006", year)
+		book.Published = utils.CustomDate{Time: date}
+		warnings = append(warnings, fmt.Sprintf("only the year %s is known, published is set to the first of January", year))
+	}
+	var code string
+	if field := record.Field("041"); field != nil {
+		code = field.Subfield('a')
+	} else if control := record.Field("008"); control != nil && len(control.Value) >= 38 {
+		code = strings.TrimSpace(control.Value[35:38])
+	}
+	if code != "" && code != "und" && code != "|||" {
+		if book.Language = languageName(code); book.Language == "" {
+			book.Language = code
+			warnings = append(warnings, fmt.Sprintf("language code %s is not known, it is kept as the code", code))
+		}
+	}
+	if field := record.Field("546"); book.Language == "" && field != nil {
+		book.Language = trimISBD(field.Subfield('a'))
+	}
+	if field := record.Field("300"); field != nil {
+		extent := field.Subfield('a')
+		if pages, err := strconv.Atoi(firstDigits(extent, 0)); err == nil {
+			book.Pages = pages
+		} else if extent != "" {
+			warnings = append(warnings, fmt.Sprintf("extent %q has no page count", extent))
+		}
+	}
+	for _, tag := range []string{"655", "650"} {
+		if field := record.Field(tag); field != nil {
+			book.Genre = trimISBD(field.Subfield('a'))
+			break
+		}
+	}
+	var unmapped []string
+	for _, field := range record.Fields {
+		if !marcMappedTags[field.Tag] && !containsString(unmapped, field.Tag) {
+			unmapped = append(unmapped, field.Tag)
+		}
+	}
+	if len(unmapped) > 0 {
+		warnings = append(warnings, fmt.Sprintf("fields %s could not be mapped", strings.Join(unmapped, ", ")))
+	}
+	return book, warnings
+}
+
+// ONIXFormat tells print books from digital ones by their ONIX product form, other forms are not books
+func ONIXFormat(form string) string {
+	switch form = strings.TrimSpace(form); {
+	case strings.HasPrefix(form, "B"):
+		return "print"
+	case strings.HasPrefix(form, "E"):
+		return "digital"
+	}
+	return ""
+}
+
+// FromONIX reads a book out of an ONIX 3.0 product. Products that are not print or digital books and
+// delete notifications are returned as an error, anything else that could not be mapped is returned as a
+// warning and the book still has to be validated before it is stored
+func FromONIX(product *onix.Product) (*Book, []string, error) {
+	if product.NotificationType == onix.NotificationDelete {
+		return nil, nil, ErrONIXDelete
+	}
+	// Product forms starting with B are printed books and E digital ones, audio, video and the rest are not books
+	form := strings.TrimSpace(product.ProductForm)
+	if form != "" && ONIXFormat(form) == "" {
+		return nil, nil, fmt.Errorf("%w: product form is %s", ErrNotABook, form)
+	}
+	book := &Book{
+		ISBN:      product.ISBN(),
+		Title:     product.Title(),
+		Author:    product.Author(),
+		Publisher: product.PublisherName(),
+		Genre:     product.SubjectHeading(),
+		Pages:     product.PageCount(),
+	}
+	var warnings []string
+	if form == "" {
+		warnings = append(warnings, "product form is not given, the product is taken to be a book")
+	}
+	if code := product.LanguageCode(); code != "" {
+		if book.Language = languageName(code); book.Language == "" {
+			book.Language = code
+			warnings = append(warnings, fmt.Sprintf("language code %s is not known, it is kept as the code", code))
+		}
+	}
+	published, ok, err := product.PublicationDate()
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+	if ok {
+		book.Published = utils.CustomDate{Time: published}
+	}
+	return book, warnings, nil
+}
+
+// MARC writes the book as a MARC 21 bibliographic record that FromMARC reads back into the same book
+func (b *Book) MARC() *marc.Record {
+	record := &marc.Record{Leader: marc.DefaultLeader}
+	if b.ID != 0 {
+		record.AddControl("001", strconv.Itoa(b.ID))
+	}
+	if !b.UpdatedAt.IsZero() {
+		record.AddControl("005", b.UpdatedAt.UTC().Format("20060102150405")+".0")
+	}
+	code := languageCode(b.Language)
+	// Fixed length data: date entered, a single known date, no place and the language
+	entered := "      "
+	if !b.CreatedAt.IsZero() {
+		entered = b.CreatedAt.UTC().Format("060102")
+	}
+	year := "    "
+	if !b.Published.IsZero() {
+		year = b.Published.Format("2006")
+	}
+	fixedCode := code
+	if fixedCode == "" {
+		fixedCode = "und"
+	}
+	record.AddControl("008", entered+"s"+year+"    xx "+strings.Repeat(" ", 17)+fixedCode+" d")
+	record.AddData("020", ' ', ' ', "a", b.ISBN)
+	record.AddData("041", '0', ' ', "a", code)
+	if !b.Published.IsZero() {
+		record.AddData("046", ' ', ' ', "k", b.Published.Format("20060102"))
+	}
+	record.AddData("100", '1', ' ', "a", b.Author)
+	record.AddData("245", '1', '0', "a", b.Title)
+	record.AddData("264", ' ', '1', "b", b.Publisher, "c", strings.TrimSpace(year))
+	if b.Pages != 0 {
+		record.AddData("300", ' ', ' ', "a", fmt.Sprintf("%d pages", b.Pages))
+	}
+	// A language without a code is written out in a language note instead
+	if code == "" {
+		record.AddData("546", ' ', ' ', "a", b.Language)
+	}
+	record.AddData("655", ' ', '4', "a", b.Genre)
+	return record
+}
+
+// Check the tests of a JSON Patch against the stored book
+func (p *Patch) CheckTests(current *Book) error {
+	for field, expected := range p.Tests {
+		if current.auditValue(field) != expected {
+			return fmt.Errorf("%w: %s", ErrPatchTestFailed, field)
+		}
+	}
 	return nil
 }
 
+// Updates holds the new value of every field that was sent, keyed by column
+func (p *Patch) Updates() map[string]interface{} {
+	updates := map[string]interface{}{}
+	for field := range p.Fields {
+		switch field {
+		case "published":
+			updates[field] = p.Values.Published.Time
+		default:
+			updates[field] = p.Values.auditValue(field)
+		}
+	}
+	return updates
+}
+
+// ETag for a version of a book, availability comes from the copies and is not part of it
+func ETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ParseETag reads the version back out of an ETag, only strong tags made by ETag are accepted
+func ParseETag(etag string) (int, error) {
+	etag = strings.TrimSpace(etag)
+	if len(etag) < 3 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, ErrVersionConflict
+	}
+	version, err := strconv.Atoi(etag[1 : len(etag)-1])
+	if err != nil || version < 1 {
+		return 0, ErrVersionConflict
+	}
+	return version, nil
+}
+
+// NormalizeISBN accepts an ISBN-10 or ISBN-13 with or without hyphens and spaces,
+// verifies its check digit and returns the bare 13 digit form
+func NormalizeISBN(isbn string) (string, error) {
+	digits := strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn))
+	switch len(digits) {
+	case 10:
+		// ISBN-10 check digits are weighted 10 down to 1 and may be an X standing for 10
+		sum := 0
+		for i, r := range digits {
+			var value int
+			switch {
+			case r >= '0' && r <= '9':
+				value = int(r - '0')
+			case (r == 'X' || r == 'x') && i == 9:
+				value = 10
+			default:
+				return "", ErrInvalidISBN
+			}
+			sum += value * (10 - i)
+		}
+		if sum%11 != 0 {
+			return "", ErrInvalidISBN
+		}
+		converted := "978" + digits[:9]
+		return converted + isbn13CheckDigit(converted), nil
+	case 13:
+		for _, r := range digits {
+			if r < '0' || r > '9' {
+				return "", ErrInvalidISBN
+			}
+		}
+		if !strings.HasPrefix(digits, "978") && !strings.HasPrefix(digits, "979") {
+			return "", ErrInvalidISBN
+		}
+		if isbn13CheckDigit(digits[:12]) != digits[12:] {
+			return "", ErrInvalidISBN
+		}
+		return digits, nil
+	default:
+		return "", ErrInvalidISBN
+	}
+}
+
 // Internal helper funcs for methods
+// Validator with the isbn tag replaced by a full check digit test
+func newValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterValidation("isbn", func(fl validator.FieldLevel) bool {
+		_, err := NormalizeISBN(fl.Field().String())
+		return err == nil
+	})
+	return validate
+}
+
+func (b *Book) changes(update *Book, sent func(audit.Change) bool) audit.Changes {
+	changes := audit.Changes{}
+	current := b.auditFields()
+	for i, field := range update.auditFields() {
+		if !sent(field) || field.New == current[i].New {
+			continue
+		}
+		changes = append(changes, audit.Change{Field: field.Field, Old: current[i].New, New: field.New})
+	}
+	return changes
+}
+
+func (b *Book) auditValue(field string) interface{} {
+	for _, f := range b.auditFields() {
+		if f.Field == field {
+			return f.New
+		}
+	}
+	return nil
+}
+
+func newPatch(bookID int) *Patch {
+	return &Patch{
+		ID:     bookID,
+		Values: Book{ID: bookID},
+		Fields: map[string]bool{},
+		Tests:  map[string]interface{}{},
+	}
+}
+
+// Record a field as sent, null leaves it at its zero value
+func (p *Patch) set(field string, value json.RawMessage) error {
+	if _, ok := patchableFields[field]; !ok {
+		return fmt.Errorf("%w: %s cannot be patched", ErrInvalidPatch, field)
+	}
+	var cleared Book
+	p.Values.setField(field, &cleared)
+	if string(value) != "null" {
+		if err := decodeField(&p.Values, field, value); err != nil {
+			return err
+		}
+	}
+	p.Fields[field] = true
+	return nil
+}
+
+// Copy one field across from another book
+func (b *Book) setField(field string, from *Book) {
+	switch field {
+	case "isbn":
+		b.ISBN = from.ISBN
+	case "title":
+		b.Title = from.Title
+	case "author":
+		b.Author = from.Author
+	case "publisher":
+		b.Publisher = from.Publisher
+	case "published":
+		b.Published = from.Published
+	case "genre":
+		b.Genre = from.Genre
+	case "language":
+		b.Language = from.Language
+	case "pages":
+		b.Pages = from.Pages
+	}
+}
+
+// Decode the json value of one patchable field into the book
+func decodeField(b *Book, field string, value json.RawMessage) error {
+	if _, ok := patchableFields[field]; !ok {
+		return fmt.Errorf("%w: %s cannot be patched", ErrInvalidPatch, field)
+	}
+	if err := json.Unmarshal([]byte(fmt.Sprintf("{%q:%s}", field, value)), b); err != nil {
+		return fmt.Errorf("%w: value for %s has the wrong type", ErrInvalidPatch, field)
+	}
+	return nil
+}
+
+// Dates are read with the layout that was asked for, or in either of the forms a CustomDate is sent and stored in
+func (o *ImportOptions) parseDate(value string) (time.Time, error) {
+	if o.DateFormat != "" {
+		date, err := time.Parse(o.DateFormat, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("published %q does not match the date format %s", value, o.DateFormat)
+		}
+		return date, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	if timestamp, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(timestamp, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("published %q is not a 2006-01-02 date or a unix timestamp", value)
+}
+
+// Writer for the books of an export, flush has to be called once the last book has been written
+func exportWriter(format ExportFormat, out io.Writer) (func(*Book) error, func() error, error) {
+	switch format {
+	case CSV:
+		writer := csv.NewWriter(out)
+		if err := writer.Write(ExportColumns); err != nil {
+			return nil, nil, err
+		}
+		write := func(book *Book) error {
+			return writer.Write([]string{
+				book.ISBN, book.Title, book.Author, book.Publisher, book.Published.Format("2006-01-02"),
+				book.Genre, book.Language, strconv.Itoa(book.Pages),
+				strconv.Itoa(book.ID), strconv.Itoa(book.Version), string(book.Availability),
+				strconv.Itoa(book.CopiesAvailable), strconv.Itoa(book.CopiesTotal),
+				book.CreatedAt.UTC().Format(time.RFC3339), book.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+		flush := func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+		return write, flush, nil
+	case MARC21, MARCXML:
+		writer := marc.NewWriter(out)
+		if format == MARCXML {
+			writer = marc.NewXMLWriter(out)
+		}
+		write := func(book *Book) error {
+			return writer.Write(book.MARC())
+		}
+		return write, writer.Close, nil
+	case NDJSON:
+		// Encode ends every book with a newline
+		encoder := json.NewEncoder(out)
+		write := func(book *Book) error {
+			return encoder.Encode(book)
+		}
+		return write, func() error { return nil }, nil
+	default:
+		return nil, nil, ErrExportFormat
+	}
+}
+
+// ParseSearch reads a CQL query and checks it only uses the indexes and relations books can be searched with.
+// The index of every clause is replaced with the field it searches and language codes with the names books use
+func ParseSearch(query string) (*cql.Query, error) {
+	parsed, err := cql.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed.SortKeys) > 0 {
+		return nil, ErrCQLSort
+	}
+	if err := checkBooleans(parsed.Root); err != nil {
+		return nil, err
+	}
+	err = cql.Walk(parsed.Root, func(clause *cql.Clause) error {
+		field, ok := searchIndexes[clause.Index]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrCQLIndex, clause.Index)
+		}
+		if !containsString(searchRelations[field], clause.Relation.Comparitor) {
+			return fmt.Errorf("%w: %s %s", ErrCQLRelation, clause.Index, clause.Relation.Comparitor)
+		}
+		if len(clause.Relation.Modifiers) > 0 {
+			return fmt.Errorf("%w: %s", ErrCQLModifier, clause.Relation.Modifiers[0].Name)
+		}
+		if strings.TrimSpace(clause.Term) == "" {
+			return fmt.Errorf("%w: %s needs a term", ErrCQLTerm, clause.Index)
+		}
+		switch field {
+		case SearchPublished:
+			if _, _, err := SearchDateRange(clause.Term); err != nil {
+				return err
+			}
+		case SearchLanguage:
+			words := strings.Fields(clause.Term)
+			for i, word := range words {
+				if name := languageName(word); name != "" {
+					words[i] = name
+				}
+			}
+			clause.Term = strings.Join(words, " ")
+		}
+		clause.Index = field
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// Only and, or and not without modifiers can be used between clauses
+func checkBooleans(node cql.Node) error {
+	boolean, ok := node.(*cql.Boolean)
+	if !ok {
+		return nil
+	}
+	if boolean.Operator == cql.Prox {
+		return fmt.Errorf("%w: %s", ErrCQLBoolean, boolean.Operator)
+	}
+	if len(boolean.Modifiers) > 0 {
+		return fmt.Errorf("%w: %s", ErrCQLModifier, boolean.Modifiers[0].Name)
+	}
+	if err := checkBooleans(boolean.Left); err != nil {
+		return err
+	}
+	return checkBooleans(boolean.Right)
+}
+
+// SearchDateRange reads a date searched for as a year or a day, the range starts on it and ends before the next one
+func SearchDateRange(term string) (time.Time, time.Time, error) {
+	term = strings.TrimSpace(term)
+	if day, err := time.Parse("2006-01-02", term); err == nil {
+		return day, day.AddDate(0, 0, 1), nil
+	}
+	if year, err := time.Parse("2006", term); err == nil {
+		return year, year.AddDate(1, 0, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%w: dates are searched as 2006 or 2006-01-02, not %q", ErrCQLTerm, term)
+}
+
+// ParseFullText reads a full-text query. Words in double quotes are searched as a phrase and a word ending in *
+// matches every word that starts with it. Fields are given as title^3,author with an optional boost, every field
+// is searched with its default boost when there are none
+func ParseFullText(query, fields string) (*FullTextQuery, error) {
+	parsed := &FullTextQuery{Boosts: map[string]float64{}}
+	for rest := strings.TrimSpace(query); rest != ""; rest = strings.TrimSpace(rest) {
+		term := &FullTextTerm{}
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			term.Words = fullTextWords(rest[1 : end+1])
+			if end+2 < len(rest) {
+				rest = rest[end+2:]
+			} else {
+				rest = ""
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t\n\"")
+			if end < 0 {
+				end = len(rest)
+			}
+			word := rest[:end]
+			term.Words = fullTextWords(word)
+			// A word joined by punctuation such as sci-fi is searched as a phrase of its parts
+			term.Prefix = strings.HasSuffix(word, "*") && len(term.Words) == 1
+			rest = rest[end:]
+		}
+		if len(term.Words) > 0 {
+			parsed.Terms = append(parsed.Terms, term)
+		}
+	}
+	if len(parsed.Terms) == 0 {
+		return nil, ErrFullTextQuery
+	}
+	if len(parsed.Terms) > MaxFullTextTerms {
+		return nil, fmt.Errorf("%w: at most %d terms can be searched for", ErrFullTextQuery, MaxFullTextTerms)
+	}
+	if strings.TrimSpace(fields) == "" {
+		fields = strings.Join(FullTextFields, ",")
+	}
+	for _, field := range strings.Split(fields, ",") {
+		name, boostStr, boosted := strings.Cut(strings.TrimSpace(field), "^")
+		name = strings.ToLower(name)
+		boost, ok := fullTextBoosts[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrFullTextField, name)
+		}
+		if boosted {
+			value, err := strconv.ParseFloat(boostStr, 64)
+			if err != nil || value <= 0 || value > MaxFullTextBoost {
+				return nil, fmt.Errorf("%w: boost of %s must be a number above 0 and up to %g",
+					ErrFullTextField, name, float64(MaxFullTextBoost))
+			}
+			boost = value
+		}
+		if _, seen := parsed.Boosts[name]; !seen {
+			parsed.Fields = append(parsed.Fields, name)
+		}
+		parsed.Boosts[name] = boost
+	}
+	return parsed, nil
+}
+
+// BooleanMode writes the query for MySQL full-text search in boolean mode. Any term can match, the score ranks
+// the books that match more of them higher
+func (q *FullTextQuery) BooleanMode() string {
+	terms := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		switch {
+		case len(term.Words) > 1:
+			terms[i] = `"` + strings.Join(term.Words, " ") + `"`
+		case term.Prefix:
+			terms[i] = term.Words[0] + "*"
+		default:
+			terms[i] = term.Words[0]
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// AllFields reports whether every field is searched, so the index over all of them can be used
+func (q *FullTextQuery) AllFields() bool {
+	return len(q.Fields) == len(FullTextFields)
+}
+
+// Score ranks a book for backends without full-text search. Every time a term is found in a field counts for the
+// boost of the field, so a match in a short title weighs as much as one in a long one
+func (q *FullTextQuery) Score(book *Book) float64 {
+	var score float64
+	for _, field := range q.Fields {
+		matches := q.matches(textField(book, field))
+		score += q.Boosts[field] * float64(len(matches))
+	}
+	return score
+}
+
+// Highlight marks the terms found in each field of the book, keyed by field. Long values are cut down to a snippet
+// around the first match and fields without a match are left out
+func (q *FullTextQuery) Highlight(book *Book) map[string]string {
+	highlights := map[string]string{}
+	for _, field := range q.Fields {
+		value := textField(book, field)
+		matches := q.matches(value)
+		if len(matches) == 0 {
+			continue
+		}
+		start, end := 0, len(value)
+		if end-start > SnippetLength {
+			start = snippetStart(value, matches[0][0])
+			end = snippetEnd(value, start)
+		}
+		var snippet strings.Builder
+		if start > 0 {
+			snippet.WriteString("…")
+		}
+		at := start
+		for _, match := range matches {
+			if match[0] < at || match[1] > end {
+				continue
+			}
+			snippet.WriteString(html.EscapeString(value[at:match[0]]))
+			snippet.WriteString(HighlightStart + html.EscapeString(value[match[0]:match[1]]) + HighlightEnd)
+			at = match[1]
+		}
+		snippet.WriteString(html.EscapeString(value[at:end]))
+		if end < len(value) {
+			snippet.WriteString("…")
+		}
+		highlights[field] = snippet.String()
+	}
+	return highlights
+}
+
+// Byte offsets of the start and end of every term found in the value, in order and without overlaps
+func (q *FullTextQuery) matches(value string) [][2]int {
+	words := wordSpans(value)
+	var matches [][2]int
+	for i := 0; i < len(words); {
+		matched := 0
+		for _, term := range q.Terms {
+			if term.matchAt(value, words[i:]) {
+				matched = len(term.Words)
+				break
+			}
+		}
+		if matched == 0 {
+			i++
+			continue
+		}
+		matches = append(matches, [2]int{words[i][0], words[i+matched-1][1]})
+		i += matched
+	}
+	return matches
+}
+
+// Whether the term starts at the first of the words
+func (t *FullTextTerm) matchAt(value string, words [][2]int) bool {
+	if len(words) < len(t.Words) {
+		return false
+	}
+	for i, word := range t.Words {
+		found := strings.ToLower(value[words[i][0]:words[i][1]])
+		if t.Prefix && i == len(t.Words)-1 {
+			if !strings.HasPrefix(found, word) {
+				return false
+			}
+		} else if found != word {
+			return false
+		}
+	}
+	return true
+}
+
+// Lower case words of the text, anything that is not a letter or a digit separates them
+func fullTextWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Byte offsets of the words of the value, split the same way as the terms
+func wordSpans(value string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range value {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(value)})
+	}
+	return spans
+}
+
+// A snippet starts a little before the match, on the start of a word
+func snippetStart(value string, match int) int {
+	start := match - SnippetLength/4
+	if start <= 0 {
+		return 0
+	}
+	if space := strings.IndexByte(value[start:match], ' '); space >= 0 {
+		return start + space + 1
+	}
+	return match
+}
+
+// and ends after the last whole word that fits
+func snippetEnd(value string, start int) int {
+	end := start + SnippetLength
+	if end >= len(value) {
+		return len(value)
+	}
+	if space := strings.LastIndexByte(value[start:end], ' '); space > 0 {
+		return start + space
+	}
+	for !utf8.RuneStart(value[end]) {
+		end--
+	}
+	return end
+}
+
+// Name books use for a MARC language code, empty when the code is not known
+func languageName(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	for _, language := range marcLanguages {
+		if containsString(language.codes, code) {
+			return language.name
+		}
+	}
+	return ""
+}
+
+// MARC code of a language, empty when there is none for it
+func languageCode(name string) string {
+	for _, language := range marcLanguages {
+		if strings.EqualFold(language.name, strings.TrimSpace(name)) {
+			return language.codes[0]
+		}
+	}
+	return ""
+}
+
+// Drop the punctuation cataloguers put between the parts of a MARC field
+func trimISBD(value string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(value), " /:;,=."))
+}
+
+// First run of digits in the value, with at least min of them when min is set
+func firstDigits(value string, min int) string {
+	start := strings.IndexFunc(value, func(r rune) bool { return r >= '0' && r <= '9' })
+	if start < 0 {
+		return ""
+	}
+	end := start
+	for end < len(value) && value[end] >= '0' && value[end] <= '9' {
+		end++
+	}
+	if end-start < min {
+		return ""
+	}
+	if min > 0 {
+		return value[start : start+min]
+	}
+	return value[start:end]
+}
+
+func containsFacet(facets []Facet, facet Facet) bool {
+	for _, f := range facets {
+		if f == facet {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// The fields of a book tracked in the audit trail, with their values held in New
+func (b *Book) auditFields() []audit.Change {
+	var published string
+	if !b.Published.IsZero() {
+		published = b.Published.Format("2006-01-02")
+	}
+	return []audit.Change{
+		{Field: "isbn", New: b.ISBN},
+		{Field: "title", New: b.Title},
+		{Field: "author", New: b.Author},
+		{Field: "publisher", New: b.Publisher},
+		{Field: "published", New: published},
+		{Field: "genre", New: b.Genre},
+		{Field: "language", New: b.Language},
+		{Field: "pages", New: b.Pages},
+	}
+}
+
+// The first twelve digits of an ISBN-13 are weighted 1 and 3 in turn
+func isbn13CheckDigit(first12 string) string {
+	sum := 0
+	for i, r := range first12 {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(r-'0') * weight
+	}
+	return strconv.Itoa((10 - sum%10) % 10)
+}
+
 func validationErrMessage(errs validator.ValidationErrors) (error, string) {
 	for _, err := range errs {
 		var errMessage string
@@ -90,8 +1827,8 @@ func validationErrMessage(errs validator.ValidationErrors) (error, string) {
 			errMessage = fmt.Sprintf("%s field is too short", strings.ToLower(err.Field()))
 		case "max":
 			errMessage = fmt.Sprintf("%s field is too long", strings.ToLower(err.Field()))
-		case "eq=available|eq=not_available":
-			errMessage = fmt.Sprintf("value for %s is not recognised, please use available or not_available", strings.ToLower(err.Field()))
+		case "isbn":
+			errMessage = ErrInvalidISBN.Error()
 		default:
 			errMessage = fmt.Sprintf("value for %s is not recognized", strings.ToLower(err.Field()))
 		}
@@ -2,11 +2,36 @@ package books
 
 import (
 	"context"
+	"time"
 )
 
 type Repository interface {
 	InsertBooks(ctx context.Context, newBooks []*Book) error
+	// Inserts the books one at a time and returns an error for each one that could not be inserted,
+	// with atomic set nothing is kept unless every book was inserted
+	InsertBooksEach(ctx context.Context, newBooks []*Book, atomic bool) ([]error, error)
+	// Returns the books and how many match the params in all, ignoring paging. The total is -1 when a page is
+	// asked for with SkipTotal
 	GetBooks(ctx context.Context, params *GetBooksParams) ([]*Book, int, error)
+	// Hands the books to each one at a time as they are read, without holding them all in memory
+	StreamBooks(ctx context.Context, params *GetBooksParams, each func(*Book) error) error
+	// Number of books that match the params, paging is ignored
+	CountBooks(ctx context.Context, params *GetBooksParams) (int, error)
+	// Buckets of each facet over the books that match the params, leaving out the facet's own filter
+	GetFacets(ctx context.Context, params *GetBooksParams, facets []Facet) (map[Facet][]*FacetBucket, error)
+	// Every language the live books are written in, in alphabetical order
+	GetLanguages(ctx context.Context) ([]*LanguageCount, error)
+	// Live books ranked by a full-text search, returns ErrFullTextUnsupported when the store has no full-text index
+	FullTextSearch(ctx context.Context, params *FullTextParams) ([]*SearchHit, int, error)
 	UpdateBook(ctx context.Context, arg *Book) error
+	// Applies only the fields that were sent in one transaction and returns the book as stored
+	PatchBook(ctx context.Context, patch *Patch) (*Book, error)
+	// Patches every selected book in one transaction, on a dry run nothing is written
+	UpdateBooksBulk(ctx context.Context, update *BulkUpdate) ([]*BulkResult, error)
 	DeleteBookByID(ctx context.Context, id int) error
+	// Moves every selected book to the trash in one transaction, on a dry run nothing is written
+	DeleteBooksBulk(ctx context.Context, selection *BulkSelection) ([]*BulkResult, error)
+	RestoreBookByID(ctx context.Context, id int) error
+	// Hard delete the books that were moved to the trash before the cutoff
+	PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time) (int, error)
 }
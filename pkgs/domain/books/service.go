@@ -1,12 +1,41 @@
 package books
 
-import "context"
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/cql"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/marc"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/onix"
+)
 
 type Service interface {
 	CreateBooks(ctx context.Context, newBooks []*Book) error
+	CreateBooksBulk(ctx context.Context, newBooks []*Book, mode BulkMode) ([]*BulkResult, error)
+	ImportBooks(ctx context.Context, source io.Reader, options *ImportOptions) (*ImportReport, error)
+	ImportMARC(ctx context.Context, source io.Reader, format ExportFormat) (*ImportReport, error)
+	IngestONIX(ctx context.Context, source io.Reader) (*IngestReport, error)
 	UpdateBook(ctx context.Context, updatedBook *Book) error
+	PatchBook(ctx context.Context, patch *Patch) (*Book, error)
+	UpdateBooksBulk(ctx context.Context, update *BulkUpdate) ([]*BulkResult, error)
 	GetBooks(ctx context.Context, params *GetBooksParams) ([]*Book, int, error)
+	GetBooksPage(ctx context.Context, params *GetBooksParams) (*BooksPage, error)
+	GetBooksByCursor(ctx context.Context, params *GetBooksParams) (*CursorPage, error)
+	GetFacets(ctx context.Context, params *GetBooksParams, facets []Facet) (map[Facet][]*FacetBucket, error)
+	ExportBooks(ctx context.Context, params *GetBooksParams, format ExportFormat, out io.Writer) (int, error)
+	GetLanguages(ctx context.Context) ([]*LanguageCount, error)
+	SearchBooks(ctx context.Context, query string, offset, limit int) ([]*Book, int, error)
+	FullTextSearch(ctx context.Context, params *FullTextParams) ([]*SearchHit, int, error)
 	DeleteBookByID(ctx context.Context, id int) error
+	DeleteBooksBulk(ctx context.Context, selection *BulkSelection) ([]*BulkResult, error)
+	RestoreBookByID(ctx context.Context, id int) error
+	PurgeDeletedBooks(ctx context.Context, retention time.Duration) (int, error)
 }
 
 type service struct {
@@ -28,19 +57,590 @@ func (s *service) CreateBooks(ctx context.Context, newBooks []*Book) error {
 	return s.repo.InsertBooks(ctx, newBooks)
 }
 
+// CreateBooksBulk implements Service.
+// Every book is validated first, in all or nothing mode the database is not touched if any book is invalid
+func (s *service) CreateBooksBulk(ctx context.Context, newBooks []*Book, mode BulkMode) ([]*BulkResult, error) {
+	results := make([]*BulkResult, len(newBooks))
+	var valid []*Book
+	var validIndexes []int
+	for i, book := range newBooks {
+		results[i] = &BulkResult{Index: i}
+		if err := book.ValidateCreateBook(); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		valid = append(valid, book)
+		validIndexes = append(validIndexes, i)
+	}
+	if mode == AllOrNothing && len(valid) != len(newBooks) {
+		for _, i := range validIndexes {
+			results[i].Error = ErrBulkRolledBack.Error()
+		}
+		return results, nil
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+	itemErrs, err := s.repo.InsertBooksEach(ctx, valid, mode == AllOrNothing)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range validIndexes {
+		if itemErrs[j] != nil {
+			results[i].Error = itemErrs[j].Error()
+			continue
+		}
+		results[i].ID = valid[j].ID
+	}
+	return results, nil
+}
+
+// ImportBooks implements Service.
+// The CSV is read a row at a time and inserted in batches, so a file never has to fit in memory
+func (s *service) ImportBooks(ctx context.Context, source io.Reader, options *ImportOptions) (*ImportReport, error) {
+	reader := csv.NewReader(source)
+	// Rows with a missing or extra column are rejected one by one rather than ending the import
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: the file is empty", ErrImportColumns)
+	}
+	var headerErr *csv.ParseError
+	if errors.As(err, &headerErr) {
+		return nil, fmt.Errorf("%w: %v", ErrImportColumns, headerErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns, err := options.ColumnIndexes(header)
+	if err != nil {
+		return nil, err
+	}
+	imports := s.newImporter(ctx)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			imports.report.Rows++
+			imports.reject(parseErr.StartLine, parseErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		imports.report.Rows++
+		line, _ := reader.FieldPos(0)
+		book, err := options.BookFromRow(row, columns)
+		if err != nil {
+			imports.reject(line, err.Error())
+			continue
+		}
+		if err := imports.add(line, book); err != nil {
+			return nil, err
+		}
+	}
+	return imports.finish()
+}
+
+// ImportMARC implements Service.
+// Records are numbered from 1 in the report and read one at a time like the rows of a CSV import
+func (s *service) ImportMARC(ctx context.Context, source io.Reader, format ExportFormat) (*ImportReport, error) {
+	var reader marc.Reader
+	switch format {
+	case MARC21:
+		reader = marc.NewReader(source)
+	case MARCXML:
+		reader = marc.NewXMLReader(source)
+	default:
+		return nil, ErrExportFormat
+	}
+	imports := s.newImporter(ctx)
+	for number := 1; ; number++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, marc.ErrMalformedRecord) {
+			imports.report.Rows++
+			imports.reject(number, err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		imports.report.Rows++
+		book, warnings := FromMARC(record)
+		for _, warning := range warnings {
+			imports.report.Warnings = append(imports.report.Warnings, &ImportWarning{Record: number, Reason: warning})
+		}
+		if err := imports.add(number, book); err != nil {
+			return nil, err
+		}
+	}
+	return imports.finish()
+}
+
+// Validates the books of an import and inserts them in batches, keeping the report as it goes
+type importer struct {
+	service *service
+	ctx     context.Context
+	report  *ImportReport
+	// Line each isbn was first seen on, a file that lists a book twice only gets it once
+	seen  map[string]int
+	batch []*Book
+	lines []int
+}
+
+func (s *service) newImporter(ctx context.Context) *importer {
+	return &importer{
+		service: s,
+		ctx:     ctx,
+		report:  &ImportReport{Rejected: []*RejectedRow{}},
+		seen:    map[string]int{},
+	}
+}
+
+func (i *importer) reject(line int, reason string) {
+	i.report.Rejected = append(i.report.Rejected, &RejectedRow{Line: line, Reason: reason})
+}
+
+// Validate the book read from a line and insert the batch once it is full
+func (i *importer) add(line int, book *Book) error {
+	if err := book.ValidateCreateBook(); err != nil {
+		i.reject(line, err.Error())
+		return nil
+	}
+	if first, ok := i.seen[book.ISBN]; ok {
+		i.reject(line, fmt.Sprintf("isbn %s is already on line %d", book.ISBN, first))
+		return nil
+	}
+	i.seen[book.ISBN] = line
+	i.batch = append(i.batch, book)
+	i.lines = append(i.lines, line)
+	if len(i.batch) < ImportBatchSize {
+		return nil
+	}
+	return i.flush()
+}
+
+// Insert the batch, a batch that fails as a whole is retried book by book so only the bad rows are rejected
+func (i *importer) flush() error {
+	if len(i.batch) == 0 {
+		return nil
+	}
+	batch, lines := i.batch, i.lines
+	i.batch, i.lines = nil, nil
+	if err := i.service.repo.InsertBooks(i.ctx, batch); err == nil {
+		i.report.Imported += len(batch)
+		return nil
+	}
+	itemErrs, err := i.service.repo.InsertBooksEach(i.ctx, batch, false)
+	if err != nil {
+		return err
+	}
+	for j, itemErr := range itemErrs {
+		if itemErr != nil {
+			i.reject(lines[j], itemErr.Error())
+			continue
+		}
+		i.report.Imported++
+	}
+	return nil
+}
+
+// Insert what is left and put the rejections in line order
+func (i *importer) finish() (*ImportReport, error) {
+	if err := i.flush(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(i.report.Rejected, func(a, b int) bool {
+		return i.report.Rejected[a].Line < i.report.Rejected[b].Line
+	})
+	return i.report, nil
+}
+
+// IngestONIX implements Service.
+// Products are matched to books by isbn, a book that is already catalogued is updated with the fields the
+// product gives and a new one is inserted. New books are inserted in batches like the rows of an import
+func (s *service) IngestONIX(ctx context.Context, source io.Reader) (*IngestReport, error) {
+	reader := onix.NewReader(source)
+	ingest := &ingester{service: s, ctx: ctx, report: &IngestReport{Results: []*IngestResult{}}, pending: map[string]bool{}}
+	for number := 1; ; number++ {
+		product, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, onix.ErrMalformedProduct) {
+			ingest.skip(&IngestResult{Product: number}, err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result := &IngestResult{Product: number, Reference: product.RecordReference, ISBN: product.ISBN(),
+			ProductForm: strings.TrimSpace(product.ProductForm), Format: ONIXFormat(product.ProductForm)}
+		if err := ingest.add(result, product); err != nil {
+			return nil, err
+		}
+	}
+	if err := ingest.flush(); err != nil {
+		return nil, err
+	}
+	// Inserted products are only recorded once their batch is in, put them back in the order of the feed
+	sort.SliceStable(ingest.report.Results, func(a, b int) bool {
+		return ingest.report.Results[a].Product < ingest.report.Results[b].Product
+	})
+	return ingest.report, nil
+}
+
+// Works out what to do with each product of an ONIX feed, keeping the report as it goes
+type ingester struct {
+	service *service
+	ctx     context.Context
+	report  *IngestReport
+	// Books waiting to be inserted and the isbns among them
+	batch   []*Book
+	results []*IngestResult
+	pending map[string]bool
+}
+
+func (i *ingester) skip(result *IngestResult, reason string) {
+	result.Outcome, result.Reason = Skipped, reason
+	i.record(result)
+}
+
+func (i *ingester) record(result *IngestResult) {
+	i.report.Products++
+	switch result.Outcome {
+	case Inserted:
+		i.report.Inserted++
+	case Updated:
+		i.report.Updated++
+	case Skipped:
+		i.report.Skipped++
+	}
+	i.report.Results = append(i.report.Results, result)
+}
+
+// Update the book catalogued under the product's isbn, or queue a new one for insertion
+func (i *ingester) add(result *IngestResult, product *onix.Product) error {
+	book, warnings, err := FromONIX(product)
+	if err != nil {
+		i.skip(result, err.Error())
+		return nil
+	}
+	result.Warnings = warnings
+	// Only the fields a product gives are changed on a stored book, so just the rules other than required apply
+	if err := book.ValidateUpdateBook(); err != nil {
+		i.skip(result, err.Error())
+		return nil
+	}
+	if book.ISBN == "" {
+		i.skip(result, "product has no isbn")
+		return nil
+	}
+	result.ISBN = book.ISBN
+	// A feed may carry a later record of a product that is still waiting to be inserted
+	if i.pending[book.ISBN] {
+		if err := i.flush(); err != nil {
+			return err
+		}
+	}
+	existing, _, err := i.service.repo.GetBooks(i.ctx, &GetBooksParams{ISBN: book.ISBN})
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return i.update(result, existing[0], book)
+	}
+	trashed, _, err := i.service.repo.GetBooks(i.ctx, &GetBooksParams{ISBN: book.ISBN, Deleted: true})
+	if err != nil {
+		return err
+	}
+	if len(trashed) > 0 {
+		result.ID = trashed[0].ID
+		i.skip(result, ErrBookDeleted.Error())
+		return nil
+	}
+	if err := book.ValidateCreateBook(); err != nil {
+		i.skip(result, err.Error())
+		return nil
+	}
+	i.batch = append(i.batch, book)
+	i.results = append(i.results, result)
+	i.pending[book.ISBN] = true
+	if len(i.batch) < ImportBatchSize {
+		return nil
+	}
+	return i.flush()
+}
+
+func (i *ingester) update(result *IngestResult, existing *Book, book *Book) error {
+	book.ID, book.Version = existing.ID, existing.Version
+	result.ID = existing.ID
+	changes := existing.Changes(book)
+	if len(changes) == 0 {
+		i.skip(result, "book is already up to date")
+		return nil
+	}
+	err := i.service.repo.UpdateBook(i.ctx, book)
+	if errors.Is(err, ErrVersionConflict) || errors.Is(err, ErrBookDeleted) {
+		i.skip(result, err.Error())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	result.Outcome, result.Changes = Updated, changes
+	i.record(result)
+	return nil
+}
+
+// Insert the queued books, a batch that fails as a whole is retried book by book so only the bad ones are skipped
+func (i *ingester) flush() error {
+	if len(i.batch) == 0 {
+		return nil
+	}
+	batch, results := i.batch, i.results
+	i.batch, i.results, i.pending = nil, nil, map[string]bool{}
+	itemErrs := make([]error, len(batch))
+	if err := i.service.repo.InsertBooks(i.ctx, batch); err != nil {
+		if itemErrs, err = i.service.repo.InsertBooksEach(i.ctx, batch, false); err != nil {
+			return err
+		}
+	}
+	for j, result := range results {
+		if itemErrs[j] != nil {
+			i.skip(result, itemErrs[j].Error())
+			continue
+		}
+		result.ID, result.Outcome = batch[j].ID, Inserted
+		i.record(result)
+	}
+	return nil
+}
+
 // GetBooks implements Service.
 func (s *service) GetBooks(ctx context.Context, params *GetBooksParams) ([]*Book, int, error) {
 	// All entity agnostic business logic to do with getting books
 	return s.repo.GetBooks(ctx, params)
 }
 
+// GetBooksPage implements Service.
+// One book more than the page is read, so whether there is a next page is known even when the books are not counted
+func (s *service) GetBooksPage(ctx context.Context, params *GetBooksParams) (*BooksPage, error) {
+	page := &BooksPage{Page: params.Page, PerPage: params.PerPage}
+	if page.Page < 1 {
+		page.Page = 1
+	}
+	query := *params
+	if query.PerPage > 0 {
+		if query.Offset <= 0 {
+			query.Offset = (page.Page - 1) * query.PerPage
+		}
+		query.Page = 0
+		query.PerPage++
+	}
+	found, total, err := s.repo.GetBooks(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	if params.PerPage > 0 && len(found) > params.PerPage {
+		found, page.HasNext = found[:params.PerPage], true
+	}
+	page.Books, page.Total = found, total
+	return page, nil
+}
+
+// GetBooksByCursor implements Service.
+// One book more than the page is read to tell whether there is another page past it
+func (s *service) GetBooksByCursor(ctx context.Context, params *GetBooksParams) (*CursorPage, error) {
+	query := *params
+	query.Page, query.Offset = 0, 0
+	if query.PerPage > 0 {
+		query.PerPage++
+	}
+	found, total, err := s.repo.GetBooks(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	backward := params.Cursor != nil && params.Cursor.Backward
+	more := params.PerPage > 0 && len(found) > params.PerPage
+	// Books before a cursor come back in order too, the extra book is the first one
+	if more && backward {
+		found = found[1:]
+	} else if more {
+		found = found[:params.PerPage]
+	}
+	page := &CursorPage{Books: found, Total: total}
+	if len(found) == 0 {
+		return page, nil
+	}
+	keys := params.Order()
+	if more || backward {
+		page.Next = CursorAt(found[len(found)-1], keys, false)
+	}
+	if (more && backward) || (params.Cursor != nil && !backward) {
+		page.Prev = CursorAt(found[0], keys, true)
+	}
+	return page, nil
+}
+
+// GetFacets implements Service.
+func (s *service) GetFacets(ctx context.Context, params *GetBooksParams,
+	facets []Facet) (map[Facet][]*FacetBucket, error) {
+	if len(facets) == 0 {
+		return map[Facet][]*FacetBucket{}, nil
+	}
+	return s.repo.GetFacets(ctx, params, facets)
+}
+
+// SearchBooks implements Service.
+// The query is CQL, see ParseSearch. Returns a page of the books that match and how many match in all
+func (s *service) SearchBooks(ctx context.Context, query string, offset, limit int) ([]*Book, int, error) {
+	search, err := ParseSearch(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountBooks(ctx, &GetBooksParams{Search: search})
+	if err != nil {
+		return nil, 0, err
+	}
+	// Asking for no records only counts them
+	if limit == 0 || offset >= total {
+		return []*Book{}, total, nil
+	}
+	found, _, err := s.repo.GetBooks(ctx, &GetBooksParams{Search: search, Offset: offset, PerPage: limit, SkipTotal: true})
+	if err != nil {
+		return nil, 0, err
+	}
+	return found, total, nil
+}
+
+// FullTextSearch implements Service.
+// Books are ranked by the repository when it can, otherwise every book with one of the words is ranked in process
+func (s *service) FullTextSearch(ctx context.Context, params *FullTextParams) ([]*SearchHit, int, error) {
+	hits, total, err := s.repo.FullTextSearch(ctx, params)
+	if errors.Is(err, ErrFullTextUnsupported) {
+		hits, total, err = s.rankBooks(ctx, params)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, hit := range hits {
+		hit.Highlights = params.Query.Highlight(hit.Book)
+	}
+	return hits, total, nil
+}
+
+// Rank the books that contain any of the words of the query and return the page asked for
+func (s *service) rankBooks(ctx context.Context, params *FullTextParams) ([]*SearchHit, int, error) {
+	// The candidates are found with a CQL search for any of the words in any of the fields
+	var candidates cql.Node
+	for _, field := range params.Query.Fields {
+		for _, term := range params.Query.Terms {
+			for _, word := range term.Words {
+				clause := &cql.Clause{Index: field, Relation: &cql.Relation{Comparitor: "="}, Term: word}
+				if candidates == nil {
+					candidates = clause
+				} else {
+					candidates = &cql.Boolean{Operator: cql.Or, Left: candidates, Right: clause}
+				}
+			}
+		}
+	}
+	var hits []*SearchHit
+	err := s.repo.StreamBooks(ctx, &GetBooksParams{Search: &cql.Query{Root: candidates}}, func(book *Book) error {
+		if score := params.Query.Score(book); score > 0 {
+			hits = append(hits, &SearchHit{Book: book, Score: score})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Book.ID < hits[j].Book.ID
+	})
+	total := len(hits)
+	if params.PerPage > 0 {
+		start, end := 0, params.PerPage
+		if params.Page > 1 {
+			start, end = (params.Page-1)*params.PerPage, params.Page*params.PerPage
+		}
+		if start > total {
+			start = total
+		}
+		if end > total {
+			end = total
+		}
+		hits = hits[start:end]
+	}
+	return hits, total, nil
+}
+
+// GetLanguages implements Service.
+func (s *service) GetLanguages(ctx context.Context) ([]*LanguageCount, error) {
+	return s.repo.GetLanguages(ctx)
+}
+
+// ExportBooks implements Service.
+// Books are written to out as they are read from the database and the number written is returned
+func (s *service) ExportBooks(ctx context.Context, params *GetBooksParams, format ExportFormat,
+	out io.Writer) (int, error) {
+	write, flush, err := exportWriter(format, out)
+	if err != nil {
+		return 0, err
+	}
+	exported := 0
+	if err := s.repo.StreamBooks(ctx, params, func(book *Book) error {
+		exported++
+		return write(book)
+	}); err != nil {
+		return exported, err
+	}
+	return exported, flush()
+}
+
 // UpdateBook implements Service.
 func (s *service) UpdateBook(ctx context.Context, updatedBook *Book) error {
 	// All entity agnostic business logic to do with updating a book goes here
 	return s.repo.UpdateBook(ctx, updatedBook)
 }
 
+// PatchBook implements Service.
+func (s *service) PatchBook(ctx context.Context, patch *Patch) (*Book, error) {
+	return s.repo.PatchBook(ctx, patch)
+}
+
+// UpdateBooksBulk implements Service.
+func (s *service) UpdateBooksBulk(ctx context.Context, update *BulkUpdate) ([]*BulkResult, error) {
+	return s.repo.UpdateBooksBulk(ctx, update)
+}
+
 func (s *service) DeleteBookByID(ctx context.Context, id int) error {
 	// All entity agnostic business logic to do with updating a book goes here
 	return s.repo.DeleteBookByID(ctx, id)
 }
+
+// DeleteBooksBulk implements Service.
+func (s *service) DeleteBooksBulk(ctx context.Context, selection *BulkSelection) ([]*BulkResult, error) {
+	return s.repo.DeleteBooksBulk(ctx, selection)
+}
+
+// RestoreBookByID implements Service.
+func (s *service) RestoreBookByID(ctx context.Context, id int) error {
+	return s.repo.RestoreBookByID(ctx, id)
+}
+
+// PurgeDeletedBooks implements Service.
+func (s *service) PurgeDeletedBooks(ctx context.Context, retention time.Duration) (int, error) {
+	// Books stay in the trash for the whole retention period before they are gone for good
+	return s.repo.PurgeDeletedBooks(ctx, time.Now().Add(-retention))
+}
@@ -1,10 +1,19 @@
 package books
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/cql"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/marc"
 	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -57,3 +66,1028 @@ func TestCreateBookValidation(t *testing.T) {
 		assertWithTest.Equal(test.ExpectedError, err)
 	}
 }
+
+func TestNormalizeISBN(t *testing.T) {
+	assertWithTest := assert.New(t)
+	testCases := []struct {
+		Input         string
+		Expected      string
+		ExpectedError error
+		Message       string
+	}{
+		{Input: "978-0451524935", Expected: "9780451524935", Message: "Hyphenated ISBN-13"},
+		{Input: "978 0 451 52493 5", Expected: "9780451524935", Message: "Spaced ISBN-13"},
+		{Input: "0451524934", Expected: "9780451524935", Message: "ISBN-10 is converted"},
+		{Input: "0-8044-2957-X", Expected: "9780804429573", Message: "ISBN-10 with an X check digit"},
+		{Input: "978-0451524936", ExpectedError: ErrInvalidISBN, Message: "Wrong ISBN-13 check digit"},
+		{Input: "0451524935", ExpectedError: ErrInvalidISBN, Message: "Wrong ISBN-10 check digit"},
+		{Input: "123-4567890123", ExpectedError: ErrInvalidISBN, Message: "Unknown ISBN-13 prefix"},
+		{Input: "787877", ExpectedError: ErrInvalidISBN, Message: "Too short"},
+	}
+	for _, test := range testCases {
+		normalized, err := NormalizeISBN(test.Input)
+		assertWithTest.Equal(test.ExpectedError, err, test.Message)
+		assertWithTest.Equal(test.Expected, normalized, test.Message)
+	}
+}
+
+func TestISBNValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	book := Book{
+		ISBN:      "0-451-52493-4",
+		Title:     "1984",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     328,
+	}
+	assertWithTest.Nil(book.ValidateCreateBook())
+	assertWithTest.Equal("9780451524935", book.ISBN)
+	book.ISBN = "978-0451524936"
+	assertWithTest.Equal(ErrInvalidISBN, book.ValidateCreateBook())
+	// Updates leave the ISBN out or give a valid one
+	assertWithTest.Nil((&Book{ID: 1}).ValidateUpdateBook())
+	assertWithTest.Equal(ErrInvalidISBN, (&Book{ID: 1, ISBN: "12345"}).ValidateUpdateBook())
+}
+
+func TestBookChanges(t *testing.T) {
+	assertWithTest := assert.New(t)
+	book := Book{
+		ISBN:      "9780451524935",
+		Title:     "1984",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     328,
+	}
+	snapshot := book.Snapshot()
+	assertWithTest.Len(snapshot, 8)
+	assertWithTest.Equal(audit.Change{Field: "published", New: "1980-06-08"}, snapshot[4])
+	// Empty fields in an update are left alone and unchanged values are not recorded
+	changes := book.Changes(&Book{ID: 1, Title: "Nineteen Eighty-Four", Author: "George Orwell", Pages: 330})
+	assertWithTest.Equal(audit.Changes{
+		{Field: "title", Old: "1984", New: "Nineteen Eighty-Four"},
+		{Field: "pages", Old: 328, New: 330},
+	}, changes)
+	assertWithTest.Empty(book.Changes(&Book{ID: 1}))
+}
+
+func TestETag(t *testing.T) {
+	assertWithTest := assert.New(t)
+	assertWithTest.Equal(`"3"`, ETag(3))
+	version, err := ParseETag(` "3" `)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(3, version)
+	for _, etag := range []string{`3`, `W/"3"`, `"abc"`, `"0"`, `""`} {
+		_, err := ParseETag(etag)
+		assertWithTest.Equal(ErrVersionConflict, err, etag)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	assertWithTest := assert.New(t)
+	patch, err := ParseMergePatch(1, []byte(`{"title": "Nineteen Eighty-Four", "pages": 0, "genre": null}`))
+	assertWithTest.Nil(err)
+	assertWithTest.Nil(patch.ValidatePatch())
+	assertWithTest.Equal(map[string]bool{"title": true, "pages": true, "genre": true}, patch.Fields)
+	assertWithTest.Equal(map[string]interface{}{"title": "Nineteen Eighty-Four", "pages": 0, "genre": ""}, patch.Updates())
+
+	current := Book{ID: 1, ISBN: "9780451524935", Title: "1984", Genre: "Dystopian", Pages: 328}
+	assertWithTest.Equal(audit.Changes{
+		{Field: "title", Old: "1984", New: "Nineteen Eighty-Four"},
+		{Field: "genre", Old: "Dystopian", New: ""},
+		{Field: "pages", Old: 328, New: 0},
+	}, current.PatchChanges(patch))
+
+	// Required fields cannot be cleared and only the fields that were sent are validated
+	patch, err = ParseMergePatch(1, []byte(`{"title": null}`))
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(errors.New("title field is required"), patch.ValidatePatch())
+	patch, err = ParseMergePatch(1, []byte(`{"isbn": "0-451-52493-4"}`))
+	assertWithTest.Nil(err)
+	assertWithTest.Nil(patch.ValidatePatch())
+	assertWithTest.Equal("9780451524935", patch.Values.ISBN)
+
+	for _, body := range []string{`[]`, `{"version": 3}`, `{"pages": "many"}`, `null`} {
+		_, err := ParseMergePatch(1, []byte(body))
+		assertWithTest.True(errors.Is(err, ErrInvalidPatch), body)
+	}
+	patch, err = ParseMergePatch(1, []byte(`{}`))
+	assertWithTest.Nil(err)
+	assertWithTest.True(errors.Is(patch.ValidatePatch(), ErrInvalidPatch))
+}
+
+func TestJSONPatch(t *testing.T) {
+	assertWithTest := assert.New(t)
+	patch, err := ParseJSONPatch(1, []byte(`[
+		{"op": "test", "path": "/title", "value": "1984"},
+		{"op": "replace", "path": "/title", "value": "Nineteen Eighty-Four"},
+		{"op": "remove", "path": "/language"}
+	]`))
+	assertWithTest.Nil(err)
+	assertWithTest.Nil(patch.ValidatePatch())
+	assertWithTest.Equal(map[string]interface{}{"title": "Nineteen Eighty-Four", "language": ""}, patch.Updates())
+	assertWithTest.Nil(patch.CheckTests(&Book{Title: "1984"}))
+	assertWithTest.True(errors.Is(patch.CheckTests(&Book{Title: "Animal Farm"}), ErrPatchTestFailed))
+
+	for _, body := range []string{
+		`{"op": "replace"}`,
+		`[{"op": "move", "path": "/title", "from": "/author"}]`,
+		`[{"op": "replace", "path": "/copies/0"}]`,
+		`[{"op": "replace", "path": "/title"}]`,
+		`[{"op": "remove", "path": "/version"}]`,
+	} {
+		_, err := ParseJSONPatch(1, []byte(body))
+		assertWithTest.True(errors.Is(err, ErrInvalidPatch), body)
+	}
+}
+
+// Repository stub for the bulk create tests, InsertBooksEach hands out ids and fails duplicate isbns
+type bulkRepo struct {
+	Repository
+	calls  int
+	nextID int
+}
+
+func (r *bulkRepo) InsertBooksEach(ctx context.Context, newBooks []*Book, atomic bool) ([]error, error) {
+	r.calls++
+	seen := map[string]bool{}
+	itemErrs := make([]error, len(newBooks))
+	for i, book := range newBooks {
+		if seen[book.ISBN] {
+			itemErrs[i] = ErrBookAlreadyExists
+			continue
+		}
+		seen[book.ISBN] = true
+		r.nextID += 10
+		book.ID = r.nextID
+	}
+	return itemErrs, nil
+}
+
+func TestCreateBooksBulk(t *testing.T) {
+	assertWithTest := assert.New(t)
+	newBook := func(isbn string) *Book {
+		return &Book{
+			ISBN:      isbn,
+			Title:     "1984",
+			Author:    "George Orwell",
+			Publisher: "Signet Classic",
+			Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Dystopian",
+			Language:  "English",
+			Pages:     328,
+		}
+	}
+	// An invalid book stops everything in all or nothing mode without touching the repository
+	repo := &bulkRepo{}
+	results, err := NewService(repo).CreateBooksBulk(context.Background(),
+		[]*Book{newBook("9780451524935"), newBook("12345")}, AllOrNothing)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(0, repo.calls)
+	assertWithTest.Equal([]*BulkResult{
+		{Index: 0, Error: ErrBulkRolledBack.Error()},
+		{Index: 1, Error: ErrInvalidISBN.Error()},
+	}, results)
+
+	// Partial mode creates what it can and ids come from the repository rather than being counted on
+	results, err = NewService(repo).CreateBooksBulk(context.Background(),
+		[]*Book{newBook("978-0451524935"), newBook("12345"), newBook("0451524934"), newBook("9780061120084")}, Partial)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, repo.calls)
+	assertWithTest.Equal([]*BulkResult{
+		{Index: 0, ID: 10},
+		{Index: 1, Error: ErrInvalidISBN.Error()},
+		{Index: 2, Error: ErrBookAlreadyExists.Error()},
+		{Index: 3, ID: 20},
+	}, results)
+}
+
+func TestValidateBulkUpdate(t *testing.T) {
+	assertWithTest := assert.New(t)
+	mergePatch := func(body string) *Patch {
+		patch, err := ParseMergePatch(0, []byte(body))
+		assertWithTest.Nil(err)
+		return patch
+	}
+	tooMany := make([]int, MaxBulkBooks+1)
+	testCases := []struct {
+		Description string
+		Input       BulkUpdate
+		ExpectedErr error
+	}{
+		{
+			Description: "Books picked by id",
+			Input:       BulkUpdate{BulkSelection: BulkSelection{IDs: []int{1, 2}}, Patch: mergePatch(`{"genre":"Fiction"}`)},
+		},
+		{
+			Description: "Books picked by a filter only have their copies taken off the shelf",
+			Input:       BulkUpdate{BulkSelection: BulkSelection{Filter: &GetBooksParams{Genre: "Horror"}}, CopyStatus: copies.InRepair},
+		},
+		{
+			Description: "Ids and a filter together",
+			Input: BulkUpdate{BulkSelection: BulkSelection{IDs: []int{1}, Filter: &GetBooksParams{Genre: "Horror"}},
+				Patch: mergePatch(`{"genre":"Fiction"}`)},
+			ExpectedErr: ErrBulkSelection,
+		},
+		{
+			Description: "An empty filter would match the whole catalogue",
+			Input:       BulkUpdate{BulkSelection: BulkSelection{Filter: &GetBooksParams{}}, Patch: mergePatch(`{"genre":"Fiction"}`)},
+			ExpectedErr: ErrBulkSelection,
+		},
+		{
+			Description: "Too many ids",
+			Input:       BulkUpdate{BulkSelection: BulkSelection{IDs: tooMany}, Patch: mergePatch(`{"genre":"Fiction"}`)},
+			ExpectedErr: ErrBulkTooLarge,
+		},
+		{
+			Description: "Copies cannot be put back on the shelf in bulk",
+			Input:       BulkUpdate{BulkSelection: BulkSelection{IDs: []int{1}}, CopyStatus: copies.Available},
+			ExpectedErr: ErrBulkCopyStatus,
+		},
+		{
+			Description: "Nothing to change",
+			Input:       BulkUpdate{BulkSelection: BulkSelection{IDs: []int{1}}},
+			ExpectedErr: ErrInvalidPatch,
+		},
+		{
+			Description: "The isbn is unique to a book",
+			Input:       BulkUpdate{BulkSelection: BulkSelection{IDs: []int{1}}, Patch: mergePatch(`{"isbn":"9780451524935"}`)},
+			ExpectedErr: ErrInvalidPatch,
+		},
+	}
+	for _, tc := range testCases {
+		err := tc.Input.ValidateBulkUpdate()
+		if tc.ExpectedErr == nil {
+			assertWithTest.Nil(err, tc.Description)
+			assertWithTest.Equal(AllOrNothing, tc.Input.Mode, tc.Description)
+			continue
+		}
+		assertWithTest.True(errors.Is(err, tc.ExpectedErr), tc.Description)
+	}
+}
+
+// Repository stub for the import tests, a batch holding an isbn that is already stored fails as a whole
+type importRepo struct {
+	Repository
+	existing map[string]bool
+	batches  []int
+}
+
+func (r *importRepo) InsertBooks(ctx context.Context, newBooks []*Book) error {
+	for _, book := range newBooks {
+		if r.existing[book.ISBN] {
+			return errors.New("Duplicate entry")
+		}
+	}
+	r.batches = append(r.batches, len(newBooks))
+	return nil
+}
+
+func (r *importRepo) InsertBooksEach(ctx context.Context, newBooks []*Book, atomic bool) ([]error, error) {
+	itemErrs := make([]error, len(newBooks))
+	for i, book := range newBooks {
+		if r.existing[book.ISBN] {
+			itemErrs[i] = ErrBookAlreadyExists
+		}
+	}
+	return itemErrs, nil
+}
+
+func TestImportBooks(t *testing.T) {
+	assertWithTest := assert.New(t)
+	csvFile := strings.Join([]string{
+		"Book Title,ISBN,Author,Publisher,Published,Genre,Language,Pages",
+		"1984,978-0451524935,George Orwell,Signet Classic,1980-06-08,Dystopian,English,328",
+		"Animal Farm,0451526341,George Orwell,Signet Classic,-712800000,Satire,English,140",
+		"Brave New World,12345,Aldous Huxley,Harper,1998-09-01,Dystopian,English,288",
+		"Nineteen Eighty-Four,9780451524935,George Orwell,Penguin,1949-06-08,Dystopian,English,328",
+		"Dune,9780441013593,Frank Herbert,Ace,1990-09-01,Science Fiction,English,many",
+		"Emma,9780141439587,Jane Austen,Penguin,2003-05-06,Romance,English,474",
+		`"The Hobbit,9780547928227,J. R. R. Tolkien`,
+	}, "\n")
+	repo := &importRepo{existing: map[string]bool{"9780141439587": true}}
+	report, err := NewService(repo).ImportBooks(context.Background(), strings.NewReader(csvFile),
+		&ImportOptions{Columns: map[string]string{"title": "book title"}})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(7, report.Rows)
+	assertWithTest.Equal(2, report.Imported)
+	lines := []int{}
+	for _, rejected := range report.Rejected {
+		lines = append(lines, rejected.Line)
+	}
+	assertWithTest.Equal([]int{4, 5, 6, 7, 8}, lines)
+	assertWithTest.Equal(ErrInvalidISBN.Error(), report.Rejected[0].Reason)
+	assertWithTest.Equal("isbn 9780451524935 is already on line 2", report.Rejected[1].Reason)
+	assertWithTest.Equal(ErrBookAlreadyExists.Error(), report.Rejected[3].Reason)
+
+	// A header without a column for every field is refused before any row is read
+	_, err = NewService(repo).ImportBooks(context.Background(), strings.NewReader(csvFile), &ImportOptions{})
+	assertWithTest.True(errors.Is(err, ErrImportColumns))
+
+	// Large files go in batches
+	rows := []string{"isbn,title,author,publisher,published,genre,language,pages"}
+	for i := 0; i < ImportBatchSize+50; i++ {
+		first12 := fmt.Sprintf("978%09d", i)
+		rows = append(rows, fmt.Sprintf("%s%s,Title %d,Author,Publisher,08/06/1980,Genre,English,100",
+			first12, isbn13CheckDigit(first12), i))
+	}
+	repo = &importRepo{}
+	report, err = NewService(repo).ImportBooks(context.Background(), strings.NewReader(strings.Join(rows, "\n")),
+		&ImportOptions{DateFormat: "02/01/2006"})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(ImportBatchSize+50, report.Imported)
+	assertWithTest.Equal([]int{ImportBatchSize, 50}, repo.batches)
+}
+
+func TestParseColumnMapping(t *testing.T) {
+	assertWithTest := assert.New(t)
+	columns, err := ParseColumnMapping([]string{"title:Book Title", "Published: Date"})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(map[string]string{"title": "Book Title", "published": "Date"}, columns)
+	_, err = ParseColumnMapping([]string{"id:Book ID"})
+	assertWithTest.True(errors.Is(err, ErrImportMapping))
+	_, err = ParseColumnMapping([]string{"title"})
+	assertWithTest.True(errors.Is(err, ErrImportMapping))
+}
+
+// Repository stub for the export tests, the books are handed over one at a time like rows off the database
+type exportRepo struct {
+	Repository
+	stored []*Book
+}
+
+func (r *exportRepo) StreamBooks(ctx context.Context, params *GetBooksParams, each func(*Book) error) error {
+	for _, book := range r.stored {
+		if err := each(book); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportBooks(t *testing.T) {
+	assertWithTest := assert.New(t)
+	repo := &exportRepo{stored: []*Book{{
+		ID:           1,
+		ISBN:         "9780451524935",
+		Title:        "Nineteen Eighty-Four, or 1984",
+		Author:       "George Orwell",
+		Publisher:    "Signet Classic",
+		Published:    utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:        "Dystopian",
+		Language:     "English",
+		Pages:        328,
+		Version:      2,
+		Availability: Available,
+		CreatedAt:    utils.CustomTime{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		UpdatedAt:    utils.CustomTime{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}}}
+	var out strings.Builder
+	exported, err := NewService(repo).ExportBooks(context.Background(), &GetBooksParams{}, CSV, &out)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, exported)
+	assertWithTest.Equal("isbn,title,author,publisher,published,genre,language,pages,"+
+		"id,version,availability,copies_available,copies_total,created_at,updated_at\n"+
+		`9780451524935,"Nineteen Eighty-Four, or 1984",George Orwell,Signet Classic,1980-06-08,Dystopian,English,328,`+
+		"1,2,available,0,0,2024-01-02T03:04:05Z,2024-01-02T03:04:05Z\n", out.String())
+
+	// An export can be imported again
+	importer := &importRepo{}
+	report, err := NewService(importer).ImportBooks(context.Background(), strings.NewReader(out.String()), &ImportOptions{})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, report.Imported)
+
+	out.Reset()
+	_, err = NewService(repo).ExportBooks(context.Background(), &GetBooksParams{}, NDJSON, &out)
+	assertWithTest.Nil(err)
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assertWithTest.Len(lines, 1)
+	var decoded map[string]interface{}
+	assertWithTest.Nil(json.Unmarshal([]byte(lines[0]), &decoded))
+	assertWithTest.Equal("9780451524935", decoded["isbn"])
+	assertWithTest.Equal("1980-06-08T00:00:00Z", decoded["published"])
+
+	_, err = NewService(repo).ExportBooks(context.Background(), &GetBooksParams{}, "xml", &out)
+	assertWithTest.Equal(ErrExportFormat, err)
+}
+
+func TestMARCMapping(t *testing.T) {
+	assertWithTest := assert.New(t)
+	// A record as a library system would catalogue it
+	record := &marc.Record{Leader: marc.DefaultLeader}
+	record.AddControl("001", "ocm12345")
+	record.AddControl("008", "830415s1950    nyu           000 1 eng d")
+	record.AddData("020", ' ', ' ', "a", "0451524934 (pbk.)")
+	record.AddData("100", '1', ' ', "a", "Orwell, George,", "d", "1903-1950.")
+	record.AddData("245", '1', '0', "a", "Nineteen eighty-four :", "b", "a novel /", "c", "George Orwell.")
+	record.AddData("260", ' ', ' ', "a", "New York :", "b", "Signet Classic,", "c", "c1950.")
+	record.AddData("300", ' ', ' ', "a", "328 p. ;", "c", "18 cm.")
+	record.AddData("650", ' ', '0', "a", "Totalitarianism", "v", "Fiction.")
+	record.AddData("700", '1', ' ', "a", "Fromm, Erich.")
+	book, warnings := FromMARC(record)
+	assertWithTest.Equal("0451524934", book.ISBN)
+	assertWithTest.Equal("Orwell, George", book.Author)
+	assertWithTest.Equal("Nineteen eighty-four: a novel", book.Title)
+	assertWithTest.Equal("Signet Classic", book.Publisher)
+	assertWithTest.Equal(time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC), book.Published.Time)
+	assertWithTest.Equal("English", book.Language)
+	assertWithTest.Equal(328, book.Pages)
+	assertWithTest.Equal("Totalitarianism", book.Genre)
+	assertWithTest.Nil(book.ValidateCreateBook())
+	assertWithTest.Equal([]string{
+		"only the year 1950 is known, published is set to the first of January",
+		"fields 700 could not be mapped",
+	}, warnings)
+
+	// A book written out as MARC reads back as the same book
+	original := &Book{
+		ID:        7,
+		ISBN:      "9780451524935",
+		Title:     "Nineteen Eighty-Four: A Novel",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "Klingon",
+		Pages:     328,
+	}
+	roundTrip, warnings := FromMARC(original.MARC())
+	assertWithTest.Empty(warnings)
+	original.ID = 0
+	assertWithTest.Equal(original, roundTrip)
+}
+
+func TestImportMARC(t *testing.T) {
+	assertWithTest := assert.New(t)
+	valid := &Book{
+		ISBN:      "9780451524935",
+		Title:     "1984",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     328,
+	}
+	// The second record has no genre, so it cannot be imported
+	noGenre := *valid
+	noGenre.ISBN, noGenre.Genre = "9780441013593", ""
+	for _, format := range []ExportFormat{MARC21, MARCXML} {
+		var out strings.Builder
+		write, flush, err := exportWriter(format, &out)
+		assertWithTest.Nil(err)
+		assertWithTest.Nil(write(valid))
+		assertWithTest.Nil(write(&noGenre))
+		assertWithTest.Nil(flush())
+
+		repo := &importRepo{}
+		report, err := NewService(repo).ImportMARC(context.Background(), strings.NewReader(out.String()), format)
+		assertWithTest.Nil(err, format)
+		assertWithTest.Equal(2, report.Rows, format)
+		assertWithTest.Equal(1, report.Imported, format)
+		assertWithTest.Equal([]*RejectedRow{{Line: 2, Reason: "genre field is required"}}, report.Rejected, format)
+	}
+}
+
+// Repository stub for the ONIX tests, books are looked up by isbn among the stored and trashed ones
+type onixRepo struct {
+	Repository
+	stored  map[string]*Book
+	trashed map[string]*Book
+	updated []*Book
+	nextID  int
+}
+
+func (r *onixRepo) GetBooks(ctx context.Context, params *GetBooksParams) ([]*Book, int, error) {
+	books := r.stored
+	if params.Deleted {
+		books = r.trashed
+	}
+	if book, ok := books[params.ISBN]; ok {
+		return []*Book{book}, 1, nil
+	}
+	return nil, 0, nil
+}
+
+func (r *onixRepo) UpdateBook(ctx context.Context, arg *Book) error {
+	r.updated = append(r.updated, arg)
+	return nil
+}
+
+func (r *onixRepo) InsertBooks(ctx context.Context, newBooks []*Book) error {
+	for _, book := range newBooks {
+		r.nextID++
+		book.ID = r.nextID
+	}
+	return nil
+}
+
+func onixProduct(isbn, form, notification, genre string, pages int) string {
+	return fmt.Sprintf(`<Product>
+		<RecordReference>%[1]s</RecordReference><NotificationType>%[3]s</NotificationType>
+		<ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>%[1]s</IDValue></ProductIdentifier>
+		<DescriptiveDetail><ProductForm>%[2]s</ProductForm>
+			<TitleDetail><TitleType>01</TitleType><TitleElement><TitleElementLevel>01</TitleElementLevel>
+				<TitleText>Title %[1]s</TitleText></TitleElement></TitleDetail>
+			<Contributor><ContributorRole>A01</ContributorRole><PersonName>George Orwell</PersonName></Contributor>
+			<Language><LanguageRole>01</LanguageRole><LanguageCode>eng</LanguageCode></Language>
+			<Extent><ExtentType>00</ExtentType><ExtentValue>%[5]d</ExtentValue><ExtentUnit>03</ExtentUnit></Extent>
+			<Subject><MainSubject/><SubjectHeadingText>%[4]s</SubjectHeadingText></Subject>
+		</DescriptiveDetail>
+		<PublishingDetail>
+			<Publisher><PublishingRole>01</PublishingRole><PublisherName>Signet Classic</PublisherName></Publisher>
+			<PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>19800608</Date></PublishingDate>
+		</PublishingDetail>
+	</Product>`, isbn, form, notification, genre, pages)
+}
+
+func TestIngestONIX(t *testing.T) {
+	assertWithTest := assert.New(t)
+	stored := &Book{
+		ID:        7,
+		Version:   3,
+		ISBN:      "9780451524935",
+		Title:     "Title 9780451524935",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     300,
+	}
+	unchanged := *stored
+	unchanged.ID, unchanged.ISBN, unchanged.Title, unchanged.Pages = 8, "9780441013593", "Title 9780441013593", 328
+	repo := &onixRepo{
+		stored:  map[string]*Book{stored.ISBN: stored, unchanged.ISBN: &unchanged},
+		trashed: map[string]*Book{"9780060850524": {ID: 9, ISBN: "9780060850524"}},
+		nextID:  100,
+	}
+	feed := `<ONIXMessage release="3.0">` +
+		onixProduct("9780451524935", "BC", "03", "Dystopian", 328) +
+		onixProduct("9780441013593", "BB", "03", "Dystopian", 328) +
+		onixProduct("9780141187761", "BC", "02", "Dystopian", 112) +
+		onixProduct("9780743273565", "AJ", "03", "Classics", 180) +
+		onixProduct("9780316769488", "BC", "05", "Classics", 277) +
+		onixProduct("9780060850524", "BC", "03", "Dystopian", 288) +
+		onixProduct("9780062315007", "BC", "03", "", 208) +
+		`</ONIXMessage>`
+
+	report, err := NewService(repo).IngestONIX(context.Background(), strings.NewReader(feed))
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(7, report.Products)
+	assertWithTest.Equal(1, report.Inserted)
+	assertWithTest.Equal(1, report.Updated)
+	assertWithTest.Equal(5, report.Skipped)
+
+	outcomes := make([]IngestOutcome, len(report.Results))
+	for i, result := range report.Results {
+		assertWithTest.Equal(i+1, result.Product)
+		outcomes[i] = result.Outcome
+	}
+	assertWithTest.Equal([]IngestOutcome{Updated, Skipped, Inserted, Skipped, Skipped, Skipped, Skipped}, outcomes)
+
+	// Only the page count differs from the stored book, and the update is made against the version that was read
+	assertWithTest.Equal(audit.Changes{{Field: "pages", Old: 300, New: 328}}, report.Results[0].Changes)
+	assertWithTest.Len(repo.updated, 1)
+	assertWithTest.Equal(7, repo.updated[0].ID)
+	assertWithTest.Equal(3, repo.updated[0].Version)
+	assertWithTest.Equal("book is already up to date", report.Results[1].Reason)
+	assertWithTest.Equal(101, report.Results[2].ID)
+	assertWithTest.Equal("product is not a book: product form is AJ", report.Results[3].Reason)
+	assertWithTest.Equal(ErrONIXDelete.Error(), report.Results[4].Reason)
+	assertWithTest.Equal(ErrBookDeleted.Error(), report.Results[5].Reason)
+	assertWithTest.Equal("genre field is required", report.Results[6].Reason)
+
+	// The product form of every product is reported, so a paperback and an e-book can be told apart
+	assertWithTest.Equal("BC", report.Results[0].ProductForm)
+	assertWithTest.Equal("print", report.Results[0].Format)
+	assertWithTest.Equal("AJ", report.Results[3].ProductForm)
+	assertWithTest.Equal("", report.Results[3].Format)
+	assertWithTest.Equal("digital", ONIXFormat("EA"))
+}
+
+func TestParseSearch(t *testing.T) {
+	assertWithTest := assert.New(t)
+	parsed, err := ParseSearch(`dc.title any "orwell" and dc.language = eng`)
+	assertWithTest.Nil(err)
+	boolean := parsed.Root.(*cql.Boolean)
+	assertWithTest.Equal(&cql.Clause{Index: SearchTitle, Relation: &cql.Relation{Comparitor: "any"}, Term: "orwell"},
+		boolean.Left)
+	// Language codes are searched as the names books are stored with
+	assertWithTest.Equal(&cql.Clause{Index: SearchLanguage, Relation: &cql.Relation{Comparitor: "="}, Term: "English"},
+		boolean.Right)
+
+	testCases := []struct {
+		Query         string
+		ExpectedError error
+	}{
+		{Query: `dc.title any`, ExpectedError: cql.ErrSyntax},
+		{Query: `dc.colour = red`, ExpectedError: ErrCQLIndex},
+		{Query: `dc.identifier any "9780451524935"`, ExpectedError: ErrCQLRelation},
+		{Query: `dc.title =/stem orwell`, ExpectedError: ErrCQLModifier},
+		{Query: `orwell prox huxley`, ExpectedError: ErrCQLBoolean},
+		{Query: `dc.date > "last year"`, ExpectedError: ErrCQLTerm},
+		{Query: `orwell sortby dc.title`, ExpectedError: ErrCQLSort},
+	}
+	for _, tc := range testCases {
+		_, err := ParseSearch(tc.Query)
+		assertWithTest.ErrorIs(err, tc.ExpectedError, tc.Query)
+	}
+}
+
+type searchRepo struct {
+	Repository
+	total   int
+	fetched *GetBooksParams
+}
+
+func (r *searchRepo) CountBooks(ctx context.Context, params *GetBooksParams) (int, error) {
+	return r.total, nil
+}
+
+func (r *searchRepo) GetBooks(ctx context.Context, params *GetBooksParams) ([]*Book, int, error) {
+	r.fetched = params
+	return []*Book{{ID: 1}}, 1, nil
+}
+
+func TestSearchBooks(t *testing.T) {
+	assertWithTest := assert.New(t)
+	repo := &searchRepo{total: 12}
+	s := NewService(repo)
+
+	found, total, err := s.SearchBooks(context.Background(), "orwell", 10, 5)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(12, total)
+	assertWithTest.Len(found, 1)
+	assertWithTest.Equal(10, repo.fetched.Offset)
+	assertWithTest.Equal(5, repo.fetched.PerPage)
+	assertWithTest.NotNil(repo.fetched.Search)
+
+	// Past the end and asking for no records only count the books
+	repo.fetched = nil
+	for _, page := range [][2]int{{12, 5}, {0, 0}} {
+		found, total, err = s.SearchBooks(context.Background(), "orwell", page[0], page[1])
+		assertWithTest.Nil(err)
+		assertWithTest.Equal(12, total)
+		assertWithTest.Empty(found)
+		assertWithTest.Nil(repo.fetched)
+	}
+
+	_, _, err = s.SearchBooks(context.Background(), "dc.colour = red", 0, 5)
+	assertWithTest.ErrorIs(err, ErrCQLIndex)
+}
+
+func TestParseFullText(t *testing.T) {
+	assertWithTest := assert.New(t)
+	query, err := ParseFullText(`"Animal  Farm" orw* sci-fi`, "")
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]*FullTextTerm{
+		{Words: []string{"animal", "farm"}},
+		{Words: []string{"orw"}, Prefix: true},
+		{Words: []string{"sci", "fi"}},
+	}, query.Terms)
+	assertWithTest.Equal(FullTextFields, query.Fields)
+	assertWithTest.True(query.AllFields())
+	assertWithTest.Equal(`"animal farm" orw* "sci fi"`, query.BooleanMode())
+
+	query, err = ParseFullText("orwell", "Author^5, title")
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]string{"author", "title"}, query.Fields)
+	assertWithTest.Equal(map[string]float64{"author": 5, "title": 4}, query.Boosts)
+	assertWithTest.False(query.AllFields())
+
+	for _, tc := range []struct{ Query, Fields string }{
+		{Query: `  "" * `},
+		{Query: "orwell", Fields: "isbn"},
+		{Query: "orwell", Fields: "title^0"},
+		{Query: "orwell", Fields: "title^high"},
+	} {
+		_, err := ParseFullText(tc.Query, tc.Fields)
+		assertWithTest.NotNil(err, tc)
+	}
+}
+
+func TestFullTextHighlight(t *testing.T) {
+	assertWithTest := assert.New(t)
+	query, err := ParseFullText(`"animal farm" orw*`, "")
+	assertWithTest.Nil(err)
+	book := &Book{Title: "Animal Farm & other <stories>", Author: "George Orwell", Publisher: "Signet", Genre: "Satire"}
+	assertWithTest.Equal(map[string]string{
+		"title":  "<mark>Animal Farm</mark> &amp; other &lt;stories&gt;",
+		"author": "George <mark>Orwell</mark>",
+	}, query.Highlight(book))
+	// A phrase is one match and the title weighs 4, the author 3
+	assertWithTest.Equal(7.0, query.Score(book))
+
+	// Long values are cut down around the first match
+	book.Title = strings.Repeat("word ", 40) + "Orwellian " + strings.Repeat("word ", 40)
+	title := query.Highlight(book)["title"]
+	assertWithTest.True(strings.HasPrefix(title, "…word "), title)
+	assertWithTest.True(strings.HasSuffix(title, " word…"), title)
+	assertWithTest.Contains(title, "<mark>Orwellian</mark>")
+	assertWithTest.LessOrEqual(len(title), SnippetLength+len(HighlightStart+HighlightEnd)+2*len("…"))
+}
+
+type fullTextRepo struct {
+	Repository
+	stored []*Book
+	params *GetBooksParams
+}
+
+func (r *fullTextRepo) FullTextSearch(ctx context.Context, params *FullTextParams) ([]*SearchHit, int, error) {
+	return nil, 0, ErrFullTextUnsupported
+}
+
+func (r *fullTextRepo) StreamBooks(ctx context.Context, params *GetBooksParams, each func(*Book) error) error {
+	r.params = params
+	for _, book := range r.stored {
+		if err := each(book); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestFullTextSearchFallback(t *testing.T) {
+	assertWithTest := assert.New(t)
+	repo := &fullTextRepo{stored: []*Book{
+		{ID: 1, Title: "Homage to Catalonia", Author: "George Orwell", Genre: "Memoir"},
+		// Only a substring of a word, the LIKE candidates include it but it does not rank
+		{ID: 2, Title: "Borwell Street", Author: "Jane Doe", Genre: "Crime"},
+		{ID: 3, Title: "Orwell's Roses", Author: "Rebecca Solnit", Genre: "Biography"},
+		{ID: 4, Title: "Nineteen Eighty-Four", Author: "George Orwell", Genre: "Dystopian"},
+	}}
+	query, err := ParseFullText("orwell", "")
+	assertWithTest.Nil(err)
+	hits, total, err := NewService(repo).FullTextSearch(context.Background(),
+		&FullTextParams{Query: query, Page: 1, PerPage: 2})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(3, total)
+	if assertWithTest.Len(hits, 2) {
+		// A title match outranks an author match
+		assertWithTest.Equal(3, hits[0].Book.ID)
+		assertWithTest.Equal(4.0, hits[0].Score)
+		assertWithTest.Equal(map[string]string{"title": "<mark>Orwell</mark>&#39;s Roses"}, hits[0].Highlights)
+		assertWithTest.Equal(1, hits[1].Book.ID)
+	}
+	// Candidates are every book with the word in any field
+	clauses := 0
+	assertWithTest.Nil(cql.Walk(repo.params.Search.Root, func(clause *cql.Clause) error {
+		clauses++
+		return nil
+	}))
+	assertWithTest.Equal(len(FullTextFields), clauses)
+
+	hits, total, err = NewService(repo).FullTextSearch(context.Background(),
+		&FullTextParams{Query: query, Page: 2, PerPage: 2})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(3, total)
+	if assertWithTest.Len(hits, 1) {
+		assertWithTest.Equal(4, hits[0].Book.ID)
+	}
+}
+
+func TestFacets(t *testing.T) {
+	assertWithTest := assert.New(t)
+	facets, err := ParseFacets("language, Genre,language,,decade")
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]Facet{FacetLanguage, FacetGenre, FacetDecade}, facets)
+	_, err = ParseFacets("language,colour")
+	assertWithTest.ErrorIs(err, ErrFacet)
+
+	params := &GetBooksParams{
+		Page:         2,
+		PerPage:      10,
+		Title:        "Dune",
+		Genre:        "Science Fiction",
+		GenreID:      4,
+		Language:     "English",
+		Publisher:    "Ace",
+		Availability: Available,
+		Decade:       1960,
+	}
+	// Each facet keeps every other filter
+	language := params.WithoutFilter(FacetLanguage)
+	assertWithTest.Equal(&GetBooksParams{Title: "Dune", Genre: "Science Fiction", GenreID: 4, Publisher: "Ace",
+		Availability: Available, Decade: 1960}, language)
+	genre := params.WithoutFilter(FacetGenre)
+	assertWithTest.Zero(genre.Genre)
+	assertWithTest.Zero(genre.GenreID)
+	assertWithTest.Equal("English", genre.Language)
+	assertWithTest.Zero(params.WithoutFilter(FacetDecade).Decade)
+	assertWithTest.Zero(params.WithoutFilter(FacetAvailability).Availability)
+	assertWithTest.Zero(params.WithoutFilter(FacetPublisher).Publisher)
+	// The params asked with are left as they were
+	assertWithTest.Equal(2, params.Page)
+	assertWithTest.Equal("English", params.Language)
+}
+
+func TestParseSort(t *testing.T) {
+	assertWithTest := assert.New(t)
+	keys, err := ParseSort(" -Published, title ,+pages")
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]SortKey{{Field: "published", Descending: true}, {Field: "title"}, {Field: "pages"}}, keys)
+	keys, err = ParseSort("")
+	assertWithTest.Nil(err)
+	assertWithTest.Empty(keys)
+	for _, value := range []string{"isbn", "title,-title", "--title", "title; DROP TABLE books", "id,title,author,genre,pages,language"} {
+		_, err := ParseSort(value)
+		assertWithTest.ErrorIs(err, ErrSort, value)
+	}
+}
+
+func TestSortBooks(t *testing.T) {
+	assertWithTest := assert.New(t)
+	day := func(year int) utils.CustomDate {
+		return utils.CustomDate{Time: time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)}
+	}
+	found := []*Book{
+		{ID: 4, Title: "animal farm", Published: day(1945)},
+		{ID: 2, Title: "Nineteen Eighty-Four", Published: day(1949)},
+		{ID: 3, Title: "Animal Farm", Published: day(1945)},
+		{ID: 1, Title: "Burmese Days", Published: day(1934)},
+	}
+	// Case is ignored and the id breaks the tie between the two editions
+	SortBooks(found, []SortKey{{Field: "published", Descending: true}, {Field: "title"}})
+	ids := []int{}
+	for _, book := range found {
+		ids = append(ids, book.ID)
+	}
+	assertWithTest.Equal([]int{2, 3, 4, 1}, ids)
+}
+
+func TestBulkSelectionIgnoresSort(t *testing.T) {
+	selection := &BulkSelection{Filter: &GetBooksParams{Sort: []SortKey{{Field: "title"}}}}
+	assert.ErrorIs(t, selection.ValidateSelection(), ErrBulkSelection)
+}
+
+func TestCursorSigner(t *testing.T) {
+	assertWithTest := assert.New(t)
+	signer := NewCursorSigner([]byte("secret"))
+	book := &Book{ID: 7, Title: "Animal Farm", Published: utils.CustomDate{Time: time.Date(1945, 8, 17, 0, 0, 0, 0, time.UTC)}}
+	keys := []SortKey{{Field: "published", Descending: true}, {Field: "title"}, {Field: "id"}}
+	cursor := CursorAt(book, keys, true)
+	assertWithTest.Equal([]string{"1945-08-17", "Animal Farm", "7"}, cursor.Values)
+
+	token, err := signer.Sign(cursor)
+	assertWithTest.Nil(err)
+	read, err := signer.Verify(token)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(cursor, read)
+	args, err := read.Args()
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]interface{}{time.Date(1945, 8, 17, 0, 0, 0, 0, time.UTC), "Animal Farm", 7}, args)
+
+	// Changing any part of the token, or signing with another key, makes it invalid
+	payload, mac, _ := strings.Cut(token, ".")
+	forged := CursorAt(&Book{ID: 1, Title: "Animal Farm", Published: book.Published}, keys, true)
+	forgedToken, err := NewCursorSigner([]byte("guess")).Sign(forged)
+	assertWithTest.Nil(err)
+	forgedPayload, _, _ := strings.Cut(forgedToken, ".")
+	for _, tampered := range []string{"", payload, payload + ".", forgedToken, forgedPayload + "." + mac, token + "x"} {
+		_, err := signer.Verify(tampered)
+		assertWithTest.ErrorIs(err, ErrInvalidCursor, tampered)
+	}
+}
+
+func TestUseCursor(t *testing.T) {
+	assertWithTest := assert.New(t)
+	cursor := &Cursor{Keys: []SortKey{{Field: "title"}, {Field: "id"}}, Values: []string{"Dune", "3"}}
+	// The cursor brings its sort with it
+	params := &GetBooksParams{Page: 4}
+	assertWithTest.Nil(params.UseCursor(cursor))
+	assertWithTest.Equal(cursor.Keys, params.Order())
+	assertWithTest.Equal(cursor, params.Cursor)
+	assertWithTest.Zero(params.Page)
+
+	params = &GetBooksParams{Sort: []SortKey{{Field: "title"}}}
+	assertWithTest.Nil(params.UseCursor(cursor))
+	params = &GetBooksParams{Sort: []SortKey{{Field: "title", Descending: true}}}
+	assertWithTest.ErrorIs(params.UseCursor(cursor), ErrCursorSort)
+	params = &GetBooksParams{Newest: true}
+	assertWithTest.ErrorIs(params.UseCursor(cursor), ErrCursorSort)
+}
+
+type cursorRepo struct {
+	Repository
+	stored []*Book
+	params *GetBooksParams
+}
+
+// Lists the books by id as the database would, the stored books are in id order
+func (r *cursorRepo) GetBooks(ctx context.Context, params *GetBooksParams) ([]*Book, int, error) {
+	r.params = params
+	var found []*Book
+	for _, book := range r.stored {
+		if params.Cursor != nil {
+			id, _ := strconv.Atoi(params.Cursor.Values[0])
+			if (params.Cursor.Backward && book.ID >= id) || (!params.Cursor.Backward && book.ID <= id) {
+				continue
+			}
+		}
+		found = append(found, book)
+	}
+	if params.Cursor != nil && params.Cursor.Backward && len(found) > params.PerPage {
+		found = found[len(found)-params.PerPage:]
+	} else if len(found) > params.PerPage {
+		found = found[:params.PerPage]
+	}
+	return found, len(found), nil
+}
+
+func TestGetBooksByCursor(t *testing.T) {
+	assertWithTest := assert.New(t)
+	repo := &cursorRepo{}
+	for id := 1; id <= 5; id++ {
+		repo.stored = append(repo.stored, &Book{ID: id})
+	}
+	service := NewService(repo)
+	ids := func(page *CursorPage) []int {
+		found := []int{}
+		for _, book := range page.Books {
+			found = append(found, book.ID)
+		}
+		return found
+	}
+	keys := []SortKey{{Field: "id"}}
+	list := func(cursor *Cursor) *CursorPage {
+		params := &GetBooksParams{Sort: keys, PerPage: 2, Cursor: cursor}
+		page, err := service.GetBooksByCursor(context.Background(), params)
+		assertWithTest.Nil(err)
+		return page
+	}
+
+	first := list(nil)
+	assertWithTest.Equal([]int{1, 2}, ids(first))
+	// One more book is read than is listed
+	assertWithTest.Equal(3, repo.params.PerPage)
+	assertWithTest.Nil(first.Prev)
+	second := list(first.Next)
+	assertWithTest.Equal([]int{3, 4}, ids(second))
+	last := list(second.Next)
+	assertWithTest.Equal([]int{5}, ids(last))
+	assertWithTest.Nil(last.Next)
+
+	// Going back lists the same pages in the same order
+	back := list(last.Prev)
+	assertWithTest.Equal([]int{3, 4}, ids(back))
+	assertWithTest.Equal(CursorAt(&Book{ID: 4}, keys, false), back.Next)
+	back = list(back.Prev)
+	assertWithTest.Equal([]int{1, 2}, ids(back))
+	assertWithTest.Nil(back.Prev)
+	assertWithTest.NotNil(back.Next)
+}
+
+type pageRepo struct {
+	Repository
+	stored []*Book
+	params *GetBooksParams
+}
+
+func (r *pageRepo) GetBooks(ctx context.Context, params *GetBooksParams) ([]*Book, int, error) {
+	r.params = params
+	found := r.stored
+	if params.Offset < len(found) {
+		found = found[params.Offset:]
+	} else {
+		found = nil
+	}
+	if params.PerPage < len(found) {
+		found = found[:params.PerPage]
+	}
+	if params.SkipTotal {
+		return found, -1, nil
+	}
+	return found, len(r.stored), nil
+}
+
+func TestGetBooksPage(t *testing.T) {
+	assertWithTest := assert.New(t)
+	repo := &pageRepo{}
+	for id := 1; id <= 5; id++ {
+		repo.stored = append(repo.stored, &Book{ID: id})
+	}
+	service := NewService(repo)
+
+	page, err := service.GetBooksPage(context.Background(), &GetBooksParams{Page: 2, PerPage: 2})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]*Book{{ID: 3}, {ID: 4}}, page.Books)
+	// The page is read from its offset with one more book than is listed
+	assertWithTest.Equal(2, repo.params.Offset)
+	assertWithTest.Equal(3, repo.params.PerPage)
+	assertWithTest.True(page.HasNext)
+	assertWithTest.Equal(5, page.Total)
+	assertWithTest.Equal(3, page.TotalPages())
+
+	page, err = service.GetBooksPage(context.Background(), &GetBooksParams{Page: 3, PerPage: 2, SkipTotal: true})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]*Book{{ID: 5}}, page.Books)
+	assertWithTest.False(page.HasNext)
+	assertWithTest.Equal(-1, page.Total)
+	assertWithTest.Equal(-1, page.TotalPages())
+}
@@ -0,0 +1,11 @@
+package copies
+
+import "net/http"
+
+type Handler interface {
+	CreateCopy(res http.ResponseWriter, req *http.Request)
+	UpdateCopy(res http.ResponseWriter, req *http.Request)
+	GetCopies(res http.ResponseWriter, req *http.Request)
+	GetCopyByID(res http.ResponseWriter, req *http.Request)
+	DeleteCopy(res http.ResponseWriter, req *http.Request)
+}
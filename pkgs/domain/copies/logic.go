@@ -0,0 +1,144 @@
+package copies
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/go-playground/validator"
+)
+
+type Condition string
+type Status string
+
+// Create global errors that are specific to this domain
+var (
+	ErrCopyAlreadyExists  = errors.New("a copy with this barcode already exists")
+	ErrCopyNotFound       = errors.New("copy does not exist")
+	ErrBookNotFound       = errors.New("book does not exist")
+	ErrCopyInCirculation  = errors.New("copy is on loan or on hold, return it or cancel the hold first")
+	ErrCirculationStatus  = errors.New("on_loan and on_hold are set by checkouts and holds, not by updating the copy")
+	ErrCopyHasLoanHistory = errors.New("copy has loan history and cannot be deleted, withdraw it instead")
+)
+
+const (
+	New     Condition = "new"
+	Good    Condition = "good"
+	Fair    Condition = "fair"
+	Poor    Condition = "poor"
+	Damaged Condition = "damaged"
+)
+
+const (
+	// On the shelf and can be checked out
+	Available Status = "available"
+	OnLoan    Status = "on_loan"
+	// Set aside for a hold that is ready for pickup
+	OnHold    Status = "on_hold"
+	InRepair  Status = "in_repair"
+	Lost      Status = "lost"
+	Withdrawn Status = "withdrawn"
+)
+
+// A physical item of a book, each with its own barcode and place on the shelves
+type Copy struct {
+	ID            int              `json:"id" db:"id"`
+	BookID        int              `json:"book_id" db:"book_id" validate:"required"`
+	Barcode       string           `json:"barcode" db:"barcode" validate:"required"`
+	ShelfLocation string           `json:"shelf_location" db:"shelf_location"`
+	Condition     Condition        `json:"condition" db:"condition" validate:"required,eq=new|eq=good|eq=fair|eq=poor|eq=damaged"`
+	Status        Status           `json:"status" db:"status" validate:"required,eq=available|eq=in_repair|eq=lost|eq=withdrawn"`
+	UpdatedAt     utils.CustomTime `json:"updated_at" db:"updated_at"`
+	CreatedAt     utils.CustomTime `json:"created_at" db:"created_at"`
+}
+
+type GetCopiesParams struct {
+	ID            int
+	Page          int
+	PerPage       int
+	BookID        int
+	Barcode       string
+	ShelfLocation string
+	Status        Status
+}
+
+// Object methods for aggregate root
+// Validation for creating a Copy, new copies go straight on the shelf unless told otherwise
+func (c *Copy) ValidateCreateCopy() error {
+	if c.Status == "" {
+		c.Status = Available
+	}
+	if c.Condition == "" {
+		c.Condition = Good
+	}
+	validate := validator.New()
+	if err := validate.Struct(c); err != nil {
+		return validationErrMessage(err.(validator.ValidationErrors))
+	}
+	return nil
+}
+
+// Validation for updating a Copy, only the fields that were supplied are checked
+func (c *Copy) ValidateUpdateCopy() error {
+	if c.Status == OnLoan || c.Status == OnHold {
+		return ErrCirculationStatus
+	}
+	fields := []string{}
+	if c.Condition != "" {
+		fields = append(fields, "Condition")
+	}
+	if c.Status != "" {
+		fields = append(fields, "Status")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	validate := validator.New()
+	if err := validate.StructPartial(c, fields...); err != nil {
+		return validationErrMessage(err.(validator.ValidationErrors))
+	}
+	return nil
+}
+
+// Merge an update into the stored copy, a copy that is out cannot be changed underneath its loan or hold
+func (c *Copy) ApplyUpdate(update *Copy) error {
+	if update.Status != "" && c.InCirculation() {
+		return ErrCopyInCirculation
+	}
+	if update.Barcode != "" {
+		c.Barcode = update.Barcode
+	}
+	if update.ShelfLocation != "" {
+		c.ShelfLocation = update.ShelfLocation
+	}
+	if update.Condition != "" {
+		c.Condition = update.Condition
+	}
+	if update.Status != "" {
+		c.Status = update.Status
+	}
+	return nil
+}
+
+func (c *Copy) InCirculation() bool {
+	return c.Status == OnLoan || c.Status == OnHold
+}
+
+// Internal helper funcs for methods
+func validationErrMessage(errs validator.ValidationErrors) error {
+	for _, err := range errs {
+		field := strings.ToLower(err.Field())
+		switch err.Tag() {
+		case "required":
+			return fmt.Errorf("%s field is required", field)
+		case "eq=new|eq=good|eq=fair|eq=poor|eq=damaged":
+			return fmt.Errorf("value for %s is not recognised, please use new, good, fair, poor or damaged", field)
+		case "eq=available|eq=in_repair|eq=lost|eq=withdrawn":
+			return fmt.Errorf("value for %s is not recognised, please use available, in_repair, lost or withdrawn", field)
+		default:
+			return fmt.Errorf("value for %s is not recognized", field)
+		}
+	}
+	return nil
+}
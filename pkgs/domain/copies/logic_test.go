@@ -0,0 +1,60 @@
+package copies
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateCopyValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	testCases := []struct {
+		Input         Copy
+		ExpectedError error
+		Message       string
+	}{
+		{Input: Copy{BookID: 1, Barcode: "C-0001"}, ExpectedError: nil, Message: "Status and condition default"},
+		{Input: Copy{BookID: 1}, ExpectedError: errors.New("barcode field is required"), Message: "Barcode is required"},
+		{
+			Input:         Copy{BookID: 1, Barcode: "C-0001", Condition: "mint"},
+			ExpectedError: errors.New("value for condition is not recognised, please use new, good, fair, poor or damaged"),
+			Message:       "Unknown condition",
+		},
+		{
+			Input:         Copy{BookID: 1, Barcode: "C-0001", Status: OnLoan},
+			ExpectedError: errors.New("value for status is not recognised, please use available, in_repair, lost or withdrawn"),
+			Message:       "Copies cannot be created on loan",
+		},
+	}
+	for _, test := range testCases {
+		assertWithTest.Equal(test.ExpectedError, test.Input.ValidateCreateCopy(), test.Message)
+	}
+	defaulted := Copy{BookID: 1, Barcode: "C-0001"}
+	assertWithTest.Nil(defaulted.ValidateCreateCopy())
+	assertWithTest.Equal(Available, defaulted.Status)
+	assertWithTest.Equal(Good, defaulted.Condition)
+}
+
+func TestUpdateCopyValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	assertWithTest.Nil((&Copy{ShelfLocation: "FIC-ORW"}).ValidateUpdateCopy())
+	assertWithTest.Nil((&Copy{Status: InRepair, Condition: Damaged}).ValidateUpdateCopy())
+	assertWithTest.Equal(ErrCirculationStatus, (&Copy{Status: OnHold}).ValidateUpdateCopy())
+	assertWithTest.Equal(errors.New("value for condition is not recognised, please use new, good, fair, poor or damaged"),
+		(&Copy{Condition: "mint"}).ValidateUpdateCopy())
+}
+
+func TestApplyUpdate(t *testing.T) {
+	assertWithTest := assert.New(t)
+	onShelf := Copy{Barcode: "C-0001", Condition: Good, Status: Available}
+	assertWithTest.Nil(onShelf.ApplyUpdate(&Copy{Condition: Damaged, Status: InRepair}))
+	assertWithTest.Equal(Copy{Barcode: "C-0001", Condition: Damaged, Status: InRepair}, onShelf)
+
+	// A copy that is out can be relabelled but keeps its circulation status
+	onLoan := Copy{Barcode: "C-0002", Condition: Good, Status: OnLoan}
+	assertWithTest.Nil(onLoan.ApplyUpdate(&Copy{ShelfLocation: "FIC-ORW"}))
+	assertWithTest.Equal("FIC-ORW", onLoan.ShelfLocation)
+	assertWithTest.Equal(ErrCopyInCirculation, onLoan.ApplyUpdate(&Copy{Status: Lost}))
+	assertWithTest.Equal(OnLoan, onLoan.Status)
+}
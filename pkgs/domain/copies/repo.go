@@ -0,0 +1,14 @@
+package copies
+
+import (
+	"context"
+)
+
+type Repository interface {
+	InsertCopies(ctx context.Context, newCopies []*Copy) error
+	GetCopies(ctx context.Context, params *GetCopiesParams) ([]*Copy, int, error)
+	// Locks the copy, applies the change and writes it back in one transaction,
+	// a copy put back on the shelf goes to the holds queue first
+	UpdateCopy(ctx context.Context, copyID int, apply func(*Copy) error) (*Copy, error)
+	DeleteCopyByID(ctx context.Context, id int) error
+}
@@ -0,0 +1,42 @@
+package copies
+
+import "context"
+
+type Service interface {
+	CreateCopies(ctx context.Context, newCopies []*Copy) error
+	UpdateCopy(ctx context.Context, updatedCopy *Copy) (*Copy, error)
+	GetCopies(ctx context.Context, params *GetCopiesParams) ([]*Copy, int, error)
+	DeleteCopyByID(ctx context.Context, id int) error
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// CreateCopies implements Service.
+func (s *service) CreateCopies(ctx context.Context, newCopies []*Copy) error {
+	return s.repo.InsertCopies(ctx, newCopies)
+}
+
+// UpdateCopy implements Service.
+func (s *service) UpdateCopy(ctx context.Context, updatedCopy *Copy) (*Copy, error) {
+	return s.repo.UpdateCopy(ctx, updatedCopy.ID, func(current *Copy) error {
+		return current.ApplyUpdate(updatedCopy)
+	})
+}
+
+// GetCopies implements Service.
+func (s *service) GetCopies(ctx context.Context, params *GetCopiesParams) ([]*Copy, int, error) {
+	return s.repo.GetCopies(ctx, params)
+}
+
+// DeleteCopyByID implements Service.
+func (s *service) DeleteCopyByID(ctx context.Context, id int) error {
+	return s.repo.DeleteCopyByID(ctx, id)
+}
@@ -0,0 +1,11 @@
+package fines
+
+import "net/http"
+
+type Handler interface {
+	GetFines(res http.ResponseWriter, req *http.Request)
+	GetFineByID(res http.ResponseWriter, req *http.Request)
+	GetMemberBalance(res http.ResponseWriter, req *http.Request)
+	PayFine(res http.ResponseWriter, req *http.Request)
+	WaiveFine(res http.ResponseWriter, req *http.Request)
+}
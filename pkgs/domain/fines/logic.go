@@ -0,0 +1,149 @@
+package fines
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+)
+
+type Status string
+
+// Create global errors that are specific to this domain
+var (
+	ErrFineNotFound       = errors.New("fine does not exist")
+	ErrFineNotOutstanding = errors.New("fine has already been paid or waived")
+	ErrInvalidPayment     = errors.New("payment amount must be greater than zero")
+	ErrOverpayment        = errors.New("payment amount is greater than the amount outstanding")
+	ErrWaiverReason       = errors.New("reason field is required to waive a fine")
+	ErrMemberNotFound     = errors.New("member does not exist")
+)
+
+const (
+	Outstanding Status = "outstanding"
+	Paid        Status = "paid"
+	Waived      Status = "waived"
+)
+
+// Charges for a membership type, all amounts are in cents
+type Rate struct {
+	DailyRate int
+	// Days after the due date before any fine is charged
+	GracePeriodDays int
+	// Zero means the fine is not capped
+	MaxFine int
+}
+
+type Policy struct {
+	// Members owing more than this cannot check out books
+	CheckoutBlockThreshold int
+	Default                Rate
+	MembershipRates        map[string]Rate
+}
+
+// All amounts are in cents
+type Fine struct {
+	ID           int              `json:"id" db:"id"`
+	LoanID       int              `json:"loan_id" db:"loan_id"`
+	MemberID     int              `json:"member_id" db:"member_id"`
+	DaysOverdue  int              `json:"days_overdue" db:"days_overdue"`
+	Amount       int              `json:"amount" db:"amount"`
+	AmountPaid   int              `json:"amount_paid" db:"amount_paid"`
+	Status       Status           `json:"status" db:"status"`
+	WaiverReason string           `json:"waiver_reason" db:"waiver_reason"`
+	AssessedAt   utils.CustomTime `json:"assessed_at" db:"assessed_at"`
+	SettledAt    utils.CustomTime `json:"settled_at" db:"settled_at"`
+	UpdatedAt    utils.CustomTime `json:"updated_at" db:"updated_at"`
+	CreatedAt    utils.CustomTime `json:"created_at" db:"created_at"`
+}
+
+// A returned loan that may have to be charged for
+type OverdueLoan struct {
+	LoanID     int
+	MemberID   int
+	DueAt      time.Time
+	ReturnedAt time.Time
+}
+
+type GetFinesParams struct {
+	ID       int
+	Page     int
+	PerPage  int
+	LoanID   int
+	MemberID int
+	Status   Status
+}
+
+// Look up the rate for a membership type, unknown types use the default rate
+func (p *Policy) RateFor(membershipType string) Rate {
+	if rate, ok := p.MembershipRates[membershipType]; ok {
+		return rate
+	}
+	return p.Default
+}
+
+func (p *Policy) BlocksCheckout(balance int) bool {
+	return balance > p.CheckoutBlockThreshold
+}
+
+// Work out the days overdue and the charge, every started day late counts as a full day
+// and only days beyond the grace period are charged
+func (r Rate) Calculate(dueAt, returnedAt time.Time) (int, int) {
+	if !returnedAt.After(dueAt) {
+		return 0, 0
+	}
+	daysOverdue := int(math.Ceil(returnedAt.Sub(dueAt).Hours() / 24))
+	chargeableDays := daysOverdue - r.GracePeriodDays
+	if chargeableDays <= 0 {
+		return daysOverdue, 0
+	}
+	amount := chargeableDays * r.DailyRate
+	if r.MaxFine > 0 && amount > r.MaxFine {
+		amount = r.MaxFine
+	}
+	return daysOverdue, amount
+}
+
+// Object methods for aggregate root
+func (f *Fine) Remaining() int {
+	if f.Status != Outstanding {
+		return 0
+	}
+	return f.Amount - f.AmountPaid
+}
+
+// Record a payment against the fine, part payments leave the fine outstanding
+func (f *Fine) ApplyPayment(amount int, at time.Time) error {
+	if f.Status != Outstanding {
+		return ErrFineNotOutstanding
+	}
+	if amount <= 0 {
+		return ErrInvalidPayment
+	}
+	if amount > f.Remaining() {
+		return ErrOverpayment
+	}
+	f.AmountPaid += amount
+	if f.AmountPaid == f.Amount {
+		f.Status = Paid
+		f.SettledAt = utils.CustomTime{Time: at}
+	}
+	f.UpdatedAt = utils.CustomTime{Time: at}
+	return nil
+}
+
+// Cancel whatever is left of the fine, a reason is always kept for the record
+func (f *Fine) Waive(reason string, at time.Time) error {
+	if f.Status != Outstanding {
+		return ErrFineNotOutstanding
+	}
+	if reason == "" {
+		return ErrWaiverReason
+	}
+	f.Status = Waived
+	f.WaiverReason = reason
+	f.SettledAt = utils.CustomTime{Time: at}
+	f.UpdatedAt = utils.CustomTime{Time: at}
+	return nil
+}
@@ -0,0 +1,66 @@
+package fines
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateFine(t *testing.T) {
+	assertWithTest := assert.New(t)
+	dueAt := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	rate := Rate{DailyRate: 25, GracePeriodDays: 2, MaxFine: 200}
+	testCases := []struct {
+		ReturnedAt          time.Time
+		ExpectedDaysOverdue int
+		ExpectedAmount      int
+		Message             string
+	}{
+		{ReturnedAt: dueAt.Add(-time.Hour), ExpectedDaysOverdue: 0, ExpectedAmount: 0, Message: "Returned on time"},
+		{ReturnedAt: dueAt.Add(time.Hour), ExpectedDaysOverdue: 1, ExpectedAmount: 0, Message: "Part day counts as a day, within grace"},
+		{ReturnedAt: dueAt.Add(72 * time.Hour), ExpectedDaysOverdue: 3, ExpectedAmount: 25, Message: "One chargeable day"},
+		{ReturnedAt: dueAt.Add(30 * 24 * time.Hour), ExpectedDaysOverdue: 30, ExpectedAmount: 200, Message: "Capped at the maximum"},
+	}
+	for _, test := range testCases {
+		daysOverdue, amount := rate.Calculate(dueAt, test.ReturnedAt)
+		assertWithTest.Equal(test.ExpectedDaysOverdue, daysOverdue, test.Message)
+		assertWithTest.Equal(test.ExpectedAmount, amount, test.Message)
+	}
+	// No cap
+	_, amount := Rate{DailyRate: 25}.Calculate(dueAt, dueAt.Add(30*24*time.Hour))
+	assertWithTest.Equal(750, amount)
+}
+
+func TestPolicy(t *testing.T) {
+	assertWithTest := assert.New(t)
+	policy := Policy{
+		CheckoutBlockThreshold: 500,
+		Default:                Rate{DailyRate: 25},
+		MembershipRates:        map[string]Rate{"student": {DailyRate: 10}},
+	}
+	assertWithTest.Equal(10, policy.RateFor("student").DailyRate)
+	assertWithTest.Equal(25, policy.RateFor("unknown").DailyRate)
+	assertWithTest.False(policy.BlocksCheckout(500))
+	assertWithTest.True(policy.BlocksCheckout(501))
+}
+
+func TestSettleFine(t *testing.T) {
+	assertWithTest := assert.New(t)
+	now := time.Now()
+	fine := Fine{Amount: 100, Status: Outstanding}
+	assertWithTest.Equal(ErrInvalidPayment, fine.ApplyPayment(0, now))
+	assertWithTest.Equal(ErrOverpayment, fine.ApplyPayment(101, now))
+	assertWithTest.Nil(fine.ApplyPayment(40, now))
+	assertWithTest.Equal(Outstanding, fine.Status)
+	assertWithTest.Equal(60, fine.Remaining())
+	assertWithTest.Nil(fine.ApplyPayment(60, now))
+	assertWithTest.Equal(Paid, fine.Status)
+	assertWithTest.Equal(ErrFineNotOutstanding, fine.Waive("lost in post", now))
+
+	fine = Fine{Amount: 100, Status: Outstanding}
+	assertWithTest.Equal(ErrWaiverReason, fine.Waive("", now))
+	assertWithTest.Nil(fine.Waive("first offence", now))
+	assertWithTest.Equal(Waived, fine.Status)
+	assertWithTest.Equal(0, fine.Remaining())
+}
@@ -0,0 +1,15 @@
+package fines
+
+import (
+	"context"
+)
+
+type Repository interface {
+	// Inserting a fine for a loan that already has one is a no-op
+	InsertFine(ctx context.Context, newFine *Fine) error
+	GetFines(ctx context.Context, params *GetFinesParams) ([]*Fine, int, error)
+	// Locks the fine, applies the change and writes it back in one transaction
+	SettleFine(ctx context.Context, fineID int, settle func(*Fine) error) (*Fine, error)
+	OutstandingBalance(ctx context.Context, memberID int) (int, error)
+	MembershipType(ctx context.Context, memberID int) (string, error)
+}
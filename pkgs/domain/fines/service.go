@@ -0,0 +1,86 @@
+package fines
+
+import (
+	"context"
+	"time"
+)
+
+type Service interface {
+	// Charge for a late return according to the member's policy, returns nil when nothing is owed
+	AssessOverdueLoan(ctx context.Context, loan OverdueLoan) (*Fine, error)
+	GetFines(ctx context.Context, params *GetFinesParams) ([]*Fine, int, error)
+	PayFine(ctx context.Context, fineID int, amount int) (*Fine, error)
+	WaiveFine(ctx context.Context, fineID int, reason string) (*Fine, error)
+	OutstandingBalance(ctx context.Context, memberID int) (int, error)
+	// Reports whether the member owes too much to borrow
+	CheckoutBlocked(ctx context.Context, memberID int) (bool, error)
+}
+
+type service struct {
+	repo   Repository
+	policy *Policy
+}
+
+func NewService(repo Repository, policy *Policy) Service {
+	return &service{
+		repo:   repo,
+		policy: policy,
+	}
+}
+
+// AssessOverdueLoan implements Service.
+func (s *service) AssessOverdueLoan(ctx context.Context, loan OverdueLoan) (*Fine, error) {
+	membershipType, err := s.repo.MembershipType(ctx, loan.MemberID)
+	if err != nil {
+		return nil, err
+	}
+	daysOverdue, amount := s.policy.RateFor(membershipType).Calculate(loan.DueAt, loan.ReturnedAt)
+	if amount == 0 {
+		return nil, nil
+	}
+	newFine := &Fine{
+		LoanID:      loan.LoanID,
+		MemberID:    loan.MemberID,
+		DaysOverdue: daysOverdue,
+		Amount:      amount,
+		Status:      Outstanding,
+	}
+	newFine.AssessedAt.Time = loan.ReturnedAt
+	if err := s.repo.InsertFine(ctx, newFine); err != nil {
+		return nil, err
+	}
+	return newFine, nil
+}
+
+// GetFines implements Service.
+func (s *service) GetFines(ctx context.Context, params *GetFinesParams) ([]*Fine, int, error) {
+	return s.repo.GetFines(ctx, params)
+}
+
+// PayFine implements Service.
+func (s *service) PayFine(ctx context.Context, fineID int, amount int) (*Fine, error) {
+	return s.repo.SettleFine(ctx, fineID, func(f *Fine) error {
+		return f.ApplyPayment(amount, time.Now())
+	})
+}
+
+// WaiveFine implements Service.
+func (s *service) WaiveFine(ctx context.Context, fineID int, reason string) (*Fine, error) {
+	return s.repo.SettleFine(ctx, fineID, func(f *Fine) error {
+		return f.Waive(reason, time.Now())
+	})
+}
+
+// OutstandingBalance implements Service.
+func (s *service) OutstandingBalance(ctx context.Context, memberID int) (int, error) {
+	return s.repo.OutstandingBalance(ctx, memberID)
+}
+
+// CheckoutBlocked implements Service.
+func (s *service) CheckoutBlocked(ctx context.Context, memberID int) (bool, error) {
+	balance, err := s.repo.OutstandingBalance(ctx, memberID)
+	if err != nil {
+		return false, err
+	}
+	return s.policy.BlocksCheckout(balance), nil
+}
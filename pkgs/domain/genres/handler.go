@@ -0,0 +1,15 @@
+package genres
+
+import "net/http"
+
+type Handler interface {
+	CreateGenre(res http.ResponseWriter, req *http.Request)
+	GetGenres(res http.ResponseWriter, req *http.Request)
+	GetGenreByID(res http.ResponseWriter, req *http.Request)
+	RenameGenre(res http.ResponseWriter, req *http.Request)
+	MoveGenre(res http.ResponseWriter, req *http.Request)
+	MergeGenre(res http.ResponseWriter, req *http.Request)
+	DeleteGenre(res http.ResponseWriter, req *http.Request)
+	AddBook(res http.ResponseWriter, req *http.Request)
+	RemoveBook(res http.ResponseWriter, req *http.Request)
+}
@@ -0,0 +1,99 @@
+package genres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/go-playground/validator"
+)
+
+type Kind string
+
+// Create global errors that are specific to this domain
+var (
+	ErrGenreNotFound      = errors.New("genre does not exist")
+	ErrParentNotFound     = errors.New("parent genre does not exist")
+	ErrBookNotFound       = errors.New("book does not exist")
+	ErrBookNotInGenre     = errors.New("book is not in this genre")
+	ErrGenreAlreadyExists = errors.New("a genre with this name already exists under the same parent")
+	ErrGenreCycle         = errors.New("a genre cannot be moved or merged into itself or one of its own sub-genres")
+	ErrGenreNotEmpty      = errors.New("genre still has sub-genres or books, move or merge them first")
+)
+
+const (
+	// A form or style of writing such as Science Fiction
+	Genre Kind = "genre"
+	// What the book is about such as World War II
+	Subject Kind = "subject"
+)
+
+// Separator used between the names of a genre and its ancestors in Path
+const PathSeparator = " > "
+
+// A node in the genre and subject tree, a genre also covers every book in the nodes below it
+type Node struct {
+	ID   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name" validate:"required"`
+	// Zero for the top level of the tree
+	ParentID int  `json:"parent_id" db:"parent_id"`
+	Kind     Kind `json:"kind" db:"kind" validate:"required,eq=genre|eq=subject"`
+	// Names from the top of the tree down to this node, e.g. Fiction > Science Fiction > Dystopian
+	Path      string           `json:"path" db:"path"`
+	UpdatedAt utils.CustomTime `json:"updated_at" db:"updated_at"`
+	CreatedAt utils.CustomTime `json:"created_at" db:"created_at"`
+}
+
+type GetNodesParams struct {
+	ID      int
+	Page    int
+	PerPage int
+	Name    string
+	Kind    Kind
+	// Only the direct children of this node, -1 for the top level of the tree
+	ParentID int
+	// Every node below this one, at any depth
+	AncestorID int
+	BookID     int
+}
+
+// Object methods for aggregate root
+// Validation for creating a Node, nodes are genres unless told otherwise
+func (n *Node) ValidateCreateNode() error {
+	n.Name = strings.TrimSpace(n.Name)
+	if n.Kind == "" {
+		n.Kind = Genre
+	}
+	validate := validator.New()
+	if err := validate.Struct(n); err != nil {
+		return validationErrMessage(err.(validator.ValidationErrors))
+	}
+	return nil
+}
+
+// Validation for renaming a Node
+func (n *Node) ValidateRename() error {
+	n.Name = strings.TrimSpace(n.Name)
+	validate := validator.New()
+	if err := validate.StructPartial(n, "Name"); err != nil {
+		return validationErrMessage(err.(validator.ValidationErrors))
+	}
+	return nil
+}
+
+// Internal helper funcs for methods
+func validationErrMessage(errs validator.ValidationErrors) error {
+	for _, err := range errs {
+		field := strings.ToLower(err.Field())
+		switch err.Tag() {
+		case "required":
+			return fmt.Errorf("%s field is required", field)
+		case "eq=genre|eq=subject":
+			return fmt.Errorf("value for %s is not recognised, please use genre or subject", field)
+		default:
+			return fmt.Errorf("value for %s is not recognized", field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package genres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateNodeValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	testCases := []struct {
+		Input         Node
+		ExpectedError error
+		Message       string
+	}{
+		{Input: Node{Name: "Science Fiction", ParentID: 1}, ExpectedError: nil, Message: "Correct format for Node"},
+		{Input: Node{Name: "World War II", Kind: Subject}, ExpectedError: nil, Message: "Subjects are allowed"},
+		{Input: Node{Name: "  "}, ExpectedError: errors.New("name field is required"), Message: "Name is required"},
+		{Input: Node{Name: "Poetry", Kind: "format"}, ExpectedError: errors.New("value for kind is not recognised, please use genre or subject"), Message: "Unknown kind"},
+	}
+	for _, test := range testCases {
+		assertWithTest.Equal(test.ExpectedError, test.Input.ValidateCreateNode(), test.Message)
+	}
+	node := Node{Name: " Fiction "}
+	assertWithTest.Nil(node.ValidateCreateNode())
+	assertWithTest.Equal("Fiction", node.Name)
+	assertWithTest.Equal(Genre, node.Kind)
+}
+
+func TestRenameValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	assertWithTest.Nil((&Node{ID: 1, Name: "Sci-Fi"}).ValidateRename())
+	assertWithTest.Equal(errors.New("name field is required"), (&Node{ID: 1}).ValidateRename())
+}
@@ -0,0 +1,16 @@
+package genres
+
+import "context"
+
+type Repository interface {
+	InsertNodes(ctx context.Context, newNodes []*Node) error
+	GetNodes(ctx context.Context, params *GetNodesParams) ([]*Node, int, error)
+	RenameNode(ctx context.Context, id int, name string) error
+	// Moves the node and everything below it under a new parent, zero moves it to the top level
+	MoveNode(ctx context.Context, id, parentID int) error
+	// Moves the books and sub-genres of the source onto the target and removes the source
+	MergeNodes(ctx context.Context, sourceID, targetID int) error
+	DeleteNodeByID(ctx context.Context, id int) error
+	AddBook(ctx context.Context, nodeID, bookID int) error
+	RemoveBook(ctx context.Context, nodeID, bookID int) error
+}
@@ -0,0 +1,101 @@
+package genres
+
+import "context"
+
+type Service interface {
+	CreateNodes(ctx context.Context, newNodes []*Node) error
+	GetNodes(ctx context.Context, params *GetNodesParams) ([]*Node, int, error)
+	RenameNode(ctx context.Context, id int, name string) (*Node, error)
+	MoveNode(ctx context.Context, id, parentID int) (*Node, error)
+	MergeNodes(ctx context.Context, sourceID, targetID int) (*Node, error)
+	DeleteNodeByID(ctx context.Context, id int) error
+	AddBook(ctx context.Context, nodeID, bookID int) error
+	RemoveBook(ctx context.Context, nodeID, bookID int) error
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// CreateNodes implements Service.
+func (s *service) CreateNodes(ctx context.Context, newNodes []*Node) error {
+	if err := s.repo.InsertNodes(ctx, newNodes); err != nil {
+		return err
+	}
+	// Fill in the derived paths for the response
+	for _, node := range newNodes {
+		created, err := s.getNode(ctx, node.ID)
+		if err != nil {
+			return err
+		}
+		node.Path = created.Path
+	}
+	return nil
+}
+
+// GetNodes implements Service.
+func (s *service) GetNodes(ctx context.Context, params *GetNodesParams) ([]*Node, int, error) {
+	return s.repo.GetNodes(ctx, params)
+}
+
+// RenameNode implements Service.
+func (s *service) RenameNode(ctx context.Context, id int, name string) (*Node, error) {
+	if err := s.repo.RenameNode(ctx, id, name); err != nil {
+		return nil, err
+	}
+	return s.getNode(ctx, id)
+}
+
+// MoveNode implements Service.
+func (s *service) MoveNode(ctx context.Context, id, parentID int) (*Node, error) {
+	if id == parentID {
+		return nil, ErrGenreCycle
+	}
+	if err := s.repo.MoveNode(ctx, id, parentID); err != nil {
+		return nil, err
+	}
+	return s.getNode(ctx, id)
+}
+
+// MergeNodes implements Service.
+func (s *service) MergeNodes(ctx context.Context, sourceID, targetID int) (*Node, error) {
+	if sourceID == targetID {
+		return nil, ErrGenreCycle
+	}
+	if err := s.repo.MergeNodes(ctx, sourceID, targetID); err != nil {
+		return nil, err
+	}
+	return s.getNode(ctx, targetID)
+}
+
+// DeleteNodeByID implements Service.
+func (s *service) DeleteNodeByID(ctx context.Context, id int) error {
+	return s.repo.DeleteNodeByID(ctx, id)
+}
+
+// AddBook implements Service.
+func (s *service) AddBook(ctx context.Context, nodeID, bookID int) error {
+	return s.repo.AddBook(ctx, nodeID, bookID)
+}
+
+// RemoveBook implements Service.
+func (s *service) RemoveBook(ctx context.Context, nodeID, bookID int) error {
+	return s.repo.RemoveBook(ctx, nodeID, bookID)
+}
+
+func (s *service) getNode(ctx context.Context, id int) (*Node, error) {
+	nodes, _, err := s.repo.GetNodes(ctx, &GetNodesParams{ID: id})
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, ErrGenreNotFound
+	}
+	return nodes[0], nil
+}
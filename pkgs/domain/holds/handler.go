@@ -0,0 +1,10 @@
+package holds
+
+import "net/http"
+
+type Handler interface {
+	PlaceHold(res http.ResponseWriter, req *http.Request)
+	CancelHold(res http.ResponseWriter, req *http.Request)
+	GetHolds(res http.ResponseWriter, req *http.Request)
+	GetHoldByID(res http.ResponseWriter, req *http.Request)
+}
@@ -0,0 +1,93 @@
+package holds
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/go-playground/validator"
+)
+
+type Status string
+
+// Create global errors that are specific to this domain
+var (
+	ErrBookNotFound      = errors.New("book does not exist")
+	ErrBookAvailable     = errors.New("a copy of the book is available, check it out instead of placing a hold")
+	ErrBookAlreadyOnLoan = errors.New("member already has this book on loan")
+	ErrMemberNotFound    = errors.New("member does not exist")
+	ErrMemberNotActive   = errors.New("member is suspended or their membership has expired")
+	ErrHoldAlreadyExists = errors.New("member already has an open hold on this book")
+	ErrHoldNotFound      = errors.New("hold does not exist")
+	ErrHoldNotOpen       = errors.New("hold has already been fulfilled, cancelled or has expired")
+)
+
+const (
+	// In the queue for the book
+	Waiting Status = "waiting"
+	// A copy of the book has been set aside for the member until ExpiresAt
+	Ready Status = "ready"
+	// The member checked the book out
+	Fulfilled Status = "fulfilled"
+	Cancelled Status = "cancelled"
+	// The member did not collect the book in time
+	Expired Status = "expired"
+)
+
+// Time a member has to collect a book once their hold is ready
+const PickupWindow = 3 * 24 * time.Hour
+
+type Hold struct {
+	ID     int `json:"id" db:"id"`
+	BookID int `json:"book_id" db:"book_id" validate:"required"`
+	// The copy set aside for the member once the hold is ready
+	CopyID   int    `json:"copy_id" db:"copy_id"`
+	MemberID int    `json:"member_id" db:"member_id" validate:"required"`
+	Status   Status `json:"status" db:"status"`
+	// Place in the queue for waiting holds, derived from the order holds were placed in
+	Position  int              `json:"position" db:"position"`
+	PlacedAt  utils.CustomTime `json:"placed_at" db:"placed_at"`
+	ReadyAt   utils.CustomTime `json:"ready_at" db:"ready_at"`
+	ExpiresAt utils.CustomTime `json:"expires_at" db:"expires_at"`
+	UpdatedAt utils.CustomTime `json:"updated_at" db:"updated_at"`
+	CreatedAt utils.CustomTime `json:"created_at" db:"created_at"`
+}
+
+type GetHoldsParams struct {
+	ID       int
+	Page     int
+	PerPage  int
+	BookID   int
+	MemberID int
+	Status   Status
+}
+
+// Object methods for aggregate root
+// Validation for placing a hold
+func (h *Hold) ValidatePlaceHold() error {
+	validate := validator.New()
+	if err := validate.Struct(h); err != nil {
+		return validationErrMessage(err.(validator.ValidationErrors))
+	}
+	return nil
+}
+
+// Open holds still hold a place in, or at the front of, the queue
+func (h *Hold) IsOpen() bool {
+	return h.Status == Waiting || h.Status == Ready
+}
+
+// Internal helper funcs for methods
+func validationErrMessage(errs validator.ValidationErrors) error {
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			return fmt.Errorf("%s field is required", strings.ToLower(err.Field()))
+		default:
+			return fmt.Errorf("value for %s is not recognized", strings.ToLower(err.Field()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package holds
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceHoldValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	testCases := []struct {
+		Input         Hold
+		ExpectedError error
+		Message       string
+	}{
+		{Input: Hold{BookID: 1, MemberID: 2}, ExpectedError: nil, Message: "Correct format for Hold"},
+		{Input: Hold{BookID: 1}, ExpectedError: errors.New("memberid field is required"), Message: "Member is required"},
+	}
+	for _, test := range testCases {
+		assertWithTest.Equal(test.ExpectedError, test.Input.ValidatePlaceHold(), test.Message)
+	}
+}
+
+func TestHoldIsOpen(t *testing.T) {
+	assertWithTest := assert.New(t)
+	for status, open := range map[Status]bool{
+		Waiting: true, Ready: true, Fulfilled: false, Cancelled: false, Expired: false,
+	} {
+		assertWithTest.Equal(open, (&Hold{Status: status}).IsOpen(), string(status))
+	}
+}
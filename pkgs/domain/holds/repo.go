@@ -0,0 +1,16 @@
+package holds
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	// Joins the back of the queue for a book that is out
+	PlaceHold(ctx context.Context, newHold *Hold) error
+	// Leaves the queue, a ready hold passes the book on to the next member in line
+	CancelHold(ctx context.Context, holdID int, cancelledAt time.Time) (*Hold, error)
+	// Expires ready holds that were not collected and passes the books on, returns how many expired
+	ExpireReadyHolds(ctx context.Context, now time.Time) (int, error)
+	GetHolds(ctx context.Context, params *GetHoldsParams) ([]*Hold, int, error)
+}
@@ -0,0 +1,43 @@
+package holds
+
+import (
+	"context"
+	"time"
+)
+
+type Service interface {
+	PlaceHold(ctx context.Context, newHold *Hold) error
+	CancelHold(ctx context.Context, holdID int) (*Hold, error)
+	ExpireReadyHolds(ctx context.Context) (int, error)
+	GetHolds(ctx context.Context, params *GetHoldsParams) ([]*Hold, int, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// PlaceHold implements Service.
+func (s *service) PlaceHold(ctx context.Context, newHold *Hold) error {
+	return s.repo.PlaceHold(ctx, newHold)
+}
+
+// CancelHold implements Service.
+func (s *service) CancelHold(ctx context.Context, holdID int) (*Hold, error) {
+	return s.repo.CancelHold(ctx, holdID, time.Now())
+}
+
+// ExpireReadyHolds implements Service.
+func (s *service) ExpireReadyHolds(ctx context.Context) (int, error) {
+	return s.repo.ExpireReadyHolds(ctx, time.Now())
+}
+
+// GetHolds implements Service.
+func (s *service) GetHolds(ctx context.Context, params *GetHoldsParams) ([]*Hold, int, error) {
+	return s.repo.GetHolds(ctx, params)
+}
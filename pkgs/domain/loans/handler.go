@@ -0,0 +1,10 @@
+package loans
+
+import "net/http"
+
+type Handler interface {
+	CheckoutBook(res http.ResponseWriter, req *http.Request)
+	ReturnBook(res http.ResponseWriter, req *http.Request)
+	GetLoans(res http.ResponseWriter, req *http.Request)
+	GetLoanByID(res http.ResponseWriter, req *http.Request)
+}
@@ -0,0 +1,119 @@
+package loans
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/fines"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/go-playground/validator"
+)
+
+type Status string
+
+// Create global errors that are specific to this domain
+var (
+	ErrBookNotFound        = errors.New("book does not exist")
+	ErrBookNotAvailable    = errors.New("no copy of the book is available for checkout")
+	ErrMemberNotFound      = errors.New("member does not exist")
+	ErrMemberNotActive     = errors.New("member is suspended or their membership has expired")
+	ErrMemberOwesFines     = errors.New("member owes more in fines than the checkout limit allows")
+	ErrLoanNotFound        = errors.New("loan does not exist")
+	ErrLoanAlreadyReturned = errors.New("loan has already been returned")
+	ErrInvalidDueDate      = errors.New("due_at must be after checked_out_at")
+)
+
+const (
+	Active   Status = "active"
+	Overdue  Status = "overdue"
+	Returned Status = "returned"
+)
+
+// Default period a book may be kept before it is due back
+const DefaultLoanPeriod = 14 * 24 * time.Hour
+
+type Loan struct {
+	ID     int `json:"id" db:"id"`
+	BookID int `json:"book_id" db:"book_id" validate:"required"`
+	// Optional on checkout, any copy on the shelf is handed out when it is left empty
+	CopyID       int              `json:"copy_id" db:"copy_id"`
+	MemberID     int              `json:"member_id" db:"member_id" validate:"required"`
+	CheckedOutAt utils.CustomTime `json:"checked_out_at" db:"checked_out_at"`
+	DueAt        utils.CustomTime `json:"due_at" db:"due_at"`
+	ReturnedAt   utils.CustomTime `json:"returned_at" db:"returned_at"`
+	Status       Status           `json:"status" db:"-"`
+	// Charged when the book came back late, only set on the return response
+	Fine *fines.Fine `json:"fine,omitempty" db:"-"`
+	// The fine could not be assessed on return, it is assessed by the background sweep instead
+	FinePending bool             `json:"fine_pending,omitempty" db:"-"`
+	UpdatedAt   utils.CustomTime `json:"updated_at" db:"updated_at"`
+	CreatedAt   utils.CustomTime `json:"created_at" db:"created_at"`
+}
+
+// Late returns are assessed again by the background sweep for this long, fines missed on return are caught up
+const LateReturnWindow = 7 * 24 * time.Hour
+
+type GetLoansParams struct {
+	ID       int
+	Page     int
+	PerPage  int
+	BookID   int
+	CopyID   int
+	MemberID int
+	Status   Status
+}
+
+// Object methods for aggregate root
+// Validation for checking out a book
+func (l *Loan) ValidateCheckout() error {
+	validate := validator.New()
+	if err := validate.Struct(l); err != nil {
+		return validationErrMessage(err.(validator.ValidationErrors))
+	}
+	// A loan without a checkout date starts now
+	checkedOutAt := l.CheckedOutAt.Time
+	if checkedOutAt.IsZero() {
+		checkedOutAt = time.Now()
+	}
+	if !l.DueAt.IsZero() && !l.DueAt.After(checkedOutAt) {
+		return ErrInvalidDueDate
+	}
+	return nil
+}
+
+// Fill in the checkout and due dates that were not supplied by the client
+func (l *Loan) ApplyDefaults(now time.Time) {
+	if l.CheckedOutAt.IsZero() {
+		l.CheckedOutAt = utils.CustomTime{Time: now}
+	}
+	if l.DueAt.IsZero() {
+		l.DueAt = utils.CustomTime{Time: l.CheckedOutAt.Add(DefaultLoanPeriod)}
+	}
+}
+
+// Derive the status of the loan at a point in time, this is never stored
+func (l *Loan) SetStatus(now time.Time) {
+	switch {
+	case !l.ReturnedAt.IsZero():
+		l.Status = Returned
+	case now.After(l.DueAt.Time):
+		l.Status = Overdue
+	default:
+		l.Status = Active
+	}
+}
+
+// Internal helper funcs for methods
+func validationErrMessage(errs validator.ValidationErrors) error {
+	for _, err := range errs {
+		switch err.Tag() {
+		case "required":
+			return fmt.Errorf("%s field is required", strings.ToLower(err.Field()))
+		default:
+			return fmt.Errorf("value for %s is not recognized", strings.ToLower(err.Field()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package loans
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckoutValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	now := time.Now()
+	testCases := []struct {
+		Input         Loan
+		ExpectedError error
+		Message       string
+	}{
+		{
+			Input:         Loan{BookID: 1, MemberID: 2},
+			ExpectedError: nil,
+			Message:       "Dates are optional",
+		},
+		{
+			Input:         Loan{MemberID: 2},
+			ExpectedError: errors.New("bookid field is required"),
+			Message:       "Book is required",
+		},
+		{
+			Input: Loan{
+				BookID:       1,
+				MemberID:     2,
+				CheckedOutAt: utils.CustomTime{Time: now},
+				DueAt:        utils.CustomTime{Time: now.Add(-time.Hour)},
+			},
+			ExpectedError: ErrInvalidDueDate,
+			Message:       "Due date before checkout",
+		},
+	}
+	for _, test := range testCases {
+		assertWithTest.Equal(test.ExpectedError, test.Input.ValidateCheckout(), test.Message)
+	}
+}
+
+func TestLoanStatus(t *testing.T) {
+	assertWithTest := assert.New(t)
+	now := time.Now()
+	loan := Loan{BookID: 1, MemberID: 2}
+	loan.ApplyDefaults(now)
+	assertWithTest.Equal(now.Add(DefaultLoanPeriod), loan.DueAt.Time)
+
+	loan.SetStatus(now)
+	assertWithTest.Equal(Active, loan.Status)
+	loan.SetStatus(now.Add(DefaultLoanPeriod + time.Hour))
+	assertWithTest.Equal(Overdue, loan.Status)
+	loan.ReturnedAt = utils.CustomTime{Time: now.Add(DefaultLoanPeriod + time.Hour)}
+	loan.SetStatus(now.Add(DefaultLoanPeriod + time.Hour))
+	assertWithTest.Equal(Returned, loan.Status)
+}
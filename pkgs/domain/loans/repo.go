@@ -0,0 +1,16 @@
+package loans
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	// Inserts the loan and takes a copy off the shelf in one transaction
+	CheckoutBook(ctx context.Context, newLoan *Loan) error
+	// Closes the loan and passes the copy to the holds queue or back on the shelf in one transaction
+	ReturnLoan(ctx context.Context, loanID int, returnedAt time.Time) (*Loan, error)
+	GetLoans(ctx context.Context, params *GetLoansParams) ([]*Loan, int, error)
+	// Loans returned after their due date since the given time that have no fine on record
+	GetUnfinedLateReturns(ctx context.Context, returnedSince time.Time) ([]*Loan, error)
+}
@@ -0,0 +1,108 @@
+package loans
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/fines"
+)
+
+type Service interface {
+	CheckoutBook(ctx context.Context, newLoan *Loan) error
+	ReturnBook(ctx context.Context, loanID int) (*Loan, error)
+	GetLoans(ctx context.Context, params *GetLoansParams) ([]*Loan, int, error)
+	// Assesses the late returns of the window that were left without a fine, returning how many were fined
+	AssessLateReturns(ctx context.Context, window time.Duration) (int, error)
+}
+
+type service struct {
+	repo        Repository
+	fineService fines.Service
+}
+
+func NewService(repo Repository, fineService fines.Service) Service {
+	return &service{
+		repo:        repo,
+		fineService: fineService,
+	}
+}
+
+// CheckoutBook implements Service.
+func (s *service) CheckoutBook(ctx context.Context, newLoan *Loan) error {
+	now := time.Now()
+	newLoan.ApplyDefaults(now)
+	blocked, err := s.fineService.CheckoutBlocked(ctx, newLoan.MemberID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrMemberOwesFines
+	}
+	if err := s.repo.CheckoutBook(ctx, newLoan); err != nil {
+		return err
+	}
+	newLoan.SetStatus(now)
+	return nil
+}
+
+// ReturnBook implements Service.
+func (s *service) ReturnBook(ctx context.Context, loanID int) (*Loan, error) {
+	now := time.Now()
+	returnedLoan, err := s.repo.ReturnLoan(ctx, loanID, now)
+	if err != nil {
+		return nil, err
+	}
+	returnedLoan.SetStatus(now)
+	// The return is already committed, a fine that cannot be assessed now is left to AssessLateReturns.
+	// A loan is only ever fined once, so the sweep cannot charge it twice
+	fine, err := s.assess(ctx, returnedLoan)
+	if err != nil {
+		returnedLoan.FinePending = true
+		return returnedLoan, nil
+	}
+	returnedLoan.Fine = fine
+	return returnedLoan, nil
+}
+
+// AssessLateReturns implements Service.
+func (s *service) AssessLateReturns(ctx context.Context, window time.Duration) (int, error) {
+	lateReturns, err := s.repo.GetUnfinedLateReturns(ctx, time.Now().Add(-window))
+	if err != nil {
+		return 0, err
+	}
+	assessed := 0
+	for _, loan := range lateReturns {
+		fine, err := s.assess(ctx, loan)
+		if err != nil {
+			return assessed, fmt.Errorf("loan %d: %w", loan.ID, err)
+		}
+		// Returns inside the grace period are late without owing anything
+		if fine != nil {
+			assessed++
+		}
+	}
+	return assessed, nil
+}
+
+func (s *service) assess(ctx context.Context, returnedLoan *Loan) (*fines.Fine, error) {
+	return s.fineService.AssessOverdueLoan(ctx, fines.OverdueLoan{
+		LoanID:     returnedLoan.ID,
+		MemberID:   returnedLoan.MemberID,
+		DueAt:      returnedLoan.DueAt.Time,
+		ReturnedAt: returnedLoan.ReturnedAt.Time,
+	})
+}
+
+// GetLoans implements Service.
+func (s *service) GetLoans(ctx context.Context, params *GetLoansParams) ([]*Loan, int, error) {
+	retrievedLoans, count, err := s.repo.GetLoans(ctx, params)
+	if err != nil {
+		return nil, -1, err
+	}
+	now := time.Now()
+	for _, loan := range retrievedLoans {
+		loan.SetStatus(now)
+	}
+	return retrievedLoans, count, nil
+}
@@ -0,0 +1,62 @@
+package loans
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/fines"
+	"github.com/stretchr/testify/assert"
+)
+
+type returnRepo struct {
+	Repository
+	late []*Loan
+}
+
+func (r *returnRepo) ReturnLoan(ctx context.Context, loanID int, returnedAt time.Time) (*Loan, error) {
+	loan := &Loan{ID: loanID, MemberID: 1}
+	loan.DueAt.Time = returnedAt.Add(-72 * time.Hour)
+	loan.ReturnedAt.Time = returnedAt
+	return loan, nil
+}
+
+func (r *returnRepo) GetUnfinedLateReturns(ctx context.Context, returnedSince time.Time) ([]*Loan, error) {
+	return r.late, nil
+}
+
+type assessFines struct {
+	fines.Service
+	err      error
+	assessed []int
+}
+
+func (f *assessFines) AssessOverdueLoan(ctx context.Context, loan fines.OverdueLoan) (*fines.Fine, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.assessed = append(f.assessed, loan.LoanID)
+	return &fines.Fine{LoanID: loan.LoanID, Amount: 75}, nil
+}
+
+func TestReturnBookFinePending(t *testing.T) {
+	assertWithTest := assert.New(t)
+	repo := &returnRepo{}
+	fineService := &assessFines{err: errors.New("fines are down")}
+	service := NewService(repo, fineService)
+
+	// The return went through, so it is not reported as a failure when the fine cannot be assessed
+	returned, err := service.ReturnBook(context.Background(), 4)
+	assertWithTest.Nil(err)
+	assertWithTest.True(returned.FinePending)
+	assertWithTest.Nil(returned.Fine)
+
+	// The sweep fines it once the fines ledger is back
+	fineService.err = nil
+	repo.late = []*Loan{returned}
+	assessed, err := service.AssessLateReturns(context.Background(), LateReturnWindow)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, assessed)
+	assertWithTest.Equal([]int{4}, fineService.assessed)
+}
@@ -0,0 +1,11 @@
+package members
+
+import "net/http"
+
+type Handler interface {
+	CreateMember(res http.ResponseWriter, req *http.Request)
+	UpdateMember(res http.ResponseWriter, req *http.Request)
+	GetMembers(res http.ResponseWriter, req *http.Request)
+	GetMemberByID(res http.ResponseWriter, req *http.Request)
+	DeleteMember(res http.ResponseWriter, req *http.Request)
+}
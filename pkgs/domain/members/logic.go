@@ -0,0 +1,141 @@
+package members
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/go-playground/validator"
+)
+
+type MembershipType string
+type Status string
+
+// Create global errors that are specific to this domain
+var (
+	ErrMemberAlreadyExists = errors.New("a member with this card number already exists")
+	ErrMemberNotFound      = errors.New("member does not exist")
+	ErrMemberHasLoans      = errors.New("member has loan or hold history and cannot be deleted, suspend the member instead")
+)
+
+const (
+	Standard MembershipType = "standard"
+	Student  MembershipType = "student"
+	Senior   MembershipType = "senior"
+	Staff    MembershipType = "staff"
+)
+
+const (
+	Active    Status = "active"
+	Suspended Status = "suspended"
+	Expired   Status = "expired"
+)
+
+type Member struct {
+	ID             int              `json:"id" db:"id"`
+	CardNumber     string           `json:"card_number" db:"card_number" validate:"required"`
+	Name           string           `json:"name" db:"name" validate:"required"`
+	Email          string           `json:"email" db:"email" validate:"required,email"`
+	Phone          string           `json:"phone" db:"phone"`
+	Address        string           `json:"address" db:"address"`
+	MembershipType MembershipType   `json:"membership_type" db:"membership_type" validate:"required,eq=standard|eq=student|eq=senior|eq=staff"`
+	Status         Status           `json:"status" db:"status" validate:"required,eq=active|eq=suspended|eq=expired"`
+	ExpiresAt      utils.CustomDate `json:"expires_at" db:"expires_at" validate:"required"`
+	UpdatedAt      utils.CustomTime `json:"updated_at" db:"updated_at"`
+	CreatedAt      utils.CustomTime `json:"created_at" db:"created_at"`
+}
+
+type GetMembersParams struct {
+	ID             int
+	Page           int
+	PerPage        int
+	CardNumber     string
+	Name           string
+	Email          string
+	MembershipType MembershipType
+	Status         Status
+}
+
+// Object methods for aggregate root
+// Validation for creating a Member
+func (m *Member) ValidateCreateMember() error {
+	validate := validator.New()
+	if err := validate.Struct(m); err != nil {
+		return validationErrMessage(err.(validator.ValidationErrors))
+	}
+	return nil
+}
+
+// Validation for updating a Member, only the fields that were supplied are checked
+func (m *Member) ValidateUpdateMember() error {
+	validate := validator.New()
+	fields := []string{}
+	if m.Email != "" {
+		fields = append(fields, "Email")
+	}
+	if m.MembershipType != "" {
+		fields = append(fields, "MembershipType")
+	}
+	if m.Status != "" {
+		fields = append(fields, "Status")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	if err := validate.StructPartial(m, fields...); err != nil {
+		return validationErrMessage(err.(validator.ValidationErrors))
+	}
+	return nil
+}
+
+// An active membership lapses once the expiry date has passed, even if the stored status was never updated
+func (m *Member) SetEffectiveStatus(now time.Time) {
+	if m.Status == Active && Lapsed(m.ExpiresAt.Time, now) {
+		m.Status = Expired
+	}
+}
+
+// Today is the date memberships are checked against, in the server's time zone and in the form expiry dates
+// are stored in so it can be compared with them in SQL as well
+func Today(now time.Time) string {
+	return now.Format("2006-01-02")
+}
+
+// Lapsed reports whether a membership has expired. It is good through its expiry date and lapses when the next
+// day starts, the expiry date is read as the date it was stored as whatever zone it was read in
+func Lapsed(expiresAt, now time.Time) bool {
+	return expiresAt.Format("2006-01-02") < Today(now)
+}
+
+// Internal helper funcs for methods
+func validationErrMessage(errs validator.ValidationErrors) error {
+	for _, err := range errs {
+		field := toSnakeCase(err.Field())
+		switch err.Tag() {
+		case "required":
+			return fmt.Errorf("%s field is required", field)
+		case "email":
+			return fmt.Errorf("value for %s is not a valid email address", field)
+		case "eq=standard|eq=student|eq=senior|eq=staff":
+			return fmt.Errorf("value for %s is not recognised, please use standard, student, senior or staff", field)
+		case "eq=active|eq=suspended|eq=expired":
+			return fmt.Errorf("value for %s is not recognised, please use active, suspended or expired", field)
+		default:
+			return fmt.Errorf("value for %s is not recognized", field)
+		}
+	}
+	return nil
+}
+
+func toSnakeCase(field string) string {
+	var builder strings.Builder
+	for i, r := range field {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			builder.WriteByte('_')
+		}
+		builder.WriteRune(r)
+	}
+	return strings.ToLower(builder.String())
+}
@@ -0,0 +1,70 @@
+package members
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateMemberValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	valid := Member{
+		CardNumber:     "LIB-000001",
+		Name:           "Ada Lovelace",
+		Email:          "ada@example.com",
+		MembershipType: Standard,
+		Status:         Active,
+		ExpiresAt:      utils.CustomDate{Time: time.Now().AddDate(1, 0, 0)},
+	}
+	missingEmail := valid
+	missingEmail.Email = ""
+	badType := valid
+	badType.MembershipType = "gold"
+	testCases := []struct {
+		Input         Member
+		ExpectedError error
+		Message       string
+	}{
+		{Input: valid, ExpectedError: nil, Message: "Correct format for Member"},
+		{Input: missingEmail, ExpectedError: errors.New("email field is required"), Message: "Email is required"},
+		{
+			Input:         badType,
+			ExpectedError: errors.New("value for membership_type is not recognised, please use standard, student, senior or staff"),
+			Message:       "Unknown membership type",
+		},
+	}
+	for _, test := range testCases {
+		assertWithTest.Equal(test.ExpectedError, test.Input.ValidateCreateMember(), test.Message)
+	}
+}
+
+func TestUpdateMemberValidation(t *testing.T) {
+	assertWithTest := assert.New(t)
+	assertWithTest.Nil((&Member{Name: "Only a name"}).ValidateUpdateMember())
+	assertWithTest.Equal(errors.New("value for status is not recognised, please use active, suspended or expired"),
+		(&Member{Status: "banned"}).ValidateUpdateMember())
+}
+
+func TestEffectiveStatus(t *testing.T) {
+	assertWithTest := assert.New(t)
+	now := time.Now()
+	member := Member{Status: Active, ExpiresAt: utils.CustomDate{Time: now.AddDate(0, 0, -1)}}
+	member.SetEffectiveStatus(now)
+	assertWithTest.Equal(Expired, member.Status)
+	member = Member{Status: Suspended, ExpiresAt: utils.CustomDate{Time: now.AddDate(0, 0, -1)}}
+	member.SetEffectiveStatus(now)
+	assertWithTest.Equal(Suspended, member.Status)
+
+	// A membership is still good on its expiry date, dates come back from the database at midnight UTC
+	expiryDay := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	lateThatDay := time.Date(2026, 10, 18, 23, 30, 0, 0, time.Local)
+	assertWithTest.False(Lapsed(expiryDay, lateThatDay))
+	assertWithTest.True(Lapsed(expiryDay, lateThatDay.AddDate(0, 0, 1)))
+	member = Member{Status: Active, ExpiresAt: utils.CustomDate{Time: expiryDay}}
+	member.SetEffectiveStatus(lateThatDay)
+	assertWithTest.Equal(Active, member.Status)
+	assertWithTest.Equal("2026-10-18", Today(lateThatDay))
+}
@@ -0,0 +1,12 @@
+package members
+
+import (
+	"context"
+)
+
+type Repository interface {
+	InsertMembers(ctx context.Context, newMembers []*Member) error
+	GetMembers(ctx context.Context, params *GetMembersParams) ([]*Member, int, error)
+	UpdateMember(ctx context.Context, arg *Member) error
+	DeleteMemberByID(ctx context.Context, id int) error
+}
@@ -0,0 +1,51 @@
+package members
+
+import (
+	"context"
+	"time"
+)
+
+type Service interface {
+	CreateMembers(ctx context.Context, newMembers []*Member) error
+	UpdateMember(ctx context.Context, updatedMember *Member) error
+	GetMembers(ctx context.Context, params *GetMembersParams) ([]*Member, int, error)
+	DeleteMemberByID(ctx context.Context, id int) error
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// CreateMembers implements Service.
+func (s *service) CreateMembers(ctx context.Context, newMembers []*Member) error {
+	return s.repo.InsertMembers(ctx, newMembers)
+}
+
+// GetMembers implements Service.
+func (s *service) GetMembers(ctx context.Context, params *GetMembersParams) ([]*Member, int, error) {
+	retrievedMembers, count, err := s.repo.GetMembers(ctx, params)
+	if err != nil {
+		return nil, -1, err
+	}
+	now := time.Now()
+	for _, member := range retrievedMembers {
+		member.SetEffectiveStatus(now)
+	}
+	return retrievedMembers, count, nil
+}
+
+// UpdateMember implements Service.
+func (s *service) UpdateMember(ctx context.Context, updatedMember *Member) error {
+	return s.repo.UpdateMember(ctx, updatedMember)
+}
+
+// DeleteMemberByID implements Service.
+func (s *service) DeleteMemberByID(ctx context.Context, id int) error {
+	return s.repo.DeleteMemberByID(ctx, id)
+}
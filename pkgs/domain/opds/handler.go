@@ -0,0 +1,13 @@
+package opds
+
+import "net/http"
+
+type Handler interface {
+	Root(res http.ResponseWriter, req *http.Request)
+	Genres(res http.ResponseWriter, req *http.Request)
+	Languages(res http.ResponseWriter, req *http.Request)
+	NewBooks(res http.ResponseWriter, req *http.Request)
+	Books(res http.ResponseWriter, req *http.Request)
+	Book(res http.ResponseWriter, req *http.Request)
+	OpenSearch(res http.ResponseWriter, req *http.Request)
+}
@@ -0,0 +1,331 @@
+package opds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/genres"
+)
+
+// Namespaces of an OPDS 1.2 catalogue, Atom is the default namespace of every feed
+const (
+	AtomNamespace       = "http://www.w3.org/2005/Atom"
+	OPDSNamespace       = "http://opds-spec.org/2010/catalog"
+	DCNamespace         = "http://purl.org/dc/terms/"
+	OpenSearchNamespace = "http://a9.com/-/spec/opensearch/1.1/"
+	ThreadNamespace     = "http://purl.org/syndication/thread/1.0"
+)
+
+// Media types of the documents in the catalogue
+const (
+	NavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	AcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	EntryType       = "application/atom+xml;type=entry;profile=opds-catalog"
+	OpenSearchType  = "application/opensearchdescription+xml"
+)
+
+// Link relations the catalogue uses beyond the plain Atom ones
+const (
+	RelStart      = "start"
+	RelSelf       = "self"
+	RelUp         = "up"
+	RelSearch     = "search"
+	RelFirst      = "first"
+	RelPrevious   = "previous"
+	RelNext       = "next"
+	RelAlternate  = "alternate"
+	RelSubsection = "subsection"
+	RelNew        = "http://opds-spec.org/sort/new"
+	// Books are lent rather than sold, so the acquisition link of every book is a borrow
+	RelBorrow = "http://opds-spec.org/acquisition/borrow"
+)
+
+// Acquisition feeds list this many books a page unless the client asks for another size
+const (
+	DefaultPerPage = 25
+	MaxPerPage     = 100
+)
+
+// Id of every feed and entry in the catalogue starts with this
+const idPrefix = "urn:library-api:opds:"
+
+// An Atom feed, navigation feeds list other feeds and acquisition feeds list books
+type Feed struct {
+	XMLName    xml.Name `xml:"feed"`
+	Namespace  string   `xml:"xmlns,attr"`
+	DC         string   `xml:"xmlns:dc,attr"`
+	OPDS       string   `xml:"xmlns:opds,attr"`
+	OpenSearch string   `xml:"xmlns:opensearch,attr"`
+	Thread     string   `xml:"xmlns:thr,attr"`
+	ID         string   `xml:"id"`
+	Title      string   `xml:"title"`
+	Updated    string   `xml:"updated"`
+	Author     *Person  `xml:"author,omitempty"`
+	Links      []*Link  `xml:"link"`
+	// Paging of an acquisition feed, startIndex counts from 1
+	ItemsPerPage int      `xml:"opensearch:itemsPerPage,omitempty"`
+	StartIndex   int      `xml:"opensearch:startIndex,omitempty"`
+	Entries      []*Entry `xml:"entry"`
+}
+
+// An entry of a feed, or a complete entry document when it carries the namespaces itself
+type Entry struct {
+	XMLName    xml.Name    `xml:"entry"`
+	Namespace  string      `xml:"xmlns,attr,omitempty"`
+	DC         string      `xml:"xmlns:dc,attr,omitempty"`
+	ID         string      `xml:"id"`
+	Title      string      `xml:"title"`
+	Updated    string      `xml:"updated"`
+	Authors    []*Person   `xml:"author"`
+	Identifier string      `xml:"dc:identifier,omitempty"`
+	Language   string      `xml:"dc:language,omitempty"`
+	Publisher  string      `xml:"dc:publisher,omitempty"`
+	Issued     string      `xml:"dc:issued,omitempty"`
+	Categories []*Category `xml:"category"`
+	Content    *Content    `xml:"content,omitempty"`
+	Links      []*Link     `xml:"link"`
+}
+
+type Person struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type Link struct {
+	Rel   string `xml:"rel,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+	// Number of books behind a navigation link, left out when it is not known
+	Count int `xml:"thr:count,attr,omitempty"`
+}
+
+type Category struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr,omitempty"`
+}
+
+type Content struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Tells clients how to search the catalogue, every parameter of the template is a filter of listing the books
+type OpenSearchDescription struct {
+	XMLName        xml.Name         `xml:"OpenSearchDescription"`
+	Namespace      string           `xml:"xmlns,attr"`
+	Atom           string           `xml:"xmlns:atom,attr"`
+	ShortName      string           `xml:"ShortName"`
+	Description    string           `xml:"Description"`
+	InputEncoding  string           `xml:"InputEncoding"`
+	OutputEncoding string           `xml:"OutputEncoding"`
+	URLs           []*OpenSearchURL `xml:"Url"`
+}
+
+type OpenSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Template string `xml:"template,attr"`
+}
+
+// Builds the documents of the catalogue with absolute links to the address it is served from
+type Catalogue struct {
+	// Scheme and host the catalogue is reached on, e.g. https://library.example.com
+	BaseURL string
+}
+
+// URL of a path in the catalogue with the query added when it is not empty
+func (c *Catalogue) URL(path string, query url.Values) string {
+	link := c.BaseURL + "/opds" + path
+	if encoded := query.Encode(); encoded != "" {
+		link += "?" + encoded
+	}
+	return link
+}
+
+// NewFeed starts a feed with the links every document of the catalogue carries
+func (c *Catalogue) NewFeed(id, title, self, kind string, updated time.Time) *Feed {
+	return &Feed{
+		Namespace:  AtomNamespace,
+		DC:         DCNamespace,
+		OPDS:       OPDSNamespace,
+		OpenSearch: OpenSearchNamespace,
+		Thread:     ThreadNamespace,
+		ID:         idPrefix + id,
+		Title:      title,
+		Updated:    updated.UTC().Format(time.RFC3339),
+		Author:     &Person{Name: "Library API"},
+		Links: []*Link{
+			{Rel: RelSelf, Href: self, Type: kind},
+			{Rel: RelStart, Href: c.URL("", nil), Type: NavigationType},
+			{Rel: RelSearch, Href: c.URL("/opensearch.xml", nil), Type: OpenSearchType},
+		},
+	}
+}
+
+// NavigationEntry links to another feed of the catalogue
+func (c *Catalogue) NavigationEntry(id, title, summary string, link *Link, updated time.Time) *Entry {
+	entry := &Entry{
+		ID:      idPrefix + id,
+		Title:   title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links:   []*Link{link},
+	}
+	if summary != "" {
+		entry.Content = &Content{Type: "text", Value: summary}
+	}
+	return entry
+}
+
+// Root lists the ways into the catalogue
+func (c *Catalogue) Root(updated time.Time) *Feed {
+	feed := c.NewFeed("root", "Library catalogue", c.URL("", nil), NavigationType, updated)
+	feed.Entries = []*Entry{
+		c.NavigationEntry("new", "New additions", "The books most recently added to the catalogue",
+			&Link{Rel: RelNew, Href: c.URL("/new", nil), Type: AcquisitionType}, updated),
+		c.NavigationEntry("genres", "By genre", "Browse the catalogue through the genre tree",
+			&Link{Rel: RelSubsection, Href: c.URL("/genres", nil), Type: NavigationType}, updated),
+		c.NavigationEntry("languages", "By language", "Books grouped by the language they are written in",
+			&Link{Rel: RelSubsection, Href: c.URL("/languages", nil), Type: NavigationType}, updated),
+		c.NavigationEntry("books", "All books", "Every book in the catalogue",
+			&Link{Rel: RelSubsection, Href: c.URL("/books", nil), Type: AcquisitionType}, updated),
+	}
+	return feed
+}
+
+// Genres lists the genres below the parent, with an entry for every book filed anywhere under the parent
+// first. A nil parent lists the top of the tree
+func (c *Catalogue) Genres(parent *genres.Node, children []*genres.Node, updated time.Time) *Feed {
+	id, title, self := "genres", "By genre", c.URL("/genres", nil)
+	if parent != nil {
+		id = "genres:" + strconv.Itoa(parent.ID)
+		title = parent.Path
+		self = c.URL("/genres/"+strconv.Itoa(parent.ID), nil)
+	}
+	feed := c.NewFeed(id, title, self, NavigationType, updated)
+	feed.Links = append(feed.Links, &Link{Rel: RelUp, Href: c.URL("", nil), Type: NavigationType})
+	if parent != nil {
+		query := url.Values{"genre_id": {strconv.Itoa(parent.ID)}}
+		feed.Entries = append(feed.Entries, c.NavigationEntry(id+":books", "All books in "+parent.Name, "",
+			&Link{Rel: RelSubsection, Href: c.URL("/books", query), Type: AcquisitionType}, updated))
+	}
+	for _, child := range children {
+		feed.Entries = append(feed.Entries, c.NavigationEntry("genres:"+strconv.Itoa(child.ID), child.Name, child.Path,
+			&Link{Rel: RelSubsection, Href: c.URL("/genres/"+strconv.Itoa(child.ID), nil), Type: NavigationType},
+			child.UpdatedAt.Time))
+	}
+	return feed
+}
+
+// Languages lists an acquisition feed for every language the catalogue has books in
+func (c *Catalogue) Languages(languages []*books.LanguageCount, updated time.Time) *Feed {
+	feed := c.NewFeed("languages", "By language", c.URL("/languages", nil), NavigationType, updated)
+	feed.Links = append(feed.Links, &Link{Rel: RelUp, Href: c.URL("", nil), Type: NavigationType})
+	for _, language := range languages {
+		query := url.Values{"language": {language.Language}}
+		feed.Entries = append(feed.Entries, c.NavigationEntry("languages:"+url.QueryEscape(language.Language),
+			language.Language, fmt.Sprintf("%d books", language.Books),
+			&Link{Rel: RelSubsection, Href: c.URL("/books", query), Type: AcquisitionType, Count: language.Books},
+			updated))
+	}
+	return feed
+}
+
+// Acquisition lists a page of books, a full page gets a next link as there may be more books after it
+func (c *Catalogue) Acquisition(id, title, path string, query url.Values, page, perPage int,
+	found []*books.Book, updated time.Time) *Feed {
+	pageQuery := func(page int) url.Values {
+		paged := url.Values{}
+		for key, values := range query {
+			paged[key] = values
+		}
+		paged.Set("page", strconv.Itoa(page))
+		paged.Set("per_page", strconv.Itoa(perPage))
+		return paged
+	}
+	feed := c.NewFeed(id, title, c.URL(path, pageQuery(page)), AcquisitionType, updated)
+	feed.Links = append(feed.Links,
+		&Link{Rel: RelUp, Href: c.URL("", nil), Type: NavigationType},
+		&Link{Rel: RelFirst, Href: c.URL(path, pageQuery(1)), Type: AcquisitionType},
+	)
+	if page > 1 {
+		feed.Links = append(feed.Links, &Link{Rel: RelPrevious, Href: c.URL(path, pageQuery(page-1)), Type: AcquisitionType})
+	}
+	if len(found) >= perPage {
+		feed.Links = append(feed.Links, &Link{Rel: RelNext, Href: c.URL(path, pageQuery(page+1)), Type: AcquisitionType})
+	}
+	feed.ItemsPerPage = perPage
+	feed.StartIndex = (page-1)*perPage + 1
+	for _, book := range found {
+		feed.Entries = append(feed.Entries, c.BookEntry(book))
+	}
+	return feed
+}
+
+// BookEntry describes a book with a link to borrow it through the api
+func (c *Catalogue) BookEntry(book *books.Book) *Entry {
+	id := strconv.Itoa(book.ID)
+	entry := &Entry{
+		ID:         "urn:isbn:" + book.ISBN,
+		Title:      book.Title,
+		Updated:    book.UpdatedAt.Time.UTC().Format(time.RFC3339),
+		Identifier: "urn:isbn:" + book.ISBN,
+		Language:   book.Language,
+		Publisher:  book.Publisher,
+		Content: &Content{Type: "text", Value: fmt.Sprintf("%d pages, %d of %d copies available",
+			book.Pages, book.CopiesAvailable, book.CopiesTotal)},
+		Links: []*Link{
+			{Rel: RelAlternate, Href: c.URL("/books/"+id, nil), Type: EntryType},
+			{Rel: RelBorrow, Href: c.BaseURL + "/books/" + id, Type: "application/json"},
+		},
+	}
+	if book.Author != "" {
+		entry.Authors = []*Person{{Name: book.Author}}
+	}
+	if !book.Published.IsZero() {
+		entry.Issued = book.Published.Format("2006-01-02")
+	}
+	if book.Genre != "" {
+		entry.Categories = []*Category{{Term: book.Genre, Label: book.Genre}}
+	}
+	return entry
+}
+
+// Document turns an entry into a complete entry document
+func (c *Catalogue) Document(entry *Entry) *Entry {
+	entry.Namespace, entry.DC = AtomNamespace, DCNamespace
+	return entry
+}
+
+// OpenSearch describes the search of the catalogue. searchTerms is matched against the title, and the
+// author, language and paging parameters map onto the filters of listing the books
+func (c *Catalogue) OpenSearch() *OpenSearchDescription {
+	template := c.URL("/books", nil) + "?" + strings.Join([]string{
+		"title={searchTerms}", "author={atom:author?}", "language={language?}",
+		"page={startPage?}", "per_page={count?}",
+	}, "&")
+	return &OpenSearchDescription{
+		Namespace:      OpenSearchNamespace,
+		Atom:           AtomNamespace,
+		ShortName:      "Library",
+		Description:    "Search the library catalogue by title, author and language",
+		InputEncoding:  "UTF-8",
+		OutputEncoding: "UTF-8",
+		URLs:           []*OpenSearchURL{{Type: AcquisitionType, Template: template}},
+	}
+}
+
+// PerPage reads the page size a client asked for, keeping it within the limits of the catalogue
+func PerPage(requested int) int {
+	switch {
+	case requested <= 0:
+		return DefaultPerPage
+	case requested > MaxPerPage:
+		return MaxPerPage
+	}
+	return requested
+}
@@ -0,0 +1,104 @@
+package opds
+
+import (
+	"encoding/xml"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBook(id int) *books.Book {
+	return &books.Book{
+		ID:              id,
+		ISBN:            "9780451524935",
+		Title:           "1984",
+		Author:          "George Orwell",
+		Publisher:       "Signet Classic",
+		Published:       utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:           "Dystopian",
+		Language:        "English",
+		Pages:           328,
+		CopiesAvailable: 1,
+		CopiesTotal:     2,
+		UpdatedAt:       utils.CustomTime{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+}
+
+func linkByRel(links []*Link, rel string) *Link {
+	for _, link := range links {
+		if link.Rel == rel {
+			return link
+		}
+	}
+	return nil
+}
+
+func TestAcquisitionPaging(t *testing.T) {
+	assertWithTest := assert.New(t)
+	c := &Catalogue{BaseURL: "https://library.example.com"}
+	query := url.Values{"language": {"English"}}
+	updated := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	// A full page may be followed by another one
+	feed := c.Acquisition("books", "Books", "/books", query, 2, 2, []*books.Book{testBook(1), testBook(2)}, updated)
+	assertWithTest.Equal("https://library.example.com/opds/books?language=English&page=2&per_page=2",
+		linkByRel(feed.Links, RelSelf).Href)
+	assertWithTest.Equal("https://library.example.com/opds/books?language=English&page=1&per_page=2",
+		linkByRel(feed.Links, RelFirst).Href)
+	assertWithTest.Equal("https://library.example.com/opds/books?language=English&page=1&per_page=2",
+		linkByRel(feed.Links, RelPrevious).Href)
+	assertWithTest.Equal("https://library.example.com/opds/books?language=English&page=3&per_page=2",
+		linkByRel(feed.Links, RelNext).Href)
+	assertWithTest.Equal(3, feed.StartIndex)
+	assertWithTest.Equal(2, feed.ItemsPerPage)
+	assertWithTest.Len(feed.Entries, 2)
+
+	// The first page has nothing before it and a short page nothing after it
+	feed = c.Acquisition("books", "Books", "/books", query, 1, 2, []*books.Book{testBook(1)}, updated)
+	assertWithTest.Nil(linkByRel(feed.Links, RelPrevious))
+	assertWithTest.Nil(linkByRel(feed.Links, RelNext))
+}
+
+func TestFeedDocument(t *testing.T) {
+	assertWithTest := assert.New(t)
+	c := &Catalogue{BaseURL: "http://localhost:8080"}
+	feed := c.Languages([]*books.LanguageCount{{Language: "English", Books: 12}}, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	feed.Entries = append(feed.Entries, c.BookEntry(testBook(7)))
+	payload, err := xml.Marshal(feed)
+	assertWithTest.Nil(err)
+	document := string(payload)
+	assertWithTest.True(strings.HasPrefix(document,
+		`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/terms/"`), document)
+	assertWithTest.Contains(document, `<updated>2024-01-02T00:00:00Z</updated>`)
+	assertWithTest.Contains(document, `<link rel="subsection" href="http://localhost:8080/opds/books?language=English" `+
+		`type="application/atom+xml;profile=opds-catalog;kind=acquisition" thr:count="12"></link>`)
+	assertWithTest.Contains(document, `<dc:identifier>urn:isbn:9780451524935</dc:identifier>`)
+	assertWithTest.Contains(document, `<dc:issued>1980-06-08</dc:issued>`)
+	assertWithTest.Contains(document, `<link rel="http://opds-spec.org/acquisition/borrow" `+
+		`href="http://localhost:8080/books/7" type="application/json"></link>`)
+
+	// A feed read back by a namespace aware client keeps its entries
+	var parsed struct {
+		Entries []struct {
+			Title string `xml:"http://www.w3.org/2005/Atom title"`
+		} `xml:"http://www.w3.org/2005/Atom entry"`
+	}
+	assertWithTest.Nil(xml.Unmarshal(payload, &parsed))
+	assertWithTest.Len(parsed.Entries, 2)
+}
+
+func TestOpenSearch(t *testing.T) {
+	assertWithTest := assert.New(t)
+	c := &Catalogue{BaseURL: "http://localhost:8080"}
+	description := c.OpenSearch()
+	assertWithTest.Equal("http://localhost:8080/opds/books?title={searchTerms}&author={atom:author?}"+
+		"&language={language?}&page={startPage?}&per_page={count?}", description.URLs[0].Template)
+	assertWithTest.Equal(DefaultPerPage, PerPage(0))
+	assertWithTest.Equal(MaxPerPage, PerPage(1000))
+	assertWithTest.Equal(10, PerPage(10))
+}
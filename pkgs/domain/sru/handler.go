@@ -0,0 +1,7 @@
+package sru
+
+import "net/http"
+
+type Handler interface {
+	SRU(res http.ResponseWriter, req *http.Request)
+}
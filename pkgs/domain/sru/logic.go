@@ -0,0 +1,381 @@
+package sru
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/cql"
+)
+
+// Namespaces of SRU 1.2 responses and the records in them
+const (
+	Namespace           = "http://www.loc.gov/zing/srw/"
+	DiagnosticNamespace = "http://www.loc.gov/zing/srw/diagnostic/"
+	ExplainNamespace    = "http://explain.z3950.org/dtd/2.0/"
+	DCRecordNamespace   = "info:srw/schema/1/dc-schema"
+	DCNamespace         = "http://purl.org/dc/elements/1.1/"
+)
+
+// Schemas records can be retrieved in
+const (
+	DCSchema      = "info:srw/schema/1/dc-v1.1"
+	MARCXMLSchema = "info:srw/schema/1/marcxml-v1.1"
+)
+
+const (
+	Version        = "1.2"
+	SearchRetrieve = "searchRetrieve"
+	Explain        = "explain"
+	// Records are packed as XML unless the client asks for them escaped as a string
+	PackingXML    = "xml"
+	PackingString = "string"
+)
+
+// A search returns this many records unless the client asks for another number, and never more than the maximum
+const (
+	DefaultMaximumRecords = 10
+	MaxMaximumRecords     = 100
+)
+
+// Codes of the SRU diagnostics the server reports
+const (
+	DiagGeneralError         = 1
+	DiagUnsupportedOperation = 4
+	DiagUnsupportedVersion   = 5
+	DiagUnsupportedValue     = 6
+	DiagMandatoryParameter   = 7
+	DiagQuerySyntax          = 10
+	DiagUnsupportedIndex     = 16
+	DiagUnsupportedRelation  = 19
+	DiagUnsupportedModifier  = 20
+	DiagUnsupportedTerm      = 24
+	DiagUnsupportedBoolean   = 37
+	DiagStartOutOfRange      = 61
+	DiagUnknownSchema        = 66
+	DiagUnsupportedPacking   = 71
+	DiagSortNotSupported     = 80
+)
+
+var diagnosticMessages = map[int]string{
+	DiagGeneralError:         "General system error",
+	DiagUnsupportedOperation: "Unsupported operation",
+	DiagUnsupportedVersion:   "Unsupported version",
+	DiagUnsupportedValue:     "Unsupported parameter value",
+	DiagMandatoryParameter:   "Mandatory parameter not supplied",
+	DiagQuerySyntax:          "Query syntax error",
+	DiagUnsupportedIndex:     "Unsupported index",
+	DiagUnsupportedRelation:  "Unsupported relation",
+	DiagUnsupportedModifier:  "Unsupported relation modifier",
+	DiagUnsupportedTerm:      "Unsupported combination of relation and term",
+	DiagUnsupportedBoolean:   "Unsupported boolean operator",
+	DiagStartOutOfRange:      "First record position out of range",
+	DiagUnknownSchema:        "Unknown schema for retrieval",
+	DiagUnsupportedPacking:   "Unsupported record packing",
+	DiagSortNotSupported:     "Sort not supported",
+}
+
+// Indexes books.ParseSearch accepts, listed in the explain record
+var explainIndexes = []struct{ set, name, title string }{
+	{"cql", "serverChoice", "Title and author"},
+	{"dc", "title", "Title"},
+	{"dc", "creator", "Author"},
+	{"dc", "publisher", "Publisher"},
+	{"dc", "date", "Publication date, as 2006 or 2006-01-02"},
+	{"dc", "subject", "Genre"},
+	{"dc", "language", "Language, by name or MARC code"},
+	{"dc", "identifier", "ISBN"},
+	{"bath", "isbn", "ISBN"},
+}
+
+type Diagnostic struct {
+	XMLName xml.Name `xml:"http://www.loc.gov/zing/srw/diagnostic/ diagnostic"`
+	URI     string   `xml:"uri"`
+	Details string   `xml:"details,omitempty"`
+	Message string   `xml:"message"`
+}
+
+func NewDiagnostic(code int, details string) *Diagnostic {
+	return &Diagnostic{
+		URI:     "info:srw/diagnostic/1/" + strconv.Itoa(code),
+		Details: details,
+		Message: diagnosticMessages[code],
+	}
+}
+
+// SearchDiagnostic reports a query the books cannot be searched with, nil when the error is something else
+func SearchDiagnostic(err error) *Diagnostic {
+	for _, known := range []struct {
+		err  error
+		code int
+	}{
+		{cql.ErrSyntax, DiagQuerySyntax},
+		{books.ErrCQLIndex, DiagUnsupportedIndex},
+		{books.ErrCQLRelation, DiagUnsupportedRelation},
+		{books.ErrCQLModifier, DiagUnsupportedModifier},
+		{books.ErrCQLTerm, DiagUnsupportedTerm},
+		{books.ErrCQLBoolean, DiagUnsupportedBoolean},
+		{books.ErrCQLSort, DiagSortNotSupported},
+	} {
+		if errors.Is(err, known.err) {
+			return NewDiagnostic(known.code, err.Error())
+		}
+	}
+	return nil
+}
+
+// CheckVersion accepts the versions of SRU the responses are compatible with, a request without one gets 1.2
+func CheckVersion(version string) *Diagnostic {
+	switch version {
+	case "", "1.1", "1.2":
+		return nil
+	}
+	return NewDiagnostic(DiagUnsupportedVersion, Version)
+}
+
+// Parameters of a searchRetrieve request
+type SearchRequest struct {
+	Query string
+	// Position of the first record to return, counted from 1
+	StartRecord    int
+	MaximumRecords int
+	RecordSchema   string
+	RecordPacking  string
+}
+
+// ReadSearchRequest reads the parameters of a searchRetrieve request and fills in the defaults
+func ReadSearchRequest(query url.Values) (*SearchRequest, *Diagnostic) {
+	request := &SearchRequest{
+		Query:          query.Get("query"),
+		StartRecord:    1,
+		MaximumRecords: DefaultMaximumRecords,
+	}
+	if request.Query == "" {
+		return nil, NewDiagnostic(DiagMandatoryParameter, "query")
+	}
+	if query.Get("sortKeys") != "" {
+		return nil, NewDiagnostic(DiagSortNotSupported, "sortKeys")
+	}
+	for key, target := range map[string]*int{
+		"startRecord":    &request.StartRecord,
+		"maximumRecords": &request.MaximumRecords,
+	} {
+		valueStr := query.Get(key)
+		if valueStr == "" {
+			continue
+		}
+		value, err := strconv.Atoi(valueStr)
+		if err != nil || value < 0 || (key == "startRecord" && value < 1) {
+			return nil, NewDiagnostic(DiagUnsupportedValue, key)
+		}
+		*target = value
+	}
+	if request.MaximumRecords > MaxMaximumRecords {
+		request.MaximumRecords = MaxMaximumRecords
+	}
+	switch schema := query.Get("recordSchema"); schema {
+	case "", "dc", DCSchema:
+		request.RecordSchema = DCSchema
+	case "marcxml", MARCXMLSchema:
+		request.RecordSchema = MARCXMLSchema
+	default:
+		return nil, NewDiagnostic(DiagUnknownSchema, schema)
+	}
+	switch packing := query.Get("recordPacking"); packing {
+	case "", PackingXML:
+		request.RecordPacking = PackingXML
+	case PackingString:
+		request.RecordPacking = PackingString
+	default:
+		return nil, NewDiagnostic(DiagUnsupportedPacking, packing)
+	}
+	return request, nil
+}
+
+type SearchRetrieveResponse struct {
+	XMLName            xml.Name      `xml:"http://www.loc.gov/zing/srw/ searchRetrieveResponse"`
+	Version            string        `xml:"version"`
+	NumberOfRecords    int           `xml:"numberOfRecords"`
+	Records            []*Record     `xml:"records>record,omitempty"`
+	NextRecordPosition int           `xml:"nextRecordPosition,omitempty"`
+	Diagnostics        []*Diagnostic `xml:"diagnostics>diagnostic,omitempty"`
+}
+
+type ExplainResponse struct {
+	XMLName     xml.Name      `xml:"http://www.loc.gov/zing/srw/ explainResponse"`
+	Version     string        `xml:"version"`
+	Record      *Record       `xml:"record,omitempty"`
+	Diagnostics []*Diagnostic `xml:"diagnostics>diagnostic,omitempty"`
+}
+
+type Record struct {
+	Schema   string      `xml:"recordSchema"`
+	Packing  string      `xml:"recordPacking"`
+	Data     *RecordData `xml:"recordData"`
+	Position int         `xml:"recordPosition,omitempty"`
+}
+
+// The record itself when it is packed as XML, or the record escaped as text when it is packed as a string
+type RecordData struct {
+	Record interface{}
+	Text   string `xml:",chardata"`
+}
+
+// A book in the Dublin Core schema of SRU
+type DublinCore struct {
+	XMLName    xml.Name `xml:"srw_dc:dc"`
+	RecordNS   string   `xml:"xmlns:srw_dc,attr"`
+	DCNS       string   `xml:"xmlns:dc,attr"`
+	Title      string   `xml:"dc:title"`
+	Creator    string   `xml:"dc:creator,omitempty"`
+	Publisher  string   `xml:"dc:publisher,omitempty"`
+	Date       string   `xml:"dc:date,omitempty"`
+	Language   string   `xml:"dc:language,omitempty"`
+	Subject    string   `xml:"dc:subject,omitempty"`
+	Identifier string   `xml:"dc:identifier"`
+	Type       string   `xml:"dc:type"`
+	Format     string   `xml:"dc:format,omitempty"`
+}
+
+// NewSearchResponse lists a page of books found by a search as records of the requested schema
+func NewSearchResponse(request *SearchRequest, found []*books.Book, total int) (*SearchRetrieveResponse, error) {
+	response := &SearchRetrieveResponse{Version: Version, NumberOfRecords: total}
+	for i, book := range found {
+		var record interface{} = DublinCoreRecord(book)
+		if request.RecordSchema == MARCXMLSchema {
+			record = book.MARC()
+		}
+		data, err := packRecord(record, request.RecordPacking)
+		if err != nil {
+			return nil, err
+		}
+		response.Records = append(response.Records, &Record{
+			Schema:   request.RecordSchema,
+			Packing:  request.RecordPacking,
+			Data:     data,
+			Position: request.StartRecord + i,
+		})
+	}
+	if next := request.StartRecord + len(found); len(found) > 0 && next <= total {
+		response.NextRecordPosition = next
+	}
+	return response, nil
+}
+
+// NewDiagnosticResponse answers a search that could not be made
+func NewDiagnosticResponse(diagnostic *Diagnostic) *SearchRetrieveResponse {
+	return &SearchRetrieveResponse{Version: Version, Diagnostics: []*Diagnostic{diagnostic}}
+}
+
+func DublinCoreRecord(book *books.Book) *DublinCore {
+	record := &DublinCore{
+		RecordNS:   DCRecordNamespace,
+		DCNS:       DCNamespace,
+		Title:      book.Title,
+		Creator:    book.Author,
+		Publisher:  book.Publisher,
+		Language:   book.Language,
+		Subject:    book.Genre,
+		Identifier: "urn:isbn:" + book.ISBN,
+		Type:       "Text",
+	}
+	if !book.Published.IsZero() {
+		record.Date = book.Published.Format("2006-01-02")
+	}
+	if book.Pages > 0 {
+		record.Format = fmt.Sprintf("%d pages", book.Pages)
+	}
+	return record
+}
+
+func packRecord(record interface{}, packing string) (*RecordData, error) {
+	if packing == PackingXML {
+		return &RecordData{Record: record}, nil
+	}
+	escaped, err := xml.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordData{Text: string(escaped)}, nil
+}
+
+// The explain record of the server in the ZeeRex schema
+type ExplainRecord struct {
+	XMLName    xml.Name `xml:"http://explain.z3950.org/dtd/2.0/ explain"`
+	ServerInfo struct {
+		Protocol string `xml:"protocol,attr"`
+		Version  string `xml:"version,attr"`
+		Host     string `xml:"host"`
+		Port     string `xml:"port"`
+		Database string `xml:"database"`
+	} `xml:"serverInfo"`
+	Title       string          `xml:"databaseInfo>title"`
+	Description string          `xml:"databaseInfo>description"`
+	Sets        []*ExplainSet   `xml:"indexInfo>set"`
+	Indexes     []*ExplainIndex `xml:"indexInfo>index"`
+	Schemas     []*ExplainSet   `xml:"schemaInfo>schema"`
+	Defaults    []*ExplainValue `xml:"configInfo>default"`
+	Settings    []*ExplainValue `xml:"configInfo>setting"`
+}
+
+type ExplainSet struct {
+	Name       string `xml:"name,attr"`
+	Identifier string `xml:"identifier,attr"`
+	Title      string `xml:"title,omitempty"`
+}
+
+type ExplainIndex struct {
+	Title string           `xml:"title"`
+	Name  ExplainIndexName `xml:"map>name"`
+}
+
+type ExplainIndexName struct {
+	Set  string `xml:"set,attr"`
+	Name string `xml:",chardata"`
+}
+
+type ExplainValue struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// NewExplainResponse describes the server, the indexes it can be searched on and the schemas records come in
+func NewExplainResponse(host, port string) *ExplainResponse {
+	explain := &ExplainRecord{
+		Title:       "Library catalogue",
+		Description: "Books in the library catalogue, searched with CQL",
+		Sets: []*ExplainSet{
+			{Name: "cql", Identifier: "info:srw/cql-context-set/1/cql-v1.2"},
+			{Name: "dc", Identifier: "info:srw/cql-context-set/1/dc-v1.1"},
+			{Name: "bath", Identifier: "http://zing.z3950.org/cql/bath/2.0/"},
+		},
+		Schemas: []*ExplainSet{
+			{Name: "dc", Identifier: DCSchema, Title: "Dublin Core"},
+			{Name: "marcxml", Identifier: MARCXMLSchema, Title: "MARCXML"},
+		},
+		Defaults: []*ExplainValue{
+			{Type: "numberOfRecords", Value: strconv.Itoa(DefaultMaximumRecords)},
+			{Type: "retrieveSchema", Value: DCSchema},
+		},
+		Settings: []*ExplainValue{{Type: "maximumRecords", Value: strconv.Itoa(MaxMaximumRecords)}},
+	}
+	explain.ServerInfo.Protocol, explain.ServerInfo.Version = "SRU", Version
+	explain.ServerInfo.Host, explain.ServerInfo.Port, explain.ServerInfo.Database = host, port, "sru"
+	for _, index := range explainIndexes {
+		explain.Indexes = append(explain.Indexes, &ExplainIndex{
+			Title: index.title,
+			Name:  ExplainIndexName{Set: index.set, Name: index.name},
+		})
+	}
+	return &ExplainResponse{
+		Version: Version,
+		Record: &Record{
+			Schema:  ExplainNamespace,
+			Packing: PackingXML,
+			Data:    &RecordData{Record: explain},
+		},
+	}
+}
@@ -0,0 +1,123 @@
+package sru
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/cql"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBook(id int) *books.Book {
+	return &books.Book{
+		ID:        id,
+		ISBN:      "9780451524935",
+		Title:     "1984",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     328,
+	}
+}
+
+func TestReadSearchRequest(t *testing.T) {
+	assertWithTest := assert.New(t)
+
+	request, diagnostic := ReadSearchRequest(url.Values{"query": {"orwell"}})
+	assertWithTest.Nil(diagnostic)
+	assertWithTest.Equal(&SearchRequest{Query: "orwell", StartRecord: 1, MaximumRecords: DefaultMaximumRecords,
+		RecordSchema: DCSchema, RecordPacking: PackingXML}, request)
+
+	request, diagnostic = ReadSearchRequest(url.Values{"query": {"orwell"}, "startRecord": {"11"},
+		"maximumRecords": {"500"}, "recordSchema": {"marcxml"}, "recordPacking": {"string"}})
+	assertWithTest.Nil(diagnostic)
+	assertWithTest.Equal(&SearchRequest{Query: "orwell", StartRecord: 11, MaximumRecords: MaxMaximumRecords,
+		RecordSchema: MARCXMLSchema, RecordPacking: PackingString}, request)
+
+	for values, code := range map[string]int{
+		"":                                 DiagMandatoryParameter,
+		"query=orwell&startRecord=0":       DiagUnsupportedValue,
+		"query=orwell&maximumRecords=many": DiagUnsupportedValue,
+		"query=orwell&recordSchema=mods":   DiagUnknownSchema,
+		"query=orwell&recordPacking=json":  DiagUnsupportedPacking,
+		"query=orwell&sortKeys=title":      DiagSortNotSupported,
+	} {
+		query, err := url.ParseQuery(values)
+		assertWithTest.Nil(err)
+		_, diagnostic := ReadSearchRequest(query)
+		if assertWithTest.NotNil(diagnostic, values) {
+			assertWithTest.Equal(fmt.Sprintf("info:srw/diagnostic/1/%d", code), diagnostic.URI, values)
+		}
+	}
+}
+
+func TestSearchDiagnostic(t *testing.T) {
+	assertWithTest := assert.New(t)
+	diagnostic := SearchDiagnostic(fmt.Errorf("%w: dc.colour", books.ErrCQLIndex))
+	assertWithTest.Equal("info:srw/diagnostic/1/16", diagnostic.URI)
+	assertWithTest.Equal("Unsupported index", diagnostic.Message)
+	assertWithTest.Equal("info:srw/diagnostic/1/10", SearchDiagnostic(cql.ErrSyntax).URI)
+	assertWithTest.Nil(SearchDiagnostic(errors.New("connection refused")))
+}
+
+func TestSearchResponse(t *testing.T) {
+	assertWithTest := assert.New(t)
+	request := &SearchRequest{Query: "orwell", StartRecord: 3, MaximumRecords: 2,
+		RecordSchema: DCSchema, RecordPacking: PackingXML}
+
+	// Records are numbered from the start of the page and the next page is offered while there is one
+	response, err := NewSearchResponse(request, []*books.Book{testBook(1), testBook(2)}, 5)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(5, response.NumberOfRecords)
+	assertWithTest.Equal(5, response.NextRecordPosition)
+	assertWithTest.Equal(4, response.Records[1].Position)
+	payload, err := xml.Marshal(response)
+	assertWithTest.Nil(err)
+	document := string(payload)
+	assertWithTest.True(strings.HasPrefix(document, `<searchRetrieveResponse xmlns="http://www.loc.gov/zing/srw/">`))
+	assertWithTest.Contains(document, `<recordData><srw_dc:dc xmlns:srw_dc="info:srw/schema/1/dc-schema" `+
+		`xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>1984</dc:title><dc:creator>George Orwell</dc:creator>`)
+	assertWithTest.Contains(document, `<dc:date>1980-06-08</dc:date>`)
+	assertWithTest.Contains(document, `<dc:identifier>urn:isbn:9780451524935</dc:identifier>`)
+	assertWithTest.Contains(document, `<dc:format>328 pages</dc:format>`)
+
+	// The last page has no next record
+	response, err = NewSearchResponse(request, []*books.Book{testBook(1)}, 3)
+	assertWithTest.Nil(err)
+	assertWithTest.Zero(response.NextRecordPosition)
+
+	// MARCXML records packed as strings are escaped
+	request.RecordSchema, request.RecordPacking = MARCXMLSchema, PackingString
+	response, err = NewSearchResponse(request, []*books.Book{testBook(1)}, 1)
+	assertWithTest.Nil(err)
+	payload, err = xml.Marshal(response)
+	assertWithTest.Nil(err)
+	document = string(payload)
+	assertWithTest.Contains(document, `<recordSchema>info:srw/schema/1/marcxml-v1.1</recordSchema>`)
+	assertWithTest.Contains(document, `<recordData>&lt;record xmlns=&#34;http://www.loc.gov/MARC21/slim&#34;&gt;`)
+
+	// Diagnostics are in their own namespace
+	payload, err = xml.Marshal(NewDiagnosticResponse(NewDiagnostic(DiagQuerySyntax, "unexpected )")))
+	assertWithTest.Nil(err)
+	assertWithTest.Contains(string(payload), `<diagnostics><diagnostic xmlns="http://www.loc.gov/zing/srw/diagnostic/">`+
+		`<uri>info:srw/diagnostic/1/10</uri><details>unexpected )</details><message>Query syntax error</message>`)
+}
+
+func TestExplainResponse(t *testing.T) {
+	assertWithTest := assert.New(t)
+	payload, err := xml.Marshal(NewExplainResponse("library.example.com", "443"))
+	assertWithTest.Nil(err)
+	document := string(payload)
+	assertWithTest.Contains(document, `<explain xmlns="http://explain.z3950.org/dtd/2.0/">`+
+		`<serverInfo protocol="SRU" version="1.2"><host>library.example.com</host><port>443</port>`)
+	assertWithTest.Contains(document, `<index><title>Title</title><map><name set="dc">title</name></map></index>`)
+}
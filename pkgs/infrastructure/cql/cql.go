@@ -0,0 +1,282 @@
+package cql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A query that does not follow the CQL grammar
+var ErrSyntax = errors.New("cql query syntax error")
+
+// Index a term without one is searched in, the server decides what that covers
+const ServerChoice = "cql.serverchoice"
+
+// Boolean operators, they all bind equally tightly and group from the left
+const (
+	And  = "and"
+	Or   = "or"
+	Not  = "not"
+	Prox = "prox"
+)
+
+// A parsed CQL query
+type Query struct {
+	Root Node
+	// Context sets the query declared with > prefix = "uri", keyed by prefix
+	Prefixes map[string]string
+	// Keys after sortby in the order they were given
+	SortKeys []*SortKey
+}
+
+// A search clause or a boolean of two nodes
+type Node interface {
+	node()
+}
+
+// Index, relation and term of a search clause. A bare term is searched in cql.serverchoice with =,
+// index names and named relations are lower case
+type Clause struct {
+	Index    string
+	Relation *Relation
+	Term     string
+}
+
+type Relation struct {
+	Comparitor string
+	Modifiers  []*Modifier
+}
+
+// A modifier of a relation, boolean or sort key, the comparitor and value are empty when it is only named
+type Modifier struct {
+	Name       string
+	Comparitor string
+	Value      string
+}
+
+type Boolean struct {
+	Operator  string
+	Modifiers []*Modifier
+	Left      Node
+	Right     Node
+}
+
+type SortKey struct {
+	Index     string
+	Modifiers []*Modifier
+}
+
+func (*Clause) node()  {}
+func (*Boolean) node() {}
+
+// Walk calls visit with every search clause of the tree from left to right, stopping at the first error
+func Walk(node Node, visit func(*Clause) error) error {
+	switch n := node.(type) {
+	case *Clause:
+		return visit(n)
+	case *Boolean:
+		if err := Walk(n.Left, visit); err != nil {
+			return err
+		}
+		return Walk(n.Right, visit)
+	}
+	return nil
+}
+
+// Parse reads a CQL 1.2 query
+func Parse(query string) (*Query, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	parsed := &Query{Prefixes: map[string]string{}}
+	if parsed.Root, err = p.query(parsed.Prefixes); err != nil {
+		return nil, err
+	}
+	if p.peekKeyword("sortby") {
+		p.next()
+		for !p.at(tokenEOF) {
+			key := &SortKey{}
+			index := p.next()
+			if index.kind != tokenWord && index.kind != tokenString {
+				return nil, p.errorAt(index, "expected an index to sort by")
+			}
+			key.Index = strings.ToLower(index.value)
+			if key.Modifiers, err = p.modifiers(); err != nil {
+				return nil, err
+			}
+			parsed.SortKeys = append(parsed.SortKeys, key)
+		}
+		if len(parsed.SortKeys) == 0 {
+			return nil, p.errorAt(p.peek(), "expected an index to sort by")
+		}
+	}
+	if !p.at(tokenEOF) {
+		return nil, p.errorAt(p.peek(), "unexpected "+p.peek().String())
+	}
+	return parsed, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// cqlQuery ::= prefixAssignment cqlQuery | scopedClause
+func (p *parser) query(prefixes map[string]string) (Node, error) {
+	for p.peek().is(tokenSymbol, ">") {
+		p.next()
+		first := p.next()
+		if first.kind != tokenWord && first.kind != tokenString {
+			return nil, p.errorAt(first, "expected a context set after >")
+		}
+		if p.peek().is(tokenSymbol, "=") {
+			p.next()
+			uri := p.next()
+			if uri.kind != tokenWord && uri.kind != tokenString {
+				return nil, p.errorAt(uri, "expected the uri of a context set")
+			}
+			prefixes[strings.ToLower(first.value)] = uri.value
+			continue
+		}
+		prefixes[""] = first.value
+	}
+	return p.scopedClause(prefixes)
+}
+
+// scopedClause ::= scopedClause booleanGroup searchClause | searchClause
+func (p *parser) scopedClause(prefixes map[string]string) (Node, error) {
+	left, err := p.searchClause(prefixes)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.boolean()
+		if !ok {
+			return left, nil
+		}
+		p.next()
+		modifiers, err := p.modifiers()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.searchClause(prefixes)
+		if err != nil {
+			return nil, err
+		}
+		left = &Boolean{Operator: operator, Modifiers: modifiers, Left: left, Right: right}
+	}
+}
+
+// searchClause ::= '(' cqlQuery ')' | index relation searchTerm | searchTerm
+func (p *parser) searchClause(prefixes map[string]string) (Node, error) {
+	first := p.next()
+	if first.is(tokenSymbol, "(") {
+		inner, err := p.query(prefixes)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); !closing.is(tokenSymbol, ")") {
+			return nil, p.errorAt(closing, "expected )")
+		}
+		return inner, nil
+	}
+	if first.kind != tokenWord && first.kind != tokenString {
+		return nil, p.errorAt(first, "expected a search term")
+	}
+	relation := p.peek()
+	named := relation.kind == tokenWord && !isKeyword(relation.value)
+	if !named && !(relation.kind == tokenSymbol && isComparitor(relation.value)) {
+		return &Clause{Index: ServerChoice, Relation: &Relation{Comparitor: "="}, Term: first.value}, nil
+	}
+	p.next()
+	clause := &Clause{Index: strings.ToLower(first.value), Relation: &Relation{Comparitor: strings.ToLower(relation.value)}}
+	var err error
+	if clause.Relation.Modifiers, err = p.modifiers(); err != nil {
+		return nil, err
+	}
+	term := p.next()
+	if term.kind != tokenWord && term.kind != tokenString {
+		return nil, p.errorAt(term, "expected a search term after "+clause.Relation.Comparitor)
+	}
+	clause.Term = term.value
+	return clause, nil
+}
+
+// modifierList ::= ('/' modifierName [comparitorSymbol modifierValue])*
+func (p *parser) modifiers() ([]*Modifier, error) {
+	var modifiers []*Modifier
+	for p.peek().is(tokenSymbol, "/") {
+		p.next()
+		name := p.next()
+		if name.kind != tokenWord && name.kind != tokenString {
+			return nil, p.errorAt(name, "expected a modifier after /")
+		}
+		modifier := &Modifier{Name: strings.ToLower(name.value)}
+		if comparitor := p.peek(); comparitor.kind == tokenSymbol && isComparitor(comparitor.value) {
+			p.next()
+			value := p.next()
+			if value.kind != tokenWord && value.kind != tokenString {
+				return nil, p.errorAt(value, "expected a value for modifier "+modifier.Name)
+			}
+			modifier.Comparitor, modifier.Value = comparitor.value, value.value
+		}
+		modifiers = append(modifiers, modifier)
+	}
+	return modifiers, nil
+}
+
+// The boolean operator at the current token, a quoted and is a term rather than an operator
+func (p *parser) boolean() (string, bool) {
+	next := p.peek()
+	if next.kind != tokenWord {
+		return "", false
+	}
+	switch operator := strings.ToLower(next.value); operator {
+	case And, Or, Not, Prox:
+		return operator, true
+	}
+	return "", false
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	next := p.tokens[p.pos]
+	if next.kind != tokenEOF {
+		p.pos++
+	}
+	return next
+}
+
+func (p *parser) at(kind tokenKind) bool {
+	return p.peek().kind == kind
+}
+
+func (p *parser) peekKeyword(keyword string) bool {
+	next := p.peek()
+	return next.kind == tokenWord && strings.EqualFold(next.value, keyword)
+}
+
+func (p *parser) errorAt(at token, message string) error {
+	return fmt.Errorf("%w: %s at position %d", ErrSyntax, message, at.pos+1)
+}
+
+func isKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case And, Or, Not, Prox, "sortby":
+		return true
+	}
+	return false
+}
+
+func isComparitor(symbol string) bool {
+	switch symbol {
+	case "=", "==", "<", ">", "<=", ">=", "<>":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package cql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	assertWithTest := assert.New(t)
+	query, err := Parse(`dc.title any "orwell" and dc.language = eng`)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(&Boolean{
+		Operator: And,
+		Left:     &Clause{Index: "dc.title", Relation: &Relation{Comparitor: "any"}, Term: "orwell"},
+		Right:    &Clause{Index: "dc.language", Relation: &Relation{Comparitor: "="}, Term: "eng"},
+	}, query.Root)
+
+	// Booleans group from the left unless there are brackets, and a bare term is a server choice search
+	query, err = Parse(`dune or (Dc.Creator == "Frank \"Herbert\"" NOT dc.date < 1970) sortby dc.date/sort.descending`)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(&Boolean{
+		Operator: Or,
+		Left:     &Clause{Index: ServerChoice, Relation: &Relation{Comparitor: "="}, Term: "dune"},
+		Right: &Boolean{
+			Operator: Not,
+			Left:     &Clause{Index: "dc.creator", Relation: &Relation{Comparitor: "=="}, Term: `Frank "Herbert"`},
+			Right:    &Clause{Index: "dc.date", Relation: &Relation{Comparitor: "<"}, Term: "1970"},
+		},
+	}, query.Root)
+	assertWithTest.Equal([]*SortKey{{Index: "dc.date", Modifiers: []*Modifier{{Name: "sort.descending"}}}}, query.SortKeys)
+
+	// A quoted keyword is a term, modifiers and prefixes are kept for the caller to check
+	query, err = Parse(`> dc = "info:srw/cql-context-set/1/dc-v1.1" title =/locale=en "and"`)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("info:srw/cql-context-set/1/dc-v1.1", query.Prefixes["dc"])
+	assertWithTest.Equal(&Clause{Index: "title", Relation: &Relation{Comparitor: "=",
+		Modifiers: []*Modifier{{Name: "locale", Comparitor: "=", Value: "en"}}}, Term: "and"}, query.Root)
+
+	var clauses []string
+	assertWithTest.Nil(Walk(mustParse(t, `a and (b or c) not d`).Root, func(clause *Clause) error {
+		clauses = append(clauses, clause.Term)
+		return nil
+	}))
+	assertWithTest.Equal([]string{"a", "b", "c", "d"}, clauses)
+}
+
+func TestParseErrors(t *testing.T) {
+	assertWithTest := assert.New(t)
+	for _, query := range []string{
+		``,
+		`dc.title =`,
+		`(orwell`,
+		`orwell and`,
+		`"orwell`,
+		`orwell huxley`,
+		`orwell sortby`,
+		`orwell)`,
+	} {
+		_, err := Parse(query)
+		assertWithTest.True(errors.Is(err, ErrSyntax), query)
+	}
+}
+
+func mustParse(t *testing.T, query string) *Query {
+	parsed, err := Parse(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
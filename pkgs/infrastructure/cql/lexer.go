@@ -0,0 +1,90 @@
+package cql
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	// A quoted term, never read as a keyword
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	// Byte offset of the token in the query
+	pos int
+}
+
+func (t token) is(kind tokenKind, value string) bool {
+	return t.kind == kind && t.value == value
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return fmt.Sprintf("%q", t.value)
+	}
+	return t.value
+}
+
+// Characters that end an unquoted word
+const delimiters = `()=<>"/`
+
+// Split the query into tokens. A backslash in a quoted term escapes a quote, other escapes such as \* are kept
+// as they are so the masking characters can still be told apart from escaped ones
+func lex(query string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '/':
+			tokens = append(tokens, token{kind: tokenSymbol, value: string(c), pos: i})
+			i++
+		case c == '=' || c == '<' || c == '>':
+			symbol := string(c)
+			if i+1 < len(query) {
+				if pair := query[i : i+2]; pair == "==" || pair == "<=" || pair == ">=" || pair == "<>" {
+					symbol = pair
+				}
+			}
+			tokens = append(tokens, token{kind: tokenSymbol, value: symbol, pos: i})
+			i += len(symbol)
+		case c == '"':
+			var value strings.Builder
+			start := i
+			i++
+			for ; i < len(query) && query[i] != '"'; i++ {
+				if query[i] == '\\' && i+1 < len(query) {
+					if query[i+1] != '"' {
+						value.WriteByte('\\')
+					}
+					i++
+				}
+				value.WriteByte(query[i])
+			}
+			if i >= len(query) {
+				return nil, fmt.Errorf("%w: quoted term at position %d is not closed", ErrSyntax, start+1)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokenString, value: value.String(), pos: start})
+		default:
+			start := i
+			for i < len(query) && !strings.ContainsRune(delimiters+" \t\n\r", rune(query[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, value: query[start:i], pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(query)}), nil
+}
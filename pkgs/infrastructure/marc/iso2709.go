@@ -0,0 +1,172 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Delimiters of the ISO 2709 exchange format
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+	leaderLength      = 24
+	directoryEntry    = 12
+	maxFieldLength    = 9999
+)
+
+type iso2709Reader struct {
+	source *bufio.Reader
+}
+
+// NewReader reads MARC 21 records in the ISO 2709 exchange format
+func NewReader(source io.Reader) Reader {
+	return &iso2709Reader{source: bufio.NewReader(source)}
+}
+
+// Read implements Reader.
+// The stream is split on the record terminator, so a broken record does not stop the ones after it being read
+func (r *iso2709Reader) Read() (*Record, error) {
+	for {
+		raw, err := r.source.ReadBytes(recordTerminator)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		// Line breaks between records are common in files that were edited by hand
+		raw = bytes.TrimLeft(raw, " \r\n")
+		if len(raw) == 0 {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			continue
+		}
+		return parseISO2709(raw)
+	}
+}
+
+func parseISO2709(raw []byte) (*Record, error) {
+	if len(raw) < leaderLength+1 {
+		return nil, fmt.Errorf("%w: record is shorter than its leader", ErrMalformedRecord)
+	}
+	if raw[len(raw)-1] != recordTerminator {
+		return nil, fmt.Errorf("%w: record is not terminated", ErrMalformedRecord)
+	}
+	leader := string(raw[:leaderLength])
+	base, err := number(leader[12:17])
+	if err != nil || base <= leaderLength || base > len(raw) {
+		return nil, fmt.Errorf("%w: base address of data %q is not valid", ErrMalformedRecord, leader[12:17])
+	}
+	directory := raw[leaderLength : base-1]
+	if raw[base-1] != fieldTerminator || len(directory)%directoryEntry != 0 {
+		return nil, fmt.Errorf("%w: directory is not valid", ErrMalformedRecord)
+	}
+	record := &Record{Leader: leader}
+	data := raw[base:]
+	for i := 0; i < len(directory); i += directoryEntry {
+		entry := string(directory[i : i+directoryEntry])
+		length, lengthErr := number(entry[3:7])
+		start, startErr := number(entry[7:12])
+		if lengthErr != nil || startErr != nil || length < 1 || start+length > len(data) {
+			return nil, fmt.Errorf("%w: directory entry %q is not valid", ErrMalformedRecord, entry)
+		}
+		// The length includes the field terminator
+		field := &Field{Tag: entry[:3]}
+		content := data[start : start+length-1]
+		if field.IsControl() {
+			field.Value = string(content)
+			record.Fields = append(record.Fields, field)
+			continue
+		}
+		if len(content) < 2 {
+			return nil, fmt.Errorf("%w: field %s has no indicators", ErrMalformedRecord, field.Tag)
+		}
+		field.Indicator1, field.Indicator2 = content[0], content[1]
+		for _, subfield := range bytes.Split(content[2:], []byte{subfieldDelimiter}) {
+			if len(subfield) == 0 {
+				continue
+			}
+			field.Subfields = append(field.Subfields, &Subfield{Code: subfield[0], Value: string(subfield[1:])})
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record, nil
+}
+
+type iso2709Writer struct {
+	out *bufio.Writer
+}
+
+// NewWriter writes MARC 21 records in the ISO 2709 exchange format
+func NewWriter(out io.Writer) Writer {
+	return &iso2709Writer{out: bufio.NewWriter(out)}
+}
+
+// Write implements Writer.
+// The record length, base address and directory are worked out from the fields, the rest of the leader is kept
+func (w *iso2709Writer) Write(record *Record) error {
+	var directory, data bytes.Buffer
+	for _, field := range record.Fields {
+		start := data.Len()
+		if field.IsControl() {
+			data.WriteString(field.Value)
+		} else {
+			data.WriteByte(indicator(field.Indicator1))
+			data.WriteByte(indicator(field.Indicator2))
+			for _, subfield := range field.Subfields {
+				data.WriteByte(subfieldDelimiter)
+				data.WriteByte(subfield.Code)
+				data.WriteString(subfield.Value)
+			}
+		}
+		data.WriteByte(fieldTerminator)
+		if data.Len()-start > maxFieldLength {
+			return fmt.Errorf("%w: field %s is %d bytes, longer than iso 2709 allows", ErrMalformedRecord, field.Tag, data.Len()-start)
+		}
+		fmt.Fprintf(&directory, "%3.3s%04d%05d", field.Tag, data.Len()-start, start)
+	}
+	directory.WriteByte(fieldTerminator)
+	data.WriteByte(recordTerminator)
+	base := leaderLength + directory.Len()
+	length := base + data.Len()
+	if length > 99999 {
+		return fmt.Errorf("%w: record is %d bytes, longer than iso 2709 allows", ErrMalformedRecord, length)
+	}
+	leader := []byte(DefaultLeader)
+	if len(record.Leader) == leaderLength {
+		leader = []byte(record.Leader)
+	}
+	copy(leader[0:5], fmt.Sprintf("%05d", length))
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+	// Records are always written in UTF-8
+	leader[9] = 'a'
+	w.out.Write(leader)
+	w.out.Write(directory.Bytes())
+	_, err := w.out.Write(data.Bytes())
+	return err
+}
+
+// Close implements Writer.
+func (w *iso2709Writer) Close() error {
+	return w.out.Flush()
+}
+
+// Lengths and addresses are unsigned digits only, strconv alone would take a sign
+func number(digits string) (int, error) {
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return 0, strconv.ErrSyntax
+		}
+	}
+	return strconv.Atoi(digits)
+}
+
+// Blank indicators are written as a space
+func indicator(value byte) byte {
+	if value == 0 {
+		return ' '
+	}
+	return value
+}
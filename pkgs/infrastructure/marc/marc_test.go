@@ -0,0 +1,140 @@
+package marc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRecord() *Record {
+	record := &Record{Leader: DefaultLeader}
+	record.AddControl("001", "42")
+	record.AddData("020", ' ', ' ', "a", "9780451524935 (pbk.)")
+	record.AddData("100", '1', ' ', "a", "Orwell, George,", "d", "1903-1950.")
+	record.AddData("245", '1', '0', "a", "Nineteen eighty-four :", "b", "a novel /")
+	record.AddData("500", ' ', ' ', "a", "Tëst of UTF-8.")
+	return record
+}
+
+func readAll(t *testing.T, reader Reader) ([]*Record, []error) {
+	var records []*Record
+	var errs []error
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return records, errs
+		}
+		if err != nil {
+			if !errors.Is(err, ErrMalformedRecord) {
+				t.Fatal(err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		records = append(records, record)
+	}
+}
+
+func TestISO2709RoundTrip(t *testing.T) {
+	assertWithTest := assert.New(t)
+	var out bytes.Buffer
+	writer := NewWriter(&out)
+	assertWithTest.Nil(writer.Write(testRecord()))
+	assertWithTest.Nil(writer.Write(testRecord()))
+	assertWithTest.Nil(writer.Close())
+
+	raw := out.String()
+	// The leader holds the length of the record in bytes and where its data starts
+	length, err := strconv.Atoi(raw[:5])
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(len(raw)/2, length)
+	assertWithTest.Equal(byte(recordTerminator), raw[length-1])
+	records, errs := readAll(t, NewReader(strings.NewReader(raw)))
+	assertWithTest.Empty(errs)
+	assertWithTest.Len(records, 2)
+	for _, record := range records {
+		expected := testRecord()
+		expected.Leader = raw[:24]
+		assertWithTest.Equal(expected, record)
+	}
+}
+
+func TestISO2709SkipsMalformedRecords(t *testing.T) {
+	assertWithTest := assert.New(t)
+	var out bytes.Buffer
+	writer := NewWriter(&out)
+	assertWithTest.Nil(writer.Write(testRecord()))
+	assertWithTest.Nil(writer.Close())
+	good := out.String()
+	broken := "00026nam a2200000 i 4500" + string(rune(recordTerminator))
+
+	records, errs := readAll(t, NewReader(strings.NewReader(broken+"\n"+good+"\n")))
+	assertWithTest.Len(errs, 1)
+	assertWithTest.Len(records, 1)
+	assertWithTest.Equal("42", records[0].Field("001").Value)
+}
+
+func TestISO2709RejectsSignedDirectory(t *testing.T) {
+	assertWithTest := assert.New(t)
+	// One directory entry that starts its field before the data
+	directory := "2450005-0001" + string(rune(fieldTerminator))
+	data := "10abc" + string(rune(fieldTerminator)) + string(rune(recordTerminator))
+	base := leaderLength + len(directory)
+	raw := fmt.Sprintf("%05dnam a22%05d i 4500", base+len(data), base) + directory + data
+
+	records, errs := readAll(t, NewReader(strings.NewReader(raw)))
+	assertWithTest.Empty(records)
+	assertWithTest.Len(errs, 1)
+}
+
+func TestISO2709FieldTooLong(t *testing.T) {
+	record := &Record{Leader: DefaultLeader}
+	record.AddData("500", ' ', ' ', "a", strings.Repeat("x", 10000))
+	err := NewWriter(io.Discard).Write(record)
+	assert.ErrorIs(t, err, ErrMalformedRecord)
+}
+
+func TestMARCXMLRoundTrip(t *testing.T) {
+	assertWithTest := assert.New(t)
+	var out bytes.Buffer
+	writer := NewXMLWriter(&out)
+	assertWithTest.Nil(writer.Write(testRecord()))
+	assertWithTest.Nil(writer.Close())
+	assertWithTest.Contains(out.String(), `<collection xmlns="http://www.loc.gov/MARC21/slim">`)
+
+	records, errs := readAll(t, NewXMLReader(&out))
+	assertWithTest.Empty(errs)
+	assertWithTest.Len(records, 1)
+	assertWithTest.Equal("a novel /", records[0].Field("245").Subfield('b'))
+	assertWithTest.Equal(byte('1'), records[0].Field("100").Indicator1)
+	assertWithTest.Equal(byte(' '), records[0].Field("100").Indicator2)
+
+	// A record embedded in another document carries the namespace itself
+	embedded, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"recordData"`
+		Record  *Record
+	}{Record: testRecord()})
+	assertWithTest.Nil(err)
+	assertWithTest.Contains(string(embedded), `<recordData><record xmlns="http://www.loc.gov/MARC21/slim"><leader>`)
+	records, errs = readAll(t, NewXMLReader(bytes.NewReader(embedded)))
+	assertWithTest.Empty(errs)
+	assertWithTest.Equal("Nineteen eighty-four :", records[0].Field("245").Subfield('a'))
+
+	// Records written by other systems usually carry a namespace prefix
+	prefixed := `<?xml version="1.0"?><marc:collection xmlns:marc="http://www.loc.gov/MARC21/slim">
+		<marc:record><marc:leader>00000nam a2200000 i 4500</marc:leader>
+		<marc:controlfield tag="001">7</marc:controlfield>
+		<marc:datafield tag="245" ind1="0" ind2="0"><marc:subfield code="a">Dune</marc:subfield></marc:datafield>
+		</marc:record><marc:record><marc:datafield tag="245"`
+	records, errs = readAll(t, NewXMLReader(strings.NewReader(prefixed)))
+	assertWithTest.Len(records, 1)
+	assertWithTest.Equal("Dune", records[0].Field("245").Subfield('a'))
+	assertWithTest.Len(errs, 1)
+}
@@ -0,0 +1,175 @@
+package marc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Namespace of the MARC 21 XML schema
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlRecord struct {
+	XMLName       xml.Name       `xml:"record"`
+	Leader        string         `xml:"leader"`
+	ControlFields []xmlControl   `xml:"controlfield"`
+	DataFields    []xmlDataField `xml:"datafield"`
+}
+
+type xmlControl struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlReader struct {
+	decoder *xml.Decoder
+	// Set once the document could not be parsed, nothing after that point can be read
+	broken bool
+}
+
+// NewXMLReader reads the records of a MARCXML collection, or a single record, one at a time
+func NewXMLReader(source io.Reader) Reader {
+	return &xmlReader{decoder: xml.NewDecoder(source)}
+}
+
+// Read implements Reader.
+func (r *xmlReader) Read() (*Record, error) {
+	if r.broken {
+		return nil, io.EOF
+	}
+	for {
+		token, err := r.decoder.Token()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			r.broken = true
+			return nil, fmt.Errorf("%w: %v, the rest of the document could not be read", ErrMalformedRecord, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var raw xmlRecord
+		if err := r.decoder.DecodeElement(&raw, &start); err != nil {
+			r.broken = true
+			return nil, fmt.Errorf("%w: %v, the rest of the document could not be read", ErrMalformedRecord, err)
+		}
+		return raw.record(), nil
+	}
+}
+
+// Control and data fields are kept in separate lists in MARCXML, control fields always come first
+func (raw *xmlRecord) record() *Record {
+	record := &Record{Leader: raw.Leader}
+	for _, control := range raw.ControlFields {
+		record.Fields = append(record.Fields, &Field{Tag: control.Tag, Value: control.Value})
+	}
+	for _, data := range raw.DataFields {
+		field := &Field{Tag: data.Tag, Indicator1: firstByte(data.Ind1), Indicator2: firstByte(data.Ind2)}
+		for _, subfield := range data.Subfields {
+			field.Subfields = append(field.Subfields, &Subfield{Code: firstByte(subfield.Code), Value: subfield.Value})
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record
+}
+
+type xmlWriter struct {
+	out     io.Writer
+	encoder *xml.Encoder
+	started bool
+}
+
+// NewXMLWriter writes records into a MARCXML collection, Close ends the collection
+func NewXMLWriter(out io.Writer) Writer {
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	return &xmlWriter{out: out, encoder: encoder}
+}
+
+// Write implements Writer.
+func (w *xmlWriter) Write(record *Record) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	return w.encoder.Encode(record.xml())
+}
+
+// MarshalXML writes the record as a MARCXML record element of its own, so it can be embedded in other documents
+func (record *Record) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(record.xml(), xml.StartElement{Name: xml.Name{Space: Namespace, Local: "record"}})
+}
+
+// Close implements Writer.
+func (w *xmlWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if err := w.encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "collection"}}); err != nil {
+		return err
+	}
+	if err := w.encoder.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.out, "\n")
+	return err
+}
+
+// Open the collection before the first record, so an export with no books is still a valid document
+func (w *xmlWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	if _, err := io.WriteString(w.out, xml.Header); err != nil {
+		return err
+	}
+	return w.encoder.EncodeToken(xml.StartElement{
+		Name: xml.Name{Local: "collection"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: Namespace}},
+	})
+}
+
+// Control fields and data fields go into their own lists, an empty leader is written as the default one
+func (record *Record) xml() xmlRecord {
+	raw := xmlRecord{Leader: record.Leader}
+	if raw.Leader == "" {
+		raw.Leader = DefaultLeader
+	}
+	for _, field := range record.Fields {
+		if field.IsControl() {
+			raw.ControlFields = append(raw.ControlFields, xmlControl{Tag: field.Tag, Value: field.Value})
+			continue
+		}
+		data := xmlDataField{
+			Tag:  field.Tag,
+			Ind1: string(indicator(field.Indicator1)),
+			Ind2: string(indicator(field.Indicator2)),
+		}
+		for _, subfield := range field.Subfields {
+			data.Subfields = append(data.Subfields, xmlSubfield{Code: string(subfield.Code), Value: subfield.Value})
+		}
+		raw.DataFields = append(raw.DataFields, data)
+	}
+	return raw
+}
+
+func firstByte(value string) byte {
+	if value == "" {
+		return ' '
+	}
+	return value[0]
+}
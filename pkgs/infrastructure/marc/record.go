@@ -0,0 +1,98 @@
+package marc
+
+import (
+	"errors"
+	"strings"
+)
+
+// A record that could not be read, the reader has moved past it and the next record can still be read
+var ErrMalformedRecord = errors.New("malformed marc record")
+
+// Leader given to records that are written without one: a new record for a book in UTF-8
+const DefaultLeader = "00000nam a2200000 i 4500"
+
+// A MARC 21 bibliographic record, the same in ISO 2709 and MARCXML
+type Record struct {
+	Leader string
+	Fields []*Field
+}
+
+// Control fields (00X) only have a value, data fields have indicators and subfields
+type Field struct {
+	Tag        string
+	Value      string
+	Indicator1 byte
+	Indicator2 byte
+	Subfields  []*Subfield
+}
+
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// Control fields hold their data directly rather than in subfields
+func (f *Field) IsControl() bool {
+	return strings.HasPrefix(f.Tag, "00")
+}
+
+// First value of the subfield with the code, empty if the field does not have it
+func (f *Field) Subfield(code byte) string {
+	for _, subfield := range f.Subfields {
+		if subfield.Code == code {
+			return subfield.Value
+		}
+	}
+	return ""
+}
+
+// Every field of the record with the tag, in the order they appear
+func (r *Record) FieldsByTag(tag string) []*Field {
+	var fields []*Field
+	for _, field := range r.Fields {
+		if field.Tag == tag {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// First field of the record with the tag, nil if there is none
+func (r *Record) Field(tag string) *Field {
+	for _, field := range r.Fields {
+		if field.Tag == tag {
+			return field
+		}
+	}
+	return nil
+}
+
+// Add a control field holding the value
+func (r *Record) AddControl(tag, value string) {
+	r.Fields = append(r.Fields, &Field{Tag: tag, Value: value})
+}
+
+// Add a data field, subfields are given as code and value pairs and empty values are left out
+func (r *Record) AddData(tag string, indicator1, indicator2 byte, subfields ...string) {
+	field := &Field{Tag: tag, Indicator1: indicator1, Indicator2: indicator2}
+	for i := 0; i+1 < len(subfields); i += 2 {
+		if subfields[i+1] == "" {
+			continue
+		}
+		field.Subfields = append(field.Subfields, &Subfield{Code: subfields[i][0], Value: subfields[i+1]})
+	}
+	if len(field.Subfields) > 0 {
+		r.Fields = append(r.Fields, field)
+	}
+}
+
+// Reads records one at a time until io.EOF
+type Reader interface {
+	Read() (*Record, error)
+}
+
+// Writes records one at a time, Close finishes the output but leaves the underlying writer open
+type Writer interface {
+	Write(record *Record) error
+	Close() error
+}
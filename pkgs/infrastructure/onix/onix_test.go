@@ -0,0 +1,139 @@
+package onix
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const referenceFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<ONIXMessage release="3.0" xmlns="http://ns.editeur.org/onix/3.0/reference">
+  <Header><Sender><SenderName>Signet</SenderName></Sender></Header>
+  <Product>
+    <RecordReference>signet.1984</RecordReference>
+    <NotificationType>03</NotificationType>
+    <ProductIdentifier><ProductIDType>02</ProductIDType><IDValue>0451524934</IDValue></ProductIdentifier>
+    <ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>9780451524935</IDValue></ProductIdentifier>
+    <DescriptiveDetail>
+      <ProductComposition>00</ProductComposition>
+      <ProductForm>BC</ProductForm>
+      <TitleDetail>
+        <TitleType>01</TitleType>
+        <TitleElement>
+          <TitleElementLevel>01</TitleElementLevel>
+          <TitleWithoutPrefix>Nineteen Eighty-Four</TitleWithoutPrefix>
+          <Subtitle>A Novel</Subtitle>
+        </TitleElement>
+      </TitleDetail>
+      <Contributor><SequenceNumber>2</SequenceNumber><ContributorRole>A15</ContributorRole><PersonName>Erich Fromm</PersonName></Contributor>
+      <Contributor><SequenceNumber>1</SequenceNumber><ContributorRole>A01</ContributorRole><PersonName>George Orwell</PersonName></Contributor>
+      <Language><LanguageRole>01</LanguageRole><LanguageCode>eng</LanguageCode></Language>
+      <Extent><ExtentType>11</ExtentType><ExtentValue>336</ExtentValue><ExtentUnit>03</ExtentUnit></Extent>
+      <Extent><ExtentType>00</ExtentType><ExtentValue>328</ExtentValue><ExtentUnit>03</ExtentUnit></Extent>
+      <Subject><SubjectSchemeIdentifier>10</SubjectSchemeIdentifier><SubjectCode>FIC028000</SubjectCode></Subject>
+      <Subject><MainSubject/><SubjectSchemeIdentifier>20</SubjectSchemeIdentifier><SubjectHeadingText>Dystopian</SubjectHeadingText></Subject>
+    </DescriptiveDetail>
+    <PublishingDetail>
+      <Imprint><ImprintName>Signet Classics</ImprintName></Imprint>
+      <Publisher><PublishingRole>01</PublishingRole><PublisherName>Signet Classic</PublisherName></Publisher>
+      <PublishingDate><PublishingDateRole>01</PublishingDateRole><Date>19800608</Date></PublishingDate>
+    </PublishingDetail>
+  </Product>
+</ONIXMessage>`
+
+const shortFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<ONIXmessage release="3.0" xmlns="http://ns.editeur.org/onix/3.0/short">
+  <product>
+    <a001>ace.dune</a001>
+    <a002>03</a002>
+    <productidentifier><b221>15</b221><b244>9780441013593</b244></productidentifier>
+    <descriptivedetail>
+      <b012>BB</b012>
+      <titledetail><b202>01</b202><titleelement><x409>01</x409><b203>Dune</b203></titleelement></titledetail>
+      <contributor><b034>1</b034><b035>A01</b035><b036>Frank Herbert</b036></contributor>
+      <language><b253>01</b253><b252>eng</b252></language>
+      <extent><b218>00</b218><b219>896</b219><b220>03</b220></extent>
+    </descriptivedetail>
+    <publishingdetail>
+      <imprint><b079>Ace</b079></imprint>
+      <publishingdate><x448>01</x448><b306 dateformat="05">2005</b306></publishingdate>
+    </publishingdetail>
+  </product>
+</ONIXmessage>`
+
+func readAll(t *testing.T, reader *Reader) ([]*Product, []error) {
+	var products []*Product
+	var errs []error
+	for {
+		product, err := reader.Read()
+		if err == io.EOF {
+			return products, errs
+		}
+		if err != nil {
+			if !errors.Is(err, ErrMalformedProduct) {
+				t.Fatal(err)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		products = append(products, product)
+	}
+}
+
+func TestReadReferenceTags(t *testing.T) {
+	assertWithTest := assert.New(t)
+	products, errs := readAll(t, NewReader(strings.NewReader(referenceFeed)))
+	assertWithTest.Empty(errs)
+	assertWithTest.Len(products, 1)
+	product := products[0]
+	assertWithTest.Equal("signet.1984", product.RecordReference)
+	assertWithTest.Equal("BC", product.ProductForm)
+	assertWithTest.Equal("9780451524935", product.ISBN())
+	assertWithTest.Equal("Nineteen Eighty-Four: A Novel", product.Title())
+	assertWithTest.Equal("George Orwell", product.Author())
+	assertWithTest.Equal("eng", product.LanguageCode())
+	assertWithTest.Equal(328, product.PageCount())
+	assertWithTest.Equal("Signet Classic", product.PublisherName())
+	assertWithTest.Equal("Dystopian", product.SubjectHeading())
+	published, ok, err := product.PublicationDate()
+	assertWithTest.Nil(err)
+	assertWithTest.True(ok)
+	assertWithTest.Equal(time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC), published)
+}
+
+func TestReadShortTags(t *testing.T) {
+	assertWithTest := assert.New(t)
+	products, errs := readAll(t, NewReader(strings.NewReader(shortFeed)))
+	assertWithTest.Empty(errs)
+	assertWithTest.Len(products, 1)
+	product := products[0]
+	assertWithTest.Equal("BB", product.ProductForm)
+	assertWithTest.Equal("9780441013593", product.ISBN())
+	assertWithTest.Equal("Dune", product.Title())
+	assertWithTest.Equal("Frank Herbert", product.Author())
+	assertWithTest.Equal(896, product.PageCount())
+	// Without a publisher the imprint is used
+	assertWithTest.Equal("Ace", product.PublisherName())
+	published, ok, err := product.PublicationDate()
+	assertWithTest.Nil(err)
+	assertWithTest.True(ok)
+	assertWithTest.Equal(time.Date(2005, 1, 1, 0, 0, 0, 0, time.UTC), published)
+}
+
+func TestReadMalformedFeed(t *testing.T) {
+	assertWithTest := assert.New(t)
+	// The feed is cut off in the middle of its second product
+	cut := strings.Replace(referenceFeed, "</ONIXMessage>", "<Product><RecordReference>cut", 1)
+	products, errs := readAll(t, NewReader(strings.NewReader(cut)))
+	assertWithTest.Len(products, 1)
+	assertWithTest.Len(errs, 1)
+
+	product := &Product{PublishingDates: []PublishingDate{{Role: DatePublication, Date: Date{Value: "1980-06"}}}}
+	_, ok, err := product.PublicationDate()
+	assertWithTest.False(ok)
+	assertWithTest.NotNil(err)
+}
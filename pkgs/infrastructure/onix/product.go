@@ -0,0 +1,250 @@
+package onix
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A product that could not be read, the reader can still carry on with the next one
+var ErrMalformedProduct = errors.New("malformed onix product")
+
+// Code lists of ONIX for Books 3.0 that the product is read with
+const (
+	NotificationDelete = "05"
+	IDTypeISBN10       = "02"
+	IDTypeISBN13       = "15"
+	TitleTypeDistinct  = "01"
+	TitleLevelProduct  = "01"
+	RoleAuthor         = "A01"
+	LanguageOfText     = "01"
+	ExtentMainContent  = "00"
+	ExtentContentPages = "11"
+	ExtentUnitPages    = "03"
+	PublisherRole      = "01"
+	DatePublication    = "01"
+)
+
+// The parts of an ONIX 3.0 product record that describe the book, everything else in the record is ignored
+type Product struct {
+	RecordReference  string           `xml:"RecordReference"`
+	NotificationType string           `xml:"NotificationType"`
+	Identifiers      []Identifier     `xml:"ProductIdentifier"`
+	ProductForm      string           `xml:"DescriptiveDetail>ProductForm"`
+	Titles           []TitleDetail    `xml:"DescriptiveDetail>TitleDetail"`
+	Contributors     []Contributor    `xml:"DescriptiveDetail>Contributor"`
+	Languages        []Language       `xml:"DescriptiveDetail>Language"`
+	Extents          []Extent         `xml:"DescriptiveDetail>Extent"`
+	Subjects         []Subject        `xml:"DescriptiveDetail>Subject"`
+	Imprints         []string         `xml:"PublishingDetail>Imprint>ImprintName"`
+	Publishers       []Publisher      `xml:"PublishingDetail>Publisher"`
+	PublishingDates  []PublishingDate `xml:"PublishingDetail>PublishingDate"`
+}
+
+type Identifier struct {
+	Type  string `xml:"ProductIDType"`
+	Value string `xml:"IDValue"`
+}
+
+type TitleDetail struct {
+	Type     string         `xml:"TitleType"`
+	Elements []TitleElement `xml:"TitleElement"`
+}
+
+type TitleElement struct {
+	Level              string `xml:"TitleElementLevel"`
+	TitleText          string `xml:"TitleText"`
+	TitlePrefix        string `xml:"TitlePrefix"`
+	TitleWithoutPrefix string `xml:"TitleWithoutPrefix"`
+	Subtitle           string `xml:"Subtitle"`
+}
+
+type Contributor struct {
+	SequenceNumber int      `xml:"SequenceNumber"`
+	Roles          []string `xml:"ContributorRole"`
+	PersonName     string   `xml:"PersonName"`
+	CorporateName  string   `xml:"CorporateName"`
+}
+
+type Language struct {
+	Role string `xml:"LanguageRole"`
+	Code string `xml:"LanguageCode"`
+}
+
+type Extent struct {
+	Type  string `xml:"ExtentType"`
+	Value string `xml:"ExtentValue"`
+	Unit  string `xml:"ExtentUnit"`
+}
+
+type Subject struct {
+	// Present on the subject the publisher considers the main one
+	MainSubject *struct{} `xml:"MainSubject"`
+	Scheme      string    `xml:"SubjectSchemeIdentifier"`
+	Code        string    `xml:"SubjectCode"`
+	HeadingText string    `xml:"SubjectHeadingText"`
+}
+
+type Publisher struct {
+	Role string `xml:"PublishingRole"`
+	Name string `xml:"PublisherName"`
+}
+
+type PublishingDate struct {
+	Role string `xml:"PublishingDateRole"`
+	Date Date   `xml:"Date"`
+}
+
+type Date struct {
+	// Code list 55, YYYYMMDD when it is not given
+	Format string `xml:"dateformat,attr"`
+	Value  string `xml:",chardata"`
+}
+
+// ISBN of the product, the ISBN-13 is preferred over the ISBN-10
+func (p *Product) ISBN() string {
+	var isbn10 string
+	for _, identifier := range p.Identifiers {
+		switch identifier.Type {
+		case IDTypeISBN13:
+			return strings.TrimSpace(identifier.Value)
+		case IDTypeISBN10:
+			isbn10 = strings.TrimSpace(identifier.Value)
+		}
+	}
+	return isbn10
+}
+
+// Distinctive title of the product with its subtitle
+func (p *Product) Title() string {
+	for _, detail := range p.Titles {
+		if detail.Type != TitleTypeDistinct {
+			continue
+		}
+		for _, element := range detail.Elements {
+			if element.Level != TitleLevelProduct {
+				continue
+			}
+			title := strings.TrimSpace(element.TitleText)
+			if title == "" {
+				title = strings.TrimSpace(element.TitlePrefix + " " + element.TitleWithoutPrefix)
+			}
+			if subtitle := strings.TrimSpace(element.Subtitle); subtitle != "" {
+				title += ": " + subtitle
+			}
+			return title
+		}
+	}
+	return ""
+}
+
+// First author of the product by sequence number, or the first contributor when no one is credited as author
+func (p *Product) Author() string {
+	contributors := append([]Contributor{}, p.Contributors...)
+	sort.SliceStable(contributors, func(i, j int) bool {
+		return contributors[i].SequenceNumber < contributors[j].SequenceNumber
+	})
+	name := func(contributor Contributor) string {
+		if contributor.PersonName != "" {
+			return strings.TrimSpace(contributor.PersonName)
+		}
+		return strings.TrimSpace(contributor.CorporateName)
+	}
+	for _, contributor := range contributors {
+		for _, role := range contributor.Roles {
+			if role == RoleAuthor {
+				return name(contributor)
+			}
+		}
+	}
+	if len(contributors) > 0 {
+		return name(contributors[0])
+	}
+	return ""
+}
+
+// Code of the language the text is written in
+func (p *Product) LanguageCode() string {
+	for _, language := range p.Languages {
+		if language.Role == LanguageOfText {
+			return strings.TrimSpace(language.Code)
+		}
+	}
+	return ""
+}
+
+// Number of pages of the main content, zero when the product does not give it
+func (p *Product) PageCount() int {
+	for _, extentType := range []string{ExtentMainContent, ExtentContentPages} {
+		for _, extent := range p.Extents {
+			if extent.Type != extentType || extent.Unit != ExtentUnitPages {
+				continue
+			}
+			if pages, err := strconv.Atoi(strings.TrimSpace(extent.Value)); err == nil {
+				return pages
+			}
+		}
+	}
+	return 0
+}
+
+// Name of the publisher, falling back to the imprint
+func (p *Product) PublisherName() string {
+	for _, publisher := range p.Publishers {
+		if publisher.Role == PublisherRole {
+			return strings.TrimSpace(publisher.Name)
+		}
+	}
+	if len(p.Publishers) > 0 {
+		return strings.TrimSpace(p.Publishers[0].Name)
+	}
+	if len(p.Imprints) > 0 {
+		return strings.TrimSpace(p.Imprints[0])
+	}
+	return ""
+}
+
+// Heading of the main subject, or of the first subject that has one
+func (p *Product) SubjectHeading() string {
+	for _, subject := range p.Subjects {
+		if subject.MainSubject != nil && subject.HeadingText != "" {
+			return strings.TrimSpace(subject.HeadingText)
+		}
+	}
+	for _, subject := range p.Subjects {
+		if subject.HeadingText != "" {
+			return strings.TrimSpace(subject.HeadingText)
+		}
+	}
+	return ""
+}
+
+// Publication date of the product, a date given only to the month or year falls on the first day of it
+func (p *Product) PublicationDate() (time.Time, bool, error) {
+	for _, published := range p.PublishingDates {
+		if published.Role != DatePublication {
+			continue
+		}
+		value := strings.TrimSpace(published.Date.Value)
+		var layout string
+		switch published.Date.Format {
+		case "", "00":
+			layout = "20060102"
+		case "01":
+			layout = "200601"
+		case "05":
+			layout = "2006"
+		default:
+			return time.Time{}, false, fmt.Errorf("date format %s is not supported", published.Date.Format)
+		}
+		date, err := time.Parse(layout, value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("publication date %q does not match its date format", value)
+		}
+		return date, true, nil
+	}
+	return time.Time{}, false, nil
+}
@@ -0,0 +1,113 @@
+package onix
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Short tags of the elements a Product is read from, feeds may use either these or the reference names
+var shortTags = map[string]string{
+	"product":           "Product",
+	"a001":              "RecordReference",
+	"a002":              "NotificationType",
+	"productidentifier": "ProductIdentifier",
+	"b221":              "ProductIDType",
+	"b244":              "IDValue",
+	"descriptivedetail": "DescriptiveDetail",
+	"b012":              "ProductForm",
+	"titledetail":       "TitleDetail",
+	"b202":              "TitleType",
+	"titleelement":      "TitleElement",
+	"x409":              "TitleElementLevel",
+	"b203":              "TitleText",
+	"b030":              "TitlePrefix",
+	"b031":              "TitleWithoutPrefix",
+	"b029":              "Subtitle",
+	"contributor":       "Contributor",
+	"b034":              "SequenceNumber",
+	"b035":              "ContributorRole",
+	"b036":              "PersonName",
+	"b047":              "CorporateName",
+	"language":          "Language",
+	"b253":              "LanguageRole",
+	"b252":              "LanguageCode",
+	"extent":            "Extent",
+	"b218":              "ExtentType",
+	"b219":              "ExtentValue",
+	"b220":              "ExtentUnit",
+	"subject":           "Subject",
+	"x425":              "MainSubject",
+	"b067":              "SubjectSchemeIdentifier",
+	"b069":              "SubjectCode",
+	"b070":              "SubjectHeadingText",
+	"publishingdetail":  "PublishingDetail",
+	"imprint":           "Imprint",
+	"b079":              "ImprintName",
+	"publisher":         "Publisher",
+	"b291":              "PublishingRole",
+	"b081":              "PublisherName",
+	"publishingdate":    "PublishingDate",
+	"x448":              "PublishingDateRole",
+	"b306":              "Date",
+}
+
+// Passes the tokens of a feed through with short tags renamed to their reference names
+type referenceNames struct {
+	decoder *xml.Decoder
+}
+
+func (r referenceNames) Token() (xml.Token, error) {
+	token, err := r.decoder.Token()
+	switch element := token.(type) {
+	case xml.StartElement:
+		if name, ok := shortTags[element.Name.Local]; ok {
+			element.Name.Local = name
+		}
+		return element, err
+	case xml.EndElement:
+		if name, ok := shortTags[element.Name.Local]; ok {
+			element.Name.Local = name
+		}
+		return element, err
+	}
+	return token, err
+}
+
+type Reader struct {
+	decoder *xml.Decoder
+	// Set once the feed could not be parsed, nothing after that point can be read
+	broken bool
+}
+
+// NewReader reads the products of an ONIX 3.0 message one at a time, in reference or short tags
+func NewReader(source io.Reader) *Reader {
+	return &Reader{decoder: xml.NewTokenDecoder(referenceNames{decoder: xml.NewDecoder(source)})}
+}
+
+// Read returns the next product of the message, io.EOF once there are none left
+func (r *Reader) Read() (*Product, error) {
+	if r.broken {
+		return nil, io.EOF
+	}
+	for {
+		token, err := r.decoder.Token()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			r.broken = true
+			return nil, fmt.Errorf("%w: %v, the rest of the feed could not be read", ErrMalformedProduct, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Product" {
+			continue
+		}
+		var product Product
+		if err := r.decoder.DecodeElement(&product, &start); err != nil {
+			r.broken = true
+			return nil, fmt.Errorf("%w: %v, the rest of the feed could not be read", ErrMalformedProduct, err)
+		}
+		return &product, nil
+	}
+}
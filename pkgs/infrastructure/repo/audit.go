@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type auditRepo struct {
+	dbClient *sqlx.DB
+	logger   logrus.FieldLogger
+}
+
+func NewAuditDB(db *sqlx.DB) audit.Repository {
+	// Create a db logger for the audit repo
+	logger := logrus.WithFields(logrus.Fields{
+		"package": "auditRepo",
+	})
+	return &auditRepo{
+		dbClient: db,
+		logger:   logger,
+	}
+}
+
+// GetRecords implements audit.Repository.
+func (repo *auditRepo) GetRecords(ctx context.Context, params *audit.GetRecordsParams) ([]*audit.Record, int, error) {
+	var records []*audit.Record
+	sb := squirrel.Select("id", "entity", "entity_id", "action", "actor", "changes", "created_at").
+		From("audit_records")
+	if params.Entity != "" {
+		sb = sb.Where(squirrel.Eq{"entity": params.Entity})
+	}
+	if params.EntityID != 0 {
+		sb = sb.Where(squirrel.Eq{"entity_id": params.EntityID})
+	}
+	if params.Action != "" {
+		sb = sb.Where(squirrel.Eq{"action": params.Action})
+	}
+	if params.Actor != "" {
+		sb = sb.Where(squirrel.Eq{"actor": params.Actor})
+	}
+	if !params.From.IsZero() {
+		sb = sb.Where(squirrel.GtOrEq{"created_at": params.From})
+	}
+	if !params.To.IsZero() {
+		sb = sb.Where(squirrel.LtOrEq{"created_at": params.To})
+	}
+	// Oldest first so a history reads in the order the changes were made
+	sb = sb.OrderBy("id")
+	if params.Page > 0 {
+		offset := (params.Page - 1) * params.PerPage
+		sb = sb.Offset(uint64(offset))
+	}
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, -1, err
+	}
+	if err := sqlx.SelectContext(ctx, repo.dbClient, &records, query, args...); err != nil {
+		return nil, -1, err
+	}
+	return records, len(records), nil
+}
+
+// Audit helper shared by the repos of audited entities
+// Write the records in the caller's transaction, attributed to the actor of the request
+func writeAuditRecords(ctx context.Context, ext sqlx.ExtContext, records []*audit.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	actor := utils.ActorFromContext(ctx)
+	now := time.Now()
+	ib := squirrel.Insert("audit_records").Columns("entity", "entity_id", "action", "actor", "changes", "created_at")
+	for _, record := range records {
+		record.Actor = actor
+		record.CreatedAt = utils.CustomTime{Time: now}
+		ib = ib.Values(record.Entity, record.EntityID, record.Action, record.Actor, record.Changes, now)
+	}
+	query, args, err := ib.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = ext.ExecContext(ctx, query, args...)
+	return err
+}
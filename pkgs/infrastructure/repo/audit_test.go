@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookAuditTrail(t *testing.T) {
+	assertWithTest := assert.New(t)
+	client, err := testConn()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := utils.WithActor(context.Background(), "librarian@example.com")
+	booksDB := booksRepo{dbClient: client, logger: testLogger}
+	auditDB := auditRepo{dbClient: client, logger: testLogger}
+	book := books.Book{
+		ISBN:      "9780451524935",
+		Title:     "1984",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     328,
+	}
+	assertWithTest.Nil(booksDB.InsertBooks(ctx, []*books.Book{&book}))
+	assertWithTest.Nil(booksDB.UpdateBook(ctx, &books.Book{ID: book.ID, Title: "Nineteen Eighty-Four"}))
+	// An update that changes nothing leaves no record
+	assertWithTest.Nil(booksDB.UpdateBook(ctx, &books.Book{ID: book.ID, Title: "Nineteen Eighty-Four"}))
+	assertWithTest.Nil(booksDB.DeleteBookByID(ctx, book.ID))
+	// Scheduled jobs are attributed to the system
+	purged, err := booksDB.PurgeDeletedBooks(context.Background(), time.Now().Add(time.Hour))
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, purged)
+
+	history, count, err := auditDB.GetRecords(ctx, &audit.GetRecordsParams{Entity: audit.Book, EntityID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(4, count)
+	if count != 4 {
+		return
+	}
+	assertWithTest.Equal(audit.Create, history[0].Action)
+	assertWithTest.Equal(audit.Update, history[1].Action)
+	assertWithTest.Equal(audit.Changes{{Field: "title", Old: "1984", New: "Nineteen Eighty-Four"}}, history[1].Changes)
+	assertWithTest.Equal("librarian@example.com", history[1].Actor)
+	assertWithTest.Equal(audit.Delete, history[2].Action)
+	assertWithTest.Equal(audit.Purge, history[3].Action)
+	assertWithTest.Equal(utils.SystemActor, history[3].Actor)
+
+	// Search across the trail by actor and time range
+	_, count, err = auditDB.GetRecords(ctx, &audit.GetRecordsParams{Actor: "librarian@example.com",
+		From: time.Now().Add(-time.Hour), To: time.Now().Add(time.Hour)})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(3, count)
+	_, count, err = auditDB.GetRecords(ctx, &audit.GetRecordsParams{To: time.Now().Add(-time.Hour)})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(0, count)
+}
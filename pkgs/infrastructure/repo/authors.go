@@ -0,0 +1,323 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/authors"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type authorsRepo struct {
+	dbClient *sqlx.DB
+	logger   logrus.FieldLogger
+}
+
+func NewAuthorsDB(db *sqlx.DB) authors.Repository {
+	// Create a db logger for the authors repo
+	logger := logrus.WithFields(logrus.Fields{
+		"package": "authorsRepo",
+	})
+	return &authorsRepo{
+		dbClient: db,
+		logger:   logger,
+	}
+}
+
+// InsertAuthors implements authors.Repository.
+func (repo *authorsRepo) InsertAuthors(ctx context.Context, newAuthors []*authors.Author) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	if err = repo.insertAuthors(ctx, tx, newAuthors); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateAuthor implements authors.Repository.
+func (repo *authorsRepo) UpdateAuthor(ctx context.Context, authorID int,
+	apply func(*authors.Author) error) (*authors.Author, error) {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer concludeTx(tx, &err)
+	var lockedAuthors []*authors.Author
+	lockedAuthors, _, err = repo.getAuthors(ctx, tx, &authors.GetAuthorsParams{ID: authorID}, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(lockedAuthors) == 0 {
+		err = authors.ErrAuthorNotFound
+		return nil, err
+	}
+	updatedAuthor := lockedAuthors[0]
+	if err = apply(updatedAuthor); err != nil {
+		return nil, err
+	}
+	updatedAuthor.UpdatedAt.Time = time.Now()
+	var query string
+	var args []interface{}
+	query, args, err = squirrel.Update("authors").
+		Set("name", updatedAuthor.Name).
+		Set("sort_name", updatedAuthor.SortName).
+		Set("birth_year", squirrel.Expr("NULLIF(?, 0)", updatedAuthor.BirthYear)).
+		Set("death_year", squirrel.Expr("NULLIF(?, 0)", updatedAuthor.DeathYear)).
+		Set("aliases", updatedAuthor.Aliases).
+		Set("updated_at", updatedAuthor.UpdatedAt.Time).
+		Where(squirrel.Eq{"id": authorID}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+	return updatedAuthor, nil
+}
+
+// DeleteAuthorByID implements authors.Repository.
+func (repo *authorsRepo) DeleteAuthorByID(ctx context.Context, id int) error {
+	result, err := repo.dbClient.ExecContext(ctx, "DELETE FROM authors WHERE id = ?;", id)
+	if err != nil {
+		return repo.handleMysqlErr(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return authors.ErrAuthorNotFound
+	}
+	return nil
+}
+
+// GetAuthors implements authors.Repository.
+func (repo *authorsRepo) GetAuthors(ctx context.Context, params *authors.GetAuthorsParams) ([]*authors.Author, int, error) {
+	return repo.getAuthors(ctx, repo.dbClient, params, false)
+}
+
+// CreditAuthor implements authors.Repository.
+func (repo *authorsRepo) CreditAuthor(ctx context.Context, credit *authors.Credit) error {
+	_, err := repo.dbClient.ExecContext(ctx,
+		"INSERT INTO book_authors (book_id, author_id, role, position) VALUES (?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE position = VALUES(position);",
+		credit.BookID, credit.AuthorID, credit.Role, credit.Position)
+	if err != nil {
+		return repo.handleMysqlErr(err)
+	}
+	return nil
+}
+
+// RemoveCredit implements authors.Repository.
+func (repo *authorsRepo) RemoveCredit(ctx context.Context, authorID, bookID int, role authors.Role) error {
+	db := squirrel.Delete("book_authors").Where(squirrel.Eq{"author_id": authorID, "book_id": bookID})
+	if role != "" {
+		db = db.Where(squirrel.Eq{"role": role})
+	}
+	query, args, err := db.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := repo.dbClient.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return authors.ErrCreditNotFound
+	}
+	return nil
+}
+
+// GetCredits implements authors.Repository.
+func (repo *authorsRepo) GetCredits(ctx context.Context, params *authors.GetCreditsParams) ([]*authors.Credit, int, error) {
+	var credits []*authors.Credit
+	sb := squirrel.Select("ba.author_id", "ba.book_id", "ba.role", "ba.position", "b.isbn", "b.title").
+		From("book_authors ba").
+		Join("books b ON b.id = ba.book_id").
+		Where("b.deleted_at IS NULL")
+	if params.AuthorID != 0 {
+		sb = sb.Where(squirrel.Eq{"ba.author_id": params.AuthorID})
+	}
+	if params.BookID != 0 {
+		sb = sb.Where(squirrel.Eq{"ba.book_id": params.BookID})
+	}
+	if params.Role != "" {
+		sb = sb.Where(squirrel.Eq{"ba.role": params.Role})
+	}
+	sb = sb.OrderBy("b.title", "ba.book_id", "ba.position")
+	if params.Page > 0 {
+		offset := (params.Page - 1) * params.PerPage
+		sb = sb.Offset(uint64(offset))
+	}
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, -1, err
+	}
+	if err := sqlx.SelectContext(ctx, repo.dbClient, &credits, query, args...); err != nil {
+		return nil, -1, err
+	}
+	return credits, len(credits), nil
+}
+
+func (repo *authorsRepo) insertAuthors(ctx context.Context, ext sqlx.ExtContext, newAuthors []*authors.Author) error {
+	ib := squirrel.Insert("authors").Columns(
+		"name", "sort_name", "birth_year", "death_year", "aliases", "updated_at", "created_at",
+	)
+	for _, author := range newAuthors {
+		author.CreatedAt = utils.CustomTime{Time: time.Now()}
+		author.UpdatedAt = utils.CustomTime{Time: time.Now()}
+		if author.Aliases == nil {
+			author.Aliases = authors.Aliases{}
+		}
+		ib = ib.Values(
+			author.Name, author.SortName,
+			squirrel.Expr("NULLIF(?, 0)", author.BirthYear), squirrel.Expr("NULLIF(?, 0)", author.DeathYear),
+			author.Aliases, author.UpdatedAt.Time, author.CreatedAt.Time,
+		)
+	}
+	query, args, err := ib.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := ext.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	// Write DB primary key ID back to the pointer
+	for _, author := range newAuthors {
+		author.ID = int(lastInsertID)
+		lastInsertID++
+	}
+	return nil
+}
+
+func (repo *authorsRepo) getAuthors(ctx context.Context, ext sqlx.ExtContext,
+	params *authors.GetAuthorsParams, forUpdate bool) ([]*authors.Author, int, error) {
+	var retrievedAuthors []*authors.Author
+	sb := squirrel.Select("id", "name", "sort_name", "COALESCE(birth_year, 0) AS birth_year",
+		"COALESCE(death_year, 0) AS death_year", "aliases", "updated_at", "created_at").From("authors")
+	if params.ID != 0 {
+		sb = sb.Where(squirrel.Eq{"id": params.ID})
+	}
+	if params.Name != "" {
+		sb = sb.Where(authorNameMatches("", params.Name))
+	}
+	if params.BookID != 0 {
+		sb = sb.Where("EXISTS (SELECT 1 FROM book_authors ba WHERE ba.author_id = authors.id AND ba.book_id = ?)",
+			params.BookID)
+	}
+	sb = sb.OrderBy("sort_name", "id")
+	if params.Page > 0 {
+		offset := (params.Page - 1) * params.PerPage
+		sb = sb.Offset(uint64(offset))
+	}
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+	}
+	if forUpdate {
+		sb = sb.Suffix("FOR UPDATE")
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, -1, err
+	}
+	if err := sqlx.SelectContext(ctx, ext, &retrievedAuthors, query, args...); err != nil {
+		return nil, -1, err
+	}
+	return retrievedAuthors, len(retrievedAuthors), nil
+}
+
+func (repo *authorsRepo) handleMysqlErr(err error) error {
+	// Lets log the actual err that we arent propagating
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1451:
+			repo.logger.Error(mysqlErr.Message)
+			return authors.ErrAuthorHasBooks
+		case 1452:
+			repo.logger.Error(mysqlErr.Message)
+			// Either side of the credit may be missing, work out which one to report
+			if strings.Contains(mysqlErr.Message, "fk__book_authors__books") {
+				return authors.ErrBookNotFound
+			}
+			return authors.ErrAuthorNotFound
+		}
+	}
+	return err
+}
+
+// Author helpers shared with the books repo
+// Match an author by name, sort name or alias, prefix is the table alias of the authors table if any
+func authorNameMatches(prefix, name string) squirrel.Sqlizer {
+	pattern := "%" + name + "%"
+	return squirrel.Or{
+		squirrel.Like{prefix + "name": pattern},
+		squirrel.Like{prefix + "sort_name": pattern},
+		squirrel.Like{prefix + "aliases": pattern},
+	}
+}
+
+// Credit a new book to the author with this display name, creating the author if there is none yet
+func creditAuthorByName(ctx context.Context, ext sqlx.ExtContext, bookID int, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	var authorID int
+	err := sqlx.GetContext(ctx, ext, &authorID,
+		"SELECT id FROM authors WHERE name = ? ORDER BY id LIMIT 1;", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		now := time.Now()
+		var result sql.Result
+		result, err = ext.ExecContext(ctx,
+			"INSERT INTO authors (name, sort_name, aliases, updated_at, created_at) VALUES (?, ?, ?, ?, ?);",
+			name, authors.DefaultSortName(name), authors.Aliases{}, now, now)
+		if err != nil {
+			return err
+		}
+		var lastInsertID int64
+		lastInsertID, err = result.LastInsertId()
+		authorID = int(lastInsertID)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = ext.ExecContext(ctx,
+		"INSERT IGNORE INTO book_authors (book_id, author_id, role, position) VALUES (?, ?, ?, 1);",
+		bookID, authorID, authors.Writer)
+	return err
+}
+
+// Move the credit of a changed book from the author with the old display name to the one with the new name
+func recreditAuthorByName(ctx context.Context, ext sqlx.ExtContext, bookID int, oldName, newName string) error {
+	_, err := ext.ExecContext(ctx, `DELETE FROM book_authors WHERE book_id = ? AND role = ?
+		AND author_id IN (SELECT id FROM authors WHERE name = ?);`, bookID, authors.Writer, strings.TrimSpace(oldName))
+	if err != nil {
+		return err
+	}
+	return creditAuthorByName(ctx, ext, bookID, newName)
+}
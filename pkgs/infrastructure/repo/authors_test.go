@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/authors"
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorsAndCredits(t *testing.T) {
+	assertWithTest := assert.New(t)
+	client, err := testConn()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	booksDB := booksRepo{dbClient: client, logger: testLogger}
+	authorsDB := authorsRepo{dbClient: client, logger: testLogger}
+	book := books.Book{
+		ISBN:      "978-0451524935",
+		Title:     "1984",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     328,
+	}
+	err = booksDB.InsertBooks(ctx, []*books.Book{&book})
+	assertWithTest.Nil(err)
+
+	// Inserting the book credits it to an author record with the same name
+	retrievedAuthors, count, err := authorsDB.GetAuthors(ctx, &authors.GetAuthorsParams{BookID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+	orwell := retrievedAuthors[0]
+	assertWithTest.Equal("Orwell, George", orwell.SortName)
+
+	// Aliases are searchable from both the authors and the books
+	_, err = authorsDB.UpdateAuthor(ctx, orwell.ID, func(a *authors.Author) error {
+		return a.ApplyUpdate(&authors.Author{BirthYear: 1903, DeathYear: 1950, Aliases: authors.Aliases{"Eric Blair"}})
+	})
+	assertWithTest.Nil(err)
+	_, count, err = authorsDB.GetAuthors(ctx, &authors.GetAuthorsParams{Name: "Blair"})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+	_, count, err = booksDB.GetBooks(ctx, &books.GetBooksParams{Author: "Blair"})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+
+	translator := authors.Author{Name: "Anna Translator", SortName: "Translator, Anna"}
+	assertWithTest.Nil(authorsDB.InsertAuthors(ctx, []*authors.Author{&translator}))
+	assertWithTest.Nil(authorsDB.CreditAuthor(ctx, &authors.Credit{AuthorID: translator.ID, BookID: book.ID,
+		Role: authors.Translator, Position: 2}))
+	assertWithTest.Equal(authors.ErrBookNotFound, authorsDB.CreditAuthor(ctx, &authors.Credit{
+		AuthorID: translator.ID, BookID: book.ID + 1000, Role: authors.Translator, Position: 1}))
+	credits, count, err := authorsDB.GetCredits(ctx, &authors.GetCreditsParams{AuthorID: translator.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+	assertWithTest.Equal(authors.Translator, credits[0].Role)
+	assertWithTest.Equal(book.Title, credits[0].Title)
+
+	// Credited authors cannot be deleted until the credit is removed
+	assertWithTest.Equal(authors.ErrAuthorHasBooks, authorsDB.DeleteAuthorByID(ctx, translator.ID))
+	assertWithTest.Nil(authorsDB.RemoveCredit(ctx, translator.ID, book.ID, ""))
+	assertWithTest.Equal(authors.ErrCreditNotFound, authorsDB.RemoveCredit(ctx, translator.ID, book.ID, ""))
+	assertWithTest.Nil(authorsDB.DeleteAuthorByID(ctx, translator.ID))
+	assertWithTest.Equal(authors.ErrAuthorNotFound, authorsDB.DeleteAuthorByID(ctx, translator.ID))
+
+	// Changing the author of the book moves its credit, by update and by patch
+	assertWithTest.Nil(booksDB.UpdateBook(ctx, &books.Book{ID: book.ID, Author: "Eric Blair"}))
+	credited := func() []string {
+		names := []string{}
+		found, _, err := authorsDB.GetAuthors(ctx, &authors.GetAuthorsParams{BookID: book.ID})
+		assertWithTest.Nil(err)
+		for _, author := range found {
+			names = append(names, author.Name)
+		}
+		return names
+	}
+	assertWithTest.Equal([]string{"Eric Blair"}, credited())
+	patch, err := books.ParseMergePatch(book.ID, []byte(`{"author": "George Orwell"}`))
+	assertWithTest.Nil(err)
+	_, err = booksDB.PatchBook(ctx, patch)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]string{"George Orwell"}, credited())
+}
@@ -2,11 +2,20 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
 	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/cql"
 	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
 	"github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 )
@@ -39,6 +48,44 @@ func (repo *booksRepo) InsertBooks(ctx context.Context, newBooks []*books.Book)
 	return nil
 }
 
+// InsertBooksEach implements books.Repository.
+func (repo *booksRepo) InsertBooksEach(ctx context.Context, newBooks []*books.Book, atomic bool) ([]error, error) {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer concludeTx(tx, &err)
+	// Each book gets a savepoint so a failure only undoes that book
+	itemErrs := make([]error, len(newBooks))
+	failed := false
+	for i, book := range newBooks {
+		if _, err = tx.ExecContext(ctx, "SAVEPOINT bulk_book;"); err != nil {
+			return nil, err
+		}
+		if insertErr := repo.insertBooks(ctx, tx, []*books.Book{book}); insertErr != nil {
+			if _, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT bulk_book;"); err != nil {
+				return nil, err
+			}
+			itemErrs[i] = repo.handleMysqlErr(insertErr)
+			book.ID = 0
+			failed = true
+		}
+	}
+	if atomic && failed {
+		for i, book := range newBooks {
+			if itemErrs[i] == nil {
+				itemErrs[i] = books.ErrBulkRolledBack
+				book.ID = 0
+			}
+		}
+		// Setting err makes concludeTx roll back, the failures are reported per book rather than returned
+		err = books.ErrBulkRolledBack
+		return itemErrs, nil
+	}
+	return itemErrs, nil
+}
+
 // UpdateBook implements books.Repository.
 func (repo *booksRepo) UpdateBook(ctx context.Context, arg *books.Book) error {
 	// Start transaction
@@ -47,12 +94,257 @@ func (repo *booksRepo) UpdateBook(ctx context.Context, arg *books.Book) error {
 		return err
 	}
 	defer concludeTx(tx, &err)
-	if err := repo.updatebook(ctx, tx, arg); err != nil {
+	// Books in the trash have to be restored before they can be changed
+	var deleted bool
+	if deleted, err = lockBookIncludingTrash(ctx, tx, arg.ID); err != nil {
 		return err
 	}
-	return nil
+	if deleted {
+		err = books.ErrBookDeleted
+		return err
+	}
+	var current []*books.Book
+	if current, _, err = repo.getBooks(ctx, tx, &books.GetBooksParams{ID: arg.ID}); err != nil {
+		return err
+	}
+	// The version is checked under the row lock so no other update can slip in between
+	if arg.Version != 0 && arg.Version != current[0].Version {
+		err = books.ErrVersionConflict
+		return err
+	}
+	changes := current[0].Changes(arg)
+	if err = repo.updatebook(ctx, tx, arg); err != nil {
+		return err
+	}
+	if err = relinkChangedBook(ctx, tx, arg.ID, changes); err != nil {
+		return err
+	}
+	arg.Version = current[0].Version + 1
+	if len(changes) == 0 {
+		return nil
+	}
+	err = writeAuditRecords(ctx, tx, []*audit.Record{
+		{Entity: audit.Book, EntityID: arg.ID, Action: audit.Update, Changes: changes},
+	})
+	return err
+}
+
+// PatchBook implements books.Repository.
+func (repo *booksRepo) PatchBook(ctx context.Context, patch *books.Patch) (*books.Book, error) {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer concludeTx(tx, &err)
+	var deleted bool
+	if deleted, err = lockBookIncludingTrash(ctx, tx, patch.ID); err != nil {
+		return nil, err
+	}
+	if deleted {
+		err = books.ErrBookDeleted
+		return nil, err
+	}
+	var current []*books.Book
+	if current, _, err = repo.getBooks(ctx, tx, &books.GetBooksParams{ID: patch.ID}); err != nil {
+		return nil, err
+	}
+	if patch.Version != 0 && patch.Version != current[0].Version {
+		err = books.ErrVersionConflict
+		return nil, err
+	}
+	if err = patch.CheckTests(current[0]); err != nil {
+		return nil, err
+	}
+	// Fields sent with the value they already hold leave the book and its version alone
+	changes := current[0].PatchChanges(patch)
+	if len(changes) == 0 {
+		return current[0], nil
+	}
+	var query string
+	var args []interface{}
+	query, args, err = squirrel.Update("books").
+		SetMap(patch.Updates()).
+		Set("updated_at", time.Now()).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": patch.ID}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+	if err = relinkChangedBook(ctx, tx, patch.ID, changes); err != nil {
+		return nil, err
+	}
+	if err = writeAuditRecords(ctx, tx, []*audit.Record{
+		{Entity: audit.Book, EntityID: patch.ID, Action: audit.Update, Changes: changes},
+	}); err != nil {
+		return nil, err
+	}
+	var patched []*books.Book
+	if patched, _, err = repo.getBooks(ctx, tx, &books.GetBooksParams{ID: patch.ID}); err != nil {
+		return nil, err
+	}
+	return patched[0], nil
 }
 
+// UpdateBooksBulk implements books.Repository.
+func (repo *booksRepo) UpdateBooksBulk(ctx context.Context, update *books.BulkUpdate) ([]*books.BulkResult, error) {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer concludeTx(tx, &err)
+	var results []*books.BulkResult
+	if results, err = repo.lockBulkSelection(ctx, tx, &update.BulkSelection); err != nil {
+		return nil, err
+	}
+	if bulkRejected(results, update.Mode) {
+		return results, nil
+	}
+	// Only copies on the shelf or in repair are moved. Copies out on loan or on hold keep circulating, and lost or
+	// withdrawn copies must not come back into stock
+	offShelf := squirrel.And{
+		squirrel.Eq{"status": []copies.Status{copies.Available, copies.InRepair}},
+		squirrel.NotEq{"status": update.CopyStatus},
+	}
+	var records []*audit.Record
+	for _, result := range results {
+		if result.Error != "" {
+			continue
+		}
+		if update.Patch != nil && len(update.Patch.Fields) > 0 {
+			var current []*books.Book
+			if current, _, err = repo.getBooks(ctx, tx, &books.GetBooksParams{ID: result.ID}); err != nil {
+				return nil, err
+			}
+			result.Changes = current[0].PatchChanges(update.Patch)
+		}
+		// Copies that would leave the shelf counted by the status they have now
+		moved := map[copies.Status]int{}
+		if update.CopyStatus != "" {
+			var query string
+			var args []interface{}
+			query, args, err = squirrel.Select("status", "COUNT(*) AS copies").From("copies").
+				Where(squirrel.Eq{"book_id": result.ID}).Where(offShelf).GroupBy("status").ToSql()
+			if err != nil {
+				return nil, err
+			}
+			var statuses []struct {
+				Status copies.Status `db:"status"`
+				Copies int           `db:"copies"`
+			}
+			if err = tx.SelectContext(ctx, &statuses, query, args...); err != nil {
+				return nil, err
+			}
+			for _, status := range statuses {
+				moved[status.Status] = status.Copies
+				result.CopiesChanged += status.Copies
+			}
+		}
+		if update.DryRun {
+			continue
+		}
+		changes := audit.Changes{}
+		if len(result.Changes) > 0 {
+			var query string
+			var args []interface{}
+			query, args, err = squirrel.Update("books").
+				SetMap(update.Patch.Updates()).
+				Set("updated_at", time.Now()).
+				Set("version", squirrel.Expr("version + 1")).
+				Where(squirrel.Eq{"id": result.ID}).ToSql()
+			if err != nil {
+				return nil, err
+			}
+			if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+				return nil, err
+			}
+			if err = relinkChangedBook(ctx, tx, result.ID, result.Changes); err != nil {
+				return nil, err
+			}
+			changes = append(changes, result.Changes...)
+		}
+		if result.CopiesChanged > 0 {
+			// Every available copy leaves the shelf, so a book that had one is no longer available
+			changes = append(changes, audit.Change{Field: "copies", Old: moved,
+				New: map[copies.Status]int{update.CopyStatus: result.CopiesChanged}})
+			if moved[copies.Available] > 0 {
+				changes = append(changes, audit.Change{Field: "availability", Old: books.Available, New: books.NotAvailable})
+			}
+			var query string
+			var args []interface{}
+			query, args, err = squirrel.Update("copies").Set("status", update.CopyStatus).
+				Where(squirrel.Eq{"book_id": result.ID}).Where(offShelf).ToSql()
+			if err != nil {
+				return nil, err
+			}
+			if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+				return nil, err
+			}
+		}
+		if len(changes) > 0 {
+			records = append(records, &audit.Record{Entity: audit.Book, EntityID: result.ID, Action: audit.Update,
+				Changes: changes})
+		}
+	}
+	if err = writeAuditRecords(ctx, tx, records); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// DeleteBooksBulk implements books.Repository.
+func (repo *booksRepo) DeleteBooksBulk(ctx context.Context, selection *books.BulkSelection) ([]*books.BulkResult, error) {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer concludeTx(tx, &err)
+	var results []*books.BulkResult
+	if results, err = repo.lockBulkSelection(ctx, tx, selection); err != nil {
+		return nil, err
+	}
+	if bulkRejected(results, selection.Mode) || selection.DryRun {
+		return results, nil
+	}
+	now := time.Now()
+	var ids []int
+	var records []*audit.Record
+	for _, result := range results {
+		if result.Error != "" {
+			continue
+		}
+		ids = append(ids, result.ID)
+		records = append(records, &audit.Record{Entity: audit.Book, EntityID: result.ID, Action: audit.Delete,
+			Changes: audit.Changes{{Field: "deleted_at", New: now.UTC().Format(time.RFC3339)}}})
+	}
+	if len(ids) == 0 {
+		return results, nil
+	}
+	var query string
+	var args []interface{}
+	query, args, err = squirrel.Update("books").
+		Set("deleted_at", now).
+		Set("updated_at", now).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+	if err = writeAuditRecords(ctx, tx, records); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// DeleteBookByID moves the book to the trash, it is hard deleted once the retention period is over
 func (repo *booksRepo) DeleteBookByID(ctx context.Context, id int) error {
 	// Start transaction
 	tx, err := repo.dbClient.BeginTxx(ctx, nil)
@@ -60,15 +352,284 @@ func (repo *booksRepo) DeleteBookByID(ctx context.Context, id int) error {
 		return err
 	}
 	defer concludeTx(tx, &err)
-	if err := repo.deleteBookByID(ctx, tx, id); err != nil {
+	var deleted bool
+	if deleted, err = lockBookIncludingTrash(ctx, tx, id); err != nil {
 		return err
 	}
-	return nil
+	if deleted {
+		err = books.ErrBookDeleted
+		return err
+	}
+	now := time.Now()
+	if _, err = tx.ExecContext(ctx, "UPDATE books SET deleted_at = ?, updated_at = ?, version = version + 1 WHERE id = ?;", now, now, id); err != nil {
+		return err
+	}
+	err = writeAuditRecords(ctx, tx, []*audit.Record{{Entity: audit.Book, EntityID: id, Action: audit.Delete,
+		Changes: audit.Changes{{Field: "deleted_at", New: now.UTC().Format(time.RFC3339)}}}})
+	return err
 }
 
+// RestoreBookByID implements books.Repository.
+func (repo *booksRepo) RestoreBookByID(ctx context.Context, id int) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	var deleted bool
+	if deleted, err = lockBookIncludingTrash(ctx, tx, id); err != nil {
+		return err
+	}
+	if !deleted {
+		err = books.ErrBookNotInTrash
+		return err
+	}
+	var deletedAt time.Time
+	if err = tx.GetContext(ctx, &deletedAt, "SELECT deleted_at FROM books WHERE id = ?;", id); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "UPDATE books SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE id = ?;", time.Now(), id); err != nil {
+		return err
+	}
+	err = writeAuditRecords(ctx, tx, []*audit.Record{{Entity: audit.Book, EntityID: id, Action: audit.Restore,
+		Changes: audit.Changes{{Field: "deleted_at", Old: deletedAt.UTC().Format(time.RFC3339)}}}})
+	return err
+}
+
+// PurgeDeletedBooks implements books.Repository.
+func (repo *booksRepo) PurgeDeletedBooks(ctx context.Context, deletedBefore time.Time) (int, error) {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer concludeTx(tx, &err)
+	// Copies, holds and links go with the book. Deleting loans would take their fines with them, so a book
+	// that was ever lent out stays in the trash to keep the circulation history and member balances whole
+	var purged []*books.Book
+	if err = tx.SelectContext(ctx, &purged, `SELECT id, isbn, title FROM books
+		WHERE deleted_at IS NOT NULL AND deleted_at < ?
+		AND NOT EXISTS (SELECT 1 FROM loans l WHERE l.book_id = books.id)
+		ORDER BY id FOR UPDATE;`, deletedBefore); err != nil {
+		return 0, err
+	}
+	if len(purged) == 0 {
+		return 0, nil
+	}
+	ids := make([]int, 0, len(purged))
+	records := make([]*audit.Record, 0, len(purged))
+	for _, book := range purged {
+		ids = append(ids, book.ID)
+		// Keep enough in the trail to tell which book was purged
+		records = append(records, &audit.Record{Entity: audit.Book, EntityID: book.ID, Action: audit.Purge,
+			Changes: audit.Changes{{Field: "isbn", Old: book.ISBN}, {Field: "title", Old: book.Title}}})
+	}
+	var query string
+	var args []interface{}
+	if query, args, err = squirrel.Delete("books").Where(squirrel.Eq{"id": ids}).ToSql(); err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return 0, err
+	}
+	if err = writeAuditRecords(ctx, tx, records); err != nil {
+		return 0, err
+	}
+	return len(purged), nil
+}
+
+// GetBooks implements books.Repository.
+// A page of the books is counted in full with the same filters, every book that matches is already a count of them
 func (b *booksRepo) GetBooks(ctx context.Context, params *books.GetBooksParams) ([]*books.Book, int, error) {
 	// Enter nil for sqlx.ExtContext as this query does not form part of a transaction chain
-	return b.getBooks(ctx, nil, params)
+	found, total, err := b.getBooks(ctx, nil, params)
+	if err != nil || params.PerPage <= 0 {
+		return found, total, err
+	}
+	if params.SkipTotal {
+		return found, -1, nil
+	}
+	if total, err = b.CountBooks(ctx, params); err != nil {
+		return nil, -1, err
+	}
+	return found, total, nil
+}
+
+// StreamBooks implements books.Repository.
+func (b *booksRepo) StreamBooks(ctx context.Context, params *books.GetBooksParams, each func(*books.Book) error) error {
+	// Rows are read one at a time rather than collected, the connection is held until the last one is handed over
+	return b.eachBook(ctx, nil, params, each)
+}
+
+// CountBooks implements books.Repository.
+func (b *booksRepo) CountBooks(ctx context.Context, params *books.GetBooksParams) (int, error) {
+	sb, err := filterBooks(squirrel.Select("COUNT(*)").From("books"), params)
+	if err != nil {
+		return 0, err
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	if err := sqlx.GetContext(ctx, b.dbClient, &count, query, args...); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetFacets implements books.Repository.
+// Every facet is counted with its own query, over the books the other filters find
+func (b *booksRepo) GetFacets(ctx context.Context, params *books.GetBooksParams,
+	facets []books.Facet) (map[books.Facet][]*books.FacetBucket, error) {
+	buckets := make(map[books.Facet][]*books.FacetBucket, len(facets))
+	for _, facet := range facets {
+		value, ok := facetValues[facet]
+		if !ok {
+			return nil, books.ErrFacet
+		}
+		sb, err := facetQuery(facet, value, params.WithoutFilter(facet))
+		if err != nil {
+			return nil, err
+		}
+		sb = sb.GroupBy("value").Having("value <> ''")
+		// Decades read best in order, the other facets list the values most books hold first
+		if facet == books.FacetDecade {
+			sb = sb.OrderBy("value")
+		} else {
+			sb = sb.OrderBy("count DESC", "value").Limit(books.MaxFacetBuckets)
+		}
+		query, args, err := sb.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		facetBuckets := []*books.FacetBucket{}
+		if err := sqlx.SelectContext(ctx, b.dbClient, &facetBuckets, query, args...); err != nil {
+			return nil, err
+		}
+		buckets[facet] = facetBuckets
+	}
+	return buckets, nil
+}
+
+// Genres are counted through the tree the way the genre filter matches them, so a book counts under the
+// genre it is filed in and every genre above it
+func facetQuery(facet books.Facet, value squirrel.Sqlizer,
+	params *books.GetBooksParams) (squirrel.SelectBuilder, error) {
+	if facet != books.FacetGenre {
+		return filterBooks(squirrel.Select().Column(value).Column("COUNT(*) AS count").From("books"), params)
+	}
+	filtered, err := filterBooks(squirrel.Select("id").From("books"), params)
+	if err != nil {
+		return filtered, err
+	}
+	query, args, err := filtered.ToSql()
+	if err != nil {
+		return filtered, err
+	}
+	return squirrel.Select().Column(value).Column("COUNT(DISTINCT bg.book_id) AS count").
+		From("book_genres bg").
+		Join("genre_closure gc ON gc.descendant_id = bg.genre_id").
+		Join("genres g ON g.id = gc.ancestor_id").
+		Where("bg.book_id IN ("+query+")", args...), nil
+}
+
+// What each facet counts the books by
+var facetValues = map[books.Facet]squirrel.Sqlizer{
+	books.FacetGenre:     squirrel.Expr("g.name AS value"),
+	books.FacetLanguage:  squirrel.Expr("language AS value"),
+	books.FacetPublisher: squirrel.Expr("publisher AS value"),
+	books.FacetAvailability: squirrel.Expr(`CASE WHEN EXISTS (SELECT 1 FROM copies c
+		WHERE c.book_id = books.id AND c.status = ?) THEN ? ELSE ? END AS value`,
+		copies.Available, books.Available, books.NotAvailable),
+	books.FacetDecade: squirrel.Expr("CAST(FLOOR(YEAR(published) / 10) * 10 AS CHAR) AS value"),
+}
+
+// GetLanguages implements books.Repository.
+func (b *booksRepo) GetLanguages(ctx context.Context) ([]*books.LanguageCount, error) {
+	query, args, err := squirrel.Select("language", "COUNT(*) AS books").
+		From("books").
+		Where("deleted_at IS NULL").
+		Where(squirrel.NotEq{"language": ""}).
+		GroupBy("language").
+		OrderBy("language").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var languages []*books.LanguageCount
+	if err := sqlx.SelectContext(ctx, b.dbClient, &languages, query, args...); err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
+
+// FullTextSearch implements books.Repository.
+// Each field is scored on its own index so it can be weighted, the books match on the index over all of them
+func (b *booksRepo) FullTextSearch(ctx context.Context, params *books.FullTextParams) ([]*books.SearchHit, int, error) {
+	against := params.Query.BooleanMode()
+	var match squirrel.Sqlizer = squirrel.Expr(
+		"MATCH (title, author, publisher, genre) AGAINST (? IN BOOLEAN MODE)", against)
+	if !params.Query.AllFields() {
+		fieldMatches := squirrel.Or{}
+		for _, field := range params.Query.Fields {
+			fieldMatches = append(fieldMatches, squirrel.Expr("MATCH ("+field+") AGAINST (? IN BOOLEAN MODE)", against))
+		}
+		match = fieldMatches
+	}
+	scores := make([]string, len(params.Query.Fields))
+	var scoreArgs []interface{}
+	for i, field := range params.Query.Fields {
+		scores[i] = "? * MATCH (" + field + ") AGAINST (? IN BOOLEAN MODE)"
+		scoreArgs = append(scoreArgs, params.Query.Boosts[field], against)
+	}
+	countQuery, countArgs, err := squirrel.Select("COUNT(*)").From("books").
+		Where("deleted_at IS NULL").Where(match).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int
+	if err := sqlx.GetContext(ctx, b.dbClient, &total, countQuery, countArgs...); err != nil {
+		return nil, 0, b.handleFullTextErr(err)
+	}
+	sb := selectBooks().
+		Column(squirrel.Expr("("+strings.Join(scores, " + ")+") AS score", scoreArgs...)).
+		Where("deleted_at IS NULL").
+		Where(match).
+		OrderBy("score DESC", "id")
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+		if params.Page > 1 {
+			sb = sb.Offset(uint64((params.Page - 1) * params.PerPage))
+		}
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+	var rows []struct {
+		books.Book
+		Score float64 `db:"score"`
+	}
+	if err := sqlx.SelectContext(ctx, b.dbClient, &rows, query, args...); err != nil {
+		return nil, 0, b.handleFullTextErr(err)
+	}
+	hits := make([]*books.SearchHit, len(rows))
+	for i := range rows {
+		hits[i] = &books.SearchHit{Book: &rows[i].Book, Score: rows[i].Score}
+	}
+	return hits, total, nil
+}
+
+// A database without the full-text indexes cannot match against them, the service ranks the books itself instead
+func (b *booksRepo) handleFullTextErr(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1191 {
+		b.logger.Warn(mysqlErr.Message)
+		return books.ErrFullTextUnsupported
+	}
+	return err
 }
 
 func (p *booksRepo) updatebook(ctx context.Context, ext sqlx.ExtContext, updatedBook *books.Book) error {
@@ -98,14 +659,12 @@ func (p *booksRepo) updatebook(ctx context.Context, ext sqlx.ExtContext, updated
 	if updatedBook.Pages != 0 {
 		updateBuilder = updateBuilder.Set("pages", updatedBook.Pages)
 	}
-	if updatedBook.Availability != "" {
-		updateBuilder = updateBuilder.Set("availability", updatedBook.Availability)
-	}
 	if (updatedBook.DeletedAt != utils.CustomTime{}) {
 		updateBuilder = updateBuilder.Set("deleted_at", updatedBook.DeletedAt.Time)
 	}
-	// Always update the updated at field
+	// Always update the updated at field and move the book on to a new version
 	updateBuilder = updateBuilder.Set("updated_at", utils.CustomTime{Time: time.Now()}.Time)
+	updateBuilder = updateBuilder.Set("version", squirrel.Expr("version + 1"))
 	updateBuilder = updateBuilder.Where(squirrel.Eq{"id": updatedBook.ID})
 	// Build the final SQL query and arguments
 	sql, args, err := updateBuilder.ToSql()
@@ -120,24 +679,29 @@ func (p *booksRepo) updatebook(ctx context.Context, ext sqlx.ExtContext, updated
 	return nil
 }
 
-func (p *booksRepo) insertBooks(ctx context.Context, ext sqlx.ExtContext, books []*books.Book) error {
+func (p *booksRepo) insertBooks(ctx context.Context, ext sqlx.ExtContext, newBooks []*books.Book) error {
 	// Make an efficient insert using a sql statement builder
 	ib := squirrel.Insert("books").Columns(
 		"isbn", "title", "author", "publisher", "published",
-		"genre", "language", "pages", "availability", "updated_at", "created_at",
+		"genre", "language", "pages", "updated_at", "created_at",
 	)
 
 	// Add values for each book
-	for _, book := range books {
+	for _, book := range newBooks {
 		book.CreatedAt = utils.CustomTime{
 			Time: time.Now(),
 		}
 		book.UpdatedAt = utils.CustomTime{
 			Time: time.Now(),
 		}
+		book.Version = 1
+		// A new book has no copies yet, so there is nothing on the shelf
+		book.Availability = books.NotAvailable
+		book.CopiesAvailable = 0
+		book.CopiesTotal = 0
 		ib = ib.Values(
 			book.ISBN, book.Title, book.Author, book.Publisher, book.Published.Time, book.Genre,
-			book.Language, book.Pages, book.Availability, book.UpdatedAt.Time, book.CreatedAt.Time,
+			book.Language, book.Pages, book.UpdatedAt.Time, book.CreatedAt.Time,
 		)
 	}
 	// Build the final SQL query and arguments
@@ -146,52 +710,265 @@ func (p *booksRepo) insertBooks(ctx context.Context, ext sqlx.ExtContext, books
 		return err
 	}
 	// Execute the query with ExecContext
-	result, err := ext.ExecContext(ctx, sql, args...)
-	if err != nil {
+	if _, err := ext.ExecContext(ctx, sql, args...); err != nil {
 		return err
 	}
-	// Retrieve last insert ID
-	lastInsertID, err := result.LastInsertId()
+	// Ids of a multi row insert are not always contiguous, so read them back by the unique isbn
+	isbns := make([]string, 0, len(newBooks))
+	for _, book := range newBooks {
+		isbns = append(isbns, book.ISBN)
+	}
+	idQuery, idArgs, err := squirrel.Select("id", "isbn").From("books").Where(squirrel.Eq{"isbn": isbns}).ToSql()
 	if err != nil {
 		return err
 	}
+	var inserted []struct {
+		ID   int    `db:"id"`
+		ISBN string `db:"isbn"`
+	}
+	if err := sqlx.SelectContext(ctx, ext, &inserted, idQuery, idArgs...); err != nil {
+		return err
+	}
+	insertedIDs := make(map[string]int, len(inserted))
+	for _, row := range inserted {
+		insertedIDs[row.ISBN] = row.ID
+	}
 	// Write DB primary key ID back to the pointer
-	for _, book := range books {
-		book.ID = int(lastInsertID)
-		lastInsertID++
+	for _, book := range newBooks {
+		book.ID = insertedIDs[book.ISBN]
+	}
+	// Link each book to an author record and a genre so it is listed under both
+	records := make([]*audit.Record, 0, len(newBooks))
+	for _, book := range newBooks {
+		records = append(records, &audit.Record{Entity: audit.Book, EntityID: book.ID, Action: audit.Create,
+			Changes: book.Snapshot()})
+		if err := creditAuthorByName(ctx, ext, book.ID, book.Author); err != nil {
+			return err
+		}
+		if err := fileBookUnderGenre(ctx, ext, book.ID, book.Genre); err != nil {
+			return err
+		}
+	}
+	if err := writeAuditRecords(ctx, ext, records); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+// Keep the author credit and genre filing of a changed book in step with its author and genre fields
+func relinkChangedBook(ctx context.Context, ext sqlx.ExtContext, bookID int, changes audit.Changes) error {
+	for _, change := range changes {
+		oldValue, _ := change.Old.(string)
+		newValue, _ := change.New.(string)
+		switch change.Field {
+		case "author":
+			if err := recreditAuthorByName(ctx, ext, bookID, oldValue, newValue); err != nil {
+				return err
+			}
+		case "genre":
+			if err := refileBookUnderGenre(ctx, ext, bookID, oldValue, newValue); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (repo *booksRepo) getBooks(ctx context.Context, ext sqlx.ExtContext,
 	params *books.GetBooksParams) ([]*books.Book, int, error) {
 	var userBooks []*books.Book
-	sb := squirrel.Select("id", "isbn", "title", "author", "publisher", "published",
-		"genre", "language", "pages", "availability", "updated_at", "created_at").From("books")
-	sb = sb.Where("deleted_at IS NULL")
+	if err := repo.eachBook(ctx, ext, params, func(book *books.Book) error {
+		userBooks = append(userBooks, book)
+		return nil
+	}); err != nil {
+		return nil, -1, err
+	}
+	// Books before a cursor are read in reverse, they are listed in order
+	if params.Cursor != nil && params.Cursor.Backward {
+		for i, j := 0, len(userBooks)-1; i < j; i, j = i+1, j-1 {
+			userBooks[i], userBooks[j] = userBooks[j], userBooks[i]
+		}
+	}
+	return userBooks, len(userBooks), nil
+}
+
+// Hand every book that matches the params to each as it is read, so a caller can stream them
+func (repo *booksRepo) eachBook(ctx context.Context, ext sqlx.ExtContext,
+	params *books.GetBooksParams, each func(*books.Book) error) error {
+	sb, err := filterBooks(selectBooks(), params)
+	if err != nil {
+		return err
+	}
+	sb = orderBooks(sb, params)
+	if params.Cursor != nil {
+		condition, err := cursorCondition(params.Cursor)
+		if err != nil {
+			return err
+		}
+		sb = sb.Where(condition)
+	}
+	// If we choose a specific page of results
+	if params.Offset > 0 {
+		sb = sb.Offset(uint64(params.Offset))
+	} else if params.Page > 0 {
+		offset := (params.Page - 1) * params.PerPage
+		sb = sb.Offset(uint64(offset))
+	}
+
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return err
+	}
+	var bookRows *sqlx.Rows
+	switch ext {
+	case nil:
+		queryRows, err := repo.dbClient.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		bookRows = queryRows
+	default:
+		txRows, err := ext.QueryxContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		bookRows = txRows
+	}
+	defer bookRows.Close()
+	rowsErr := bookRows.Err()
+	if rowsErr != nil {
+		return rowsErr
+	}
+	for bookRows.Next() {
+		var book books.Book
+		if err := bookRows.StructScan(&book); err != nil {
+			return err
+		}
+		if err := each(&book); err != nil {
+			return err
+		}
+	}
+	return bookRows.Err()
+}
+
+// Order the books as asked, by updated_at when no order was given. The id breaks ties so pages never overlap.
+// Books before a cursor are read nearest first, in the reverse of the order
+func orderBooks(sb squirrel.SelectBuilder, params *books.GetBooksParams) squirrel.SelectBuilder {
+	backward := params.Cursor != nil && params.Cursor.Backward
+	for _, key := range params.Order() {
+		// Sort fields are checked against a whitelist of the columns by books.ParseSort
+		if key.Descending != backward {
+			sb = sb.OrderBy(key.Field + " DESC")
+		} else {
+			sb = sb.OrderBy(key.Field)
+		}
+	}
+	return sb
+}
+
+// Books past the position of the cursor in the order of the listing. For keys a, b and id that is
+// a > ? OR (a = ? AND b > ?) OR (a = ? AND b = ? AND id > ?), with < for descending keys and going backward
+func cursorCondition(cursor *books.Cursor) (squirrel.Sqlizer, error) {
+	args, err := cursor.Args()
+	if err != nil {
+		return nil, err
+	}
+	past := squirrel.Or{}
+	for i, key := range cursor.Keys {
+		if !sortColumns[key.Field] {
+			return nil, books.ErrInvalidCursor
+		}
+		comparison := " > ?"
+		if key.Descending != cursor.Backward {
+			comparison = " < ?"
+		}
+		equal := squirrel.And{}
+		for j := 0; j < i; j++ {
+			equal = append(equal, squirrel.Expr(cursor.Keys[j].Field+" = ?", args[j]))
+		}
+		past = append(past, append(equal, squirrel.Expr(key.Field+comparison, args[i])))
+	}
+	return past, nil
+}
+
+// Columns books can be ordered by, which are the fields books.ParseSort accepts
+var sortColumns = map[string]bool{
+	"id": true, "title": true, "author": true, "publisher": true, "published": true, "genre": true,
+	"language": true, "pages": true, "created_at": true, "updated_at": true,
+}
+
+// Columns of a book as it is listed, read from the books table
+func selectBooks() squirrel.SelectBuilder {
+	return squirrel.Select("id", "isbn", "title", "author", "publisher", "published",
+		"genre", "language", "pages", "version", "updated_at", "created_at", "deleted_at").
+		// Availability is a count of the copies on the shelf rather than a stored flag
+		Column(squirrel.Expr(`(SELECT COUNT(*) FROM copies c
+			WHERE c.book_id = books.id AND c.status = ?) AS copies_available`, copies.Available)).
+		Column(squirrel.Expr(`(SELECT COUNT(*) FROM copies c
+			WHERE c.book_id = books.id AND c.status NOT IN (?, ?)) AS copies_total`, copies.Lost, copies.Withdrawn)).
+		Column(squirrel.Expr(`CASE WHEN EXISTS (SELECT 1 FROM copies c
+			WHERE c.book_id = books.id AND c.status = ?) THEN ? ELSE ? END AS availability`,
+			copies.Available, books.Available, books.NotAvailable)).
+		From("books")
+}
+
+// Add the conditions of the params to a query on the books table, paging and order are left to the caller
+func filterBooks(sb squirrel.SelectBuilder, params *books.GetBooksParams) (squirrel.SelectBuilder, error) {
+	// The trash is kept apart from the live catalogue
+	if params.Deleted {
+		sb = sb.Where("deleted_at IS NOT NULL")
+	} else {
+		sb = sb.Where("deleted_at IS NULL")
+	}
 	// Select by id
 	if params.ID != 0 {
 		sb = sb.Where(squirrel.Eq{"id": params.ID})
 	}
 	// Availability is a binary value that always holds a statement significant to the business context
-	if params.Availability != "" {
-		sb = sb.Where(squirrel.Eq{"availability": params.Availability})
+	switch params.Availability {
+	case books.Available:
+		sb = sb.Where("EXISTS (SELECT 1 FROM copies c WHERE c.book_id = books.id AND c.status = ?)", copies.Available)
+	case books.NotAvailable:
+		sb = sb.Where("NOT EXISTS (SELECT 1 FROM copies c WHERE c.book_id = books.id AND c.status = ?)", copies.Available)
 	}
+	// Any valid form of the ISBN finds the book, the raw value is kept for rows stored before normalization
 	if params.ISBN != "" {
-		sb = sb.Where(squirrel.Eq{"isbn": params.ISBN})
+		if normalized, err := books.NormalizeISBN(params.ISBN); err == nil {
+			sb = sb.Where(squirrel.Eq{"isbn": []string{normalized, params.ISBN}})
+		} else {
+			sb = sb.Where(squirrel.Eq{"isbn": params.ISBN})
+		}
 	}
 	if params.Title != "" {
 		sb = sb.Where(squirrel.Like{"title": "%" + params.Title + "%"})
 	}
 	if params.Author != "" {
-		sb = sb.Where(squirrel.Like{"author": "%" + params.Author + "%"})
+		condition, err := authorCondition(func(column string) squirrel.Sqlizer {
+			return squirrel.Like{column: "%" + params.Author + "%"}
+		})
+		if err != nil {
+			return sb, err
+		}
+		sb = sb.Where(condition)
 	}
 	if params.Publisher != "" {
 		sb = sb.Where(squirrel.Like{"publisher": "%" + params.Publisher + "%"})
 	}
+	// A genre covers the books filed anywhere below it in the tree
 	if params.Genre != "" {
-		sb = sb.Where(squirrel.Like{"genre": "%" + params.Genre + "%"})
+		sb = sb.Where(`EXISTS (SELECT 1 FROM book_genres bg
+			JOIN genre_closure gc ON gc.descendant_id = bg.genre_id
+			JOIN genres g ON g.id = gc.ancestor_id
+			WHERE bg.book_id = books.id AND g.name = ?)`, params.Genre)
+	}
+	if params.GenreID != 0 {
+		sb = sb.Where(`EXISTS (SELECT 1 FROM book_genres bg
+			JOIN genre_closure gc ON gc.descendant_id = bg.genre_id
+			WHERE bg.book_id = books.id AND gc.ancestor_id = ?)`, params.GenreID)
 	}
 	if params.Language != "" {
 		sb = sb.Where(squirrel.Eq{"language": params.Language})
@@ -199,72 +976,307 @@ func (repo *booksRepo) getBooks(ctx context.Context, ext sqlx.ExtContext,
 	if (params.Published != utils.CustomDate{}) {
 		sb = sb.Where(squirrel.Eq{"published": params.Published.Time})
 	}
+	if params.Decade != 0 {
+		start := time.Date(params.Decade, time.January, 1, 0, 0, 0, 0, time.UTC)
+		sb = sb.Where("published >= ? AND published < ?", start, start.AddDate(10, 0, 0))
+	}
 	if (params.UpdatedAt != utils.CustomTime{}) {
 		sb = sb.Where("updated_at >= ?", params.UpdatedAt.Time)
 	}
-	// We always want to order the retrieved data by the updated_at
-	sb = sb.OrderBy("updated_at")
-	// If we choose a specific page of results
-	if params.Page > 0 {
-		offset := (params.Page - 1) * params.PerPage
-		sb = sb.Offset(uint64(offset))
+	if params.Search != nil {
+		condition, err := searchCondition(params.Search.Root)
+		if err != nil {
+			return sb, err
+		}
+		sb = sb.Where(condition)
 	}
+	return sb, nil
+}
 
-	if params.PerPage > 0 {
-		sb = sb.Limit(uint64(params.PerPage))
+// The free text author and every credited author, including their aliases, are searched with match
+func authorCondition(match func(column string) squirrel.Sqlizer) (squirrel.Sqlizer, error) {
+	creditedQuery, creditedArgs, err := squirrel.Or{match("a.name"), match("a.sort_name"), match("a.aliases")}.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return squirrel.Or{
+		match("author"),
+		squirrel.Expr(`EXISTS (SELECT 1 FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+			WHERE ba.book_id = books.id AND `+creditedQuery+`)`, creditedArgs...),
+	}, nil
+}
+
+// Lock the book row whether or not it is in the trash and report if it is
+func lockBookIncludingTrash(ctx context.Context, ext sqlx.ExtContext, id int) (bool, error) {
+	var deletedAt sql.NullTime
+	err := sqlx.GetContext(ctx, ext, &deletedAt, "SELECT deleted_at FROM books WHERE id = ? FOR UPDATE;", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, books.ErrBookNotFound
 	}
-	query, args, err := sb.ToSql()
 	if err != nil {
-		return nil, -1, err
+		return false, err
 	}
-	var bookRows *sqlx.Rows
-	switch ext {
-	case nil:
-		queryRows, err := repo.dbClient.QueryxContext(ctx, query, args...)
+	return deletedAt.Valid, nil
+}
+
+// Lock the books a bulk request picked in id order, so two bulk requests cannot deadlock on each other,
+// a book that is missing or in the trash is reported on its result rather than failing the request
+func (repo *booksRepo) lockBulkSelection(ctx context.Context, ext sqlx.ExtContext,
+	selection *books.BulkSelection) ([]*books.BulkResult, error) {
+	ids := selection.IDs
+	if selection.Filter != nil {
+		matched, _, err := repo.getBooks(ctx, ext, selection.Filter)
 		if err != nil {
-			return nil, -1, err
+			return nil, err
 		}
-		bookRows = queryRows
-	default:
-		txRows, err := ext.QueryxContext(ctx, query, args...)
+		if len(matched) > books.MaxBulkBooks {
+			return nil, books.ErrBulkTooLarge
+		}
+		for _, book := range matched {
+			ids = append(ids, book.ID)
+		}
+	}
+	sorted := append([]int{}, ids...)
+	sort.Ints(sorted)
+	failed := map[int]error{}
+	for _, id := range sorted {
+		if _, locked := failed[id]; locked {
+			continue
+		}
+		deleted, err := lockBookIncludingTrash(ctx, ext, id)
+		switch {
+		case errors.Is(err, books.ErrBookNotFound):
+			failed[id] = err
+		case err != nil:
+			return nil, err
+		case deleted:
+			failed[id] = books.ErrBookDeleted
+		default:
+			failed[id] = nil
+		}
+	}
+	results := make([]*books.BulkResult, 0, len(ids))
+	for i, id := range ids {
+		result := &books.BulkResult{Index: i, ID: id}
+		if failed[id] != nil {
+			result.Error = failed[id].Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// In all or nothing mode one book that cannot be changed leaves every book alone, report whether that happened
+func bulkRejected(results []*books.BulkResult, mode books.BulkMode) bool {
+	if mode != books.AllOrNothing {
+		return false
+	}
+	rejected := false
+	for _, result := range results {
+		if result.Error != "" {
+			rejected = true
+		}
+	}
+	if !rejected {
+		return false
+	}
+	for _, result := range results {
+		if result.Error == "" {
+			result.Error = books.ErrBulkRolledBack.Error()
+		}
+	}
+	return true
+}
+
+func (repo *booksRepo) handleMysqlErr(err error) error {
+	// Lets log the actual err that we arent propagating
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			repo.logger.Error(mysqlErr.Message)
+			return books.ErrBookAlreadyExists
+		}
+	}
+	return err
+}
+
+// Columns of the fields a CQL search is made in, a keywords search covers the title and the author
+var searchColumns = map[string]string{
+	books.SearchTitle:     "title",
+	books.SearchPublisher: "publisher",
+	books.SearchGenre:     "genre",
+	books.SearchLanguage:  "language",
+	books.SearchISBN:      "isbn",
+	books.SearchPublished: "published",
+}
+
+// Turn a CQL search that went through books.ParseSearch into a condition on the books table
+func searchCondition(node cql.Node) (squirrel.Sqlizer, error) {
+	switch n := node.(type) {
+	case *cql.Clause:
+		return clauseCondition(n)
+	case *cql.Boolean:
+		left, err := searchCondition(n.Left)
 		if err != nil {
-			return nil, -1, err
+			return nil, err
 		}
-		bookRows = txRows
+		right, err := searchCondition(n.Right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Operator {
+		case cql.And:
+			return squirrel.And{left, right}, nil
+		case cql.Or:
+			return squirrel.Or{left, right}, nil
+		case cql.Not:
+			excluded, err := notCondition(right)
+			if err != nil {
+				return nil, err
+			}
+			return squirrel.And{left, excluded}, nil
+		}
+		return nil, fmt.Errorf("%w: %s", books.ErrCQLBoolean, n.Operator)
 	}
-	defer bookRows.Close()
-	rowsErr := bookRows.Err()
-	if rowsErr != nil {
-		return nil, -1, rowsErr
+	return nil, fmt.Errorf("%w: unexpected node %T", cql.ErrSyntax, node)
+}
+
+func clauseCondition(clause *cql.Clause) (squirrel.Sqlizer, error) {
+	relation, term := clause.Relation.Comparitor, strings.TrimSpace(clause.Term)
+	// Not equal matches every book the exact search does not
+	if relation == "<>" {
+		exact, err := clauseCondition(&cql.Clause{Index: clause.Index, Relation: &cql.Relation{Comparitor: "=="}, Term: term})
+		if err != nil {
+			return nil, err
+		}
+		return notCondition(exact)
 	}
-	for bookRows.Next() {
-		var book books.Book
-		if err := bookRows.StructScan(&book); err != nil {
-			return nil, -1, err
+	column := searchColumns[clause.Index]
+	switch clause.Index {
+	case books.SearchPublished:
+		start, end, err := books.SearchDateRange(term)
+		if err != nil {
+			return nil, err
+		}
+		switch relation {
+		case "=", "==":
+			return squirrel.And{squirrel.GtOrEq{column: start}, squirrel.Lt{column: end}}, nil
+		case "<":
+			return squirrel.Lt{column: start}, nil
+		case "<=":
+			return squirrel.Lt{column: end}, nil
+		case ">":
+			return squirrel.GtOrEq{column: end}, nil
+		case ">=":
+			return squirrel.GtOrEq{column: start}, nil
+		}
+		return nil, fmt.Errorf("%w: %s %s", books.ErrCQLRelation, clause.Index, relation)
+	case books.SearchISBN:
+		if relation != "=" && relation != "==" && relation != "exact" {
+			return nil, fmt.Errorf("%w: %s %s", books.ErrCQLRelation, clause.Index, relation)
 		}
-		userBooks = append(userBooks, &book)
+		// Any valid form of the ISBN finds the book, like the isbn filter
+		values := []string{term}
+		if normalized, err := books.NormalizeISBN(term); err == nil {
+			values = append(values, normalized)
+		}
+		return squirrel.Eq{column: values}, nil
+	case books.SearchLanguage:
+		switch relation {
+		case "any":
+			return squirrel.Eq{column: strings.Fields(term)}, nil
+		case "=", "==", "exact":
+			return squirrel.Eq{column: term}, nil
+		}
+		return nil, fmt.Errorf("%w: %s %s", books.ErrCQLRelation, clause.Index, relation)
 	}
-	return userBooks, len(userBooks), nil
+	match, err := textMatch(relation, term)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %s", books.ErrCQLRelation, clause.Index, relation)
+	}
+	switch clause.Index {
+	case books.SearchKeywords:
+		byAuthor, err := authorCondition(match)
+		if err != nil {
+			return nil, err
+		}
+		return squirrel.Or{match("title"), byAuthor}, nil
+	case books.SearchAuthor:
+		return authorCondition(match)
+	}
+	if column == "" {
+		return nil, fmt.Errorf("%w: %s", books.ErrCQLIndex, clause.Index)
+	}
+	return match(column), nil
 }
 
-// hard delete
-func (repo *booksRepo) deleteBookByID(ctx context.Context, ext sqlx.ExtContext, id int) error {
-	if _, err := repo.dbClient.Exec("DELETE FROM books where id=?;", id); err != nil {
-		return err
+// How a text column is compared with the term of a relation. = and adj look for the term as a phrase,
+// any and all for its words and == and exact for the whole value. * and ? in a term are masks
+func textMatch(relation, term string) (func(column string) squirrel.Sqlizer, error) {
+	words := strings.Fields(term)
+	switch relation {
+	case "=", "adj":
+		return func(column string) squirrel.Sqlizer {
+			return squirrel.Like{column: "%" + likePattern(term) + "%"}
+		}, nil
+	case "==", "exact":
+		return func(column string) squirrel.Sqlizer {
+			return squirrel.Like{column: likePattern(term)}
+		}, nil
+	case "any":
+		return func(column string) squirrel.Sqlizer {
+			condition := squirrel.Or{}
+			for _, word := range words {
+				condition = append(condition, squirrel.Like{column: "%" + likePattern(word) + "%"})
+			}
+			return condition
+		}, nil
+	case "all":
+		return func(column string) squirrel.Sqlizer {
+			condition := squirrel.And{}
+			for _, word := range words {
+				condition = append(condition, squirrel.Like{column: "%" + likePattern(word) + "%"})
+			}
+			return condition
+		}, nil
 	}
-	return nil
+	return nil, books.ErrCQLRelation
 }
 
-// func (repo *booksRepo) handleMysqlErr(err error) error {
-// 	// Lets log the actual err that we arent propagating
-// 	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
-// 		switch mysqlErr.Number {
-// 		case 1062:
-// 			repo.logger.Error(mysqlErr.Message)
-// 			return books.ErrBookAlreadyExists
-// 		default:
-// 			return fmt.Errorf("Unexpected MySQL error: %v", err)
-// 		}
-// 	}
-// 	return err
-// }
+// Turn the masks of a CQL term into a LIKE pattern, a backslash keeps the character after it as it is
+func likePattern(term string) string {
+	var pattern strings.Builder
+	for i := 0; i < len(term); i++ {
+		switch c := term[i]; c {
+		case '\\':
+			if i+1 < len(term) {
+				i++
+				pattern.WriteString(escapeLike(term[i]))
+			}
+		case '*':
+			pattern.WriteByte('%')
+		case '?':
+			pattern.WriteByte('_')
+		default:
+			pattern.WriteString(escapeLike(c))
+		}
+	}
+	return pattern.String()
+}
+
+func escapeLike(c byte) string {
+	switch c {
+	case '%', '_', '\\':
+		return `\` + string(c)
+	}
+	return string(c)
+}
+
+func notCondition(condition squirrel.Sqlizer) (squirrel.Sqlizer, error) {
+	query, args, err := condition.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return squirrel.Expr("NOT ("+query+")", args...), nil
+}
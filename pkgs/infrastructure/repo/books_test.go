@@ -2,21 +2,30 @@ package repo
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
 	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/cql"
 	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/Masterminds/squirrel"
 	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
+// Logger for repos built in tests, the mysql error handlers log what they translate
+var testLogger = logrus.WithField("package", "repoTest")
+
 func testingBooksDB() (booksRepo, error) {
 	client, err := testConn()
 	if err != nil {
 		return booksRepo{}, err
 	}
-	return booksRepo{dbClient: client}, nil
+	return booksRepo{dbClient: client, logger: testLogger}, nil
 }
 
 func TestConn(t *testing.T) {
@@ -49,63 +58,59 @@ func TestCreateNewBook(t *testing.T) {
 			ExpectedErr: nil,
 			Input: []*books.Book{
 				{
-					ISBN:         "978-1234567890",
-					Title:        "The Great Gatsby",
-					Author:       "F. Scott Fitzgerald",
-					Publisher:    "Scribner",
-					Published:    utils.CustomDate{Time: time.Date(1990, 4, 10, 0, 0, 0, 0, time.UTC)},
-					Genre:        "Fiction",
-					Language:     "English",
-					Pages:        180,
-					Availability: books.Available,
-					UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-					CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-					DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+					ISBN:      "978-1234567890",
+					Title:     "The Great Gatsby",
+					Author:    "F. Scott Fitzgerald",
+					Publisher: "Scribner",
+					Published: utils.CustomDate{Time: time.Date(1990, 4, 10, 0, 0, 0, 0, time.UTC)},
+					Genre:     "Fiction",
+					Language:  "English",
+					Pages:     180,
+					UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+					CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+					DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 				},
 				{
-					ID:           3,
-					ISBN:         "978-0061120084",
-					Title:        "To Kill a Mockingbird",
-					Author:       "Harper Lee",
-					Publisher:    "Harper Perennial Modern Classics",
-					Published:    utils.CustomDate{Time: time.Date(1980, 7, 11, 0, 0, 0, 0, time.UTC)},
-					Genre:        "Classics",
-					Language:     "English",
-					Pages:        336,
-					Availability: books.NotAvailable,
-					UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-					CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-					DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+					ID:        3,
+					ISBN:      "978-0061120084",
+					Title:     "To Kill a Mockingbird",
+					Author:    "Harper Lee",
+					Publisher: "Harper Perennial Modern Classics",
+					Published: utils.CustomDate{Time: time.Date(1980, 7, 11, 0, 0, 0, 0, time.UTC)},
+					Genre:     "Classics",
+					Language:  "English",
+					Pages:     336,
+					UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+					CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+					DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 				},
 				{
-					ID:           4,
-					ISBN:         "978-0142407332",
-					Title:        "The Outsiders",
-					Author:       "S.E. Hinton",
-					Publisher:    "Penguin Books",
-					Published:    utils.CustomDate{Time: time.Date(1980, 4, 24, 0, 0, 0, 0, time.UTC)},
-					Genre:        "Young Adult",
-					Language:     "English",
-					Pages:        192,
-					Availability: books.NotAvailable,
-					UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-					CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-					DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+					ID:        4,
+					ISBN:      "978-0142407332",
+					Title:     "The Outsiders",
+					Author:    "S.E. Hinton",
+					Publisher: "Penguin Books",
+					Published: utils.CustomDate{Time: time.Date(1980, 4, 24, 0, 0, 0, 0, time.UTC)},
+					Genre:     "Young Adult",
+					Language:  "English",
+					Pages:     192,
+					UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+					CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+					DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 				},
 				{
-					ID:           5,
-					ISBN:         "978-1400032493",
-					Title:        "The Kite Runner",
-					Author:       "Khaled Hosseini",
-					Publisher:    "Riverhead Books",
-					Published:    utils.CustomDate{Time: time.Date(2003, 5, 29, 0, 0, 0, 0, time.UTC)},
-					Genre:        "Fiction",
-					Language:     "English",
-					Pages:        371,
-					Availability: books.NotAvailable,
-					UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-					CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-					DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+					ID:        5,
+					ISBN:      "978-1400032493",
+					Title:     "The Kite Runner",
+					Author:    "Khaled Hosseini",
+					Publisher: "Riverhead Books",
+					Published: utils.CustomDate{Time: time.Date(2003, 5, 29, 0, 0, 0, 0, time.UTC)},
+					Genre:     "Fiction",
+					Language:  "English",
+					Pages:     371,
+					UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+					CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+					DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 				},
 			},
 			Description: "Successful insert of books",
@@ -114,32 +119,30 @@ func TestCreateNewBook(t *testing.T) {
 			ExpectedErr: &mysql.MySQLError{Number: 0x426, SQLState: [5]uint8{0x32, 0x33, 0x30, 0x30, 0x30}, Message: "Duplicate entry '978-1234567890' for key 'books.isbn'"},
 			Input: []*books.Book{
 				{
-					ISBN:         "978-1234567890",
-					Title:        "The Great Gatsby",
-					Author:       "F. Scott Fitzgerald",
-					Publisher:    "Scribner",
-					Published:    utils.CustomDate{Time: time.Date(1990, 4, 10, 0, 0, 0, 0, time.UTC)},
-					Genre:        "Fiction",
-					Language:     "English",
-					Pages:        180,
-					Availability: books.Available,
-					UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-					CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-					DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+					ISBN:      "978-1234567890",
+					Title:     "The Great Gatsby",
+					Author:    "F. Scott Fitzgerald",
+					Publisher: "Scribner",
+					Published: utils.CustomDate{Time: time.Date(1990, 4, 10, 0, 0, 0, 0, time.UTC)},
+					Genre:     "Fiction",
+					Language:  "English",
+					Pages:     180,
+					UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+					CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+					DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 				},
 				{
-					ISBN:         "978-1234567890",
-					Title:        "The Great Gatsby",
-					Author:       "F. Scott Fitzgerald",
-					Publisher:    "Scribner",
-					Published:    utils.CustomDate{Time: time.Date(1990, 4, 10, 0, 0, 0, 0, time.UTC)},
-					Genre:        "Fiction",
-					Language:     "English",
-					Pages:        180,
-					Availability: books.Available,
-					UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-					CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-					DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+					ISBN:      "978-1234567890",
+					Title:     "The Great Gatsby",
+					Author:    "F. Scott Fitzgerald",
+					Publisher: "Scribner",
+					Published: utils.CustomDate{Time: time.Date(1990, 4, 10, 0, 0, 0, 0, time.UTC)},
+					Genre:     "Fiction",
+					Language:  "English",
+					Pages:     180,
+					UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+					CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+					DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 				},
 			},
 			Description: "Duplicate entry",
@@ -165,32 +168,30 @@ func TestUpdateBook(t *testing.T) {
 	ctx := context.Background()
 	seed := []*books.Book{
 		{
-			ISBN:         "978-1234567890",
-			Title:        "The Great Gatsby",
-			Author:       "F. Scott Fitzgerald",
-			Publisher:    "Scribner",
-			Published:    utils.CustomDate{Time: time.Date(1990, 4, 10, 0, 0, 0, 0, time.UTC)},
-			Genre:        "Fiction",
-			Language:     "English",
-			Pages:        180,
-			Availability: books.Available,
-			UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-			CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-			DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+			ISBN:      "978-1234567890",
+			Title:     "The Great Gatsby",
+			Author:    "F. Scott Fitzgerald",
+			Publisher: "Scribner",
+			Published: utils.CustomDate{Time: time.Date(1990, 4, 10, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Fiction",
+			Language:  "English",
+			Pages:     180,
+			UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+			CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+			DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 		},
 		{
-			ISBN:         "978-0451524935",
-			Title:        "1984",
-			Author:       "George Orwell",
-			Publisher:    "Signet Classic",
-			Published:    utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
-			Genre:        "Dystopian",
-			Language:     "English",
-			Pages:        328,
-			Availability: books.Available,
-			UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-			CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-			DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+			ISBN:      "978-0451524935",
+			Title:     "1984",
+			Author:    "George Orwell",
+			Publisher: "Signet Classic",
+			Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Dystopian",
+			Language:  "English",
+			Pages:     328,
+			UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+			CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+			DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 		},
 	}
 	err = booksRepo.InsertBooks(ctx, seed)
@@ -203,17 +204,16 @@ func TestUpdateBook(t *testing.T) {
 		{
 			ExpectedErr: nil,
 			Input: books.Book{
-				ID:           seed[0].ID,
-				ISBN:         "787877",
-				Title:        "The Great Gatsby Updated",
-				Author:       "F. Scott Fitzgerald Updated",
-				Publisher:    "Scribner Updated",
-				Published:    utils.CustomDate{Time: time.Date(1999, 4, 10, 0, 0, 0, 0, time.UTC)},
-				Genre:        "Fiction Updated",
-				Language:     "English Updated",
-				Pages:        220,
-				Availability: books.NotAvailable,
-				DeletedAt:    utils.CustomTime{Time: time.Now()},
+				ID:        seed[0].ID,
+				ISBN:      "787877",
+				Title:     "The Great Gatsby Updated",
+				Author:    "F. Scott Fitzgerald Updated",
+				Publisher: "Scribner Updated",
+				Published: utils.CustomDate{Time: time.Date(1999, 4, 10, 0, 0, 0, 0, time.UTC)},
+				Genre:     "Fiction Updated",
+				Language:  "English Updated",
+				Pages:     220,
+				DeletedAt: utils.CustomTime{Time: time.Now()},
 			},
 			Description: "Update all fields",
 		},
@@ -238,81 +238,81 @@ func TestGetBooks(t *testing.T) {
 	ctx := context.Background()
 	seed := []*books.Book{
 		{
-			ISBN:         "978-1234567890",
-			Title:        "The Great Gatsby",
-			Author:       "F. Scott Fitzgerald",
-			Publisher:    "Scribner",
-			Published:    utils.CustomDate{Time: time.Date(1990, 4, 10, 0, 0, 0, 0, time.UTC)},
-			Genre:        "Fiction",
-			Language:     "English",
-			Pages:        180,
-			Availability: books.Available,
-			UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-			CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-			DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+			ISBN:      "978-1234567890",
+			Title:     "The Great Gatsby",
+			Author:    "F. Scott Fitzgerald",
+			Publisher: "Scribner",
+			Published: utils.CustomDate{Time: time.Date(1990, 4, 10, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Fiction",
+			Language:  "English",
+			Pages:     180,
+			UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+			CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+			DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 		},
 		{
-			ISBN:         "978-0451524935",
-			Title:        "1984",
-			Author:       "George Orwell",
-			Publisher:    "Signet Classic",
-			Published:    utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
-			Genre:        "Dystopian",
-			Language:     "English",
-			Pages:        328,
-			Availability: books.Available,
-			UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-			CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-			DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+			ISBN:      "978-0451524935",
+			Title:     "1984",
+			Author:    "George Orwell",
+			Publisher: "Signet Classic",
+			Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Dystopian",
+			Language:  "English",
+			Pages:     328,
+			UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+			CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+			DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 		},
 		{
-			ID:           3,
-			ISBN:         "978-0061120084",
-			Title:        "To Kill a Mockingbird",
-			Author:       "Harper Lee",
-			Publisher:    "Harper Perennial Modern Classics",
-			Published:    utils.CustomDate{Time: time.Date(1980, 7, 11, 0, 0, 0, 0, time.UTC)},
-			Genre:        "Classics",
-			Language:     "English",
-			Pages:        336,
-			Availability: books.NotAvailable,
-			UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-			CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-			DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+			ID:        3,
+			ISBN:      "978-0061120084",
+			Title:     "To Kill a Mockingbird",
+			Author:    "Harper Lee",
+			Publisher: "Harper Perennial Modern Classics",
+			Published: utils.CustomDate{Time: time.Date(1980, 7, 11, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Classics",
+			Language:  "English",
+			Pages:     336,
+			UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+			CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+			DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 		},
 		{
-			ID:           4,
-			ISBN:         "978-0142407332",
-			Title:        "The Outsiders",
-			Author:       "S.E. Hinton",
-			Publisher:    "Penguin Books",
-			Published:    utils.CustomDate{Time: time.Date(1980, 4, 24, 0, 0, 0, 0, time.UTC)},
-			Genre:        "Young Adult",
-			Language:     "English",
-			Pages:        192,
-			Availability: books.NotAvailable,
-			UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-			CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-			DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+			ID:        4,
+			ISBN:      "978-0142407332",
+			Title:     "The Outsiders",
+			Author:    "S.E. Hinton",
+			Publisher: "Penguin Books",
+			Published: utils.CustomDate{Time: time.Date(1980, 4, 24, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Young Adult",
+			Language:  "English",
+			Pages:     192,
+			UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+			CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+			DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 		},
 		{
-			ID:           5,
-			ISBN:         "978-1400032493",
-			Title:        "The Kite Runner",
-			Author:       "Khaled Hosseini",
-			Publisher:    "Riverhead Books",
-			Published:    utils.CustomDate{Time: time.Date(2003, 5, 29, 0, 0, 0, 0, time.UTC)},
-			Genre:        "Fiction",
-			Language:     "English",
-			Pages:        371,
-			Availability: books.NotAvailable,
-			UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-			CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-			DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+			ID:        5,
+			ISBN:      "978-1400032493",
+			Title:     "The Kite Runner",
+			Author:    "Khaled Hosseini",
+			Publisher: "Riverhead Books",
+			Published: utils.CustomDate{Time: time.Date(2003, 5, 29, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Fiction",
+			Language:  "English",
+			Pages:     371,
+			UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+			CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+			DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 		},
 	}
 	err = booksRepo.InsertBooks(ctx, seed)
 	assertWithTest.Nil(err)
+	// Only the first two books have a copy on the shelf
+	err = (&copiesRepo{dbClient: booksRepo.dbClient, logger: testLogger}).InsertCopies(ctx, []*copies.Copy{
+		testCopy(seed[0].ID, "C-0001"), testCopy(seed[1].ID, "C-0002"),
+	})
+	assertWithTest.Nil(err)
 	testCases := []struct {
 		ExpectedOutput struct {
 			Count int
@@ -337,27 +337,39 @@ func TestGetBooks(t *testing.T) {
 				Count int
 				Error error
 			}{
-				Count: 2,
+				Count: 5,
 				Error: nil,
 			},
 			Input: books.GetBooksParams{
-				Availability: books.Available,
-				Page:         1,
-				PerPage:      2,
+				Page:    1,
+				PerPage: 2,
 			},
-			Description: "Get all available books",
+			Description: "Count every book when getting a page of them",
 		},
 		{
 			ExpectedOutput: struct {
 				Count int
 				Error error
 			}{
-				Count: 3,
+				Count: -1,
 				Error: nil,
 			},
 			Input: books.GetBooksParams{
-				Availability: books.NotAvailable,
+				Page:      1,
+				PerPage:   2,
+				SkipTotal: true,
+			},
+			Description: "Leave the books uncounted when the total is skipped",
+		},
+		{
+			ExpectedOutput: struct {
+				Count int
+				Error error
+			}{
+				Count: 3,
+				Error: nil,
 			},
+			Input:       books.GetBooksParams{},
 			Description: "Get all unavailable books",
 		},
 		{
@@ -369,8 +381,7 @@ func TestGetBooks(t *testing.T) {
 				Error: nil,
 			},
 			Input: books.GetBooksParams{
-				Availability: books.NotAvailable,
-				Title:        "The",
+				Title: "The",
 			},
 			Description: "Get all unavailable books that have `THE` in the title",
 		},
@@ -390,7 +401,7 @@ func TestGetBooks(t *testing.T) {
 				Published:    seed[0].Published,
 				Genre:        seed[0].Genre,
 				Language:     seed[0].Language,
-				Availability: seed[0].Availability,
+				Availability: books.Available,
 				UpdatedAt: utils.CustomTime{
 					Time: seed[0].UpdatedAt.Time.Add(-5 * time.Hour),
 				},
@@ -432,25 +443,523 @@ func TestDeleteBook(t *testing.T) {
 	}
 	ctx := context.Background()
 	book := books.Book{
-		ISBN:         "978-1400032493",
-		Title:        "The Kite Runner",
-		Author:       "Khaled Hosseini",
-		Publisher:    "Riverhead Books",
-		Published:    utils.CustomDate{Time: time.Date(2003, 5, 29, 0, 0, 0, 0, time.UTC)},
-		Genre:        "Fiction",
-		Language:     "English",
-		Pages:        371,
-		Availability: books.NotAvailable,
-		UpdatedAt:    utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
-		CreatedAt:    utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
-		DeletedAt:    utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
+		ISBN:      "978-1400032493",
+		Title:     "The Kite Runner",
+		Author:    "Khaled Hosseini",
+		Publisher: "Riverhead Books",
+		Published: utils.CustomDate{Time: time.Date(2003, 5, 29, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Fiction",
+		Language:  "English",
+		Pages:     371,
+		UpdatedAt: utils.CustomTime{Time: time.Now().Add(-24 * time.Hour)},
+		CreatedAt: utils.CustomTime{Time: time.Now().Add(-48 * time.Hour)},
+		DeletedAt: utils.CustomTime{Time: time.Now().Add(-72 * time.Hour)},
 	}
 	err = booksRepo.InsertBooks(ctx, []*books.Book{&book})
 	assertWithTest.Nil(err)
 	if err != nil {
 		return
 	}
+	// Updates made against an old version are refused
+	assertWithTest.Equal(1, book.Version)
+	update := books.Book{ID: book.ID, Pages: 372, Version: 1}
+	assertWithTest.Nil(booksRepo.UpdateBook(ctx, &update))
+	assertWithTest.Equal(2, update.Version)
+	assertWithTest.Equal(books.ErrVersionConflict, booksRepo.UpdateBook(ctx, &books.Book{ID: book.ID, Pages: 373, Version: 1}))
+	// Patches can clear fields that a full update would skip
+	patch, err := books.ParseMergePatch(book.ID, []byte(`{"pages": 0, "genre": null}`))
+	assertWithTest.Nil(err)
+	patched, err := booksRepo.PatchBook(ctx, patch)
+	assertWithTest.Nil(err)
+	if err == nil {
+		assertWithTest.Equal(0, patched.Pages)
+		assertWithTest.Equal("", patched.Genre)
+		assertWithTest.Equal(3, patched.Version)
+	}
+	patch.Version = 2
+	_, err = booksRepo.PatchBook(ctx, patch)
+	assertWithTest.Equal(books.ErrVersionConflict, err)
 	// Delete Book
 	err = booksRepo.DeleteBookByID(ctx, book.ID)
 	assertWithTest.Nil(err)
+	assertWithTest.Equal(books.ErrBookDeleted, booksRepo.DeleteBookByID(ctx, book.ID))
+	assertWithTest.Equal(books.ErrBookDeleted, booksRepo.UpdateBook(ctx, &books.Book{ID: book.ID, Pages: 372}))
+	// Deleted books are only listed in the trash
+	_, count, err := booksRepo.GetBooks(ctx, &books.GetBooksParams{ID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(0, count)
+	trashed, count, err := booksRepo.GetBooks(ctx, &books.GetBooksParams{ID: book.ID, Deleted: true})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+	if count == 1 {
+		assertWithTest.False(trashed[0].DeletedAt.IsZero())
+	}
+	// Restore and delete again, the book is only purged once it has been in the trash long enough
+	assertWithTest.Nil(booksRepo.RestoreBookByID(ctx, book.ID))
+	assertWithTest.Equal(books.ErrBookNotInTrash, booksRepo.RestoreBookByID(ctx, book.ID))
+	assertWithTest.Nil(booksRepo.DeleteBookByID(ctx, book.ID))
+	purged, err := booksRepo.PurgeDeletedBooks(ctx, time.Now().Add(-time.Hour))
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(0, purged)
+	purged, err = booksRepo.PurgeDeletedBooks(ctx, time.Now().Add(time.Hour))
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, purged)
+	assertWithTest.Equal(books.ErrBookNotFound, booksRepo.DeleteBookByID(ctx, book.ID))
+}
+
+func TestInsertBooksEach(t *testing.T) {
+	assertWithTest := assert.New(t)
+	booksRepo, err := testingBooksDB()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	newBook := func(isbn, title string) *books.Book {
+		return &books.Book{
+			ISBN:      isbn,
+			Title:     title,
+			Author:    "George Orwell",
+			Publisher: "Signet Classic",
+			Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Dystopian",
+			Language:  "English",
+			Pages:     328,
+		}
+	}
+	// A duplicate undoes the whole batch in atomic mode
+	batch := []*books.Book{newBook("9780451524935", "1984"), newBook("9780451524935", "Animal Farm")}
+	itemErrs, err := booksRepo.InsertBooksEach(ctx, batch, true)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]error{books.ErrBulkRolledBack, books.ErrBookAlreadyExists}, itemErrs)
+	_, count, err := booksRepo.GetBooks(ctx, &books.GetBooksParams{Author: "George Orwell"})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(0, count)
+
+	// And only undoes the duplicate in partial mode
+	batch = []*books.Book{newBook("9780451524935", "1984"), newBook("9780451524935", "Animal Farm"),
+		newBook("9780451526342", "Animal Farm")}
+	itemErrs, err = booksRepo.InsertBooksEach(ctx, batch, false)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]error{nil, books.ErrBookAlreadyExists, nil}, itemErrs)
+	retrieved, count, err := booksRepo.GetBooks(ctx, &books.GetBooksParams{Author: "George Orwell"})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(2, count)
+	ids := map[int]bool{}
+	for _, book := range retrieved {
+		ids[book.ID] = true
+	}
+	assertWithTest.True(ids[batch[0].ID])
+	assertWithTest.True(ids[batch[2].ID])
+	assertWithTest.Equal(0, batch[1].ID)
+}
+
+func TestGetLanguagesAndNewest(t *testing.T) {
+	assertWithTest := assert.New(t)
+	booksRepo, err := testingBooksDB()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	seed := []*books.Book{}
+	for _, book := range []struct{ isbn, title, language string }{
+		{"9780451524935", "1984", "English"},
+		{"9782070368228", "1984 (French)", "French"},
+		{"9780451526342", "Animal Farm", "English"},
+	} {
+		newBook := &books.Book{
+			ISBN:      book.isbn,
+			Title:     book.title,
+			Author:    "George Orwell",
+			Publisher: "Signet Classic",
+			Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Dystopian",
+			Language:  book.language,
+			Pages:     328,
+		}
+		// Insert one at a time so every book is added after the one before it
+		assertWithTest.Nil(booksRepo.InsertBooks(ctx, []*books.Book{newBook}))
+		seed = append(seed, newBook)
+	}
+	languages, err := booksRepo.GetLanguages(ctx)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]*books.LanguageCount{{Language: "English", Books: 2}, {Language: "French", Books: 1}}, languages)
+
+	newest, _, err := booksRepo.GetBooks(ctx, &books.GetBooksParams{Newest: true, Page: 1, PerPage: 2})
+	assertWithTest.Nil(err)
+	assertWithTest.Len(newest, 2)
+	if len(newest) == 2 {
+		assertWithTest.Equal(seed[2].ID, newest[0].ID)
+		assertWithTest.Equal(seed[1].ID, newest[1].ID)
+	}
+}
+
+func TestBulkUpdateAndDelete(t *testing.T) {
+	assertWithTest := assert.New(t)
+	client, err := testConn()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	booksDB := booksRepo{dbClient: client, logger: testLogger}
+	copiesDB := copiesRepo{dbClient: client, logger: testLogger}
+	newBook := func(isbn, title string) *books.Book {
+		return &books.Book{
+			ISBN:      isbn,
+			Title:     title,
+			Author:    "George Orwell",
+			Publisher: "Signet Classic",
+			Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Dystopian",
+			Language:  "English",
+			Pages:     328,
+		}
+	}
+	first, second := newBook("9780451524935", "1984"), newBook("9780451526342", "Animal Farm")
+	err = booksDB.InsertBooks(ctx, []*books.Book{first, second})
+	assertWithTest.Nil(err)
+	// A lost copy of the second book has to stay lost
+	lost := testCopy(second.ID, "C-0003")
+	lost.Status = copies.Lost
+	err = copiesDB.InsertCopies(ctx, []*copies.Copy{testCopy(first.ID, "C-0001"), testCopy(second.ID, "C-0002"), lost})
+	assertWithTest.Nil(err)
+	patch, err := books.ParseMergePatch(0, []byte(`{"genre":"Satire"}`))
+	assertWithTest.Nil(err)
+	update := &books.BulkUpdate{
+		BulkSelection: books.BulkSelection{IDs: []int{first.ID, second.ID, second.ID + 1000}, Mode: books.AllOrNothing},
+		Patch:         patch,
+		CopyStatus:    copies.InRepair,
+	}
+
+	// A missing book stops the whole update in all or nothing mode
+	results, err := booksDB.UpdateBooksBulk(ctx, update)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(books.ErrBulkRolledBack.Error(), results[0].Error)
+	assertWithTest.Equal(books.ErrBookNotFound.Error(), results[2].Error)
+
+	// A dry run reports the changes without making them
+	update.Mode, update.DryRun = books.Partial, true
+	results, err = booksDB.UpdateBooksBulk(ctx, update)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(audit.Changes{{Field: "genre", Old: "Dystopian", New: "Satire"}}, results[0].Changes)
+	assertWithTest.Equal(1, results[1].CopiesChanged)
+	retrieved, _, err := booksDB.GetBooks(ctx, &books.GetBooksParams{ID: first.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("Dystopian", retrieved[0].Genre)
+	assertWithTest.Equal(books.Available, retrieved[0].Availability)
+
+	// In partial mode the books that exist are changed and their copies leave the shelf
+	update.DryRun = false
+	results, err = booksDB.UpdateBooksBulk(ctx, update)
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("", results[1].Error)
+	retrieved, _, err = booksDB.GetBooks(ctx, &books.GetBooksParams{ID: second.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("Satire", retrieved[0].Genre)
+	assertWithTest.Equal(books.NotAvailable, retrieved[0].Availability)
+	assertWithTest.Equal(second.Version+1, retrieved[0].Version)
+	assertWithTest.Equal(1, results[1].CopiesChanged)
+	lostCopies, _, err := copiesDB.GetCopies(ctx, &copies.GetCopiesParams{Barcode: "C-0003"})
+	assertWithTest.Nil(err)
+	if assertWithTest.Len(lostCopies, 1) {
+		assertWithTest.Equal(copies.Lost, lostCopies[0].Status)
+	}
+	// The copies that left the shelf are part of the audit record of the update
+	records, _, err := (&auditRepo{dbClient: client, logger: testLogger}).GetRecords(ctx, &audit.GetRecordsParams{
+		Entity: audit.Book, EntityID: second.ID, Action: audit.Update})
+	assertWithTest.Nil(err)
+	if assertWithTest.Len(records, 1) {
+		fields := []string{}
+		for _, change := range records[0].Changes {
+			fields = append(fields, change.Field)
+		}
+		assertWithTest.Equal([]string{"genre", "copies", "availability"}, fields)
+	}
+
+	// Deleting by a filter moves every match to the trash
+	results, err = booksDB.DeleteBooksBulk(ctx, &books.BulkSelection{
+		Filter: &books.GetBooksParams{Genre: "Satire"}, Mode: books.AllOrNothing})
+	assertWithTest.Nil(err)
+	assertWithTest.Len(results, 2)
+	_, count, err := booksDB.GetBooks(ctx, &books.GetBooksParams{Deleted: true})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(2, count)
+}
+
+func TestSearchCondition(t *testing.T) {
+	assertWithTest := assert.New(t)
+	parsed, err := books.ParseSearch(`dc.title any "orwell huxley" and dc.language = eng and dc.date >= 1950`)
+	assertWithTest.Nil(err)
+	condition, err := searchCondition(parsed.Root)
+	assertWithTest.Nil(err)
+	query, args, err := condition.ToSql()
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("(((title LIKE ? OR title LIKE ?) AND language = ?) AND published >= ?)", query)
+	assertWithTest.Equal([]interface{}{"%orwell%", "%huxley%", "English",
+		time.Date(1950, 1, 1, 0, 0, 0, 0, time.UTC)}, args)
+
+	// Masking characters become wildcards and escaped ones are matched as they are
+	assertWithTest.Equal("50\\%%off_", likePattern(`50\%*off?`))
+
+	// Dates, languages and ISBNs are only compared in the ways that make sense for them
+	for _, clause := range []*cql.Clause{
+		{Index: books.SearchPublished, Relation: &cql.Relation{Comparitor: "adj"}, Term: "1950"},
+		{Index: books.SearchPublished, Relation: &cql.Relation{Comparitor: "any"}, Term: "1950"},
+		{Index: books.SearchLanguage, Relation: &cql.Relation{Comparitor: "<"}, Term: "English"},
+		{Index: books.SearchLanguage, Relation: &cql.Relation{Comparitor: "all"}, Term: "English"},
+		{Index: books.SearchISBN, Relation: &cql.Relation{Comparitor: "any"}, Term: "9780451524935"},
+	} {
+		_, err := clauseCondition(clause)
+		assertWithTest.ErrorIs(err, books.ErrCQLRelation, clause.Index+" "+clause.Relation.Comparitor)
+	}
+}
+
+func TestFullTextSearch(t *testing.T) {
+	assertWithTest := assert.New(t)
+	booksRepo, err := testingBooksDB()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	seed := []*books.Book{}
+	for _, book := range []struct{ isbn, title, author string }{
+		{"9780451524935", "Nineteen Eighty-Four", "George Orwell"},
+		{"9780451526342", "Animal Farm", "George Orwell"},
+		{"9780393357684", "Orwell's Roses", "Rebecca Solnit"},
+	} {
+		seed = append(seed, &books.Book{
+			ISBN:      book.isbn,
+			Title:     book.title,
+			Author:    book.author,
+			Publisher: "Signet Classic",
+			Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Fiction",
+			Language:  "English",
+			Pages:     328,
+		})
+	}
+	assertWithTest.Nil(booksRepo.InsertBooks(ctx, seed))
+
+	query, err := books.ParseFullText("orwell", "")
+	assertWithTest.Nil(err)
+	hits, total, err := booksRepo.FullTextSearch(ctx, &books.FullTextParams{Query: query, Page: 1, PerPage: 2})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(3, total)
+	if assertWithTest.Len(hits, 2) {
+		// The title weighs more than the author
+		assertWithTest.Equal(seed[2].ID, hits[0].Book.ID)
+		assertWithTest.Greater(hits[0].Score, hits[1].Score)
+	}
+
+	query, err = books.ParseFullText(`"animal farm"`, "title")
+	assertWithTest.Nil(err)
+	hits, total, err = booksRepo.FullTextSearch(ctx, &books.FullTextParams{Query: query, Page: 1, PerPage: 10})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, total)
+	if assertWithTest.Len(hits, 1) {
+		assertWithTest.Equal(seed[1].ID, hits[0].Book.ID)
+	}
+}
+
+func TestGetFacets(t *testing.T) {
+	assertWithTest := assert.New(t)
+	booksRepo, err := testingBooksDB()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	for _, book := range []struct {
+		isbn, title, language string
+		year                  int
+	}{
+		{"9780451524935", "1984", "English", 1949},
+		{"9782070368228", "1984 (French)", "French", 1950},
+		{"9780451526342", "Animal Farm", "English", 1945},
+	} {
+		assertWithTest.Nil(booksRepo.InsertBooks(ctx, []*books.Book{{
+			ISBN:      book.isbn,
+			Title:     book.title,
+			Author:    "George Orwell",
+			Publisher: "Signet Classic",
+			Published: utils.CustomDate{Time: time.Date(book.year, 6, 8, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Dystopian",
+			Language:  book.language,
+			Pages:     328,
+		}}))
+	}
+	facets, err := booksRepo.GetFacets(ctx, &books.GetBooksParams{Language: "English", Decade: 1940},
+		[]books.Facet{books.FacetLanguage, books.FacetDecade, books.FacetAvailability})
+	assertWithTest.Nil(err)
+	// The language facet ignores the language filter, the decade facet the decade filter
+	assertWithTest.Equal([]*books.FacetBucket{{Value: "English", Count: 2}}, facets[books.FacetLanguage])
+	assertWithTest.Equal([]*books.FacetBucket{{Value: "1940", Count: 2}}, facets[books.FacetDecade])
+	assertWithTest.Equal([]*books.FacetBucket{{Value: string(books.NotAvailable), Count: 2}},
+		facets[books.FacetAvailability])
+
+	facets, err = booksRepo.GetFacets(ctx, &books.GetBooksParams{Language: "French"},
+		[]books.Facet{books.FacetLanguage, books.FacetDecade})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]*books.FacetBucket{{Value: "English", Count: 2}, {Value: "French", Count: 1}},
+		facets[books.FacetLanguage])
+	assertWithTest.Equal([]*books.FacetBucket{{Value: "1950", Count: 1}}, facets[books.FacetDecade])
+
+	// A genre bucket counts the books the genre filter lists
+	facets, err = booksRepo.GetFacets(ctx, &books.GetBooksParams{Language: "English"}, []books.Facet{books.FacetGenre})
+	assertWithTest.Nil(err)
+	_, count, err := booksRepo.GetBooks(ctx, &books.GetBooksParams{Language: "English", Genre: "Dystopian"})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]*books.FacetBucket{{Value: "Dystopian", Count: count}}, facets[books.FacetGenre])
+}
+
+func TestFacetQuery(t *testing.T) {
+	assertWithTest := assert.New(t)
+	sb, err := facetQuery(books.FacetGenre, facetValues[books.FacetGenre], &books.GetBooksParams{Language: "English"})
+	assertWithTest.Nil(err)
+	query, args, err := sb.ToSql()
+	assertWithTest.Nil(err)
+	// Genres are counted through the tree, over the books the other filters select
+	assertWithTest.Equal("SELECT g.name AS value, COUNT(DISTINCT bg.book_id) AS count FROM book_genres bg "+
+		"JOIN genre_closure gc ON gc.descendant_id = bg.genre_id JOIN genres g ON g.id = gc.ancestor_id "+
+		"WHERE bg.book_id IN (SELECT id FROM books WHERE deleted_at IS NULL AND language = ?)", query)
+	assertWithTest.Equal([]interface{}{"English"}, args)
+}
+
+func TestOrderBooks(t *testing.T) {
+	assertWithTest := assert.New(t)
+	for _, tc := range []struct {
+		Params   books.GetBooksParams
+		Expected string
+	}{
+		{Params: books.GetBooksParams{}, Expected: "ORDER BY updated_at, id"},
+		{Params: books.GetBooksParams{Newest: true}, Expected: "ORDER BY created_at DESC, id DESC"},
+		{
+			Params:   books.GetBooksParams{Sort: []books.SortKey{{Field: "published", Descending: true}, {Field: "title"}}},
+			Expected: "ORDER BY published DESC, title, id",
+		},
+		{
+			Params:   books.GetBooksParams{Newest: true, Sort: []books.SortKey{{Field: "id", Descending: true}}},
+			Expected: "ORDER BY id DESC",
+		},
+	} {
+		query, _, err := orderBooks(squirrel.Select("id").From("books"), &tc.Params).ToSql()
+		assertWithTest.Nil(err)
+		assertWithTest.Equal("SELECT id FROM books "+tc.Expected, query)
+	}
+}
+
+func TestSortedBooks(t *testing.T) {
+	assertWithTest := assert.New(t)
+	booksRepo, err := testingBooksDB()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	seed := []*books.Book{}
+	for _, book := range []struct {
+		isbn, title string
+		year        int
+	}{
+		{"9780451524935", "animal farm", 1945},
+		{"9780451526342", "Nineteen Eighty-Four", 1949},
+		{"9782070368228", "Animal Farm (French)", 1945},
+		{"9780156148504", "Burmese Days", 1934},
+	} {
+		seed = append(seed, &books.Book{
+			ISBN:      book.isbn,
+			Title:     book.title,
+			Author:    "George Orwell",
+			Publisher: "Signet Classic",
+			Published: utils.CustomDate{Time: time.Date(book.year, 6, 8, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Fiction",
+			Language:  "English",
+			Pages:     328,
+		})
+	}
+	assertWithTest.Nil(booksRepo.InsertBooks(ctx, seed))
+	sortKeys := []books.SortKey{{Field: "published", Descending: true}, {Field: "title"}}
+	sorted, _, err := booksRepo.GetBooks(ctx, &books.GetBooksParams{Sort: sortKeys})
+	assertWithTest.Nil(err)
+	// The database orders the books as the domain does
+	books.SortBooks(seed, sortKeys)
+	if assertWithTest.Len(sorted, len(seed)) {
+		for i := range seed {
+			assertWithTest.Equal(seed[i].ID, sorted[i].ID)
+		}
+	}
+}
+
+func TestCursorCondition(t *testing.T) {
+	assertWithTest := assert.New(t)
+	cursor := &books.Cursor{
+		Keys:   []books.SortKey{{Field: "published", Descending: true}, {Field: "title"}, {Field: "id"}},
+		Values: []string{"1945-08-17", "Animal Farm", "7"},
+	}
+	published := time.Date(1945, 8, 17, 0, 0, 0, 0, time.UTC)
+	condition, err := cursorCondition(cursor)
+	assertWithTest.Nil(err)
+	query, args, err := condition.ToSql()
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("((published < ?) OR (published = ? AND title > ?) OR "+
+		"(published = ? AND title = ? AND id > ?))", query)
+	assertWithTest.Equal([]interface{}{published, published, "Animal Farm", published, "Animal Farm", 7}, args)
+
+	// Going backward turns every comparison around
+	cursor.Backward = true
+	condition, err = cursorCondition(cursor)
+	assertWithTest.Nil(err)
+	query, _, err = condition.ToSql()
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("((published > ?) OR (published = ? AND title < ?) OR "+
+		"(published = ? AND title = ? AND id < ?))", query)
+	query, _, err = orderBooks(squirrel.Select("id").From("books"), &books.GetBooksParams{
+		Sort: cursor.Keys, Cursor: cursor}).ToSql()
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("SELECT id FROM books ORDER BY published, title DESC, id DESC", query)
+
+	_, err = cursorCondition(&books.Cursor{Keys: []books.SortKey{{Field: "isbn"}}, Values: []string{"1"}})
+	assertWithTest.ErrorIs(err, books.ErrInvalidCursor)
+}
+
+func TestBooksByCursor(t *testing.T) {
+	assertWithTest := assert.New(t)
+	booksRepo, err := testingBooksDB()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	seed := []*books.Book{}
+	for i, isbn := range []string{"9780451524935", "9780451526342", "9782070368228", "9780156148504", "9780393357684"} {
+		seed = append(seed, &books.Book{
+			ISBN:      isbn,
+			Title:     fmt.Sprintf("Title %d", i%2),
+			Author:    fmt.Sprintf("Author %d", i),
+			Publisher: "Signet Classic",
+			Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+			Genre:     "Fiction",
+			Language:  "English",
+			Pages:     328,
+		})
+	}
+	assertWithTest.Nil(booksRepo.InsertBooks(ctx, seed))
+	keys := []books.SortKey{{Field: "title", Descending: true}, {Field: "id"}}
+	all, _, err := booksRepo.GetBooks(ctx, &books.GetBooksParams{Sort: keys})
+	assertWithTest.Nil(err)
+	if !assertWithTest.Len(all, len(seed)) {
+		return
+	}
+	// Reading on from the second book gives the rest in the same order, reading back from the fourth the first three
+	after, _, err := booksRepo.GetBooks(ctx, &books.GetBooksParams{Sort: keys, PerPage: 2,
+		Cursor: books.CursorAt(all[1], keys, false)})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]int{all[2].ID, all[3].ID}, []int{after[0].ID, after[1].ID})
+	before, _, err := booksRepo.GetBooks(ctx, &books.GetBooksParams{Sort: keys, PerPage: 2,
+		Cursor: books.CursorAt(all[3], keys, true)})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal([]int{all[1].ID, all[2].ID}, []int{before[0].ID, before[1].ID})
 }
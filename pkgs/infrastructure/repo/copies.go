@@ -0,0 +1,273 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type copiesRepo struct {
+	dbClient *sqlx.DB
+	logger   logrus.FieldLogger
+}
+
+func NewCopiesDB(db *sqlx.DB) copies.Repository {
+	// Create a db logger for the copies repo
+	logger := logrus.WithFields(logrus.Fields{
+		"package": "copiesRepo",
+	})
+	return &copiesRepo{
+		dbClient: db,
+		logger:   logger,
+	}
+}
+
+// InsertCopies implements copies.Repository.
+func (repo *copiesRepo) InsertCopies(ctx context.Context, newCopies []*copies.Copy) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	// Lock every book up front in id order, so two batches cannot deadlock on each other
+	bookIDs := []int{}
+	seen := map[int]bool{}
+	for _, newCopy := range newCopies {
+		if !seen[newCopy.BookID] {
+			seen[newCopy.BookID] = true
+			bookIDs = append(bookIDs, newCopy.BookID)
+		}
+	}
+	sort.Ints(bookIDs)
+	for _, bookID := range bookIDs {
+		if err = lockBook(ctx, tx, bookID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = copies.ErrBookNotFound
+			}
+			return err
+		}
+	}
+	if err = repo.insertCopies(ctx, tx, newCopies); err != nil {
+		err = repo.handleMysqlErr(err)
+		return err
+	}
+	// A new copy on the shelf goes to the front of the holds queue before anyone else can take it
+	for _, newCopy := range newCopies {
+		if newCopy.Status != copies.Available {
+			continue
+		}
+		if err = releaseCopy(ctx, tx, newCopy.BookID, newCopy.ID, newCopy.CreatedAt.Time); err != nil {
+			return err
+		}
+		if err = tx.GetContext(ctx, &newCopy.Status, "SELECT status FROM copies WHERE id = ?;", newCopy.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateCopy implements copies.Repository.
+func (repo *copiesRepo) UpdateCopy(ctx context.Context, copyID int,
+	apply func(*copies.Copy) error) (*copies.Copy, error) {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer concludeTx(tx, &err)
+	var updatedCopy *copies.Copy
+	updatedCopy, err = repo.lockCopy(ctx, tx, copyID)
+	if err != nil {
+		return nil, err
+	}
+	previousStatus := updatedCopy.Status
+	if err = apply(updatedCopy); err != nil {
+		return nil, err
+	}
+	updatedCopy.UpdatedAt.Time = time.Now()
+	if err = repo.updateCopy(ctx, tx, updatedCopy); err != nil {
+		err = repo.handleMysqlErr(err)
+		return nil, err
+	}
+	// A copy back from repair or found again is offered to the holds queue first
+	if updatedCopy.Status == copies.Available && previousStatus != copies.Available {
+		if err = releaseCopy(ctx, tx, updatedCopy.BookID, updatedCopy.ID, updatedCopy.UpdatedAt.Time); err != nil {
+			return nil, err
+		}
+		if err = tx.GetContext(ctx, &updatedCopy.Status, "SELECT status FROM copies WHERE id = ?;", copyID); err != nil {
+			return nil, err
+		}
+	}
+	return updatedCopy, nil
+}
+
+// DeleteCopyByID implements copies.Repository.
+func (repo *copiesRepo) DeleteCopyByID(ctx context.Context, id int) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	var lockedCopy *copies.Copy
+	lockedCopy, err = repo.lockCopy(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if lockedCopy.InCirculation() {
+		err = copies.ErrCopyInCirculation
+		return err
+	}
+	// Loans keep pointing at the copy that was handed out, so those copies are withdrawn rather than deleted
+	var loanCount int
+	if err = tx.GetContext(ctx, &loanCount, "SELECT COUNT(*) FROM loans WHERE copy_id = ?;", id); err != nil {
+		return err
+	}
+	if loanCount > 0 {
+		err = copies.ErrCopyHasLoanHistory
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM copies WHERE id = ?;", id)
+	return err
+}
+
+// GetCopies implements copies.Repository.
+func (repo *copiesRepo) GetCopies(ctx context.Context, params *copies.GetCopiesParams) ([]*copies.Copy, int, error) {
+	return repo.getCopies(ctx, repo.dbClient, params, false)
+}
+
+// Lock the book before the copy, the same order checkouts and returns take their locks in
+func (repo *copiesRepo) lockCopy(ctx context.Context, tx *sqlx.Tx, copyID int) (*copies.Copy, error) {
+	var bookID int
+	if err := tx.GetContext(ctx, &bookID, "SELECT book_id FROM copies WHERE id = ?;", copyID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, copies.ErrCopyNotFound
+		}
+		return nil, err
+	}
+	if err := lockBook(ctx, tx, bookID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	lockedCopies, _, err := repo.getCopies(ctx, tx, &copies.GetCopiesParams{ID: copyID}, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(lockedCopies) == 0 {
+		return nil, copies.ErrCopyNotFound
+	}
+	return lockedCopies[0], nil
+}
+
+func (repo *copiesRepo) insertCopies(ctx context.Context, ext sqlx.ExtContext, newCopies []*copies.Copy) error {
+	ib := squirrel.Insert("copies").Columns(
+		"book_id", "barcode", "shelf_location", "`condition`", "status", "updated_at", "created_at",
+	)
+	for _, newCopy := range newCopies {
+		newCopy.CreatedAt = utils.CustomTime{Time: time.Now()}
+		newCopy.UpdatedAt = utils.CustomTime{Time: time.Now()}
+		ib = ib.Values(
+			newCopy.BookID, newCopy.Barcode, newCopy.ShelfLocation, newCopy.Condition, newCopy.Status,
+			newCopy.UpdatedAt.Time, newCopy.CreatedAt.Time,
+		)
+	}
+	query, args, err := ib.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := ext.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	// Write DB primary key ID back to the pointer
+	for _, newCopy := range newCopies {
+		newCopy.ID = int(lastInsertID)
+		lastInsertID++
+	}
+	return nil
+}
+
+func (repo *copiesRepo) updateCopy(ctx context.Context, ext sqlx.ExtContext, updatedCopy *copies.Copy) error {
+	query, args, err := squirrel.Update("copies").
+		Set("barcode", updatedCopy.Barcode).
+		Set("shelf_location", updatedCopy.ShelfLocation).
+		Set("`condition`", updatedCopy.Condition).
+		Set("status", updatedCopy.Status).
+		Set("updated_at", updatedCopy.UpdatedAt.Time).
+		Where(squirrel.Eq{"id": updatedCopy.ID}).ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = ext.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (repo *copiesRepo) getCopies(ctx context.Context, ext sqlx.ExtContext,
+	params *copies.GetCopiesParams, forUpdate bool) ([]*copies.Copy, int, error) {
+	var retrievedCopies []*copies.Copy
+	sb := squirrel.Select("id", "book_id", "barcode", "shelf_location", "`condition`", "status",
+		"updated_at", "created_at").From("copies")
+	if params.ID != 0 {
+		sb = sb.Where(squirrel.Eq{"id": params.ID})
+	}
+	if params.BookID != 0 {
+		sb = sb.Where(squirrel.Eq{"book_id": params.BookID})
+	}
+	if params.Barcode != "" {
+		sb = sb.Where(squirrel.Eq{"barcode": params.Barcode})
+	}
+	if params.ShelfLocation != "" {
+		sb = sb.Where(squirrel.Like{"shelf_location": params.ShelfLocation + "%"})
+	}
+	if params.Status != "" {
+		sb = sb.Where(squirrel.Eq{"status": params.Status})
+	}
+	sb = sb.OrderBy("book_id", "id")
+	if params.Page > 0 {
+		offset := (params.Page - 1) * params.PerPage
+		sb = sb.Offset(uint64(offset))
+	}
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+	}
+	if forUpdate {
+		sb = sb.Suffix("FOR UPDATE")
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, -1, err
+	}
+	if err := sqlx.SelectContext(ctx, ext, &retrievedCopies, query, args...); err != nil {
+		return nil, -1, err
+	}
+	return retrievedCopies, len(retrievedCopies), nil
+}
+
+func (repo *copiesRepo) handleMysqlErr(err error) error {
+	// Lets log the actual err that we arent propagating
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			repo.logger.Error(mysqlErr.Message)
+			return copies.ErrCopyAlreadyExists
+		case 1452:
+			repo.logger.Error(mysqlErr.Message)
+			return copies.ErrBookNotFound
+		}
+	}
+	return err
+}
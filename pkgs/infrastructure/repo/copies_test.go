@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/domain/holds"
+	"github.com/GabDewraj/library-api/pkgs/domain/loans"
+	"github.com/GabDewraj/library-api/pkgs/domain/members"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCopy(bookID int, barcode string) *copies.Copy {
+	return &copies.Copy{
+		BookID:        bookID,
+		Barcode:       barcode,
+		ShelfLocation: "FIC-ORW",
+		Condition:     copies.Good,
+		Status:        copies.Available,
+	}
+}
+
+func TestCopiesLifecycle(t *testing.T) {
+	assertWithTest := assert.New(t)
+	client, err := testConn()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	booksDB := booksRepo{dbClient: client, logger: testLogger}
+	copiesDB := copiesRepo{dbClient: client, logger: testLogger}
+	loansDB := loansRepo{dbClient: client, logger: testLogger}
+	membersDB := membersRepo{dbClient: client, logger: testLogger}
+	holdsDB := holdsRepo{dbClient: client, logger: testLogger}
+	book := books.Book{
+		ISBN:      "978-0451524935",
+		Title:     "1984",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     328,
+	}
+	err = booksDB.InsertBooks(ctx, []*books.Book{&book})
+	assertWithTest.Nil(err)
+	borrower, waiting := testMember("LIB-000001"), testMember("LIB-000002")
+	err = membersDB.InsertMembers(ctx, []*members.Member{borrower, waiting})
+	assertWithTest.Nil(err)
+
+	// Three copies of the same title, one of them away for repair
+	shelved, second, repaired := testCopy(book.ID, "C-0001"), testCopy(book.ID, "C-0002"), testCopy(book.ID, "C-0003")
+	repaired.Status = copies.InRepair
+	err = copiesDB.InsertCopies(ctx, []*copies.Copy{shelved, second, repaired})
+	assertWithTest.Nil(err)
+	err = copiesDB.InsertCopies(ctx, []*copies.Copy{testCopy(book.ID, "C-0001")})
+	assertWithTest.Equal(copies.ErrCopyAlreadyExists, err)
+	retrievedBooks, _, err := booksDB.GetBooks(ctx, &books.GetBooksParams{ID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(books.Available, retrievedBooks[0].Availability)
+	assertWithTest.Equal(2, retrievedBooks[0].CopiesAvailable)
+	assertWithTest.Equal(3, retrievedBooks[0].CopiesTotal)
+
+	// Borrowing both shelved copies leaves the title unavailable
+	now := time.Now()
+	firstLoan := loans.Loan{BookID: book.ID, CopyID: shelved.ID, MemberID: borrower.ID}
+	firstLoan.ApplyDefaults(now)
+	assertWithTest.Nil(loansDB.CheckoutBook(ctx, &firstLoan))
+	secondLoan := loans.Loan{BookID: book.ID, MemberID: borrower.ID}
+	secondLoan.ApplyDefaults(now)
+	assertWithTest.Nil(loansDB.CheckoutBook(ctx, &secondLoan))
+	assertWithTest.Equal(second.ID, secondLoan.CopyID)
+	retrievedBooks, _, err = booksDB.GetBooks(ctx, &books.GetBooksParams{ID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(books.NotAvailable, retrievedBooks[0].Availability)
+	assertWithTest.Equal(0, retrievedBooks[0].CopiesAvailable)
+
+	// A copy on loan cannot change status or be deleted
+	_, err = copiesDB.UpdateCopy(ctx, shelved.ID, func(c *copies.Copy) error {
+		return c.ApplyUpdate(&copies.Copy{Status: copies.Lost})
+	})
+	assertWithTest.Equal(copies.ErrCopyInCirculation, err)
+	assertWithTest.Equal(copies.ErrCopyInCirculation, copiesDB.DeleteCopyByID(ctx, shelved.ID))
+
+	// The repaired copy goes to the waiting hold instead of the shelf
+	hold := holds.Hold{BookID: book.ID, MemberID: waiting.ID}
+	assertWithTest.Nil(holdsDB.PlaceHold(ctx, &hold))
+	updatedCopy, err := copiesDB.UpdateCopy(ctx, repaired.ID, func(c *copies.Copy) error {
+		return c.ApplyUpdate(&copies.Copy{Status: copies.Available})
+	})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(copies.OnHold, updatedCopy.Status)
+	queue, _, err := holdsDB.GetHolds(ctx, &holds.GetHoldsParams{ID: hold.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(holds.Ready, queue[0].Status)
+	assertWithTest.Equal(repaired.ID, queue[0].CopyID)
+
+	// Copies that have been lent out keep their history
+	_, err = loansDB.ReturnLoan(ctx, firstLoan.ID, now.Add(time.Hour))
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(copies.ErrCopyHasLoanHistory, copiesDB.DeleteCopyByID(ctx, shelved.ID))
+	unused := testCopy(book.ID, "C-0004")
+	assertWithTest.Nil(copiesDB.InsertCopies(ctx, []*copies.Copy{unused}))
+	assertWithTest.Nil(copiesDB.DeleteCopyByID(ctx, unused.ID))
+	assertWithTest.Equal(copies.ErrCopyNotFound, copiesDB.DeleteCopyByID(ctx, unused.ID))
+}
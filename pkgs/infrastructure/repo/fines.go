@@ -0,0 +1,167 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/fines"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type finesRepo struct {
+	dbClient *sqlx.DB
+	logger   logrus.FieldLogger
+}
+
+func NewFinesDB(db *sqlx.DB) fines.Repository {
+	// Create a db logger for the fines repo
+	logger := logrus.WithFields(logrus.Fields{
+		"package": "finesRepo",
+	})
+	return &finesRepo{
+		dbClient: db,
+		logger:   logger,
+	}
+}
+
+// InsertFine implements fines.Repository.
+func (repo *finesRepo) InsertFine(ctx context.Context, newFine *fines.Fine) error {
+	now := time.Now()
+	newFine.CreatedAt.Time = now
+	newFine.UpdatedAt.Time = now
+	// A loan is only ever fined once, a repeated assessment keeps the original fine
+	query, args, err := squirrel.Insert("fines").Columns(
+		"loan_id", "member_id", "days_overdue", "amount", "status", "assessed_at", "updated_at", "created_at",
+	).Values(
+		newFine.LoanID, newFine.MemberID, newFine.DaysOverdue, newFine.Amount, newFine.Status,
+		newFine.AssessedAt.Time, newFine.UpdatedAt.Time, newFine.CreatedAt.Time,
+	).Suffix("ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)").ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := repo.dbClient.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	newFine.ID = int(lastInsertID)
+	return nil
+}
+
+// GetFines implements fines.Repository.
+func (repo *finesRepo) GetFines(ctx context.Context, params *fines.GetFinesParams) ([]*fines.Fine, int, error) {
+	return repo.getFines(ctx, repo.dbClient, params, false)
+}
+
+// SettleFine implements fines.Repository.
+func (repo *finesRepo) SettleFine(ctx context.Context, fineID int,
+	settle func(*fines.Fine) error) (*fines.Fine, error) {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer concludeTx(tx, &err)
+	// Lock the fine so two payments cannot both be applied to the same balance
+	var lockedFines []*fines.Fine
+	lockedFines, _, err = repo.getFines(ctx, tx, &fines.GetFinesParams{ID: fineID}, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(lockedFines) == 0 {
+		err = fines.ErrFineNotFound
+		return nil, err
+	}
+	settledFine := lockedFines[0]
+	if err = settle(settledFine); err != nil {
+		return nil, err
+	}
+	updateBuilder := squirrel.Update("fines").
+		Set("amount_paid", settledFine.AmountPaid).
+		Set("status", settledFine.Status).
+		Set("waiver_reason", settledFine.WaiverReason).
+		Set("updated_at", settledFine.UpdatedAt.Time).
+		Where(squirrel.Eq{"id": fineID})
+	if !settledFine.SettledAt.IsZero() {
+		updateBuilder = updateBuilder.Set("settled_at", settledFine.SettledAt.Time)
+	}
+	var query string
+	var args []interface{}
+	query, args, err = updateBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+	return settledFine, nil
+}
+
+// OutstandingBalance implements fines.Repository.
+func (repo *finesRepo) OutstandingBalance(ctx context.Context, memberID int) (int, error) {
+	var balance int
+	if err := repo.dbClient.GetContext(ctx, &balance,
+		"SELECT COALESCE(SUM(amount - amount_paid), 0) FROM fines WHERE member_id = ? AND status = ?;",
+		memberID, fines.Outstanding); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// MembershipType implements fines.Repository.
+func (repo *finesRepo) MembershipType(ctx context.Context, memberID int) (string, error) {
+	var membershipType string
+	if err := repo.dbClient.GetContext(ctx, &membershipType,
+		"SELECT membership_type FROM members WHERE id = ?;", memberID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fines.ErrMemberNotFound
+		}
+		return "", err
+	}
+	return membershipType, nil
+}
+
+func (repo *finesRepo) getFines(ctx context.Context, ext sqlx.ExtContext,
+	params *fines.GetFinesParams, forUpdate bool) ([]*fines.Fine, int, error) {
+	var retrievedFines []*fines.Fine
+	sb := squirrel.Select("id", "loan_id", "member_id", "days_overdue", "amount", "amount_paid",
+		"status", "waiver_reason", "assessed_at", "settled_at", "updated_at", "created_at").From("fines")
+	if params.ID != 0 {
+		sb = sb.Where(squirrel.Eq{"id": params.ID})
+	}
+	if params.LoanID != 0 {
+		sb = sb.Where(squirrel.Eq{"loan_id": params.LoanID})
+	}
+	if params.MemberID != 0 {
+		sb = sb.Where(squirrel.Eq{"member_id": params.MemberID})
+	}
+	if params.Status != "" {
+		sb = sb.Where(squirrel.Eq{"status": params.Status})
+	}
+	sb = sb.OrderBy("assessed_at DESC", "id DESC")
+	if params.Page > 0 {
+		offset := (params.Page - 1) * params.PerPage
+		sb = sb.Offset(uint64(offset))
+	}
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+	}
+	if forUpdate {
+		sb = sb.Suffix("FOR UPDATE")
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, -1, err
+	}
+	if err := sqlx.SelectContext(ctx, ext, &retrievedFines, query, args...); err != nil {
+		return nil, -1, err
+	}
+	return retrievedFines, len(retrievedFines), nil
+}
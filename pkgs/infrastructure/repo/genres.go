@@ -0,0 +1,471 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
+	"github.com/GabDewraj/library-api/pkgs/domain/genres"
+	"github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type genresRepo struct {
+	dbClient *sqlx.DB
+	logger   logrus.FieldLogger
+}
+
+func NewGenresDB(db *sqlx.DB) genres.Repository {
+	// Create a db logger for the genres repo
+	logger := logrus.WithFields(logrus.Fields{
+		"package": "genresRepo",
+	})
+	return &genresRepo{
+		dbClient: db,
+		logger:   logger,
+	}
+}
+
+// InsertNodes implements genres.Repository.
+func (repo *genresRepo) InsertNodes(ctx context.Context, newNodes []*genres.Node) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	for _, node := range newNodes {
+		if node.ParentID != 0 {
+			if err = lockGenre(ctx, tx, node.ParentID); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					err = genres.ErrParentNotFound
+				}
+				return err
+			}
+		}
+		if err = checkSiblingName(ctx, tx, node.ParentID, node.Name, 0); err != nil {
+			return err
+		}
+		if err = insertGenre(ctx, tx, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameNode implements genres.Repository.
+func (repo *genresRepo) RenameNode(ctx context.Context, id int, name string) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	var current struct {
+		ParentID int    `db:"parent_id"`
+		Name     string `db:"name"`
+	}
+	if err = tx.GetContext(ctx, &current,
+		"SELECT COALESCE(parent_id, 0) AS parent_id, name FROM genres WHERE id = ? FOR UPDATE;", id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = genres.ErrGenreNotFound
+		}
+		return err
+	}
+	if err = checkSiblingName(ctx, tx, current.ParentID, name, id); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "UPDATE genres SET name = ?, updated_at = ? WHERE id = ?;",
+		name, time.Now(), id); err != nil {
+		return err
+	}
+	err = renameBooksGenre(ctx, tx, id, current.Name, name)
+	return err
+}
+
+// MoveNode implements genres.Repository.
+func (repo *genresRepo) MoveNode(ctx context.Context, id, parentID int) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	if err = repo.lockPair(ctx, tx, id, parentID, genres.ErrParentNotFound); err != nil {
+		return err
+	}
+	if parentID != 0 {
+		if err = checkNotBelow(ctx, tx, parentID, id); err != nil {
+			return err
+		}
+	}
+	var name string
+	if err = tx.GetContext(ctx, &name, "SELECT name FROM genres WHERE id = ?;", id); err != nil {
+		return err
+	}
+	if err = checkSiblingName(ctx, tx, parentID, name, id); err != nil {
+		return err
+	}
+	err = moveSubtree(ctx, tx, id, parentID)
+	return err
+}
+
+// MergeNodes implements genres.Repository.
+func (repo *genresRepo) MergeNodes(ctx context.Context, sourceID, targetID int) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	if err = repo.lockPair(ctx, tx, sourceID, targetID, genres.ErrGenreNotFound); err != nil {
+		return err
+	}
+	if err = checkNotBelow(ctx, tx, targetID, sourceID); err != nil {
+		return err
+	}
+	// The sub-genres of the source are moved across whole, keeping their own children
+	var children []struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+	if err = tx.SelectContext(ctx, &children,
+		"SELECT id, name FROM genres WHERE parent_id = ? ORDER BY id FOR UPDATE;", sourceID); err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err = checkSiblingName(ctx, tx, targetID, child.Name, child.ID); err != nil {
+			return err
+		}
+		if err = moveSubtree(ctx, tx, child.ID, targetID); err != nil {
+			return err
+		}
+	}
+	var names struct {
+		Source string `db:"source"`
+		Target string `db:"target"`
+	}
+	if err = tx.GetContext(ctx, &names, "SELECT s.name AS source, t.name AS target FROM genres s, genres t "+
+		"WHERE s.id = ? AND t.id = ?;", sourceID, targetID); err != nil {
+		return err
+	}
+	if err = renameBooksGenre(ctx, tx, sourceID, names.Source, names.Target); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx,
+		"INSERT IGNORE INTO book_genres (book_id, genre_id) SELECT book_id, ? FROM book_genres WHERE genre_id = ?;",
+		targetID, sourceID); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "DELETE FROM book_genres WHERE genre_id = ?;", sourceID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM genres WHERE id = ?;", sourceID)
+	return err
+}
+
+// DeleteNodeByID implements genres.Repository.
+func (repo *genresRepo) DeleteNodeByID(ctx context.Context, id int) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	if err = lockGenre(ctx, tx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = genres.ErrGenreNotFound
+		}
+		return err
+	}
+	var dependants int
+	if err = tx.GetContext(ctx, &dependants,
+		"SELECT (SELECT COUNT(*) FROM genres WHERE parent_id = ?) + (SELECT COUNT(*) FROM book_genres WHERE genre_id = ?);",
+		id, id); err != nil {
+		return err
+	}
+	if dependants > 0 {
+		err = genres.ErrGenreNotEmpty
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM genres WHERE id = ?;", id)
+	return err
+}
+
+// GetNodes implements genres.Repository.
+func (repo *genresRepo) GetNodes(ctx context.Context, params *genres.GetNodesParams) ([]*genres.Node, int, error) {
+	var nodes []*genres.Node
+	// The path is derived from the closure table so a move or rename is reflected everywhere at once
+	sb := squirrel.Select("id", "COALESCE(parent_id, 0) AS parent_id", "name", "kind", "updated_at", "created_at").
+		Column(squirrel.Expr(`(SELECT GROUP_CONCAT(a.name ORDER BY gc.depth DESC SEPARATOR ?)
+			FROM genre_closure gc JOIN genres a ON a.id = gc.ancestor_id
+			WHERE gc.descendant_id = genres.id) AS path`, genres.PathSeparator)).
+		From("genres")
+	if params.ID != 0 {
+		sb = sb.Where(squirrel.Eq{"id": params.ID})
+	}
+	if params.Name != "" {
+		sb = sb.Where(squirrel.Like{"name": "%" + params.Name + "%"})
+	}
+	if params.Kind != "" {
+		sb = sb.Where(squirrel.Eq{"kind": params.Kind})
+	}
+	switch {
+	case params.ParentID < 0:
+		sb = sb.Where("parent_id IS NULL")
+	case params.ParentID > 0:
+		sb = sb.Where(squirrel.Eq{"parent_id": params.ParentID})
+	}
+	if params.AncestorID != 0 {
+		sb = sb.Where(`EXISTS (SELECT 1 FROM genre_closure gc
+			WHERE gc.ancestor_id = ? AND gc.descendant_id = genres.id AND gc.depth > 0)`, params.AncestorID)
+	}
+	if params.BookID != 0 {
+		sb = sb.Where("EXISTS (SELECT 1 FROM book_genres bg WHERE bg.genre_id = genres.id AND bg.book_id = ?)",
+			params.BookID)
+	}
+	// Ordering by path lists the tree depth first
+	sb = sb.OrderBy("path", "id")
+	if params.Page > 0 {
+		offset := (params.Page - 1) * params.PerPage
+		sb = sb.Offset(uint64(offset))
+	}
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, -1, err
+	}
+	if err := sqlx.SelectContext(ctx, repo.dbClient, &nodes, query, args...); err != nil {
+		return nil, -1, err
+	}
+	return nodes, len(nodes), nil
+}
+
+// AddBook implements genres.Repository.
+func (repo *genresRepo) AddBook(ctx context.Context, nodeID, bookID int) error {
+	_, err := repo.dbClient.ExecContext(ctx,
+		"INSERT INTO book_genres (book_id, genre_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE genre_id = genre_id;",
+		bookID, nodeID)
+	if err != nil {
+		return repo.handleMysqlErr(err)
+	}
+	return nil
+}
+
+// RemoveBook implements genres.Repository.
+func (repo *genresRepo) RemoveBook(ctx context.Context, nodeID, bookID int) error {
+	result, err := repo.dbClient.ExecContext(ctx,
+		"DELETE FROM book_genres WHERE book_id = ? AND genre_id = ?;", bookID, nodeID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return genres.ErrBookNotInGenre
+	}
+	return nil
+}
+
+// Lock both nodes in id order so two moves cannot deadlock or build a cycle between them
+func (repo *genresRepo) lockPair(ctx context.Context, tx *sqlx.Tx, id, otherID int, otherMissing error) error {
+	ids := []int{id}
+	if otherID != 0 {
+		ids = append(ids, otherID)
+	}
+	sort.Ints(ids)
+	for _, lockID := range ids {
+		if err := lockGenre(ctx, tx, lockID); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if lockID == id {
+				return genres.ErrGenreNotFound
+			}
+			return otherMissing
+		}
+	}
+	return nil
+}
+
+func (repo *genresRepo) handleMysqlErr(err error) error {
+	// Lets log the actual err that we arent propagating
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 {
+		repo.logger.Error(mysqlErr.Message)
+		if strings.Contains(mysqlErr.Message, "fk__book_genres__books") {
+			return genres.ErrBookNotFound
+		}
+		return genres.ErrGenreNotFound
+	}
+	return err
+}
+
+// Genre tree helpers shared with the books repo
+func lockGenre(ctx context.Context, ext sqlx.ExtContext, id int) error {
+	var lockedID int
+	return sqlx.GetContext(ctx, ext, &lockedID, "SELECT id FROM genres WHERE id = ? FOR UPDATE;", id)
+}
+
+// Names only need to be unique among siblings, Fiction > Classics and Non-fiction > Classics can both exist
+func checkSiblingName(ctx context.Context, ext sqlx.ExtContext, parentID int, name string, excludeID int) error {
+	var siblings int
+	if err := sqlx.GetContext(ctx, ext, &siblings,
+		"SELECT COUNT(*) FROM genres WHERE parent_id <=> NULLIF(?, 0) AND name = ? AND id <> ?;",
+		parentID, name, excludeID); err != nil {
+		return err
+	}
+	if siblings > 0 {
+		return genres.ErrGenreAlreadyExists
+	}
+	return nil
+}
+
+// A node cannot end up below itself, which would happen if it was moved under one of its own descendants
+func checkNotBelow(ctx context.Context, ext sqlx.ExtContext, nodeID, subtreeRootID int) error {
+	var below int
+	if err := sqlx.GetContext(ctx, ext, &below,
+		"SELECT COUNT(*) FROM genre_closure WHERE ancestor_id = ? AND descendant_id = ?;",
+		subtreeRootID, nodeID); err != nil {
+		return err
+	}
+	if below > 0 {
+		return genres.ErrGenreCycle
+	}
+	return nil
+}
+
+func insertGenre(ctx context.Context, ext sqlx.ExtContext, node *genres.Node) error {
+	now := time.Now()
+	node.CreatedAt.Time = now
+	node.UpdatedAt.Time = now
+	result, err := ext.ExecContext(ctx,
+		"INSERT INTO genres (parent_id, name, kind, updated_at, created_at) VALUES (NULLIF(?, 0), ?, ?, ?, ?);",
+		node.ParentID, node.Name, node.Kind, now, now)
+	if err != nil {
+		return err
+	}
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	node.ID = int(lastInsertID)
+	// The new node sits below every ancestor of its parent, and below itself at depth 0
+	_, err = ext.ExecContext(ctx, `INSERT INTO genre_closure (ancestor_id, descendant_id, depth)
+		SELECT ancestor_id, ?, depth + 1 FROM genre_closure WHERE descendant_id = ?
+		UNION ALL SELECT ?, ?, 0;`, node.ID, node.ParentID, node.ID, node.ID)
+	return err
+}
+
+// Detach the subtree from its old ancestors and attach it below the new parent, the caller holds the locks
+func moveSubtree(ctx context.Context, ext sqlx.ExtContext, id, parentID int) error {
+	var subtree []int
+	if err := sqlx.SelectContext(ctx, ext, &subtree,
+		"SELECT descendant_id FROM genre_closure WHERE ancestor_id = ?;", id); err != nil {
+		return err
+	}
+	query, args, err := squirrel.Delete("genre_closure").
+		Where(squirrel.Eq{"descendant_id": subtree}).
+		Where(squirrel.NotEq{"ancestor_id": subtree}).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := ext.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	if parentID != 0 {
+		if _, err := ext.ExecContext(ctx, `INSERT INTO genre_closure (ancestor_id, descendant_id, depth)
+			SELECT p.ancestor_id, s.descendant_id, p.depth + s.depth + 1
+			FROM genre_closure p JOIN genre_closure s
+			WHERE p.descendant_id = ? AND s.ancestor_id = ?;`, parentID, id); err != nil {
+			return err
+		}
+	}
+	_, err = ext.ExecContext(ctx, "UPDATE genres SET parent_id = NULLIF(?, 0), updated_at = ? WHERE id = ?;",
+		parentID, time.Now(), id)
+	return err
+}
+
+// File a new book under the genre with this name, creating a top level genre if there is none yet
+func fileBookUnderGenre(ctx context.Context, ext sqlx.ExtContext, bookID int, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	var genreID int
+	err := sqlx.GetContext(ctx, ext, &genreID, "SELECT id FROM genres WHERE name = ? ORDER BY id LIMIT 1;", name)
+	if errors.Is(err, sql.ErrNoRows) {
+		node := genres.Node{Name: name, Kind: genres.Genre}
+		err = insertGenre(ctx, ext, &node)
+		genreID = node.ID
+	}
+	if err != nil {
+		return err
+	}
+	_, err = ext.ExecContext(ctx,
+		"INSERT INTO book_genres (book_id, genre_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE genre_id = genre_id;",
+		bookID, genreID)
+	return err
+}
+
+// Move a changed book from the genre with the old name to the one with the new name.
+// Only the node fileBookUnderGenre picks for the old name is unlinked, links to other nodes
+// that share the name were made through the genre tree and are kept
+func refileBookUnderGenre(ctx context.Context, ext sqlx.ExtContext, bookID int, oldName, newName string) error {
+	var genreID int
+	err := sqlx.GetContext(ctx, ext, &genreID, "SELECT id FROM genres WHERE name = ? ORDER BY id LIMIT 1;",
+		strings.TrimSpace(oldName))
+	switch {
+	case err == nil:
+		if _, err := ext.ExecContext(ctx, "DELETE FROM book_genres WHERE book_id = ? AND genre_id = ?;",
+			bookID, genreID); err != nil {
+			return err
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+	return fileBookUnderGenre(ctx, ext, bookID, newName)
+}
+
+// The books filed under a renamed or merged genre that show its old name take on the new one
+func renameBooksGenre(ctx context.Context, ext sqlx.ExtContext, genreID int, oldName, newName string) error {
+	if oldName == newName {
+		return nil
+	}
+	var ids []int
+	if err := sqlx.SelectContext(ctx, ext, &ids, `SELECT b.id FROM books b
+		JOIN book_genres bg ON bg.book_id = b.id
+		WHERE bg.genre_id = ? AND b.genre = ? FOR UPDATE;`, genreID, oldName); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	query, args, err := squirrel.Update("books").
+		Set("genre", newName).
+		Set("updated_at", time.Now()).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": ids}).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := ext.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	records := make([]*audit.Record, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, &audit.Record{Entity: audit.Book, EntityID: id, Action: audit.Update,
+			Changes: audit.Changes{{Field: "genre", Old: oldName, New: newName}}})
+	}
+	return writeAuditRecords(ctx, ext, records)
+}
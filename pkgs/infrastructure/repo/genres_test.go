@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/genres"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenreTree(t *testing.T) {
+	assertWithTest := assert.New(t)
+	client, err := testConn()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	booksDB := booksRepo{dbClient: client, logger: testLogger}
+	genresDB := genresRepo{dbClient: client, logger: testLogger}
+
+	fiction := genres.Node{Name: "Fiction", Kind: genres.Genre}
+	assertWithTest.Nil(genresDB.InsertNodes(ctx, []*genres.Node{&fiction}))
+	sciFi := genres.Node{Name: "Science Fiction", ParentID: fiction.ID, Kind: genres.Genre}
+	assertWithTest.Nil(genresDB.InsertNodes(ctx, []*genres.Node{&sciFi}))
+	duplicate := genres.Node{Name: "Science Fiction", ParentID: fiction.ID, Kind: genres.Genre}
+	assertWithTest.Equal(genres.ErrGenreAlreadyExists, genresDB.InsertNodes(ctx, []*genres.Node{&duplicate}))
+	orphan := genres.Node{Name: "Orphan", ParentID: sciFi.ID + 1000, Kind: genres.Genre}
+	assertWithTest.Equal(genres.ErrParentNotFound, genresDB.InsertNodes(ctx, []*genres.Node{&orphan}))
+
+	// Inserting a book files it under a top level genre of the same name
+	book := books.Book{
+		ISBN:      "978-0451524935",
+		Title:     "1984",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     328,
+	}
+	assertWithTest.Nil(booksDB.InsertBooks(ctx, []*books.Book{&book}))
+	dystopian, count, err := genresDB.GetNodes(ctx, &genres.GetNodesParams{BookID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+
+	// Moving the genre under Science Fiction makes the book show up under Fiction
+	assertWithTest.Nil(genresDB.MoveNode(ctx, dystopian[0].ID, sciFi.ID))
+	moved, _, err := genresDB.GetNodes(ctx, &genres.GetNodesParams{ID: dystopian[0].ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("Fiction > Science Fiction > Dystopian", moved[0].Path)
+	_, count, err = booksDB.GetBooks(ctx, &books.GetBooksParams{Genre: "Fiction"})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+	_, count, err = booksDB.GetBooks(ctx, &books.GetBooksParams{GenreID: fiction.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+
+	// A node cannot be moved below itself
+	assertWithTest.Equal(genres.ErrGenreCycle, genresDB.MoveNode(ctx, fiction.ID, dystopian[0].ID))
+
+	// Renames show up in the paths of the nodes below
+	assertWithTest.Nil(genresDB.RenameNode(ctx, sciFi.ID, "Sci-Fi"))
+	moved, _, err = genresDB.GetNodes(ctx, &genres.GetNodesParams{ID: dystopian[0].ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("Fiction > Sci-Fi > Dystopian", moved[0].Path)
+
+	// Merging refiles the children and books of the source under the target
+	assertWithTest.Equal(genres.ErrGenreNotEmpty, genresDB.DeleteNodeByID(ctx, sciFi.ID))
+	assertWithTest.Nil(genresDB.MergeNodes(ctx, sciFi.ID, fiction.ID))
+	children, count, err := genresDB.GetNodes(ctx, &genres.GetNodesParams{ParentID: fiction.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+	assertWithTest.Equal("Fiction > Dystopian", children[0].Path)
+	assertWithTest.Equal(genres.ErrGenreNotFound, genresDB.DeleteNodeByID(ctx, sciFi.ID))
+
+	// Books can be filed under several nodes
+	assertWithTest.Nil(genresDB.AddBook(ctx, fiction.ID, book.ID))
+	_, count, err = genresDB.GetNodes(ctx, &genres.GetNodesParams{BookID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(2, count)
+	assertWithTest.Nil(genresDB.RemoveBook(ctx, fiction.ID, book.ID))
+	assertWithTest.Equal(genres.ErrBookNotInGenre, genresDB.RemoveBook(ctx, fiction.ID, book.ID))
+
+	// Only the tree decides the genre filter, a name that merely contains it does not match
+	_, count, err = booksDB.GetBooks(ctx, &books.GetBooksParams{Genre: "Dysto"})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(0, count)
+
+	// Renaming the genre of the book renames it on the book too
+	assertWithTest.Nil(genresDB.RenameNode(ctx, dystopian[0].ID, "Dystopia"))
+	renamed, _, err := booksDB.GetBooks(ctx, &books.GetBooksParams{ID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal("Dystopia", renamed[0].Genre)
+
+	// Changing the genre of the book refiles it, a link to another node of the old name stays
+	otherDystopia := genres.Node{Name: "Dystopia", Kind: genres.Genre}
+	assertWithTest.Nil(genresDB.InsertNodes(ctx, []*genres.Node{&otherDystopia}))
+	assertWithTest.Nil(genresDB.AddBook(ctx, otherDystopia.ID, book.ID))
+	assertWithTest.Nil(booksDB.UpdateBook(ctx, &books.Book{ID: book.ID, Genre: "Satire"}))
+	filed, count, err := genresDB.GetNodes(ctx, &genres.GetNodesParams{BookID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(2, count)
+	names := map[int]string{}
+	for _, node := range filed {
+		names[node.ID] = node.Name
+	}
+	assertWithTest.Equal("Dystopia", names[otherDystopia.ID])
+	assertWithTest.NotContains(names, dystopian[0].ID)
+}
@@ -0,0 +1,317 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/domain/holds"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type holdsRepo struct {
+	dbClient *sqlx.DB
+	logger   logrus.FieldLogger
+}
+
+func NewHoldsDB(db *sqlx.DB) holds.Repository {
+	// Create a db logger for the holds repo
+	logger := logrus.WithFields(logrus.Fields{
+		"package": "holdsRepo",
+	})
+	return &holdsRepo{
+		dbClient: db,
+		logger:   logger,
+	}
+}
+
+// PlaceHold implements holds.Repository.
+func (repo *holdsRepo) PlaceHold(ctx context.Context, newHold *holds.Hold) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	now := time.Now()
+	var canBorrow bool
+	canBorrow, err = memberCanBorrow(ctx, tx, newHold.MemberID, now)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = holds.ErrMemberNotFound
+		}
+		return err
+	}
+	if !canBorrow {
+		err = holds.ErrMemberNotActive
+		return err
+	}
+	// Locking the book serialises every change to its queue, so positions cannot interleave
+	if err = lockBook(ctx, tx, newHold.BookID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = holds.ErrBookNotFound
+		}
+		return err
+	}
+	var copiesOnShelf, openHolds, activeLoans int
+	if err = tx.GetContext(ctx, &copiesOnShelf,
+		"SELECT COUNT(*) FROM copies WHERE book_id = ? AND status = ?;",
+		newHold.BookID, copies.Available); err != nil {
+		return err
+	}
+	if copiesOnShelf > 0 {
+		err = holds.ErrBookAvailable
+		return err
+	}
+	if err = tx.GetContext(ctx, &openHolds,
+		"SELECT COUNT(*) FROM holds WHERE book_id = ? AND member_id = ? AND status IN (?, ?);",
+		newHold.BookID, newHold.MemberID, holds.Waiting, holds.Ready); err != nil {
+		return err
+	}
+	if openHolds > 0 {
+		err = holds.ErrHoldAlreadyExists
+		return err
+	}
+	if err = tx.GetContext(ctx, &activeLoans,
+		"SELECT COUNT(*) FROM loans WHERE book_id = ? AND member_id = ? AND returned_at IS NULL;",
+		newHold.BookID, newHold.MemberID); err != nil {
+		return err
+	}
+	if activeLoans > 0 {
+		err = holds.ErrBookAlreadyOnLoan
+		return err
+	}
+	newHold.Status = holds.Waiting
+	newHold.PlacedAt.Time = now
+	newHold.UpdatedAt.Time = now
+	newHold.CreatedAt.Time = now
+	var query string
+	var args []interface{}
+	query, args, err = squirrel.Insert("holds").Columns(
+		"book_id", "member_id", "status", "placed_at", "updated_at", "created_at",
+	).Values(
+		newHold.BookID, newHold.MemberID, newHold.Status, newHold.PlacedAt.Time,
+		newHold.UpdatedAt.Time, newHold.CreatedAt.Time,
+	).ToSql()
+	if err != nil {
+		return err
+	}
+	var result sql.Result
+	result, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	var lastInsertID int64
+	lastInsertID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	newHold.ID = int(lastInsertID)
+	err = tx.GetContext(ctx, &newHold.Position,
+		"SELECT COUNT(*) FROM holds WHERE book_id = ? AND status = ? AND id <= ?;",
+		newHold.BookID, holds.Waiting, newHold.ID)
+	return err
+}
+
+// CancelHold implements holds.Repository.
+func (repo *holdsRepo) CancelHold(ctx context.Context, holdID int, cancelledAt time.Time) (*holds.Hold, error) {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer concludeTx(tx, &err)
+	var cancelledHold *holds.Hold
+	cancelledHold, err = repo.lockOpenHold(ctx, tx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if err = setHoldStatus(ctx, tx, holdID, holds.Cancelled, cancelledAt); err != nil {
+		return nil, err
+	}
+	// A copy set aside for this hold goes to the next member in line
+	if cancelledHold.Status == holds.Ready && cancelledHold.CopyID != 0 {
+		if err = releaseCopy(ctx, tx, cancelledHold.BookID, cancelledHold.CopyID, cancelledAt); err != nil {
+			return nil, err
+		}
+	}
+	cancelledHold.Status = holds.Cancelled
+	cancelledHold.Position = 0
+	cancelledHold.UpdatedAt.Time = cancelledAt
+	return cancelledHold, nil
+}
+
+// ExpireReadyHolds implements holds.Repository.
+func (repo *holdsRepo) ExpireReadyHolds(ctx context.Context, now time.Time) (int, error) {
+	var expiredIDs []int
+	if err := repo.dbClient.SelectContext(ctx, &expiredIDs,
+		"SELECT id FROM holds WHERE status = ? AND expires_at < ? ORDER BY id;", holds.Ready, now); err != nil {
+		return 0, err
+	}
+	expired := 0
+	for _, holdID := range expiredIDs {
+		// Each hold gets its own transaction so one failure does not hold up the rest of the queue
+		ok, err := repo.expireHold(ctx, holdID, now)
+		if err != nil {
+			repo.logger.Errorf("failed to expire hold %d: %v", holdID, err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// GetHolds implements holds.Repository.
+func (repo *holdsRepo) GetHolds(ctx context.Context, params *holds.GetHoldsParams) ([]*holds.Hold, int, error) {
+	return repo.getHolds(ctx, repo.dbClient, params, false)
+}
+
+func (repo *holdsRepo) expireHold(ctx context.Context, holdID int, now time.Time) (bool, error) {
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer concludeTx(tx, &err)
+	var readyHold *holds.Hold
+	readyHold, err = repo.lockOpenHold(ctx, tx, holdID)
+	if err != nil {
+		return false, err
+	}
+	// The hold may have been collected or cancelled since it was selected
+	if readyHold.Status != holds.Ready || !readyHold.ExpiresAt.Before(now) {
+		return false, nil
+	}
+	if err = setHoldStatus(ctx, tx, holdID, holds.Expired, now); err != nil {
+		return false, err
+	}
+	if readyHold.CopyID != 0 {
+		if err = releaseCopy(ctx, tx, readyHold.BookID, readyHold.CopyID, now); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Lock the book before the hold, the same order checkouts and returns take their locks in
+func (repo *holdsRepo) lockOpenHold(ctx context.Context, tx *sqlx.Tx, holdID int) (*holds.Hold, error) {
+	var bookID int
+	if err := tx.GetContext(ctx, &bookID, "SELECT book_id FROM holds WHERE id = ?;", holdID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, holds.ErrHoldNotFound
+		}
+		return nil, err
+	}
+	if err := lockBook(ctx, tx, bookID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	lockedHolds, _, err := repo.getHolds(ctx, tx, &holds.GetHoldsParams{ID: holdID}, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(lockedHolds) == 0 {
+		return nil, holds.ErrHoldNotFound
+	}
+	if !lockedHolds[0].IsOpen() {
+		return nil, holds.ErrHoldNotOpen
+	}
+	return lockedHolds[0], nil
+}
+
+func (repo *holdsRepo) getHolds(ctx context.Context, ext sqlx.ExtContext,
+	params *holds.GetHoldsParams, forUpdate bool) ([]*holds.Hold, int, error) {
+	var retrievedHolds []*holds.Hold
+	// The position is derived on every read so it can never drift from the real queue
+	sb := squirrel.Select("h.id", "h.book_id", "COALESCE(h.copy_id, 0) AS copy_id", "h.member_id", "h.status", "h.placed_at", "h.ready_at",
+		"h.expires_at", "h.updated_at", "h.created_at").
+		Column(squirrel.Expr(`CASE WHEN h.status = ? THEN (SELECT COUNT(*) FROM holds q
+			WHERE q.book_id = h.book_id AND q.status = ? AND q.id <= h.id) ELSE 0 END AS position`,
+			holds.Waiting, holds.Waiting)).
+		From("holds h")
+	if params.ID != 0 {
+		sb = sb.Where(squirrel.Eq{"h.id": params.ID})
+	}
+	if params.BookID != 0 {
+		sb = sb.Where(squirrel.Eq{"h.book_id": params.BookID})
+	}
+	if params.MemberID != 0 {
+		sb = sb.Where(squirrel.Eq{"h.member_id": params.MemberID})
+	}
+	if params.Status != "" {
+		sb = sb.Where(squirrel.Eq{"h.status": params.Status})
+	}
+	// FIFO order, the oldest hold is first in line
+	sb = sb.OrderBy("h.id")
+	if params.Page > 0 {
+		offset := (params.Page - 1) * params.PerPage
+		sb = sb.Offset(uint64(offset))
+	}
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+	}
+	if forUpdate {
+		sb = sb.Suffix("FOR UPDATE")
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, -1, err
+	}
+	if err := sqlx.SelectContext(ctx, ext, &retrievedHolds, query, args...); err != nil {
+		return nil, -1, err
+	}
+	return retrievedHolds, len(retrievedHolds), nil
+}
+
+// Circulation helpers shared with the loans and copies repos, the caller must hold the book row lock
+// Hand a copy that has come back to the oldest waiting hold, or put it back on the shelf
+func releaseCopy(ctx context.Context, ext sqlx.ExtContext, bookID, copyID int, now time.Time) error {
+	var nextHoldID int
+	err := sqlx.GetContext(ctx, ext, &nextHoldID,
+		"SELECT id FROM holds WHERE book_id = ? AND status = ? ORDER BY id LIMIT 1 FOR UPDATE;",
+		bookID, holds.Waiting)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return setCopyStatus(ctx, ext, copyID, copies.Available)
+	case err != nil:
+		return err
+	}
+	query, args, err := squirrel.Update("holds").
+		Set("status", holds.Ready).
+		Set("copy_id", copyID).
+		Set("ready_at", now).
+		Set("expires_at", now.Add(holds.PickupWindow)).
+		Set("updated_at", now).
+		Where(squirrel.Eq{"id": nextHoldID}).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := ext.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	// The copy stays off the shelf while it waits for pickup
+	return setCopyStatus(ctx, ext, copyID, copies.OnHold)
+}
+
+// Returns the member's ready hold on the book and the copy set aside for it, zero if that copy is gone
+func readyHoldForMember(ctx context.Context, ext sqlx.ExtContext, bookID, memberID int) (int, int, error) {
+	var readyHold struct {
+		ID     int `db:"id"`
+		CopyID int `db:"copy_id"`
+	}
+	err := sqlx.GetContext(ctx, ext, &readyHold,
+		"SELECT id, COALESCE(copy_id, 0) AS copy_id FROM holds "+
+			"WHERE book_id = ? AND member_id = ? AND status = ? LIMIT 1 FOR UPDATE;",
+		bookID, memberID, holds.Ready)
+	return readyHold.ID, readyHold.CopyID, err
+}
+
+func setHoldStatus(ctx context.Context, ext sqlx.ExtContext, holdID int, status holds.Status, at time.Time) error {
+	_, err := ext.ExecContext(ctx, "UPDATE holds SET status = ?, updated_at = ? WHERE id = ?;",
+		status, at, holdID)
+	return err
+}
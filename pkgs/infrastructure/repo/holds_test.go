@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/domain/holds"
+	"github.com/GabDewraj/library-api/pkgs/domain/loans"
+	"github.com/GabDewraj/library-api/pkgs/domain/members"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoldsQueue(t *testing.T) {
+	assertWithTest := assert.New(t)
+	client, err := testConn()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	booksDB := booksRepo{dbClient: client, logger: testLogger}
+	loansDB := loansRepo{dbClient: client, logger: testLogger}
+	membersDB := membersRepo{dbClient: client, logger: testLogger}
+	holdsDB := holdsRepo{dbClient: client, logger: testLogger}
+	book := books.Book{
+		ISBN:      "978-0451524935",
+		Title:     "1984",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     328,
+	}
+	err = booksDB.InsertBooks(ctx, []*books.Book{&book})
+	assertWithTest.Nil(err)
+	err = (&copiesRepo{dbClient: client, logger: testLogger}).InsertCopies(ctx, []*copies.Copy{testCopy(book.ID, "C-0001")})
+	assertWithTest.Nil(err)
+	borrower, first, second := testMember("LIB-000001"), testMember("LIB-000002"), testMember("LIB-000003")
+	err = membersDB.InsertMembers(ctx, []*members.Member{borrower, first, second})
+	assertWithTest.Nil(err)
+
+	// Holds can only be placed on books that are out
+	err = holdsDB.PlaceHold(ctx, &holds.Hold{BookID: book.ID, MemberID: first.ID})
+	assertWithTest.Equal(holds.ErrBookAvailable, err)
+	loan := loans.Loan{BookID: book.ID, MemberID: borrower.ID}
+	loan.ApplyDefaults(time.Now())
+	err = loansDB.CheckoutBook(ctx, &loan)
+	assertWithTest.Nil(err)
+
+	firstHold := holds.Hold{BookID: book.ID, MemberID: first.ID}
+	secondHold := holds.Hold{BookID: book.ID, MemberID: second.ID}
+	assertWithTest.Nil(holdsDB.PlaceHold(ctx, &firstHold))
+	assertWithTest.Nil(holdsDB.PlaceHold(ctx, &secondHold))
+	assertWithTest.Equal(1, firstHold.Position)
+	assertWithTest.Equal(2, secondHold.Position)
+	assertWithTest.Equal(holds.ErrHoldAlreadyExists,
+		holdsDB.PlaceHold(ctx, &holds.Hold{BookID: book.ID, MemberID: first.ID}))
+
+	// Returning the book sets it aside for the first hold instead of shelving it
+	_, err = loansDB.ReturnLoan(ctx, loan.ID, time.Now())
+	assertWithTest.Nil(err)
+	queue, _, err := holdsDB.GetHolds(ctx, &holds.GetHoldsParams{BookID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(holds.Ready, queue[0].Status)
+	assertWithTest.Equal(holds.Waiting, queue[1].Status)
+	assertWithTest.Equal(1, queue[1].Position)
+
+	// Only the member the book was set aside for can check it out
+	err = loansDB.CheckoutBook(ctx, &loans.Loan{BookID: book.ID, MemberID: second.ID,
+		CheckedOutAt: loan.CheckedOutAt, DueAt: loan.DueAt})
+	assertWithTest.Equal(loans.ErrBookNotAvailable, err)
+
+	// Cancelling the ready hold passes the book to the next in line
+	_, err = holdsDB.CancelHold(ctx, firstHold.ID, time.Now())
+	assertWithTest.Nil(err)
+	queue, _, err = holdsDB.GetHolds(ctx, &holds.GetHoldsParams{ID: secondHold.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(holds.Ready, queue[0].Status)
+
+	// An uncollected hold expires and the book goes back on the shelf
+	expired, err := holdsDB.ExpireReadyHolds(ctx, time.Now().Add(holds.PickupWindow+time.Hour))
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, expired)
+	retrievedBooks, _, err := booksDB.GetBooks(ctx, &books.GetBooksParams{ID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(books.Available, retrievedBooks[0].Availability)
+}
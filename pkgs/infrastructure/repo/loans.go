@@ -0,0 +1,281 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/domain/holds"
+	"github.com/GabDewraj/library-api/pkgs/domain/loans"
+	"github.com/GabDewraj/library-api/pkgs/domain/members"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type loansRepo struct {
+	dbClient *sqlx.DB
+	logger   logrus.FieldLogger
+}
+
+func NewLoansDB(db *sqlx.DB) loans.Repository {
+	// Create a db logger for the loans repo
+	logger := logrus.WithFields(logrus.Fields{
+		"package": "loansRepo",
+	})
+	return &loansRepo{
+		dbClient: db,
+		logger:   logger,
+	}
+}
+
+// CheckoutBook implements loans.Repository.
+func (repo *loansRepo) CheckoutBook(ctx context.Context, newLoan *loans.Loan) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	if err = repo.checkMemberCanBorrow(ctx, tx, newLoan.MemberID, newLoan.CheckedOutAt.Time); err != nil {
+		return err
+	}
+	// Lock the book row so two checkouts of the same copy cannot both succeed
+	if err = lockBook(ctx, tx, newLoan.BookID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = loans.ErrBookNotFound
+		}
+		return err
+	}
+	// A copy set aside for this member's hold is theirs, whatever else is on the shelf
+	var readyHoldID, heldCopyID int
+	readyHoldID, heldCopyID, err = readyHoldForMember(ctx, tx, newLoan.BookID, newLoan.MemberID)
+	switch {
+	case err == nil && heldCopyID != 0:
+		if err = setHoldStatus(ctx, tx, readyHoldID, holds.Fulfilled, newLoan.CheckedOutAt.Time); err != nil {
+			return err
+		}
+		newLoan.CopyID = heldCopyID
+	case err == nil, errors.Is(err, sql.ErrNoRows):
+		// A ready hold whose copy has since been deleted is served from the shelf like any other checkout
+		newLoan.CopyID, err = availableCopy(ctx, tx, newLoan.BookID, newLoan.CopyID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = loans.ErrBookNotAvailable
+			}
+			return err
+		}
+		if readyHoldID != 0 {
+			if err = setHoldStatus(ctx, tx, readyHoldID, holds.Fulfilled, newLoan.CheckedOutAt.Time); err != nil {
+				return err
+			}
+		}
+	default:
+		return err
+	}
+	if err = repo.insertLoan(ctx, tx, newLoan); err != nil {
+		return err
+	}
+	if err = setCopyStatus(ctx, tx, newLoan.CopyID, copies.OnLoan); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReturnLoan implements loans.Repository.
+func (repo *loansRepo) ReturnLoan(ctx context.Context, loanID int, returnedAt time.Time) (*loans.Loan, error) {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer concludeTx(tx, &err)
+	var retrievedLoans []*loans.Loan
+	retrievedLoans, _, err = repo.getLoans(ctx, tx, &loans.GetLoansParams{ID: loanID}, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(retrievedLoans) == 0 {
+		err = loans.ErrLoanNotFound
+		return nil, err
+	}
+	returnedLoan := retrievedLoans[0]
+	if !returnedLoan.ReturnedAt.IsZero() {
+		err = loans.ErrLoanAlreadyReturned
+		return nil, err
+	}
+	// Lock the book row so the hand over to the holds queue is serialised with new holds
+	if err = lockBook(ctx, tx, returnedLoan.BookID); errors.Is(err, sql.ErrNoRows) {
+		// A book withdrawn while it was out can still be returned
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	returnedLoan.ReturnedAt.Time = returnedAt
+	returnedLoan.UpdatedAt.Time = returnedAt
+	if _, err = tx.ExecContext(ctx, "UPDATE loans SET returned_at = ?, updated_at = ? WHERE id = ?;",
+		returnedAt, returnedAt, loanID); err != nil {
+		return nil, err
+	}
+	if err = releaseCopy(ctx, tx, returnedLoan.BookID, returnedLoan.CopyID, returnedAt); err != nil {
+		return nil, err
+	}
+	return returnedLoan, nil
+}
+
+// GetLoans implements loans.Repository.
+func (repo *loansRepo) GetLoans(ctx context.Context, params *loans.GetLoansParams) ([]*loans.Loan, int, error) {
+	return repo.getLoans(ctx, repo.dbClient, params, false)
+}
+
+// GetUnfinedLateReturns implements loans.Repository.
+func (repo *loansRepo) GetUnfinedLateReturns(ctx context.Context, returnedSince time.Time) ([]*loans.Loan, error) {
+	var lateReturns []*loans.Loan
+	if err := repo.dbClient.SelectContext(ctx, &lateReturns, `SELECT l.id, l.book_id, l.copy_id, l.member_id,
+		l.checked_out_at, l.due_at, l.returned_at, l.updated_at, l.created_at FROM loans l
+		WHERE l.returned_at >= ? AND l.returned_at > l.due_at
+		AND NOT EXISTS (SELECT 1 FROM fines f WHERE f.loan_id = l.id)
+		ORDER BY l.returned_at, l.id;`, returnedSince); err != nil {
+		return nil, err
+	}
+	return lateReturns, nil
+}
+
+// Share lock the member row so they cannot be suspended halfway through a checkout
+func (repo *loansRepo) checkMemberCanBorrow(ctx context.Context, ext sqlx.ExtContext,
+	memberID int, checkedOutAt time.Time) error {
+	canBorrow, err := memberCanBorrow(ctx, ext, memberID, checkedOutAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return loans.ErrMemberNotFound
+		}
+		return err
+	}
+	if !canBorrow {
+		return loans.ErrMemberNotActive
+	}
+	return nil
+}
+
+// Circulation helpers shared by the loans and holds repos, missing rows are reported as sql.ErrNoRows
+// Lock the book row, every change to the circulation of a book or its copies takes this lock first
+func lockBook(ctx context.Context, ext sqlx.ExtContext, bookID int) error {
+	var lockedID int
+	return sqlx.GetContext(ctx, ext, &lockedID,
+		"SELECT id FROM books WHERE id = ? AND deleted_at IS NULL FOR UPDATE;", bookID)
+}
+
+// Lock a copy of the book that is on the shelf, the requested one if a copy was asked for
+func availableCopy(ctx context.Context, ext sqlx.ExtContext, bookID, copyID int) (int, error) {
+	sb := squirrel.Select("id").From("copies").
+		Where(squirrel.Eq{"book_id": bookID, "status": copies.Available})
+	if copyID != 0 {
+		sb = sb.Where(squirrel.Eq{"id": copyID})
+	}
+	query, args, err := sb.OrderBy("id").Limit(1).Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return 0, err
+	}
+	var availableID int
+	err = sqlx.GetContext(ctx, ext, &availableID, query, args...)
+	return availableID, err
+}
+
+func memberCanBorrow(ctx context.Context, ext sqlx.ExtContext, memberID int, at time.Time) (bool, error) {
+	var member struct {
+		Status    members.Status `db:"status"`
+		ExpiresAt time.Time      `db:"expires_at"`
+	}
+	row := ext.QueryRowxContext(ctx,
+		"SELECT status, expires_at FROM members WHERE id = ? LOCK IN SHARE MODE;", memberID)
+	if err := row.StructScan(&member); err != nil {
+		return false, err
+	}
+	return member.Status == members.Active && !members.Lapsed(member.ExpiresAt, at), nil
+}
+
+func setCopyStatus(ctx context.Context, ext sqlx.ExtContext, copyID int, status copies.Status) error {
+	query, args, err := squirrel.Update("copies").
+		Set("status", status).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": copyID}).ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = ext.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (repo *loansRepo) insertLoan(ctx context.Context, ext sqlx.ExtContext, newLoan *loans.Loan) error {
+	now := time.Now()
+	newLoan.CreatedAt.Time = now
+	newLoan.UpdatedAt.Time = now
+	query, args, err := squirrel.Insert("loans").Columns(
+		"book_id", "copy_id", "member_id", "checked_out_at", "due_at", "updated_at", "created_at",
+	).Values(
+		newLoan.BookID, newLoan.CopyID, newLoan.MemberID, newLoan.CheckedOutAt.Time, newLoan.DueAt.Time,
+		newLoan.UpdatedAt.Time, newLoan.CreatedAt.Time,
+	).ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := ext.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	newLoan.ID = int(lastInsertID)
+	return nil
+}
+
+func (repo *loansRepo) getLoans(ctx context.Context, ext sqlx.ExtContext,
+	params *loans.GetLoansParams, forUpdate bool) ([]*loans.Loan, int, error) {
+	var retrievedLoans []*loans.Loan
+	sb := squirrel.Select("id", "book_id", "copy_id", "member_id", "checked_out_at", "due_at",
+		"returned_at", "updated_at", "created_at").From("loans")
+	if params.ID != 0 {
+		sb = sb.Where(squirrel.Eq{"id": params.ID})
+	}
+	if params.BookID != 0 {
+		sb = sb.Where(squirrel.Eq{"book_id": params.BookID})
+	}
+	if params.CopyID != 0 {
+		sb = sb.Where(squirrel.Eq{"copy_id": params.CopyID})
+	}
+	if params.MemberID != 0 {
+		sb = sb.Where(squirrel.Eq{"member_id": params.MemberID})
+	}
+	switch params.Status {
+	case loans.Active:
+		sb = sb.Where("returned_at IS NULL")
+	case loans.Overdue:
+		sb = sb.Where("returned_at IS NULL").Where("due_at < ?", time.Now())
+	case loans.Returned:
+		sb = sb.Where("returned_at IS NOT NULL")
+	}
+	sb = sb.OrderBy("checked_out_at DESC", "id DESC")
+	if params.Page > 0 {
+		offset := (params.Page - 1) * params.PerPage
+		sb = sb.Offset(uint64(offset))
+	}
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+	}
+	if forUpdate {
+		sb = sb.Suffix("FOR UPDATE")
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, -1, err
+	}
+	if err := sqlx.SelectContext(ctx, ext, &retrievedLoans, query, args...); err != nil {
+		return nil, -1, err
+	}
+	return retrievedLoans, len(retrievedLoans), nil
+}
@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/domain/loans"
+	"github.com/GabDewraj/library-api/pkgs/domain/members"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckoutAndReturnBook(t *testing.T) {
+	assertWithTest := assert.New(t)
+	client, err := testConn()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	booksDB := booksRepo{dbClient: client, logger: testLogger}
+	loansDB := loansRepo{dbClient: client, logger: testLogger}
+	membersDB := membersRepo{dbClient: client, logger: testLogger}
+	borrower, other := testMember("LIB-000001"), testMember("LIB-000002")
+	err = membersDB.InsertMembers(ctx, []*members.Member{borrower, other})
+	assertWithTest.Nil(err)
+	book := books.Book{
+		ISBN:      "978-0451524935",
+		Title:     "1984",
+		Author:    "George Orwell",
+		Publisher: "Signet Classic",
+		Published: utils.CustomDate{Time: time.Date(1980, 6, 8, 0, 0, 0, 0, time.UTC)},
+		Genre:     "Dystopian",
+		Language:  "English",
+		Pages:     328,
+	}
+	err = booksDB.InsertBooks(ctx, []*books.Book{&book})
+	assertWithTest.Nil(err)
+	err = (&copiesRepo{dbClient: client, logger: testLogger}).InsertCopies(ctx, []*copies.Copy{testCopy(book.ID, "C-0001")})
+	assertWithTest.Nil(err)
+
+	now := time.Now()
+	loan := loans.Loan{BookID: book.ID, MemberID: borrower.ID}
+	loan.ApplyDefaults(now)
+	err = loansDB.CheckoutBook(ctx, &loan)
+	assertWithTest.Nil(err)
+	assertWithTest.NotZero(loan.ID)
+
+	// The book is now out, a second checkout must fail
+	err = loansDB.CheckoutBook(ctx, &loans.Loan{BookID: book.ID, MemberID: other.ID,
+		CheckedOutAt: loan.CheckedOutAt, DueAt: loan.DueAt})
+	assertWithTest.Equal(loans.ErrBookNotAvailable, err)
+	retrievedBooks, _, err := booksDB.GetBooks(ctx, &books.GetBooksParams{ID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(books.NotAvailable, retrievedBooks[0].Availability)
+
+	returnedLoan, err := loansDB.ReturnLoan(ctx, loan.ID, now.Add(time.Hour))
+	assertWithTest.Nil(err)
+	assertWithTest.False(returnedLoan.ReturnedAt.IsZero())
+	_, err = loansDB.ReturnLoan(ctx, loan.ID, now.Add(time.Hour))
+	assertWithTest.Equal(loans.ErrLoanAlreadyReturned, err)
+	retrievedBooks, _, err = booksDB.GetBooks(ctx, &books.GetBooksParams{ID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(books.Available, retrievedBooks[0].Availability)
+
+	// A book that was lent out is never purged, so its loans and their fines are kept
+	assertWithTest.Nil(booksDB.DeleteBookByID(ctx, book.ID))
+	purged, err := booksDB.PurgeDeletedBooks(ctx, now.Add(time.Hour))
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(0, purged)
+	_, count, err := loansDB.GetLoans(ctx, &loans.GetLoansParams{BookID: book.ID})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+}
@@ -0,0 +1,227 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/members"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/Masterminds/squirrel"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type membersRepo struct {
+	dbClient *sqlx.DB
+	logger   logrus.FieldLogger
+}
+
+func NewMembersDB(db *sqlx.DB) members.Repository {
+	// Create a db logger for the members repo
+	logger := logrus.WithFields(logrus.Fields{
+		"package": "membersRepo",
+	})
+	return &membersRepo{
+		dbClient: db,
+		logger:   logger,
+	}
+}
+
+// InsertMembers implements members.Repository.
+func (repo *membersRepo) InsertMembers(ctx context.Context, newMembers []*members.Member) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	if err = repo.insertMembers(ctx, tx, newMembers); err != nil {
+		return repo.handleMysqlErr(err)
+	}
+	return nil
+}
+
+// UpdateMember implements members.Repository.
+func (repo *membersRepo) UpdateMember(ctx context.Context, arg *members.Member) error {
+	// Start transaction
+	tx, err := repo.dbClient.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer concludeTx(tx, &err)
+	if err = repo.updateMember(ctx, tx, arg); err != nil {
+		return repo.handleMysqlErr(err)
+	}
+	return nil
+}
+
+// DeleteMemberByID implements members.Repository.
+func (repo *membersRepo) DeleteMemberByID(ctx context.Context, id int) error {
+	result, err := repo.dbClient.ExecContext(ctx, "DELETE FROM members WHERE id = ?;", id)
+	if err != nil {
+		return repo.handleMysqlErr(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return members.ErrMemberNotFound
+	}
+	return nil
+}
+
+// GetMembers implements members.Repository.
+func (repo *membersRepo) GetMembers(ctx context.Context, params *members.GetMembersParams) ([]*members.Member, int, error) {
+	return repo.getMembers(ctx, repo.dbClient, params)
+}
+
+func (repo *membersRepo) insertMembers(ctx context.Context, ext sqlx.ExtContext, newMembers []*members.Member) error {
+	ib := squirrel.Insert("members").Columns(
+		"card_number", "name", "email", "phone", "address", "membership_type",
+		"status", "expires_at", "updated_at", "created_at",
+	)
+	for _, member := range newMembers {
+		member.CreatedAt = utils.CustomTime{Time: time.Now()}
+		member.UpdatedAt = utils.CustomTime{Time: time.Now()}
+		ib = ib.Values(
+			member.CardNumber, member.Name, member.Email, member.Phone, member.Address,
+			member.MembershipType, member.Status, member.ExpiresAt.Time,
+			member.UpdatedAt.Time, member.CreatedAt.Time,
+		)
+	}
+	query, args, err := ib.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := ext.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	// Write DB primary key ID back to the pointer
+	for _, member := range newMembers {
+		member.ID = int(lastInsertID)
+		lastInsertID++
+	}
+	return nil
+}
+
+func (repo *membersRepo) updateMember(ctx context.Context, ext sqlx.ExtContext, updatedMember *members.Member) error {
+	updateBuilder := squirrel.Update("members")
+	if updatedMember.CardNumber != "" {
+		updateBuilder = updateBuilder.Set("card_number", updatedMember.CardNumber)
+	}
+	if updatedMember.Name != "" {
+		updateBuilder = updateBuilder.Set("name", updatedMember.Name)
+	}
+	if updatedMember.Email != "" {
+		updateBuilder = updateBuilder.Set("email", updatedMember.Email)
+	}
+	if updatedMember.Phone != "" {
+		updateBuilder = updateBuilder.Set("phone", updatedMember.Phone)
+	}
+	if updatedMember.Address != "" {
+		updateBuilder = updateBuilder.Set("address", updatedMember.Address)
+	}
+	if updatedMember.MembershipType != "" {
+		updateBuilder = updateBuilder.Set("membership_type", updatedMember.MembershipType)
+	}
+	if updatedMember.Status != "" {
+		updateBuilder = updateBuilder.Set("status", updatedMember.Status)
+	}
+	if (updatedMember.ExpiresAt != utils.CustomDate{}) {
+		updateBuilder = updateBuilder.Set("expires_at", updatedMember.ExpiresAt.Time)
+	}
+	// Always update the updated at field
+	updateBuilder = updateBuilder.Set("updated_at", time.Now())
+	updateBuilder = updateBuilder.Where(squirrel.Eq{"id": updatedMember.ID})
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := ext.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return members.ErrMemberNotFound
+	}
+	return nil
+}
+
+func (repo *membersRepo) getMembers(ctx context.Context, ext sqlx.ExtContext,
+	params *members.GetMembersParams) ([]*members.Member, int, error) {
+	var retrievedMembers []*members.Member
+	sb := squirrel.Select("id", "card_number", "name", "email", "phone", "address",
+		"membership_type", "status", "expires_at", "updated_at", "created_at").From("members")
+	if params.ID != 0 {
+		sb = sb.Where(squirrel.Eq{"id": params.ID})
+	}
+	if params.CardNumber != "" {
+		sb = sb.Where(squirrel.Eq{"card_number": params.CardNumber})
+	}
+	if params.Name != "" {
+		sb = sb.Where(squirrel.Like{"name": "%" + params.Name + "%"})
+	}
+	if params.Email != "" {
+		sb = sb.Where(squirrel.Eq{"email": params.Email})
+	}
+	if params.MembershipType != "" {
+		sb = sb.Where(squirrel.Eq{"membership_type": params.MembershipType})
+	}
+	// A lapsed active membership is reported as expired, so the filter has to agree with it
+	today := members.Today(time.Now())
+	switch params.Status {
+	case members.Active:
+		sb = sb.Where(squirrel.Eq{"status": members.Active}).Where(squirrel.GtOrEq{"expires_at": today})
+	case members.Expired:
+		sb = sb.Where(squirrel.Or{
+			squirrel.Eq{"status": members.Expired},
+			squirrel.And{squirrel.Eq{"status": members.Active}, squirrel.Lt{"expires_at": today}},
+		})
+	case members.Suspended:
+		sb = sb.Where(squirrel.Eq{"status": members.Suspended})
+	}
+	sb = sb.OrderBy("name", "id")
+	if params.Page > 0 {
+		offset := (params.Page - 1) * params.PerPage
+		sb = sb.Offset(uint64(offset))
+	}
+	if params.PerPage > 0 {
+		sb = sb.Limit(uint64(params.PerPage))
+	}
+	query, args, err := sb.ToSql()
+	if err != nil {
+		return nil, -1, err
+	}
+	if err := sqlx.SelectContext(ctx, ext, &retrievedMembers, query, args...); err != nil {
+		return nil, -1, err
+	}
+	return retrievedMembers, len(retrievedMembers), nil
+}
+
+func (repo *membersRepo) handleMysqlErr(err error) error {
+	// Lets log the actual err that we arent propagating
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			repo.logger.Error(mysqlErr.Message)
+			return members.ErrMemberAlreadyExists
+		case 1451:
+			repo.logger.Error(mysqlErr.Message)
+			return members.ErrMemberHasLoans
+		}
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/GabDewraj/library-api/pkgs/domain/members"
+	"github.com/GabDewraj/library-api/pkgs/infrastructure/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func testMember(cardNumber string) *members.Member {
+	return &members.Member{
+		CardNumber:     cardNumber,
+		Name:           "Ada Lovelace",
+		Email:          "ada@example.com",
+		MembershipType: members.Standard,
+		Status:         members.Active,
+		ExpiresAt:      utils.CustomDate{Time: time.Now().AddDate(1, 0, 0)},
+	}
+}
+
+func TestMembersLifecycle(t *testing.T) {
+	assertWithTest := assert.New(t)
+	client, err := testConn()
+	assertWithTest.Nil(err, "Test org db conn successful")
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	membersDB := membersRepo{dbClient: client, logger: testLogger}
+	member := testMember("LIB-000001")
+	err = membersDB.InsertMembers(ctx, []*members.Member{member})
+	assertWithTest.Nil(err)
+	// Card numbers are unique
+	err = membersDB.InsertMembers(ctx, []*members.Member{testMember("LIB-000001")})
+	assertWithTest.Equal(members.ErrMemberAlreadyExists, err)
+
+	err = membersDB.UpdateMember(ctx, &members.Member{ID: member.ID, Status: members.Suspended})
+	assertWithTest.Nil(err)
+	retrievedMembers, count, err := membersDB.GetMembers(ctx, &members.GetMembersParams{Status: members.Suspended})
+	assertWithTest.Nil(err)
+	assertWithTest.Equal(1, count)
+	assertWithTest.Equal(member.ID, retrievedMembers[0].ID)
+
+	err = membersDB.DeleteMemberByID(ctx, member.ID)
+	assertWithTest.Nil(err)
+	err = membersDB.DeleteMemberByID(ctx, member.ID)
+	assertWithTest.Equal(members.ErrMemberNotFound, err)
+}
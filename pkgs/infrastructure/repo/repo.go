@@ -28,6 +28,40 @@ func testConn() (*sqlx.DB, error) {
 }
 
 func cleanTestTB(db *sqlx.DB) error {
+	if _, err := db.Exec("DELETE FROM audit_records;"); err != nil {
+		return fmt.Errorf("Could not delete audit records: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM fines;"); err != nil {
+		return fmt.Errorf("Could not delete fines: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM holds;"); err != nil {
+		return fmt.Errorf("Could not delete holds: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM loans;"); err != nil {
+		return fmt.Errorf("Could not delete loans: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM book_genres;"); err != nil {
+		return fmt.Errorf("Could not delete book genres: %v", err)
+	}
+	// Detach the tree first so no node is still referenced by a child, the closure rows cascade
+	if _, err := db.Exec("UPDATE genres SET parent_id = NULL;"); err != nil {
+		return fmt.Errorf("Could not detach genres: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM genres;"); err != nil {
+		return fmt.Errorf("Could not delete genres: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM book_authors;"); err != nil {
+		return fmt.Errorf("Could not delete book authors: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM authors;"); err != nil {
+		return fmt.Errorf("Could not delete authors: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM copies;"); err != nil {
+		return fmt.Errorf("Could not delete copies: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM members;"); err != nil {
+		return fmt.Errorf("Could not delete members: %v", err)
+	}
 	if _, err := db.Exec("DELETE FROM books;"); err != nil {
 		return fmt.Errorf("Could not delete books: %v", err)
 	}
@@ -1,34 +1,266 @@
 package swagger
 
-import "github.com/GabDewraj/library-api/pkgs/domain/books"
+import (
+	"github.com/GabDewraj/library-api/pkgs/domain/audit"
+	"github.com/GabDewraj/library-api/pkgs/domain/authors"
+	"github.com/GabDewraj/library-api/pkgs/domain/books"
+	"github.com/GabDewraj/library-api/pkgs/domain/copies"
+	"github.com/GabDewraj/library-api/pkgs/domain/fines"
+	"github.com/GabDewraj/library-api/pkgs/domain/genres"
+	"github.com/GabDewraj/library-api/pkgs/domain/holds"
+	"github.com/GabDewraj/library-api/pkgs/domain/loans"
+	"github.com/GabDewraj/library-api/pkgs/domain/members"
+)
 
 // File defining all Request bodies
 
 type CreateBookRequestBody struct {
-	ISBN         string
-	Title        string
-	Author       string
-	Publisher    string
-	Published    int64
-	Genre        string
-	Language     string
-	Pages        int
-	Availability string
+	ISBN      string
+	Title     string
+	Author    string
+	Publisher string
+	Published int64
+	Genre     string
+	Language  string
+	Pages     int
 }
 
 type UpdateBookRequestBody struct {
-	ISBN         string
-	Title        string
-	Author       string
-	Publisher    string
-	Published    int64
-	Genre        string
-	Language     string
-	Pages        int
-	Availability string
+	ISBN      string
+	Title     string
+	Author    string
+	Publisher string
+	Published int64
+	Genre     string
+	Language  string
+	Pages     int
 }
 
 type GetBooksReponse struct {
 	Books []*books.Book `json:"books"`
+	// Every book that matches the filters, left out when total=false
+	Count int `json:"count"`
+	// Page and total_pages are only given when listing by page
+	Page       int  `json:"page"`
+	PerPage    int  `json:"per_page"`
+	TotalPages int  `json:"total_pages"`
+	HasNext    bool `json:"has_next"`
+	// Only when the books are listed by cursor and there is a page in that direction
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// Only when facets are asked for, keyed by facet
+	Facets map[string][]*books.FacetBucket `json:"facets,omitempty"`
+}
+
+type SearchBooksResponse struct {
+	Hits  []*books.SearchHit `json:"hits"`
+	Count int                `json:"count"`
+}
+
+type CheckoutBookRequestBody struct {
+	BookID   int   `json:"book_id"`
+	CopyID   int   `json:"copy_id"`
+	MemberID int   `json:"member_id"`
+	DueAt    int64 `json:"due_at"`
+}
+
+type GetLoansResponse struct {
+	Loans []*loans.Loan `json:"loans"`
+	Count int           `json:"count"`
+}
+
+type CreateMemberRequestBody struct {
+	CardNumber     string `json:"card_number"`
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	Address        string `json:"address"`
+	MembershipType string `json:"membership_type"`
+	Status         string `json:"status"`
+	ExpiresAt      int64  `json:"expires_at"`
+}
+
+type UpdateMemberRequestBody struct {
+	CardNumber     string `json:"card_number"`
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	Address        string `json:"address"`
+	MembershipType string `json:"membership_type"`
+	Status         string `json:"status"`
+	ExpiresAt      int64  `json:"expires_at"`
+}
+
+type GetMembersResponse struct {
+	Members []*members.Member `json:"members"`
+	Count   int               `json:"count"`
+}
+
+type PlaceHoldRequestBody struct {
+	BookID   int `json:"book_id"`
+	MemberID int `json:"member_id"`
+}
+
+type GetHoldsResponse struct {
+	Holds []*holds.Hold `json:"holds"`
 	Count int           `json:"count"`
 }
+
+type GetFinesResponse struct {
+	Fines []*fines.Fine `json:"fines"`
+	Count int           `json:"count"`
+}
+
+type MemberBalanceResponse struct {
+	MemberID        int  `json:"member_id"`
+	Balance         int  `json:"balance"`
+	CheckoutBlocked bool `json:"checkout_blocked"`
+}
+
+type PayFineRequestBody struct {
+	Amount int `json:"amount"`
+}
+
+type WaiveFineRequestBody struct {
+	Reason string `json:"reason"`
+}
+
+type CreateCopyRequestBody struct {
+	BookID        int    `json:"book_id"`
+	Barcode       string `json:"barcode"`
+	ShelfLocation string `json:"shelf_location"`
+	Condition     string `json:"condition"`
+	Status        string `json:"status"`
+}
+
+type UpdateCopyRequestBody struct {
+	Barcode       string `json:"barcode"`
+	ShelfLocation string `json:"shelf_location"`
+	Condition     string `json:"condition"`
+	Status        string `json:"status"`
+}
+
+type GetCopiesResponse struct {
+	Copies []*copies.Copy `json:"copies"`
+	Count  int            `json:"count"`
+}
+
+type CreateAuthorRequestBody struct {
+	Name      string   `json:"name"`
+	SortName  string   `json:"sort_name"`
+	BirthYear int      `json:"birth_year"`
+	DeathYear int      `json:"death_year"`
+	Aliases   []string `json:"aliases"`
+}
+
+type UpdateAuthorRequestBody struct {
+	Name      string   `json:"name"`
+	SortName  string   `json:"sort_name"`
+	BirthYear int      `json:"birth_year"`
+	DeathYear int      `json:"death_year"`
+	Aliases   []string `json:"aliases"`
+}
+
+type GetAuthorsResponse struct {
+	Authors []*authors.Author `json:"authors"`
+	Count   int               `json:"count"`
+}
+
+type CreditAuthorRequestBody struct {
+	Role     string `json:"role"`
+	Position int    `json:"position"`
+}
+
+type GetAuthorBooksResponse struct {
+	Books []*authors.Credit `json:"books"`
+	Count int               `json:"count"`
+}
+
+type CreateGenreRequestBody struct {
+	Name     string `json:"name"`
+	ParentID int    `json:"parent_id"`
+	Kind     string `json:"kind"`
+}
+
+type RenameGenreRequestBody struct {
+	Name string `json:"name"`
+}
+
+type MoveGenreRequestBody struct {
+	ParentID int `json:"parent_id"`
+}
+
+type MergeGenreRequestBody struct {
+	TargetID int `json:"target_id"`
+}
+
+type GetGenresResponse struct {
+	Genres []*genres.Node `json:"genres"`
+	Count  int            `json:"count"`
+}
+
+type GetAuditRecordsResponse struct {
+	Records []*audit.Record `json:"records"`
+	Count   int             `json:"count"`
+}
+
+// Every field is optional, null clears publisher, genre, language or pages
+type PatchBookRequestBody struct {
+	ISBN      *string `json:"isbn"`
+	Title     *string `json:"title"`
+	Author    *string `json:"author"`
+	Publisher *string `json:"publisher"`
+	Published *int64  `json:"published"`
+	Genre     *string `json:"genre"`
+	Language  *string `json:"language"`
+	Pages     *int    `json:"pages"`
+}
+
+type BulkCreateBooksRequestBody struct {
+	// all_or_nothing (default) or partial
+	Mode  string                  `json:"mode"`
+	Books []CreateBookRequestBody `json:"books"`
+}
+
+type BulkCreateBooksResponse struct {
+	Results []*books.BulkResult `json:"results"`
+	Created int                 `json:"created"`
+	Failed  int                 `json:"failed"`
+}
+
+// Send either ids or a filter
+type BulkDeleteBooksRequestBody struct {
+	// all_or_nothing (default) or partial
+	Mode   string           `json:"mode"`
+	DryRun bool             `json:"dry_run"`
+	IDs    []int            `json:"ids"`
+	Filter *BulkBooksFilter `json:"filter"`
+}
+
+type BulkUpdateBooksRequestBody struct {
+	BulkDeleteBooksRequestBody
+	// A merge patch applied to every book, isbn cannot be set in bulk
+	Changes PatchBookRequestBody `json:"changes"`
+	// in_repair, lost or withdrawn
+	CopyStatus string `json:"copy_status"`
+}
+
+type BulkBooksFilter struct {
+	ISBN         string `json:"isbn"`
+	Title        string `json:"title"`
+	Author       string `json:"author"`
+	Publisher    string `json:"publisher"`
+	Published    string `json:"published"`
+	Genre        string `json:"genre"`
+	GenreID      int    `json:"genre_id"`
+	Language     string `json:"language"`
+	BookPages    int    `json:"book_pages"`
+	Availability string `json:"availability"`
+}
+
+type BulkBooksResponse struct {
+	Results []*books.BulkResult `json:"results"`
+	Applied int                 `json:"applied"`
+	Failed  int                 `json:"failed"`
+	DryRun  bool                `json:"dry_run"`
+}
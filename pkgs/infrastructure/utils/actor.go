@@ -0,0 +1,21 @@
+package utils
+
+import "context"
+
+type actorKey struct{}
+
+// Actor recorded for work that no request asked for, such as scheduled jobs
+const SystemActor = "system"
+
+// WithActor stores who is making the request so that changes can be attributed to them
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored by WithActor, or SystemActor when there is none
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}